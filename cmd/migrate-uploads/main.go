@@ -0,0 +1,193 @@
+// Command migrate-uploads memindahkan file unggahan lama ke layout kanonik storage.
+//
+// Sebelumnya file ditulis ke campuran folder uploads/... dan Uploads/... (mis. uploads/user,
+// Uploads/news, uploads/request/barang). Tool ini membaca kedua folder tersebut dan menulis
+// setiap file ke storage yang dikonfigurasi (STORAGE_DRIVER, lihat storage.ConfigFromEnv) dengan
+// key "<folder kanonik>/<nama file>", lalu menghapus file sumbernya. Database tidak perlu diubah
+// karena yang disimpan hanya nama file.
+//
+// Pemakaian:
+//
+//	go run ./cmd/migrate-uploads -dry-run
+//	go run ./cmd/migrate-uploads
+//	STORAGE_DRIVER=s3 S3_ENDPOINT=http://localhost:9000 ... go run ./cmd/migrate-uploads -keep
+package main
+
+import (
+	"flag"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bem_be/internal/storage"
+)
+
+// folderRenames memetakan folder lama ke folder kanonik; dicek berurutan, yang lebih spesifik dulu
+var folderRenames = []struct {
+	from string
+	to   string
+}{
+	{"request/barang/", storage.FolderRequestItems + "/"},
+	{"request/", storage.FolderRequests + "/"},
+	{"user/", storage.FolderUsers + "/"},
+}
+
+type report struct {
+	moved, canonical, conflicts, failed int
+	written                             map[string]bool // key yang ditulis run ini, agar tidak dihitung ulang
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "tampilkan rencana pemindahan tanpa mengubah apa pun")
+	keep := flag.Bool("keep", false, "salin file tanpa menghapus sumbernya")
+	flag.Parse()
+
+	cfg := storage.ConfigFromEnv()
+	target, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("Gagal menginisialisasi storage: %v", err)
+	}
+	log.Printf("Target storage: %s (%s)", cfg.Driver, cfg.PublicURL)
+
+	r := report{written: map[string]bool{}}
+	var visited []os.FileInfo
+	for _, root := range []string{"uploads", "Uploads"} {
+		info, err := os.Stat(root)
+		if err != nil {
+			continue
+		}
+		// Di filesystem case-insensitive "uploads" dan "Uploads" adalah folder yang sama
+		if seenBefore(visited, info) {
+			continue
+		}
+		visited = append(visited, info)
+		migrateRoot(root, target, *dryRun, *keep, &r)
+	}
+
+	log.Printf("Selesai: %d dipindahkan, %d sudah kanonik, %d konflik, %d gagal", r.moved, r.canonical, r.conflicts, r.failed)
+	if r.failed > 0 || r.conflicts > 0 {
+		os.Exit(1)
+	}
+}
+
+func migrateRoot(root string, target storage.Storage, dryRun, keep bool, r *report) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Printf("Gagal membaca %s: %v", p, err)
+			r.failed++
+			return nil
+		}
+		if d.IsDir() {
+			if p != root {
+				dirs = append(dirs, p)
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		key := canonicalKey(filepath.ToSlash(rel))
+		if r.written[key] {
+			return nil
+		}
+
+		if isSameLocalFile(target, key, p) {
+			r.canonical++
+			return nil
+		}
+		if target.Exists(key) {
+			log.Printf("KONFLIK %s: %s sudah ada, dilewati", p, key)
+			r.conflicts++
+			return nil
+		}
+
+		log.Printf("%s -> %s", p, key)
+		if dryRun {
+			r.moved++
+			return nil
+		}
+		if err := copyFile(target, p, key); err != nil {
+			log.Printf("Gagal memindahkan %s: %v", p, err)
+			r.failed++
+			return nil
+		}
+		if !keep {
+			if err := os.Remove(p); err != nil {
+				log.Printf("Gagal menghapus sumber %s: %v", p, err)
+			}
+		}
+		r.written[key] = true
+		r.moved++
+		return nil
+	})
+	if err != nil {
+		log.Printf("Gagal menelusuri %s: %v", root, err)
+		r.failed++
+	}
+
+	// Folder lama yang sudah kosong ikut dibersihkan, dari yang terdalam
+	if !dryRun && !keep {
+		sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+		for _, dir := range dirs {
+			_ = os.Remove(dir) // gagal jika masih berisi file, dan memang dibiarkan
+		}
+		_ = os.Remove(root)
+	}
+}
+
+// canonicalKey mengubah path relatif lama menjadi key storage kanonik
+func canonicalKey(rel string) string {
+	segments := strings.SplitN(rel, "/", 2)
+	if len(segments) == 2 {
+		rel = strings.ToLower(segments[0]) + "/" + segments[1]
+	}
+	for _, rename := range folderRenames {
+		if strings.HasPrefix(rel, rename.from) {
+			return rename.to + strings.TrimPrefix(rel, rename.from)
+		}
+	}
+	return rel
+}
+
+// isSameLocalFile mengecek apakah file sumber sudah berada di lokasi kanonik storage local
+func isSameLocalFile(target storage.Storage, key, src string) bool {
+	local, ok := target.(*storage.LocalStorage)
+	if !ok {
+		return false
+	}
+	dest, err := os.Stat(filepath.Join(local.Root(), filepath.FromSlash(key)))
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(src)
+	return err == nil && os.SameFile(dest, info)
+}
+
+func copyFile(target storage.Storage, src, key string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return target.Put(key, f, mime.TypeByExtension(strings.ToLower(path.Ext(key))))
+}
+
+func seenBefore(visited []os.FileInfo, info os.FileInfo) bool {
+	for _, v := range visited {
+		if os.SameFile(v, info) {
+			return true
+		}
+	}
+	return false
+}
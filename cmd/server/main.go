@@ -2,20 +2,41 @@ package main
 
 import (
 	"log"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"bem_be/internal/auth"
+	"bem_be/internal/auth/campus"
 	"bem_be/internal/database"
 	"bem_be/internal/handlers"
+	"bem_be/internal/middleware"
 	"bem_be/internal/repositories"
 	"bem_be/internal/services"
+	"bem_be/internal/storage"
 	"bem_be/internal/utils"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
+// legacyUploadMounts memetakan mount statis lama ke folder storage kanoniknya
+var legacyUploadMounts = map[string]string{
+	"/associations": storage.FolderAssociations,
+	"/clubs":        storage.FolderClubs,
+	"/departments":  storage.FolderDepartments,
+	"/bems":         storage.FolderBems,
+	"/users":        storage.FolderUsers,
+	"/requests":     storage.FolderRequests,
+	"/request":      storage.FolderRequests,
+	"/barang":       storage.FolderRequestItems,
+	"/news":         storage.FolderNews,
+	"/announcement": storage.FolderAnnouncements,
+	"/galery":       storage.FolderGalery,
+	"/items":        storage.FolderItems,
+}
+
 func main() {
 	// Validate required environment variables
 	requiredEnvVars := []string{
@@ -26,6 +47,9 @@ func main() {
 			log.Fatalf("Variabel lingkungan %s tidak diatur", env)
 		}
 	}
+	if err := services.CheckAspirationAnonSecret(); err != nil {
+		log.Fatal(err)
+	}
 
 	// Set Gin mode
 	gin.SetMode(utils.GetEnvWithDefault("GIN_MODE", "debug"))
@@ -43,23 +67,25 @@ func main() {
 		log.Fatalf("Gagal membuat pengguna admin: %v", err)
 	}
 
-	statusRepo := repositories.NewStatusRepository()
-	handlers.CreateStatusSeeder(statusRepo)
-
 	// Create a new Gin router
 	router := gin.Default()
 
-	router.Static("/associations", "./uploads/associations")
-	router.Static("/clubs", "./uploads/clubs")
-	router.Static("/departments", "./uploads/departments")
-	router.Static("/bems", "./uploads/bems")
-	router.Static("/users", "./uploads/user")
-	router.Static("/requests", "./uploads/requests")
-	router.Static("/news", "./Uploads/news")
-	router.Static("/uploads", "./Uploads")
-	router.Static("/announcement", "./Uploads/announcements")
-	router.Static("/request", "./Uploads/requests")
-	router.Static("/barang", "./Uploads/request/barang")
+	// File unggahan disimpan lewat storage (local atau S3). Backend local disajikan di /uploads;
+	// mount lama (/news, /clubs, ...) diarahkan ke URL kanonik agar link yang sudah tersebar tetap jalan.
+	fileStorage := storage.Default()
+	if local, ok := fileStorage.(*storage.LocalStorage); ok {
+		router.Static("/uploads", local.Root())
+	} else {
+		router.GET("/uploads/*filepath", func(c *gin.Context) {
+			c.Redirect(http.StatusMovedPermanently, fileStorage.URL(strings.TrimPrefix(c.Param("filepath"), "/")))
+		})
+	}
+	for mount, folder := range legacyUploadMounts {
+		folder := folder
+		router.GET(mount+"/*filepath", func(c *gin.Context) {
+			c.Redirect(http.StatusMovedPermanently, fileStorage.URL(folder+c.Param("filepath")))
+		})
+	}
 
 	// Configure CORS
 	config := cors.DefaultConfig()
@@ -79,12 +105,23 @@ func main() {
 	router.POST("/api/auth/totp/verify", handlers.TOTPVerify)
 
 	// Initialize repositories and services
+	webhookRepo := repositories.NewWebhookRepository(database.DB)
+	webhookService := services.NewWebhookService(webhookRepo)
 	notificationRepo := repositories.NewNotificationRepository(database.DB)
-	notificationService := services.NewNotificationService(notificationRepo)
+	notificationService := services.NewNotificationService(notificationRepo, webhookService)
+
+	newsService := services.NewNewsService(database.DB, notificationService)
+	announcementService := services.NewAnnouncementService(database.DB, notificationService)
 
 	// Handlers
 	newsHandler := handlers.NewNewsHandler(database.DB, notificationService)
 	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	searchHandler := handlers.NewSearchHandler()
+	feedHandler := handlers.NewFeedHandler()
+	galeryHandler := handlers.NewGaleryHandler(database.DB)
+	newsCategoryHandler := handlers.NewNewsCategoryHandler()
+	sitemapHandler := handlers.NewSitemapHandler()
 
 	// Create handlers
 	campusAuthHandler := handlers.NewCampusAuthHandler()
@@ -99,7 +136,10 @@ func main() {
 	requestHandler := handlers.NewRequestHandler(database.DB, notificationService)
 	itemHandler := handlers.NewItemHandler(database.DB)
 	aspirationHandler := handlers.NewAspirationHandler(database.DB, notificationService)
+	aspirationWindowHandler := handlers.NewAspirationWindowHandler(database.DB)
 	eventHandler := handlers.NewEventHandler(database.DB, notificationService)
+	calendarFeedHandler := handlers.NewCalendarFeedHandler(database.DB)
+	venueHandler := handlers.NewVenueHandler(database.DB, notificationService)
 	mpmHandler := handlers.NewMpmHandler(database.DB)
 
 	// Guest Page
@@ -112,20 +152,41 @@ func main() {
 	router.GET("/api/department", departmentHandler.GetAllDepartmentsGuest)
 	router.GET("/api/bems/manage", bemHandler.GetAllLeaders)
 	router.GET("/api/visimisibem/:period", visimisiHandler.GetVisiMisiByPeriod)
-	router.GET("/api/news", newsHandler.GetAllNews)
-	router.GET("/api/announcements", announcementHandler.GetAllAnnouncement)
+	router.GET("/api/search", searchHandler.Search)
+	router.GET("/sitemap.xml", sitemapHandler.GetSitemap)
+	router.GET("/api/sitemap.xml", sitemapHandler.GetSitemap)
+	router.GET("/api/feeds/news/:format", feedHandler.GetNewsFeed)
+	router.GET("/api/feeds/announcements/:format", feedHandler.GetAnnouncementFeed)
+	router.GET("/api/news", newsHandler.GetPublishedNews)
+	router.GET("/api/news/categories", newsCategoryHandler.GetPublicCategories)
+	router.GET("/api/news/tags", newsCategoryHandler.GetPublicTags)
+	router.GET("/api/announcements", announcementHandler.GetPublicAnnouncements)
+	router.GET("/api/announcements/archive", announcementHandler.GetAnnouncementArchive)
 	router.GET("/api/announcements/:id", announcementHandler.GetAnnouncementByID)
-	router.GET("/api/news/:id", newsHandler.GetNewsByID)
+	router.GET("/api/news/:id", newsHandler.GetPublishedNewsByID)
+	router.GET("/api/galery/albums", galeryHandler.GetAlbums)
+	router.GET("/api/galery/albums/:id", galeryHandler.GetAlbumByID)
+	router.GET("/api/galery/photos/:id", galeryHandler.GetGaleryByID)
 	router.GET("/api/item_sarpras", itemHandler.GetAllItemsSarpras)
 	router.GET("/api/item_depol", itemHandler.GetAllItemsDepol)
 	router.GET("/api/events", eventHandler.GetEventsCurrentMonth)
 	router.GET("/api/event", eventHandler.GetAllEvents)
-	router.GET("/api/status", func(c *gin.Context) {
-		handlers.GetStatusAspirations(c, statusRepo)
-	})
+	router.GET("/api/calendar/events.ics", calendarFeedHandler.GetPublicFeed)
+	router.GET("/api/calendar/organizations/:organization", calendarFeedHandler.GetOrganizationFeed)
+	router.GET("/api/calendar/personal/:token", calendarFeedHandler.GetPersonalFeed)
+	router.GET("/api/aspirations/anonymity-policy", aspirationHandler.GetAnonymityPolicy)
+	router.POST("/api/aspirations/track", aspirationHandler.TrackAspiration)
+	router.POST("/api/aspirations/track/responses", aspirationHandler.ReplyByTrackingCode)
+	router.GET("/api/status", aspirationWindowHandler.GetIntakeStatus)
+	router.GET("/api/aspiration-windows/current", aspirationWindowHandler.GetIntakeStatus)
 
 	// Protected routes
 	authRequired := router.Group("/api")
+	// requireLogin mengisi username dari token (internal atau kampus) untuk endpoint yang
+	// bertindak atas nama pengguna yang login
+	requireLogin := campus.CampusAuthMiddleware()
+	// requireAdmin hanya menerima token internal ber-role admin
+	requireAdmin := []gin.HandlerFunc{middleware.AuthMiddleware(), middleware.RoleMiddleware("admin")}
 	{
 		// Current user
 		authRequired.GET("/auth/me", handlers.GetCurrentUser)
@@ -138,19 +199,74 @@ func main() {
 			adminRoutes.POST("/campus/token/refresh", campusAuthHandler.RefreshToken)
 
 			adminRoutes.GET("/organizations/:id", organizationHandler.GetOrganizationByID)
+			adminRoutes.PUT("/organizations/:id/seo", organizationHandler.UpdateOrganizationSEO)
 
 			// Admin access to student data
 			adminRoutes.GET("/students", studentHandler.GetAllStudents)
 			adminRoutes.GET("/students/:id", studentHandler.GetStudentByID)
 			// adminRoutes.GET("/students/by-user-id/:user_id", studentHandler.GetStudentByUserID)
 			adminRoutes.PUT("/students/:id/assign", studentHandler.AssignStudent)
+			adminRoutes.GET("/students/:id/attendance", eventHandler.GetStudentAttendance)
+
+			adminVenues := adminRoutes.Group("/venues", requireAdmin...)
+			{
+				adminVenues.GET("", venueHandler.GetAllVenues)
+				adminVenues.POST("", venueHandler.CreateVenue)
+				adminVenues.PUT("/:id", venueHandler.UpdateVenue)
+				adminVenues.DELETE("/:id", venueHandler.DeleteVenue)
+				adminVenues.GET("/:id/availability", venueHandler.GetAvailability)
+				adminVenues.GET("/reservations", venueHandler.GetAllReservations)
+				adminVenues.PUT("/reservations/:id/approve", venueHandler.AdminApproveReservation)
+				adminVenues.PUT("/reservations/:id/reject", venueHandler.AdminRejectReservation)
+			}
+
+			adminWindows := adminRoutes.Group("/aspiration-windows", requireAdmin...)
+			{
+				adminWindows.GET("", aspirationWindowHandler.GetWindows)
+				adminWindows.POST("", aspirationWindowHandler.CreateWindow)
+				adminWindows.GET("/:id", aspirationWindowHandler.GetWindow)
+				adminWindows.PUT("/:id", aspirationWindowHandler.UpdateWindow)
+				adminWindows.POST("/:id/close", aspirationWindowHandler.CloseWindow)
+				adminWindows.DELETE("/:id", aspirationWindowHandler.DeleteWindow)
+			}
+
+			adminAspirations := adminRoutes.Group("/aspirations", requireAdmin...)
+			{
+				adminAspirations.GET("", aspirationHandler.GetAllAspirations)
+				adminAspirations.GET("/trending", aspirationHandler.GetTrending)
+				adminAspirations.GET("/most-supported", aspirationHandler.GetMostSupported)
+				adminAspirations.GET("/analytics", aspirationHandler.AdminGetAnalytics)
+				adminAspirations.GET("/analytics/export", aspirationHandler.AdminExportAnalytics)
+				adminAspirations.GET("/:id", aspirationHandler.AdminGetAspirationDetail)
+				adminAspirations.PUT("/:id/status", aspirationHandler.AdminChangeStatus)
+				adminAspirations.PUT("/:id/assign", aspirationHandler.AdminAssignAspiration)
+				adminAspirations.POST("/:id/responses", aspirationHandler.AdminAddResponse)
+				adminAspirations.PUT("/:id/visibility", aspirationHandler.AdminSetVisibility)
+				adminAspirations.GET("/:id/comments", aspirationHandler.AdminGetComments)
+				adminAspirations.DELETE("/:id/comments/:commentId", aspirationHandler.AdminDeleteComment)
+				adminAspirations.POST("/:id/merge", aspirationHandler.AdminMergeAspiration)
+			}
 
 			adminRoutes.GET("/news", newsHandler.GetAllNews)
+			adminRoutes.GET("/news/categories", newsCategoryHandler.GetCategories)
+			adminRoutes.POST("/news/categories", newsCategoryHandler.CreateCategory)
+			adminRoutes.PUT("/news/categories/order", newsCategoryHandler.ReorderCategories)
+			adminRoutes.PUT("/news/categories/:id", newsCategoryHandler.UpdateCategory)
+			adminRoutes.DELETE("/news/categories/:id", newsCategoryHandler.DeleteCategory)
+			adminRoutes.GET("/news/tags", newsCategoryHandler.GetTags)
+			adminRoutes.POST("/news/tags", newsCategoryHandler.CreateTag)
+			adminRoutes.PUT("/news/tags/:id", newsCategoryHandler.UpdateTag)
+			adminRoutes.DELETE("/news/tags/:id", newsCategoryHandler.DeleteTag)
 			adminRoutes.GET("/news/:id", newsHandler.GetNewsByID)
-			adminRoutes.POST("/news", newsHandler.CreateNews)
-			adminRoutes.PUT("/news/:id", newsHandler.UpdateNews)
+			adminRoutes.POST("/news", requireLogin, newsHandler.CreateNews)
+			adminRoutes.PUT("/news/:id", requireLogin, newsHandler.UpdateNews)
 			adminRoutes.DELETE("/news/:id", newsHandler.DeleteNews)
 			adminRoutes.POST("/news/deleted/:id", newsHandler.RestoreNews)
+			adminRoutes.PUT("/news/:id/status", requireLogin, newsHandler.ChangeNewsStatus)
+			adminRoutes.GET("/news/:id/preview", requireLogin, newsHandler.PreviewNews)
+			adminRoutes.GET("/news/:id/revisions", requireLogin, newsHandler.GetNewsRevisions)
+			adminRoutes.GET("/news/:id/revisions/:version", requireLogin, newsHandler.GetNewsRevision)
+			adminRoutes.POST("/news/:id/revisions/:version/rollback", requireLogin, newsHandler.RollbackNews)
 
 			// Admin access to study program data
 			adminRoutes.GET("/clubs", clubHandler.GetAllClubs)
@@ -172,13 +288,34 @@ func main() {
 			adminRoutes.DELETE("/bems/:id", bemHandler.DeleteBem)
 
 			adminRoutes.GET("/announcement", announcementHandler.GetAllAnnouncement)
-			adminRoutes.GET("/announcements/:id", announcementHandler.GetAnnouncementByID)
-			adminRoutes.POST("/announcements", announcementHandler.CreateAnnouncement)
-			adminRoutes.PUT("/announcements/:id", announcementHandler.UpdateAnnouncement)
+			adminRoutes.GET("/announcements/:id", announcementHandler.AdminGetAnnouncementByID)
+			adminRoutes.POST("/announcements", requireLogin, announcementHandler.CreateAnnouncement)
+			adminRoutes.PUT("/announcements/:id", requireLogin, announcementHandler.UpdateAnnouncement)
 			adminRoutes.DELETE("/announcements/:id", announcementHandler.DeleteAnnouncement)
-
-			adminRoutes.GET("/notifications/:username", notificationHandler.GetUserNotifications)
-			adminRoutes.PUT("/notifications/:username/:notificationID/read", notificationHandler.MarkAsRead)
+			adminRoutes.PUT("/announcements/:id/pin", announcementHandler.PinAnnouncement)
+			adminRoutes.GET("/announcements/:id/revisions", requireLogin, announcementHandler.GetAnnouncementRevisions)
+			adminRoutes.GET("/announcements/:id/revisions/:version", requireLogin, announcementHandler.GetAnnouncementRevision)
+			adminRoutes.POST("/announcements/:id/revisions/:version/rollback", requireLogin, announcementHandler.RollbackAnnouncement)
+
+			adminRoutes.GET("/notifications/:username", requireLogin, notificationHandler.GetUserNotifications)
+			adminRoutes.GET("/notifications/:username/unread-count", requireLogin, notificationHandler.GetUnreadCount)
+			adminRoutes.PUT("/notifications/:username/read-all", requireLogin, notificationHandler.MarkAllAsRead)
+			adminRoutes.PUT("/notifications/:username/:notificationID/read", requireLogin, notificationHandler.MarkAsRead)
+			adminRoutes.PUT("/notifications/:username/:notificationID/archive", requireLogin, notificationHandler.ArchiveNotification)
+			adminRoutes.DELETE("/notifications/:username/:notificationID", requireLogin, notificationHandler.DeleteNotification)
+
+			// Outbound webhooks (Discord/Telegram bot, portal fakultas)
+			adminWebhooks := adminRoutes.Group("/webhooks", requireAdmin...)
+			{
+				adminWebhooks.GET("", webhookHandler.GetAllWebhooks)
+				adminWebhooks.GET("/events", webhookHandler.GetEventTypes)
+				adminWebhooks.POST("", webhookHandler.CreateWebhook)
+				adminWebhooks.PUT("/:id", webhookHandler.UpdateWebhook)
+				adminWebhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+				adminWebhooks.POST("/:id/rotate-secret", webhookHandler.RotateWebhookSecret)
+				adminWebhooks.GET("/:id/deliveries", webhookHandler.GetWebhookDeliveries)
+				adminWebhooks.POST("/deliveries/:deliveryID/redeliver", webhookHandler.RedeliverWebhook)
+			}
 
 			adminRoutes.GET("/department", departmentHandler.GetAllDepartments)
 			adminRoutes.GET("/department/:id", departmentHandler.GetDepartmentByID)
@@ -195,6 +332,19 @@ func main() {
 			adminRoutes.GET("/item", itemHandler.GetAllItemsSarpras)
 			adminRoutes.GET("/item/:id", itemHandler.GetItemSarparsByID)
 
+			adminGalery := adminRoutes.Group("/galery")
+			{
+				adminGalery.GET("/albums", galeryHandler.GetAlbums)
+				adminGalery.POST("/albums", galeryHandler.CreateAlbum)
+				adminGalery.PUT("/albums/:id", galeryHandler.UpdateAlbum)
+				adminGalery.DELETE("/albums/:id", galeryHandler.DeleteAlbum)
+				adminGalery.POST("/albums/:id/photos", galeryHandler.UploadAlbumPhotos)
+				adminGalery.PUT("/albums/:id/order", galeryHandler.ReorderAlbumPhotos)
+				adminGalery.PUT("/albums/:id/cover", galeryHandler.SetAlbumCover)
+				adminGalery.PUT("/photos/:id", galeryHandler.UpdatePhoto)
+				adminGalery.DELETE("/photos/:id", galeryHandler.DeletePhoto)
+			}
+
 		}
 
 		// Student routes
@@ -203,22 +353,51 @@ func main() {
 			studentRoutes.GET("/visimisibem/:id", visimisiHandler.GetVisiMisiById)
 			studentRoutes.PUT("/visimisibem/:id", visimisiHandler.UpdateVisiMisiBem)
 			studentRoutes.PUT("/visimisiperiod/:id", visimisiHandler.UpdateVisiMisiPeriod)
-			studentRoutes.POST("/announcements", announcementHandler.CreateAnnouncement)
-			studentRoutes.GET("/announcement", announcementHandler.GetAllAnnouncement)
-			studentRoutes.GET("/announcements/:id", announcementHandler.GetAnnouncementByID)
-			studentRoutes.PUT("/announcements/:id", announcementHandler.UpdateAnnouncement)
+			studentRoutes.POST("/announcements", requireLogin, announcementHandler.CreateAnnouncement)
+			studentRoutes.GET("/announcement", requireLogin, announcementHandler.GetStudentAnnouncements)
+			studentRoutes.GET("/announcements/:id", requireLogin, announcementHandler.GetStudentAnnouncementByID)
+			studentRoutes.PUT("/announcements/:id", requireLogin, announcementHandler.UpdateAnnouncement)
+			studentRoutes.PUT("/announcements/:id/pin", announcementHandler.PinAnnouncement)
+			studentRoutes.GET("/announcements/:id/revisions", requireLogin, announcementHandler.GetAnnouncementRevisions)
+			studentRoutes.GET("/announcements/:id/revisions/:version", requireLogin, announcementHandler.GetAnnouncementRevision)
+			studentRoutes.POST("/announcements/:id/revisions/:version/rollback", requireLogin, announcementHandler.RollbackAnnouncement)
 
 			studentRoutes.GET("/news", newsHandler.GetAllNews)
+			studentRoutes.GET("/news/categories", newsCategoryHandler.GetCategories)
+			studentRoutes.GET("/news/tags", newsCategoryHandler.GetTags)
 			studentRoutes.GET("/news/:id", newsHandler.GetNewsByID)
-			studentRoutes.POST("/news", newsHandler.CreateNews)
-			studentRoutes.PUT("/news/:id", newsHandler.UpdateNews)
+			studentRoutes.POST("/news", requireLogin, newsHandler.CreateNews)
+			studentRoutes.PUT("/news/:id", requireLogin, newsHandler.UpdateNews)
 			studentRoutes.DELETE("/news/:id", newsHandler.DeleteNews)
+			studentRoutes.PUT("/news/:id/status", requireLogin, newsHandler.ChangeNewsStatus)
+			studentRoutes.GET("/news/:id/preview", requireLogin, newsHandler.PreviewNews)
+			studentRoutes.GET("/news/:id/revisions", requireLogin, newsHandler.GetNewsRevisions)
+			studentRoutes.GET("/news/:id/revisions/:version", requireLogin, newsHandler.GetNewsRevision)
+			studentRoutes.POST("/news/:id/revisions/:version/rollback", requireLogin, newsHandler.RollbackNews)
 
 			studentRoutes.GET("/clubs", clubHandler.GetAllClubs)
 			studentRoutes.GET("/clubs/:id", clubHandler.GetClubByID)
 
-			studentRoutes.GET("/notifications/:username", notificationHandler.GetUserNotifications)
-			studentRoutes.POST("/notifications/:username/:notificationID/read", notificationHandler.MarkAsRead)
+			// Galeri: pengurus hanya mengelola album organisasinya
+			studentGalery := studentRoutes.Group("/galery", requireLogin, galeryHandler.OfficerScope())
+			{
+				studentGalery.GET("/albums", galeryHandler.GetAlbums)
+				studentGalery.POST("/albums", galeryHandler.CreateAlbum)
+				studentGalery.PUT("/albums/:id", galeryHandler.UpdateAlbum)
+				studentGalery.DELETE("/albums/:id", galeryHandler.DeleteAlbum)
+				studentGalery.POST("/albums/:id/photos", galeryHandler.UploadAlbumPhotos)
+				studentGalery.PUT("/albums/:id/order", galeryHandler.ReorderAlbumPhotos)
+				studentGalery.PUT("/albums/:id/cover", galeryHandler.SetAlbumCover)
+				studentGalery.PUT("/photos/:id", galeryHandler.UpdatePhoto)
+				studentGalery.DELETE("/photos/:id", galeryHandler.DeletePhoto)
+			}
+
+			studentRoutes.GET("/notifications/:username", requireLogin, notificationHandler.GetUserNotifications)
+			studentRoutes.GET("/notifications/:username/unread-count", requireLogin, notificationHandler.GetUnreadCount)
+			studentRoutes.POST("/notifications/:username/read-all", requireLogin, notificationHandler.MarkAllAsRead)
+			studentRoutes.POST("/notifications/:username/:notificationID/read", requireLogin, notificationHandler.MarkAsRead)
+			studentRoutes.PUT("/notifications/:username/:notificationID/archive", requireLogin, notificationHandler.ArchiveNotification)
+			studentRoutes.DELETE("/notifications/:username/:notificationID", requireLogin, notificationHandler.DeleteNotification)
 
 			studentRoutes.GET("/organization", departmentHandler.GetAllOrganizations)
 			studentRoutes.GET("/departments", departmentHandler.GetAllDepartments)
@@ -263,21 +442,57 @@ func main() {
 			studentRoutes.PUT("/item_depol/:id", itemHandler.UpdateItemDepol)
 			studentRoutes.DELETE("/item_depol/:id", itemHandler.DeleteItemDepol)
 
-			studentRoutes.POST("/aspirations", aspirationHandler.CreateAspiration)
+			studentRoutes.POST("/aspirations", requireLogin, aspirationHandler.CreateAspiration)
 			studentRoutes.GET("/aspirations", aspirationHandler.GetAllAspirations)
 			studentRoutes.DELETE("/aspirations/:id", aspirationHandler.DeleteAspiration)
+			studentRoutes.GET("/aspirations/mine", requireLogin, aspirationHandler.GetMyAspirations)
+			studentRoutes.GET("/aspirations/board", aspirationHandler.GetBoard)
+			studentRoutes.GET("/aspirations/trending", aspirationHandler.GetTrending)
+			studentRoutes.GET("/aspirations/most-supported", aspirationHandler.GetMostSupported)
+			studentRoutes.GET("/aspirations/analytics", requireLogin, aspirationHandler.GetAnalytics)
+			studentRoutes.GET("/aspirations/analytics/export", requireLogin, aspirationHandler.ExportAnalytics)
+			studentRoutes.GET("/aspirations/:id", requireLogin, aspirationHandler.GetAspirationDetail)
+			studentRoutes.PUT("/aspirations/:id/status", requireLogin, aspirationHandler.ChangeStatus)
+			studentRoutes.PUT("/aspirations/:id/assign", requireLogin, aspirationHandler.AssignAspiration)
+			studentRoutes.POST("/aspirations/:id/responses", requireLogin, aspirationHandler.AddResponse)
+			studentRoutes.POST("/aspirations/:id/vote", requireLogin, aspirationHandler.Vote)
+			studentRoutes.DELETE("/aspirations/:id/vote", requireLogin, aspirationHandler.Unvote)
+			studentRoutes.PUT("/aspirations/:id/visibility", requireLogin, aspirationHandler.SetVisibility)
+			studentRoutes.GET("/aspirations/:id/comments", requireLogin, aspirationHandler.GetComments)
+			studentRoutes.POST("/aspirations/:id/comments", requireLogin, aspirationHandler.AddComment)
+			studentRoutes.DELETE("/aspirations/:id/comments/:commentId", requireLogin, aspirationHandler.DeleteComment)
+			studentRoutes.POST("/aspirations/:id/merge", requireLogin, aspirationHandler.MergeAspiration)
 
 			studentRoutes.POST("/events", eventHandler.CreateEvent)
 			studentRoutes.PUT("/events/:id", eventHandler.UpdateEvent)
 			studentRoutes.GET("/events/current-month", eventHandler.GetEventsCurrentMonth)
 			studentRoutes.DELETE("/events/:id", eventHandler.DeleteEvent)
-
-			studentRoutes.GET("/status", func(c *gin.Context) {
-				handlers.GetStatusAspirations(c, statusRepo)
-			})
-			studentRoutes.PUT("/status", func(c *gin.Context) {
-				handlers.UpdateStatusAspirations(c, statusRepo)
-			})
+			studentRoutes.POST("/events/import", requireLogin, eventHandler.ImportEvents)
+			studentRoutes.POST("/events/:id/register", requireLogin, eventHandler.RegisterEvent)
+			studentRoutes.DELETE("/events/:id/register", requireLogin, eventHandler.CancelRegistration)
+			studentRoutes.GET("/events/my-registrations", requireLogin, eventHandler.GetMyRegistrations)
+			studentRoutes.PUT("/events/:id/registration", requireLogin, eventHandler.UpdateRegistrationSettings)
+			studentRoutes.GET("/events/:id/registrations", requireLogin, eventHandler.GetRegistrants)
+			studentRoutes.GET("/events/:id/registrations/export", requireLogin, eventHandler.ExportRegistrants)
+			studentRoutes.POST("/events/check-in", requireLogin, eventHandler.CheckIn)
+			studentRoutes.GET("/events/my-attendance", requireLogin, eventHandler.GetMyAttendance)
+			studentRoutes.GET("/events/:id/ticket", requireLogin, eventHandler.GetAttendanceTicket)
+			studentRoutes.PUT("/events/:id/attendance/settings", requireLogin, eventHandler.UpdateAttendanceSettings)
+			studentRoutes.GET("/events/:id/attendance/qr", requireLogin, eventHandler.GetAttendanceQR)
+			studentRoutes.POST("/events/:id/attendance/ticket", requireLogin, eventHandler.CheckInTicket)
+			studentRoutes.POST("/events/:id/attendance", requireLogin, eventHandler.ManualCheckIn)
+			studentRoutes.GET("/events/:id/attendance", requireLogin, eventHandler.GetAttendance)
+			studentRoutes.GET("/events/:id/attendance/export", requireLogin, eventHandler.ExportAttendance)
+			studentRoutes.DELETE("/events/:id/attendance/:username", requireLogin, eventHandler.RemoveAttendance)
+			studentRoutes.GET("/venues", venueHandler.GetActiveVenues)
+			studentRoutes.GET("/venues/:id/availability", venueHandler.GetAvailability)
+			studentRoutes.GET("/venues/reservations", requireLogin, venueHandler.GetMyApprovalQueue)
+			studentRoutes.PUT("/venues/reservations/:id/approve", requireLogin, venueHandler.ApproveReservation)
+			studentRoutes.PUT("/venues/reservations/:id/reject", requireLogin, venueHandler.RejectReservation)
+			studentRoutes.GET("/calendar/subscription", requireLogin, calendarFeedHandler.GetSubscription)
+			studentRoutes.POST("/calendar/subscription/reset", requireLogin, calendarFeedHandler.ResetSubscription)
+
+			studentRoutes.GET("/status", aspirationWindowHandler.GetIntakeStatus)
 		}
 	}
 
@@ -303,6 +518,104 @@ func main() {
 		}
 	}()
 
+	// Kirim ulang webhook yang gagal sesuai jadwal backoff
+	go func() {
+		for {
+			webhookService.ProcessDueDeliveries()
+			time.Sleep(30 * time.Second)
+		}
+	}()
+
+	// Terbitkan berita terjadwal yang sudah waktunya
+	go func() {
+		for {
+			published, err := newsService.PublishScheduledNews()
+			if err != nil {
+				log.Printf("Gagal menerbitkan berita terjadwal: %v", err)
+			} else if published > 0 {
+				log.Printf("%d berita terjadwal diterbitkan", published)
+			}
+			time.Sleep(time.Minute)
+		}
+	}()
+
+	// Kirim notifikasi pengumuman yang baru memasuki masa tayang
+	go func() {
+		for {
+			notified, err := announcementService.NotifyActivatedAnnouncements()
+			if err != nil {
+				log.Printf("Gagal mengirim notifikasi pengumuman terjadwal: %v", err)
+			} else if notified > 0 {
+				log.Printf("%d pengumuman terjadwal diumumkan", notified)
+			}
+			time.Sleep(time.Minute)
+		}
+	}()
+
+	// Kirim pengingat event sesuai EVENT_REMINDER_OFFSETS (EVENT_REMINDERS_ENABLED=false untuk mematikan)
+	if utils.GetEnvAsBool("EVENT_REMINDERS_ENABLED", true) {
+		reminderService := services.NewCalenderService(database.DB, notificationService)
+		go func() {
+			for {
+				sent, err := reminderService.SendDueReminders(time.Now())
+				if err != nil {
+					log.Printf("Gagal mengirim pengingat event: %v", err)
+				} else if sent > 0 {
+					log.Printf("%d pengingat event dikirim", sent)
+				}
+				time.Sleep(time.Minute)
+			}
+		}()
+	}
+
+	// Tandai aspirasi yang belum ditanggapi melewati ASPIRATION_SLA_HOURS dan bersihkan
+	// hitungan batas pengajuan lama setiap jam
+	aspirationService := services.NewAspirationService(database.DB, notificationService)
+	slaEnabled := utils.GetEnvAsBool("ASPIRATION_SLA_ENABLED", true)
+	go func() {
+		for {
+			if slaEnabled {
+				flagged, err := aspirationService.FlagOverdueAspirations(time.Now())
+				if err != nil {
+					log.Printf("Gagal memeriksa SLA aspirasi: %v", err)
+				} else if flagged > 0 {
+					log.Printf("%d aspirasi melewati batas waktu tanggapan", flagged)
+				}
+			}
+			if _, err := aspirationService.PruneRateLimits(time.Now()); err != nil {
+				log.Printf("Gagal membersihkan batas pengajuan aspirasi: %v", err)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+
+	// Bersihkan notifikasi lama yang sudah dibaca setiap hari
+	go func() {
+		retention := time.Duration(utils.GetEnvAsInt("NOTIFICATION_RETENTION_DAYS", 90)) * 24 * time.Hour
+		for {
+			pruned, err := notificationService.PruneReadNotifications(retention)
+			if err != nil {
+				log.Printf("Gagal membersihkan notifikasi lama: %v", err)
+			} else if pruned > 0 {
+				log.Printf("%d notifikasi lama yang sudah dibaca dibersihkan", pruned)
+			}
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+
+	// Bersihkan file unggahan yatim secara berkala (UPLOAD_GC_INTERVAL_HOURS=0 untuk mematikan)
+	if interval := utils.GetEnvAsInt("UPLOAD_GC_INTERVAL_HOURS", 24); interval > 0 {
+		uploadGCService := services.NewUploadGCService()
+		grace := time.Duration(utils.GetEnvAsInt("UPLOAD_GC_GRACE_HOURS", 24)) * time.Hour
+		dryRun := utils.GetEnvAsBool("UPLOAD_GC_DRY_RUN", false)
+		go func() {
+			for {
+				uploadGCService.RunAndLog(grace, dryRun)
+				time.Sleep(time.Duration(interval) * time.Hour)
+			}
+		}()
+	}
+
 	log.Printf("Server berjalan di port %s", port)
 	err = router.Run(":" + port)
 	if err != nil {
@@ -0,0 +1,68 @@
+// Command upload-gc merekonsiliasi storage file unggahan dengan database.
+//
+// Tool ini melaporkan file yatim (tidak dirujuk kolom database mana pun) dan referensi
+// database yang filenya sudah tidak ada, lalu menghapus file yatim yang lebih tua dari
+// grace period. Gunakan -dry-run untuk hanya melihat laporan.
+//
+// Pemakaian:
+//
+//	go run ./cmd/upload-gc -dry-run
+//	go run ./cmd/upload-gc -grace 72h -json > laporan.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"bem_be/internal/database"
+	"bem_be/internal/services"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "laporkan tanpa menghapus file")
+	grace := flag.Duration("grace", 24*time.Hour, "umur minimum file yatim sebelum dihapus")
+	asJSON := flag.Bool("json", false, "cetak laporan lengkap dalam format JSON")
+	flag.Parse()
+
+	database.Initialize()
+	defer database.Close()
+
+	report, err := services.NewUploadGCService().Run(*grace, *dryRun)
+	if err != nil {
+		log.Fatalf("Gagal menjalankan pembersihan: %v", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("Gagal menulis laporan: %v", err)
+		}
+		return
+	}
+
+	for _, orphan := range report.Orphans {
+		status := "dihapus"
+		switch {
+		case orphan.InGrace:
+			status = "dalam grace period"
+		case !orphan.Deleted && report.DryRun:
+			status = "akan dihapus"
+		case !orphan.Deleted:
+			status = "gagal dihapus"
+		}
+		fmt.Printf("YATIM   %-60s %10d  %s  (%s)\n", orphan.Key, orphan.Size, orphan.ModTime.Format("2006-01-02 15:04"), status)
+	}
+	for _, ref := range report.Dangling {
+		fmt.Printf("HILANG  %s.%s id=%d -> %s\n", ref.Table, ref.Column, ref.ID, ref.Value)
+	}
+	for _, e := range report.Errors {
+		fmt.Printf("ERROR   %s\n", e)
+	}
+	fmt.Printf("\n%d file diperiksa, %d referensi, %d yatim, %d dihapus (%d byte), %d referensi tanpa file\n",
+		report.FilesScanned, report.References, len(report.Orphans), report.DeletedCount, report.DeletedBytes, len(report.Dangling))
+}
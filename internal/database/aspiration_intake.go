@@ -0,0 +1,43 @@
+package database
+
+import (
+	"time"
+
+	"bem_be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateAspirationToggle menggantikan toggle lama status_aspirations (baris id 1) dengan
+// jendela aspirasi. Jika toggle sedang terbuka dan belum ada jendela sama sekali, dibuat
+// jendela 30 hari mulai sekarang agar penerimaan tidak tiba-tiba tertutup. Toggle lalu
+// dimatikan agar migrasi tidak berjalan lagi; tabelnya dibiarkan.
+func migrateAspirationToggle(db *gorm.DB) error {
+	if !db.Migrator().HasTable("status_aspirations") {
+		return nil
+	}
+	var open int64
+	if err := db.Table("status_aspirations").Where("id = 1 AND status = 1").Count(&open).Error; err != nil {
+		return err
+	}
+	var windows int64
+	if err := db.Model(&models.AspirationWindow{}).Count(&windows).Error; err != nil {
+		return err
+	}
+	if open == 0 {
+		return nil
+	}
+	if windows == 0 {
+		now := time.Now()
+		window := models.AspirationWindow{
+			Name:      "Aspirasi Mahasiswa",
+			OpensAt:   now,
+			ClosesAt:  now.AddDate(0, 0, 30),
+			CreatedBy: "migrasi",
+		}
+		if err := db.Create(&window).Error; err != nil {
+			return err
+		}
+	}
+	return db.Table("status_aspirations").Where("id = 1").Update("status", 0).Error
+}
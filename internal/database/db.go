@@ -81,17 +81,35 @@ func Initialize() {
 		&models.Student{},
 		&models.BEM{},
 		&models.Activity{},
+		&models.NewsCategory{},
+		&models.NewsTag{},
 		&models.News{},
 		&models.Galery{},
+		&models.GaleryAlbum{},
 		&models.Item{},
 		&models.Request{},
 		&models.Aspiration{},
+		&models.AspirationResponse{},
+		&models.AspirationStatusHistory{},
+		&models.AspirationRateLimit{},
+		&models.AspirationVote{},
+		&models.AspirationComment{},
+		&models.AspirationWindow{},
 		&models.Calender{},
 		&models.Announcement{},
 		&models.MPM{},
 		&models.Notification{},
 		&models.UserNotification{},
-		&models.Status_Aspirations{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.ContentRevision{},
+		&models.SlugRedirect{},
+		&models.CalendarToken{},
+		&models.EventRegistration{},
+		&models.EventAttendance{},
+		&models.Venue{},
+		&models.VenueReservation{},
+		&models.EventReminder{},
 	}
 
 	for _, model := range modelsToMigrate {
@@ -102,6 +120,22 @@ func Initialize() {
 		log.Printf("%T table migrated successfully", model)
 	}
 
+	if err := setupFullTextSearch(DB); err != nil {
+		log.Fatalf("Error setting up full-text search: %v", err)
+	}
+
+	if err := normalizeNewsCategories(DB); err != nil {
+		log.Fatalf("Error normalizing news categories: %v", err)
+	}
+
+	if err := setupSlugs(DB); err != nil {
+		log.Fatalf("Error setting up slugs: %v", err)
+	}
+
+	if err := migrateAspirationToggle(DB); err != nil {
+		log.Fatalf("Error migrating aspiration toggle: %v", err)
+	}
+
 	log.Println("Database schema migrated successfully")
 }
 
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"log"
+	"strings"
+
+	"bem_be/internal/models"
+	"bem_be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// normalizeNewsCategories memindahkan kategori berita lama (teks bebas) ke tabel
+// news_categories. Ejaan berbeda yang menghasilkan slug sama ("Kegiatan", " kegiatan ")
+// digabung ke satu kategori; nama kategori diambil dari ejaan yang paling sering dipakai.
+// Hanya berita yang belum punya category_id yang diproses, jadi aman dijalankan
+// setiap startup.
+func normalizeNewsCategories(db *gorm.DB) error {
+	var rows []struct {
+		ID       uint
+		Category string
+	}
+	err := db.Unscoped().Model(&models.News{}).
+		Select("id, category").
+		Where("category_id IS NULL AND COALESCE(TRIM(category), '') <> ''").
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+
+	type group struct {
+		ids      []uint
+		spelling map[string]int
+	}
+	groups := map[string]*group{}
+	for _, row := range rows {
+		slug := utils.Slugify(row.Category)
+		if slug == "" {
+			continue
+		}
+		g, ok := groups[slug]
+		if !ok {
+			g = &group{spelling: map[string]int{}}
+			groups[slug] = g
+		}
+		g.ids = append(g.ids, row.ID)
+		g.spelling[strings.Join(strings.Fields(row.Category), " ")]++
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for slug, g := range groups {
+			var category models.NewsCategory
+			err := tx.Where("slug = ?", slug).First(&category).Error
+			if err == gorm.ErrRecordNotFound {
+				category = models.NewsCategory{Slug: slug, Name: mostUsed(g.spelling)}
+				err = tx.Create(&category).Error
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Unscoped().Model(&models.News{}).
+				Where("id IN ?", g.ids).
+				UpdateColumns(map[string]interface{}{"category_id": category.ID, "category": category.Name}).Error; err != nil {
+				return err
+			}
+			log.Printf("Kategori berita %q: %d berita dinormalisasi", category.Name, len(g.ids))
+		}
+		return nil
+	})
+}
+
+func mostUsed(counts map[string]int) string {
+	best, bestCount := "", 0
+	for value, count := range counts {
+		if count > bestCount || (count == bestCount && value < best) {
+			best, bestCount = value, count
+		}
+	}
+	return best
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SearchConfig adalah konfigurasi text search Postgres yang dipakai kolom search_vector.
+// Default "indonesian" (stemmer Snowball, Postgres 12+), jatuh ke "simple" jika tidak tersedia.
+// Kolom yang sudah terbentuk tidak diubah; ganti konfigurasi dengan DROP COLUMN search_vector dulu.
+var SearchConfig = "simple"
+
+// searchColumns mendefinisikan isi search_vector per tabel: judul berbobot A, isi berbobot B.
+// uses adalah kolom yang dirujuk; search_vector lama yang belum memuat semuanya dibentuk ulang.
+var searchColumns = []struct {
+	table   string
+	title   string
+	content string
+	uses    []string
+}{
+	{"news", "title", "content", []string{"title", "content"}},
+	{"announcements", "title", "content", []string{"title", "content"}},
+	{"calenders", "title", "coalesce(description, '') || ' ' || coalesce(location, '')", []string{"title", "description", "location"}},
+	{"organizations", "name", "coalesce(short_name, '') || ' ' || coalesce(meta_description, '')", []string{"name", "short_name", "meta_description"}},
+	{"aspirations", "title", "coalesce(description, '') || ' ' || coalesce(content, '')", []string{"title", "description", "content"}},
+}
+
+// setupFullTextSearch menambahkan kolom generated search_vector beserta index GIN.
+// Karena kolomnya GENERATED ... STORED, Postgres memperbaruinya sendiri setiap insert/update.
+func setupFullTextSearch(db *gorm.DB) error {
+	config := os.Getenv("SEARCH_TS_CONFIG")
+	if config == "" {
+		config = "indonesian"
+	}
+
+	var available int64
+	if err := db.Raw("SELECT COUNT(*) FROM pg_ts_config WHERE cfgname = ?", config).Scan(&available).Error; err != nil {
+		return err
+	}
+	if available == 0 {
+		log.Printf("Konfigurasi text search %q tidak tersedia, memakai \"simple\"", config)
+		config = "simple"
+	}
+	SearchConfig = config
+
+	for _, c := range searchColumns {
+		if err := dropOutdatedSearchVector(db, c.table, c.uses); err != nil {
+			return err
+		}
+		vector := fmt.Sprintf(
+			"setweight(to_tsvector('%s'::regconfig, coalesce(%s, '')), 'A') || setweight(to_tsvector('%s'::regconfig, %s), 'B')",
+			config, c.title, config, "coalesce("+c.content+", '')",
+		)
+		if err := db.Exec(fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (%s) STORED",
+			c.table, vector,
+		)).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(fmt.Sprintf(
+			"CREATE INDEX IF NOT EXISTS idx_%s_search_vector ON %s USING GIN (search_vector)",
+			c.table, c.table,
+		)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropOutdatedSearchVector menghapus search_vector yang ekspresinya belum merujuk semua kolom
+// (mis. dibuat sebelum deskripsi organisasi ikut diindeks) agar dibentuk ulang; index GIN-nya
+// ikut terhapus dan dibuat lagi
+func dropOutdatedSearchVector(db *gorm.DB, table string, uses []string) error {
+	var expression string
+	if err := db.Raw(
+		"SELECT COALESCE(generation_expression, '') FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = 'search_vector'",
+		table,
+	).Scan(&expression).Error; err != nil {
+		return err
+	}
+	if expression == "" {
+		return nil
+	}
+	for _, column := range uses {
+		if !strings.Contains(expression, column) {
+			log.Printf("Membentuk ulang search_vector %s", table)
+			return db.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN search_vector", table)).Error
+		}
+	}
+	return nil
+}
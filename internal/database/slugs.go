@@ -0,0 +1,69 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"bem_be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// slugTables adalah tabel konten publik yang memiliki kolom slug
+var slugTables = []struct {
+	table    string
+	fallback string // dipakai jika judul tidak menghasilkan slug
+}{
+	{"news", "berita"},
+	{"announcements", "pengumuman"},
+}
+
+// setupSlugs mengisi slug konten lama dari judulnya lalu memasang unique index.
+// Index bersifat parsial (slug kosong diabaikan) supaya kolom baru bisa ditambahkan
+// ke tabel yang sudah berisi data. Baris yang di-soft-delete ikut diisi agar slugnya
+// tidak direbut konten lain sebelum dipulihkan.
+func setupSlugs(db *gorm.DB) error {
+	for _, t := range slugTables {
+		var rows []struct {
+			ID    uint
+			Title string
+			Slug  string
+		}
+		if err := db.Table(t.table).Select("id, title, slug").Order("id ASC").Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		// slug yang hanya angka tidak pernah bisa dibuka (route membacanya sebagai ID),
+		// jadi diisi ulang seperti slug kosong
+		used := map[string]bool{}
+		for _, row := range rows {
+			if row.Slug != "" && !utils.NumericSlug(row.Slug) {
+				used[row.Slug] = true
+			}
+		}
+
+		filled := 0
+		for _, row := range rows {
+			if row.Slug != "" && !utils.NumericSlug(row.Slug) {
+				continue
+			}
+			slug := utils.UniqueSlug(row.Title, t.fallback, func(candidate string) bool { return used[candidate] })
+			used[slug] = true
+			if err := db.Table(t.table).Where("id = ?", row.ID).UpdateColumn("slug", slug).Error; err != nil {
+				return err
+			}
+			filled++
+		}
+		if filled > 0 {
+			log.Printf("Slug %s: %d baris diisi dari judul", t.table, filled)
+		}
+
+		if err := db.Exec(fmt.Sprintf(
+			"CREATE UNIQUE INDEX IF NOT EXISTS idx_%s_slug ON %s (slug) WHERE slug <> ''",
+			t.table, t.table,
+		)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
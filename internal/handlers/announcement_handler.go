@@ -2,12 +2,13 @@ package handlers
 
 import (
 	"bem_be/internal/models"
+	"bem_be/internal/repositories"
 	"bem_be/internal/services"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 	"fmt"
 	"math"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -26,14 +27,54 @@ type AnnouncementHandler struct {
 
 func NewAnnouncementHandler(db *gorm.DB, notificationService *services.NotificationService) *AnnouncementHandler {
 	return &AnnouncementHandler{
-		service:             services.NewAnnouncementService(db),
+		service:             services.NewAnnouncementService(db, notificationService),
 		notificationService: notificationService,
 	}
 }
 
 // GetAllAnnouncements returns all announcements
 // GetAllAnnouncements returns all announcements with pagination and optional filters
+// ?view=all (default) | active | archive
 func (h *AnnouncementHandler) GetAllAnnouncement(c *gin.Context) {
+	h.listAnnouncements(c, repositories.AnnouncementFilter{View: c.DefaultQuery("view", "all")})
+}
+
+// GetPublicAnnouncements returns the currently active announcements meant for everyone
+func (h *AnnouncementHandler) GetPublicAnnouncements(c *gin.Context) {
+	h.listAnnouncements(c, repositories.AnnouncementFilter{View: "active", Public: true})
+}
+
+// GetAnnouncementArchive returns public announcements whose end date has passed
+func (h *AnnouncementHandler) GetAnnouncementArchive(c *gin.Context) {
+	h.listAnnouncements(c, repositories.AnnouncementFilter{View: "archive", Public: true})
+}
+
+// GetStudentAnnouncements returns announcements visible to the logged-in student (untargeted
+// or matching the student's faculty, study program, year and dormitory)
+// ?view=active (default) | archive | all
+func (h *AnnouncementHandler) GetStudentAnnouncements(c *gin.Context) {
+	student, ok := h.currentStudent(c)
+	if !ok {
+		return
+	}
+	h.listAnnouncements(c, repositories.AnnouncementFilter{View: c.DefaultQuery("view", "active"), Audience: student})
+}
+
+// currentStudent looks up the logged-in student used for audience targeting
+func (h *AnnouncementHandler) currentStudent(c *gin.Context) (*models.Student, bool) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return nil, false
+	}
+	student, err := h.service.GetStudentByUsername(username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+		return nil, false
+	}
+	return student, true
+}
+
+func (h *AnnouncementHandler) listAnnouncements(c *gin.Context, filter repositories.AnnouncementFilter) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
 	title := c.DefaultQuery("title", "")
@@ -47,7 +88,11 @@ func (h *AnnouncementHandler) GetAllAnnouncement(c *gin.Context) {
 		perPage = 10
 	}
 
-	announcementList, total, err := h.service.GetAllAnnouncements(page, perPage, title, content, category)
+	filter.Title = title
+	filter.Content = content
+	filter.Category = category
+
+	announcementList, total, err := h.service.GetAllAnnouncements(page, perPage, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
@@ -68,29 +113,59 @@ func (h *AnnouncementHandler) GetAllAnnouncement(c *gin.Context) {
 			"title":    title,
 			"content":  content,
 			"category": category,
+			"view":     filter.View,
 		},
 		"data": announcementList,
 	})
 }
 
-// GetAnnouncementByID returns an announcement by ID
+// GetAnnouncementByID returns a started, untargeted announcement by ID or slug for visitors.
+// Old slugs are redirected (301) to the current slug.
 func (h *AnnouncementHandler) GetAnnouncementByID(c *gin.Context) {
+	h.announcementDetail(c, nil, false)
+}
+
+// GetStudentAnnouncementByID returns an announcement visible to the logged-in student
+func (h *AnnouncementHandler) GetStudentAnnouncementByID(c *gin.Context) {
+	student, ok := h.currentStudent(c)
+	if !ok {
+		return
+	}
+	h.announcementDetail(c, student, false)
+}
+
+// AdminGetAnnouncementByID returns any announcement, including scheduled and targeted ones
+func (h *AnnouncementHandler) AdminGetAnnouncementByID(c *gin.Context) {
+	h.announcementDetail(c, nil, true)
+}
+
+// announcementDetail applies services.CanView for viewer unless all is set
+func (h *AnnouncementHandler) announcementDetail(c *gin.Context, viewer *models.Student, all bool) {
 	idStr := c.Param("id")
+	var announcement *models.Announcement
+	redirected := false
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		announcement, redirected, err = h.service.GetAnnouncementBySlug(idStr)
+	} else {
+		announcement, err = h.service.GetAnnouncementByID(uint(id))
+	}
+	if err != nil || (!all && !services.CanView(announcement, viewer, time.Now())) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Announcement not found"})
 		return
 	}
+	if redirected {
+		redirectToSlug(c, announcement.Slug)
+		return
+	}
+	id = uint64(announcement.ID)
 
 	stats := c.Query("stats")
-	var result interface{}
+	var result interface{} = announcement
 
 	if stats == "true" {
 		result, err = h.service.GetAnnouncementWithStats(uint(id))
-	} else {
-		result, err = h.service.GetAnnouncementByID(uint(id))
 	}
-
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Announcement not found"})
 		return
@@ -110,6 +185,10 @@ func formatPosition(pos string) string {
 
 // CreateAnnouncement creates a new announcement (with optional file)
 func (h *AnnouncementHandler) CreateAnnouncement(c *gin.Context) {
+	editor, ok := currentUsername(c)
+	if !ok {
+		return
+	}
 	var announcement models.Announcement
 
 	announcement.Title = c.PostForm("title")
@@ -156,56 +235,74 @@ func (h *AnnouncementHandler) CreateAnnouncement(c *gin.Context) {
 		announcement.EndDate = &endDate
 	}
 
-	file, err := c.FormFile("file")
-	if err == nil {
-		uploadPath := "uploads/announcements"
-		os.MkdirAll(uploadPath, os.ModePerm)
-
-		fileName := fmt.Sprintf("%d_%s", time.Now().Unix(), filepath.Base(file.Filename))
-		filePath := filepath.Join(uploadPath, fileName)
+	if announcement.StartDate != nil && announcement.EndDate != nil && announcement.EndDate.Before(*announcement.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date tidak boleh sebelum start_date"})
+		return
+	}
 
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+	announcement.IsPinned = c.PostForm("is_pinned") == "true"
+	if priorityStr := c.PostForm("priority"); priorityStr != "" {
+		priority, err := strconv.Atoi(priorityStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "priority harus berupa angka"})
 			return
 		}
-
-		announcement.FileURL = fileName
+		announcement.Priority = priority
 	}
-
-	if err := h.service.Createannouncement(&announcement); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	announcement.TargetFaculties = c.PostForm("target_faculties")
+	announcement.TargetStudyPrograms = c.PostForm("target_study_programs")
+	announcement.TargetYears = c.PostForm("target_years")
+	announcement.TargetDormitories = c.PostForm("target_dormitories")
+	announcement.Slug = c.PostForm("slug")
+	announcement.MetaDescription = c.PostForm("meta_description")
+
+	// gambar OpenGraph opsional untuk pratinjau tautan
+	if file, err := c.FormFile("og_image"); err == nil {
+		fileName, err := utils.SaveImage(file, storage.FolderAnnouncements, utils.ImagePolicy)
+		if err != nil {
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"error": "Failed to process OpenGraph image: " + err.Error()})
+			return
+		}
+		announcement.OGImage = fileName
 	}
 
-	title := "Pengumuman Baru: " + announcement.Title
-	message := fmt.Sprintf("Pengumuman baru telah dibuat. Cek sekarang!")
+	file, err := c.FormFile("file")
+	if err == nil {
+		fileName, err := utils.SaveFile(file, storage.FolderAnnouncements, utils.AttachmentPolicy)
+		if err != nil {
+			utils.RemoveImage(storage.FolderAnnouncements, announcement.OGImage)
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"error": "Failed to save file: " + err.Error()})
+			return
+		}
 
-	// Buat instance Notification
-	notification := &models.Notification{
-		Title:   title,
-		Message: message,
+		announcement.FileURL = fileName
 	}
 
-	// Simpan ke database menggunakan service
-	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message)
+	// Notifikasi dikirim oleh service saat pengumuman mulai aktif.
+	// Lampiran yang sudah tersimpan dihapus lagi jika insert gagal.
+	var files utils.FileChanges
+	files.Added(storage.FolderAnnouncements, announcement.FileURL)
+	files.Added(storage.FolderAnnouncements, announcement.OGImage)
+	err = h.service.Createannouncement(&announcement, editor)
+	files.Done(err)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Gagal membuat notifikasi berita",
-		})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"status":       "success",
-		"message":      "Announcement created successfully",
-		"data":         announcement,
-		"notification": createdNotif,
+		"status":  "success",
+		"message": "Announcement created successfully",
+		"data":    announcement,
 	})
 }
 
 // UpdateAnnouncement updates an existing announcement (with optional file update)
 func (h *AnnouncementHandler) UpdateAnnouncement(c *gin.Context) {
+	editor, ok := currentUsername(c)
+	if !ok {
+		return
+	}
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
@@ -237,27 +334,94 @@ func (h *AnnouncementHandler) UpdateAnnouncement(c *gin.Context) {
 		announcement.EndDate = &endDate
 	}
 
+	announcement.Slug = c.PostForm("slug")
+
+	if file, err := c.FormFile("og_image"); err == nil {
+		fileName, err := utils.SaveImage(file, storage.FolderAnnouncements, utils.ImagePolicy)
+		if err != nil {
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"error": "Failed to process OpenGraph image: " + err.Error()})
+			return
+		}
+		announcement.OGImage = fileName
+	}
+
 	file, err := c.FormFile("file")
 	if err == nil {
-		uploadPath := "uploads/announcements"
-		if err := os.MkdirAll(uploadPath, os.ModePerm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot create upload folder"})
+		fileName, err := utils.SaveFile(file, storage.FolderAnnouncements, utils.AttachmentPolicy)
+		if err != nil {
+			utils.RemoveImage(storage.FolderAnnouncements, announcement.OGImage)
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"error": "Failed to save file: " + err.Error()})
 			return
 		}
 
-		fileName := fmt.Sprintf("%d_%s", time.Now().Unix(), filepath.Base(file.Filename))
-		filePath := filepath.Join(uploadPath, fileName)
+		announcement.FileURL = fileName
+	}
 
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
-			return
+	if err := h.service.Updateannouncement(&announcement, editor); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Target audiens hanya diubah jika field-nya dikirim (string kosong = untuk semua mahasiswa)
+	settings := map[string]interface{}{}
+	for field, column := range map[string]string{
+		"target_faculties":      "target_faculties",
+		"target_study_programs": "target_study_programs",
+		"target_years":          "target_years",
+		"target_dormitories":    "target_dormitories",
+	} {
+		if value, ok := c.GetPostForm(field); ok {
+			settings[column] = value
 		}
+	}
+	if meta, ok := c.GetPostForm("meta_description"); ok {
+		settings["meta_description"] = meta
+	}
+	if announcement.OGImage == "" && c.PostForm("remove_og_image") == "true" {
+		settings["og_image"] = ""
+	}
+	updated, err := h.service.UpdateAnnouncementSettings(uint(id), settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-		announcement.FileURL = filePath
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Announcement updated successfully",
+		"data":    updated,
+	})
+}
+
+// PinAnnouncement pins/unpins an announcement and sets its priority
+// JSON: {"is_pinned": true, "priority": 10}
+func (h *AnnouncementHandler) PinAnnouncement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
 	}
 
-	if err := h.service.Updateannouncement(&announcement); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var req struct {
+		IsPinned *bool `json:"is_pinned"`
+		Priority *int  `json:"priority"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid: " + err.Error()})
+		return
+	}
+
+	settings := map[string]interface{}{}
+	if req.IsPinned != nil {
+		settings["is_pinned"] = *req.IsPinned
+	}
+	if req.Priority != nil {
+		settings["priority"] = *req.Priority
+	}
+
+	announcement, err := h.service.UpdateAnnouncementSettings(uint(id), settings)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Announcement not found"})
 		return
 	}
 
@@ -287,3 +451,84 @@ func (h *AnnouncementHandler) DeleteAnnouncement(c *gin.Context) {
 		"message": "Announcement deleted successfully",
 	})
 }
+
+// GetAnnouncementRevisions lists the revision history of an announcement, newest first
+func (h *AnnouncementHandler) GetAnnouncementRevisions(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	page, perPage := revisionPagination(c)
+
+	revisions, total, err := h.service.GetAnnouncementRevisions(id, page, perPage)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Announcement revisions retrieved successfully",
+		"metadata": gin.H{
+			"current_page": page,
+			"per_page":     perPage,
+			"total_items":  total,
+			"total_pages":  int(math.Ceil(float64(total) / float64(perPage))),
+		},
+		"data": revisions,
+	})
+}
+
+// GetAnnouncementRevision returns the full content of one announcement revision
+func (h *AnnouncementHandler) GetAnnouncementRevision(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return
+	}
+
+	revision, err := h.service.GetAnnouncementRevision(id, version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Announcement revision retrieved successfully",
+		"data":    revision,
+	})
+}
+
+// RollbackAnnouncement restores an announcement to a revision (recorded as a new revision)
+func (h *AnnouncementHandler) RollbackAnnouncement(c *gin.Context) {
+	editor, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return
+	}
+
+	announcement, err := h.service.RollbackAnnouncement(id, version, editor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("Announcement restored to revision #%d", version),
+		"data":    announcement,
+	})
+}
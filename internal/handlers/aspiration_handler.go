@@ -1,15 +1,19 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"bem_be/internal/models"
+	"bem_be/internal/repositories"
 	"bem_be/internal/services"
 	"bem_be/internal/utils"
 )
@@ -22,7 +26,7 @@ type AspirationHandler struct {
 
 func NewAspirationHandler(db *gorm.DB, notificationService *services.NotificationService) *AspirationHandler {
 	return &AspirationHandler{
-		service:             services.NewAspirationService(db),
+		service:             services.NewAspirationService(db, notificationService),
 		db:                  db,
 		notificationService: notificationService,
 	}
@@ -38,9 +42,42 @@ type Aspiration struct {
 	PriorityLevel string `json:"priority_level" gorm:"type:text;not null"`
 	CreatedAt     string `json:"created_at"`
 	StudentName   string `json:"student_name"`
+	// Siklus tanggapan
+	Status           string     `json:"status"`
+	OrganizationID   *int       `json:"organization_id"`
+	OrganizationName string     `json:"organization_name,omitempty"`
+	ResponseDueAt    *time.Time `json:"response_due_at,omitempty"`
+	Overdue          bool       `json:"overdue"`
+	Anonymous        bool       `json:"anonymous"`
+	Public           bool       `json:"public"`
+	VoteCount        int        `json:"vote_count"`
+	MergedIntoID     *uint      `json:"merged_into_id,omitempty"`
 }
 
+// GET /aspirations?status=&organization_id=&overdue=true
 func (h *AspirationHandler) GetAllAspirations(c *gin.Context) {
+	filter := repositories.AspirationFilter{Status: c.Query("status")}
+	if orgID := c.Query("organization_id"); orgID != "" {
+		id, err := strconv.Atoi(orgID)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, utils.ResponseHandler("error", "organization_id tidak valid", nil))
+			return
+		}
+		filter.OrganizationID = id
+	}
+	h.listAspirations(c, filter, "/aspirations")
+}
+
+// GET /aspirations/mine: aspirasi milik pengguna beserta statusnya
+func (h *AspirationHandler) GetMyAspirations(c *gin.Context) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	h.listAspirations(c, repositories.AspirationFilter{UserName: username, Status: c.Query("status")}, "/aspirations/mine")
+}
+
+func (h *AspirationHandler) listAspirations(c *gin.Context, filter repositories.AspirationFilter, path string) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
 
@@ -53,7 +90,11 @@ func (h *AspirationHandler) GetAllAspirations(c *gin.Context) {
 
 	offset := (page - 1) * perPage
 
-	aspirations, total, err := h.service.GetAllAspirations(perPage, offset)
+	aspirations, total, err := h.service.GetAllAspirations(filter, c.Query("overdue") == "true", perPage, offset)
+	if errors.Is(err, services.ErrAspirationStatus) {
+		c.JSON(http.StatusBadRequest, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", err.Error(), nil))
 		return
@@ -61,7 +102,7 @@ func (h *AspirationHandler) GetAllAspirations(c *gin.Context) {
 
 	var responseData []Aspiration
 	for _, a := range aspirations {
-		responseData = append(responseData, Aspiration{
+		item := Aspiration{
 			ID:            a.ID,
 			Content:       a.Content,
 			Title:         a.Title,
@@ -71,7 +112,19 @@ func (h *AspirationHandler) GetAllAspirations(c *gin.Context) {
 			StudentName:   a.Student.FullName,
 			CreatedAt:     a.CreatedAt.Local().Format("2006-01-02 15:04:05"),
 			// ambil nama mahasiswa
-		})
+			Status:         a.Status,
+			OrganizationID: a.OrganizationID,
+			ResponseDueAt:  a.ResponseDueAt,
+			Overdue:        a.Overdue,
+			Anonymous:      a.Anonymous,
+			Public:         a.Public,
+			VoteCount:      a.VoteCount,
+			MergedIntoID:   a.MergedIntoID,
+		}
+		if a.Organization != nil {
+			item.OrganizationName = a.Organization.Name
+		}
+		responseData = append(responseData, item)
 	}
 
 	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
@@ -81,8 +134,8 @@ func (h *AspirationHandler) GetAllAspirations(c *gin.Context) {
 		TotalItems:  int(total),
 		TotalPages:  totalPages,
 		Links: utils.PaginationLinks{
-			First: fmt.Sprintf("/aspirations?page=1&per_page=%d", perPage),
-			Last:  fmt.Sprintf("/aspirations?page=%d&per_page=%d", totalPages, perPage),
+			First: fmt.Sprintf("%s?page=1&per_page=%d", path, perPage),
+			Last:  fmt.Sprintf("%s?page=%d&per_page=%d", path, totalPages, perPage),
 		},
 	}
 
@@ -135,6 +188,13 @@ func (h *AspirationHandler) DeleteAspiration(c *gin.Context) {
 }
 
 func (h *AspirationHandler) CreateAspiration(c *gin.Context) {
+	// Pengirim diambil dari token: dipakai sebagai pemilik aspirasi dan untuk batas
+	// pengajuan harian, termasuk untuk aspirasi anonim
+	submitter, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+
 	var aspiration models.Aspiration
 
 	// ✅ Baca body JSON dari frontend
@@ -151,17 +211,55 @@ func (h *AspirationHandler) CreateAspiration(c *gin.Context) {
 		aspiration.Description == "" ||
 		aspiration.Category == "" ||
 		aspiration.PriorityLevel == "" ||
-		aspiration.Content == "" ||
-		aspiration.UserName == "" { // pastikan username dikirim dari frontend
+		aspiration.Content == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
-			"message": "Semua field wajib diisi (title, description, category, content, priority_level, username)",
+			"message": "Semua field wajib diisi (title, description, category, content, priority_level)",
 		})
 		return
 	}
 
+	// Username dari body diabaikan; aspirasi anonim tidak menyimpannya sama sekali
+	aspiration.UserName = submitter
+
 	// ✅ Simpan ke DB
-	if err := h.service.CreateAspiration(&aspiration); err != nil {
+	trackingCode, err := h.service.CreateAspiration(&aspiration, submitter)
+	if errors.Is(err, services.ErrAspirationLoginNeeded) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrAspirationRateLimited) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrAspirationAnonSecret) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrAspirationIntakeClosed) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrAspirationCategory) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": err.Error(),
@@ -179,21 +277,610 @@ func (h *AspirationHandler) CreateAspiration(c *gin.Context) {
 	}
 
 	// Simpan ke database menggunakan service
-	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message)
+	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message, services.NotificationTarget{
+		Type:       models.NotificationTypeAspiration,
+		EntityType: models.NotificationTypeAspiration,
+		EntityID:   aspiration.ID,
+		Event:      models.WebhookEventAspirationCreated,
+		Entity:     services.AspirationWebhookEntity(&aspiration),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Gagal membuat notifikasi berita",
-		})
-		return
+		// Aspirasi sudah tersimpan; kode pelacakan tetap harus sampai ke pengaju
+		log.Printf("Gagal membuat notifikasi aspirasi %d: %v", aspiration.ID, err)
 	}
 
 	// ✅ Response sukses
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"status":       "success",
 		"message":      "Aspirasi berhasil dikirim",
 		"data":         aspiration,
 		"notification": createdNotif,
+	}
+	if trackingCode != "" {
+		// Kode hanya ditampilkan sekali; server menyimpan hash-nya saja
+		response["tracking_code"] = trackingCode
+	}
+	c.JSON(http.StatusCreated, response)
+
+}
+
+// GET /aspirations/:id: detail aspirasi beserta utas tanggapan dan riwayat status
+func (h *AspirationHandler) GetAspirationDetail(c *gin.Context) {
+	h.detail(c, false)
+}
+
+func (h *AspirationHandler) AdminGetAspirationDetail(c *gin.Context) {
+	h.detail(c, true)
+}
+
+func (h *AspirationHandler) detail(c *gin.Context, isAdmin bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	aspiration, err := h.service.GetAspirationDetail(id, username, isAdmin)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan aspirasi",
+		"data":    aspiration,
+	})
+}
+
+// PUT /aspirations/:id/status
+// JSON: {"status": "under_review" | "closed", "note": "catatan untuk pengaju"}
+func (h *AspirationHandler) ChangeStatus(c *gin.Context) {
+	h.changeStatus(c, false)
+}
+
+func (h *AspirationHandler) AdminChangeStatus(c *gin.Context) {
+	h.changeStatus(c, true)
+}
+
+func (h *AspirationHandler) changeStatus(c *gin.Context, isAdmin bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var input struct {
+		Status string `json:"status" binding:"required"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	aspiration, err := h.service.ChangeStatus(id, input.Status, input.Note, username, isAdmin)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Status aspirasi berhasil diperbarui",
+		"data":    aspiration,
+	})
+}
+
+// PUT /aspirations/:id/assign
+// JSON: {"organization_id": 3, "note": "mohon ditindaklanjuti"}
+func (h *AspirationHandler) AssignAspiration(c *gin.Context) {
+	h.assign(c, false)
+}
+
+func (h *AspirationHandler) AdminAssignAspiration(c *gin.Context) {
+	h.assign(c, true)
+}
+
+func (h *AspirationHandler) assign(c *gin.Context, isAdmin bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var input struct {
+		OrganizationID int    `json:"organization_id" binding:"required"`
+		Note           string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	aspiration, err := h.service.AssignAspiration(id, input.OrganizationID, input.Note, username, isAdmin)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Aspirasi berhasil diteruskan",
+		"data":    aspiration,
+	})
+}
+
+// POST /aspirations/:id/responses
+// JSON: {"message": "...", "parent_id": 12}; pengurus menanggapi resmi, pengaju membalas
+func (h *AspirationHandler) AddResponse(c *gin.Context) {
+	h.respond(c, false)
+}
+
+func (h *AspirationHandler) AdminAddResponse(c *gin.Context) {
+	h.respond(c, true)
+}
+
+func (h *AspirationHandler) respond(c *gin.Context, isAdmin bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var input struct {
+		Message  string `json:"message" binding:"required"`
+		ParentID *uint  `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	response, err := h.service.AddResponse(id, input.ParentID, input.Message, username, isAdmin)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Tanggapan berhasil dikirim",
+		"data":    response,
+	})
+}
+
+// GET /api/aspirations/anonymity-policy: kebijakan aspirasi anonim
+func (h *AspirationHandler) GetAnonymityPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Kebijakan aspirasi anonim",
+		"data":    services.AspirationAnonymityPolicy(),
+	})
+}
+
+// POST /api/aspirations/track
+// JSON: {"tracking_code": "ASP-XXXX-XXXX-XXXX-XXXX"}; kode dikirim di body agar tidak
+// tercatat di log akses
+func (h *AspirationHandler) TrackAspiration(c *gin.Context) {
+	var input struct {
+		TrackingCode string `json:"tracking_code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	aspiration, err := h.service.TrackAspiration(input.TrackingCode)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan aspirasi",
+		"data":    aspiration,
+	})
+}
+
+// POST /api/aspirations/track/responses
+// JSON: {"tracking_code": "...", "message": "...", "parent_id": 12}
+func (h *AspirationHandler) ReplyByTrackingCode(c *gin.Context) {
+	var input struct {
+		TrackingCode string `json:"tracking_code" binding:"required"`
+		Message      string `json:"message" binding:"required"`
+		ParentID     *uint  `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	response, err := h.service.ReplyByTrackingCode(input.TrackingCode, input.ParentID, input.Message)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Balasan berhasil dikirim",
+		"data":    response,
+	})
+}
+
+// GET /aspirations/board?sort=recent|votes: papan aspirasi publik
+func (h *AspirationHandler) GetBoard(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 10
+	}
+	sort := c.DefaultQuery("sort", "recent")
+
+	aspirations, total, err := h.service.GetBoard(editorUsername(c), sort, perPage, (page-1)*perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
+	metadata := utils.PaginationMetadata{
+		CurrentPage: page,
+		PerPage:     perPage,
+		TotalItems:  int(total),
+		TotalPages:  totalPages,
+		Links: utils.PaginationLinks{
+			First: fmt.Sprintf("/aspirations/board?sort=%s&page=1&per_page=%d", sort, perPage),
+			Last:  fmt.Sprintf("/aspirations/board?sort=%s&page=%d&per_page=%d", sort, totalPages, perPage),
+		},
+	}
+	c.JSON(http.StatusOK, utils.MetadataFormatResponse("success", "Berhasil mendapatkan papan aspirasi", metadata, aspirations))
+}
+
+// GET /aspirations/trending?limit=10
+func (h *AspirationHandler) GetTrending(c *gin.Context) {
+	items, err := h.service.GetTrending(editorUsername(c), limitQuery(c), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan aspirasi trending",
+		"data":    items,
 	})
+}
+
+// GET /aspirations/most-supported?start=<RFC3339>&end=<RFC3339>&limit=10 (default bulan ini)
+func (h *AspirationHandler) GetMostSupported(c *gin.Context) {
+	start, ok := timeQuery(c, "start")
+	if !ok {
+		return
+	}
+	end, ok := timeQuery(c, "end")
+	if !ok {
+		return
+	}
+	report, err := h.service.GetMostSupported(editorUsername(c), start, end, limitQuery(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan aspirasi dengan dukungan terbanyak",
+		"data":    report,
+	})
+}
+
+// GET /aspirations/analytics?start=<RFC3339>&end=<RFC3339>&keywords=30 (default 6 bulan terakhir)
+func (h *AspirationHandler) GetAnalytics(c *gin.Context) {
+	h.analytics(c, false)
+}
+
+func (h *AspirationHandler) AdminGetAnalytics(c *gin.Context) {
+	h.analytics(c, true)
+}
+
+func (h *AspirationHandler) analytics(c *gin.Context, isAdmin bool) {
+	start, end, keywords, ok := analyticsQuery(c)
+	if !ok {
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	report, err := h.service.GetAnalytics(start, end, keywords, username, isAdmin)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan laporan aspirasi",
+		"data":    report,
+	})
+}
+
+// GET /aspirations/analytics/export?start=<RFC3339>&end=<RFC3339>&keywords=30
+func (h *AspirationHandler) ExportAnalytics(c *gin.Context) {
+	h.exportAnalytics(c, false)
+}
+
+func (h *AspirationHandler) AdminExportAnalytics(c *gin.Context) {
+	h.exportAnalytics(c, true)
+}
+
+func (h *AspirationHandler) exportAnalytics(c *gin.Context, isAdmin bool) {
+	start, end, keywords, ok := analyticsQuery(c)
+	if !ok {
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	content, filename, err := h.service.ExportAnalytics(start, end, keywords, username, isAdmin)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", content)
+}
+
+// analyticsQuery membaca ?start=, ?end= dan ?keywords= (default 30, maksimal 100)
+func analyticsQuery(c *gin.Context) (*time.Time, *time.Time, int, bool) {
+	start, ok := timeQuery(c, "start")
+	if !ok {
+		return nil, nil, 0, false
+	}
+	end, ok := timeQuery(c, "end")
+	if !ok {
+		return nil, nil, 0, false
+	}
+	keywords, err := strconv.Atoi(c.DefaultQuery("keywords", "30"))
+	if err != nil || keywords < 1 {
+		keywords = 30
+	}
+	if keywords > 100 {
+		keywords = 100
+	}
+	return start, end, keywords, true
+}
+
+// limitQuery membaca ?limit= (default 10, maksimal 50)
+func limitQuery(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		return 10
+	}
+	if limit > 50 {
+		return 50
+	}
+	return limit
+}
+
+// POST dan DELETE /aspirations/:id/vote
+func (h *AspirationHandler) Vote(c *gin.Context) {
+	h.vote(c, true)
+}
+
+func (h *AspirationHandler) Unvote(c *gin.Context) {
+	h.vote(c, false)
+}
+
+func (h *AspirationHandler) vote(c *gin.Context, vote bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	var aspiration *models.Aspiration
+	var err error
+	if vote {
+		aspiration, err = h.service.Vote(id, username)
+	} else {
+		aspiration, err = h.service.Unvote(id, username)
+	}
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	message := "Dukungan berhasil ditambahkan"
+	if !vote {
+		message = "Dukungan berhasil dibatalkan"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    aspiration,
+	})
+}
+
+// PUT /aspirations/:id/visibility
+// JSON: {"public": true, "comments_locked": false}
+func (h *AspirationHandler) SetVisibility(c *gin.Context) {
+	h.setVisibility(c, false)
+}
 
+func (h *AspirationHandler) AdminSetVisibility(c *gin.Context) {
+	h.setVisibility(c, true)
+}
+
+func (h *AspirationHandler) setVisibility(c *gin.Context, isAdmin bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var input services.AspirationVisibility
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	aspiration, err := h.service.SetVisibility(id, input, username, isAdmin)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Visibilitas aspirasi berhasil diperbarui",
+		"data":    aspiration,
+	})
+}
+
+// GET /aspirations/:id/comments
+func (h *AspirationHandler) GetComments(c *gin.Context) {
+	h.comments(c, false)
+}
+
+func (h *AspirationHandler) AdminGetComments(c *gin.Context) {
+	h.comments(c, true)
+}
+
+func (h *AspirationHandler) comments(c *gin.Context, isAdmin bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	comments, err := h.service.GetComments(id, username, isAdmin)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan komentar aspirasi",
+		"data":    comments,
+	})
+}
+
+// POST /aspirations/:id/comments
+// JSON: {"content": "..."}
+func (h *AspirationHandler) AddComment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	comment, err := h.service.AddComment(id, username, input.Content)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Komentar berhasil dikirim",
+		"data":    comment,
+	})
+}
+
+// DELETE /aspirations/:id/comments/:commentId
+func (h *AspirationHandler) DeleteComment(c *gin.Context) {
+	h.deleteComment(c, false)
+}
+
+func (h *AspirationHandler) AdminDeleteComment(c *gin.Context) {
+	h.deleteComment(c, true)
+}
+
+func (h *AspirationHandler) deleteComment(c *gin.Context, isAdmin bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	commentID, ok := parseIDParam(c, "commentId")
+	if !ok {
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteComment(id, commentID, username, isAdmin); err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Komentar berhasil dihapus",
+	})
+}
+
+// POST /aspirations/:id/merge
+// JSON: {"target_id": 7, "note": "duplikat"}; aspirasi :id digabung ke target_id
+func (h *AspirationHandler) MergeAspiration(c *gin.Context) {
+	h.merge(c, false)
+}
+
+func (h *AspirationHandler) AdminMergeAspiration(c *gin.Context) {
+	h.merge(c, true)
+}
+
+func (h *AspirationHandler) merge(c *gin.Context, isAdmin bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var input struct {
+		TargetID uint   `json:"target_id" binding:"required"`
+		Note     string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	target, err := h.service.MergeAspiration(id, input.TargetID, input.Note, username, isAdmin)
+	if err != nil {
+		c.JSON(aspirationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Aspirasi berhasil digabung",
+		"data":    target,
+	})
+}
+
+func aspirationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAspirationNotFound), errors.Is(err, services.ErrTrackingCodeInvalid),
+		errors.Is(err, services.ErrCommentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAspirationForbidden), errors.Is(err, services.ErrAspirationNotPublic),
+		errors.Is(err, services.ErrNotStudent):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrAspirationClosed):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
 }
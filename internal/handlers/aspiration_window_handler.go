@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"bem_be/internal/models"
+	"bem_be/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AspirationWindowHandler menangani jendela penerimaan aspirasi
+type AspirationWindowHandler struct {
+	service *services.AspirationWindowService
+}
+
+func NewAspirationWindowHandler(db *gorm.DB) *AspirationWindowHandler {
+	return &AspirationWindowHandler{
+		service: services.NewAspirationWindowService(db),
+	}
+}
+
+// GET /api/status dan /api/aspiration-windows/current: status penerimaan aspirasi
+func (h *AspirationWindowHandler) GetIntakeStatus(c *gin.Context) {
+	status, err := h.service.GetIntakeStatus(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// GET /admin/aspiration-windows: riwayat jendela beserta jumlah aspirasi
+func (h *AspirationWindowHandler) GetWindows(c *gin.Context) {
+	windows, err := h.service.GetWindows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan jendela aspirasi",
+		"data":    windows,
+	})
+}
+
+// GET /admin/aspiration-windows/:id
+func (h *AspirationWindowHandler) GetWindow(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	window, err := h.service.GetWindow(id)
+	if err != nil {
+		c.JSON(aspirationWindowErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan jendela aspirasi",
+		"data":    window,
+	})
+}
+
+// POST /admin/aspiration-windows
+// JSON: {"name": "Aspirasi Semester Ganjil", "opens_at": "...", "closes_at": "...",
+// "categories": "Akademik, Fasilitas", "organization_id": 3}
+func (h *AspirationWindowHandler) CreateWindow(c *gin.Context) {
+	creator, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	var window models.AspirationWindow
+	if err := c.ShouldBindJSON(&window); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.CreateWindow(&window, creator); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Jendela aspirasi berhasil dibuat",
+		"data":    window,
+	})
+}
+
+// PUT /admin/aspiration-windows/:id
+func (h *AspirationWindowHandler) UpdateWindow(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var input models.AspirationWindow
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	window, err := h.service.UpdateWindow(id, input)
+	if err != nil {
+		c.JSON(aspirationWindowErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Jendela aspirasi berhasil diperbarui",
+		"data":    window,
+	})
+}
+
+// POST /admin/aspiration-windows/:id/close: menutup jendela yang sedang terbuka sekarang
+func (h *AspirationWindowHandler) CloseWindow(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	window, err := h.service.CloseWindow(id)
+	if err != nil {
+		c.JSON(aspirationWindowErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Jendela aspirasi ditutup",
+		"data":    window,
+	})
+}
+
+// DELETE /admin/aspiration-windows/:id
+func (h *AspirationWindowHandler) DeleteWindow(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteWindow(id); err != nil {
+		c.JSON(aspirationWindowErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Jendela aspirasi berhasil dihapus",
+	})
+}
+
+func aspirationWindowErrorStatus(err error) int {
+	if errors.Is(err, services.ErrAspirationWindowNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
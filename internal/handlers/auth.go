@@ -3,12 +3,13 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 
 	"bem_be/internal/auth"
 	"bem_be/internal/database"
 	"bem_be/internal/models"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -205,40 +206,45 @@ func EditProfile(c *gin.Context) {
 	var imageName string
 
 	if err == nil {
-		imageName = fmt.Sprintf("%d_%s", student.ID, file.Filename)
-		savePath := fmt.Sprintf("uploads/user/%s", imageName)
-
-		if err := c.SaveUploadedFile(file, savePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
+		// Foto profil lewat pipeline gambar (EXIF/GPS dibuang, varian dibuat)
+		imageName, err = utils.SaveImage(file, storage.FolderUsers, utils.ImagePolicy)
+		if err != nil {
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"error": "Failed to process image: " + err.Error()})
 			return
 		}
 	}
 
 	// Update data student
+	oldImage := student.Image
 	if imageName != "" {
 		student.Image = imageName
+		student.ImageVariants = utils.ImageVariantURLs(storage.FolderUsers, imageName)
 	}
 	student.LinkedIn = request.LinkedIn
 	student.Instagram = request.Instagram
 	student.WhatsApp = request.WhatsApp
 
 	if err := database.DB.Save(&student).Error; err != nil {
+		if imageName != "" {
+			utils.RemoveImage(storage.FolderUsers, imageName)
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
 		return
 	}
+	if imageName != "" && oldImage != "" && oldImage != imageName {
+		utils.RemoveImage(storage.FolderUsers, oldImage)
+	}
 
 	// Buat URL lengkap untuk gambar (biar frontend bisa akses)
-	imageURL := ""
-	if student.Image != "" {
-		imageURL = fmt.Sprintf("http://localhost:9090/uploads/user/%s", student.Image)
-	}
+	imageURL := utils.FileURL(storage.FolderUsers, student.Image)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":   "Profile updated successfully",
-		"image":     student.Image,
-		"image_url": imageURL,
-		"linkedin":  student.LinkedIn,
-		"instagram": student.Instagram,
-		"whatsapp":  student.WhatsApp,
+		"message":        "Profile updated successfully",
+		"image":          student.Image,
+		"image_url":      imageURL,
+		"image_variants": student.ImageVariants,
+		"linkedin":       student.LinkedIn,
+		"instagram":      student.Instagram,
+		"whatsapp":       student.WhatsApp,
 	})
 }
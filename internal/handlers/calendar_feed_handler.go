@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"bem_be/internal/services"
+	"bem_be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const icsContentType = "text/calendar; charset=utf-8"
+
+// CalendarFeedHandler menyajikan feed iCalendar (.ics) untuk aplikasi kalender
+type CalendarFeedHandler struct {
+	service *services.CalendarFeedService
+}
+
+func NewCalendarFeedHandler(db *gorm.DB) *CalendarFeedHandler {
+	return &CalendarFeedHandler{service: services.NewCalendarFeedService(db)}
+}
+
+// GetPublicFeed GET /api/calendar/events.ics
+func (h *CalendarFeedHandler) GetPublicFeed(c *gin.Context) {
+	feed, err := h.service.PublicFeed()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+	serveCached(c, feed, icsContentType)
+}
+
+// GetOrganizationFeed GET /api/calendar/organizations/:organization (mis. bem.ics)
+func (h *CalendarFeedHandler) GetOrganizationFeed(c *gin.Context) {
+	feed, err := h.service.OrganizationFeed(strings.TrimSuffix(c.Param("organization"), ".ics"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+	serveCached(c, feed, icsContentType)
+}
+
+// GetPersonalFeed GET /api/calendar/personal/:token (token.ics), berisi event yang didaftari
+func (h *CalendarFeedHandler) GetPersonalFeed(c *gin.Context) {
+	feed, err := h.service.PersonalFeed(strings.TrimSuffix(c.Param("token"), ".ics"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrCalendarTokenInvalid) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+	// feed pribadi tidak boleh disimpan cache bersama
+	c.Header("Cache-Control", "private, max-age=300")
+	serveCached(c, feed, icsContentType)
+}
+
+// GetSubscription mengembalikan tautan langganan feed pribadi pengguna
+func (h *CalendarFeedHandler) GetSubscription(c *gin.Context) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	subscription, err := h.service.GetSubscription(username)
+	h.respondSubscription(c, subscription, err, "Berhasil mendapatkan tautan kalender pribadi")
+}
+
+// ResetSubscription membuat token baru; tautan lama berhenti berlaku
+func (h *CalendarFeedHandler) ResetSubscription(c *gin.Context) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	subscription, err := h.service.ResetSubscription(username)
+	h.respondSubscription(c, subscription, err, "Tautan kalender pribadi berhasil diganti")
+}
+
+func (h *CalendarFeedHandler) respondSubscription(c *gin.Context, subscription *services.CalendarSubscription, err error, message string) {
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    subscription,
+	})
+}
@@ -2,8 +2,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 	"fmt"
 
@@ -22,7 +25,7 @@ type EventHandler struct {
 
 func NewEventHandler(db *gorm.DB, notificationService *services.NotificationService) *EventHandler {
 	return &EventHandler{
-		service: services.NewCalenderService(db),
+		service: services.NewCalenderService(db, notificationService),
 		db:      db,
 		notificationService: notificationService,
 	}
@@ -45,13 +48,9 @@ func (h *EventHandler) GetEventsByMonth(c *gin.Context) {
 		return
 	}
 
-	// Tentukan rentang waktu awal dan akhir bulan
-	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	end := start.AddDate(0, 1, 0).Add(-time.Nanosecond) // hari terakhir bulan itu
-
-	var events []models.Calender
-	// ambil event yang overlap rentang bulan
-	if err := h.db.Where("end_time >= ? AND start_time <= ?", start, end).Find(&events).Error; err != nil {
+	// ambil event yang overlap rentang bulan, termasuk kemunculan event berulang
+	events, err := h.service.GetEventsByMonthYear(month, year)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -91,9 +90,9 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 		return
 	}
 
-	// Simpan (timestamps otomatis oleh GORM)
-	if err := h.db.Create(&payload).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Simpan (timestamps otomatis oleh GORM); rrule dan venue_id opsional
+	if err := h.service.CreateEvent(&payload); err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -107,7 +106,13 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 	}
 
 	// Simpan ke database menggunakan service
-	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message)
+	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message, services.NotificationTarget{
+		Type:       models.NotificationTypeEvent,
+		EntityType: models.NotificationTypeEvent,
+		EntityID:   payload.ID,
+		Event:      models.WebhookEventEventCreated,
+		Entity:     services.EventWebhookEntity(&payload),
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -124,51 +129,81 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 	})
 }
 
-// PUT /events/:id
+// PUT /events/:id?scope=all|occurrence|following&occurrence=<RFC3339>
+// Untuk event berulang, occurrence adalah original_start kemunculan yang disunting.
+// Field rrule hanya diubah jika dikirim ("" menghapus pengulangan); venue_id juga
+// (0 melepas venue, hanya untuk seluruh seri).
 func (h *EventHandler) UpdateEvent(c *gin.Context) {
 	idStr := c.Param("id")
 	id, _ := strconv.Atoi(idStr)
-	var existing models.Calender
-	if err := h.db.First(&existing, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
-		return
-	}
 
-	var payload models.Calender
+	var payload struct {
+		models.Calender
+		RRule   *string `json:"rrule"`
+		VenueID *uint   `json:"venue_id"`
+	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	// update fields
-	existing.Title = payload.Title
-	existing.Description = payload.Description
-	existing.Location = payload.Location
-	existing.StartTime = payload.StartTime
-	existing.EndTime = payload.EndTime
-
-	if existing.EndTime.Before(existing.StartTime) {
+	if payload.EndTime.Before(payload.StartTime) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "end must be after start"})
 		return
 	}
+	occurrence, ok := occurrenceQuery(c)
+	if !ok {
+		return
+	}
 
-	if err := h.db.Save(&existing).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	updated, err := h.service.UpdateEvent(uint(id), services.EventChanges{
+		Title:       payload.Title,
+		Description: payload.Description,
+		Location:    payload.Location,
+		StartTime:   payload.StartTime,
+		EndTime:     payload.EndTime,
+		RRule:       payload.RRule,
+		VenueID:     payload.VenueID,
+	}, c.Query("scope"), occurrence)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, existing)
+	c.JSON(http.StatusOK, updated)
 }
 
-// DELETE /events/:id
+// DELETE /events/:id?scope=all|occurrence|following&occurrence=<RFC3339>
 func (h *EventHandler) DeleteEvent(c *gin.Context) {
 	idStr := c.Param("id")
 	id, _ := strconv.Atoi(idStr)
-	if err := h.db.Delete(&models.Calender{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	occurrence, ok := occurrenceQuery(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteEvent(uint(id), c.Query("scope"), occurrence); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrEventNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
+// occurrenceQuery membaca ?occurrence= (waktu mulai asli kemunculan, RFC3339)
+func occurrenceQuery(c *gin.Context) (*time.Time, bool) {
+	value := c.Query("occurrence")
+	if value == "" {
+		return nil, true
+	}
+	occurrence, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "occurrence harus berformat RFC3339"})
+		return nil, false
+	}
+	return &occurrence, true
+}
+
 func (h *EventHandler) GetAllEvents(c *gin.Context) {
 	events, err := h.service.GetAllEvents()
 	if err != nil {
@@ -180,3 +215,489 @@ func (h *EventHandler) GetAllEvents(c *gin.Context) {
 		"events": events,
 	})
 }
+
+// maxICSImportSize adalah ukuran maksimum file .ics yang bisa diimpor
+const maxICSImportSize = 2 << 20
+
+// POST /events/import (multipart: file=<.ics>, organization_id opsional)
+// Hanya admin atau pengurus inti organisasi tujuan (BEM/MPM untuk event tanpa organisasi).
+func (h *EventHandler) ImportEvents(c *gin.Context) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file .ics wajib diunggah"})
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(file.Filename), ".ics") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file harus berformat .ics"})
+		return
+	}
+	if file.Size > maxICSImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ukuran file .ics maksimal 2 MB"})
+		return
+	}
+
+	var organizationID *int
+	if value := c.PostForm("organization_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "organization_id tidak valid"})
+			return
+		}
+		organizationID = &id
+	}
+	if !isAdmin(c) {
+		if err := h.service.AuthorizeImport(organizationID, username); err != nil {
+			c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer src.Close()
+
+	result, err := h.service.ImportICS(src, organizationID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("%d event dibuat, %d diperbarui, %d dilewati", result.Created, result.Updated, result.Skipped),
+		"data":    result,
+	})
+}
+
+// POST /events/:id/register
+func (h *EventHandler) RegisterEvent(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	registration, err := h.service.RegisterEvent(id, username)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "Berhasil mendaftar event"
+	if registration.Status == models.EventRegistrationWaitlisted {
+		message = fmt.Sprintf("Kuota penuh, kamu masuk daftar tunggu (urutan %d)", registration.WaitlistPosition)
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    registration,
+	})
+}
+
+// DELETE /events/:id/register
+func (h *EventHandler) CancelRegistration(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	if err := h.service.CancelRegistration(id, username); err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Pendaftaran event dibatalkan",
+	})
+}
+
+// PUT /events/:id/registration
+// JSON: {"capacity": 50, "registration_opens_at": "...", "registration_closes_at": "...",
+// "eligible_faculties": "FITE,FTI", "eligible_years": "2023,2024", "members_only": false}
+func (h *EventHandler) UpdateRegistrationSettings(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.eventOrganizer(c, id); !ok {
+		return
+	}
+	var settings services.RegistrationSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	event, err := h.service.UpdateRegistrationSettings(id, settings)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Pengaturan pendaftaran event diperbarui",
+		"data":    event,
+	})
+}
+
+// GET /events/:id/registrations?status=registered|waitlisted
+func (h *EventHandler) GetRegistrants(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.eventOrganizer(c, id); !ok {
+		return
+	}
+	registrants, summary, err := h.service.GetRegistrants(id, c.Query("status"))
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan pendaftar event",
+		"summary": summary,
+		"data":    registrants,
+	})
+}
+
+// GET /events/:id/registrations/export
+func (h *EventHandler) ExportRegistrants(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.eventOrganizer(c, id); !ok {
+		return
+	}
+	content, filename, err := h.service.ExportRegistrants(id)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", content)
+}
+
+// GET /events/my-registrations
+func (h *EventHandler) GetMyRegistrations(c *gin.Context) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	registrations, err := h.service.GetMyRegistrations(username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan pendaftaran event",
+		"data":    registrations,
+	})
+}
+
+// PUT /events/:id/attendance/settings
+// JSON: {"check_in_opens_before": 30, "check_in_closes_after": 30, "latitude": 2.38,
+// "longitude": 99.15, "geofence_radius": 150}
+func (h *EventHandler) UpdateAttendanceSettings(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.eventOrganizer(c, id); !ok {
+		return
+	}
+	var settings services.AttendanceSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	event, err := h.service.UpdateAttendanceSettings(id, settings)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Pengaturan presensi event diperbarui",
+		"data":    event,
+	})
+}
+
+// GET /events/:id/attendance/qr?occurrence=2025-10-06T09:00:00+07:00
+// Dipanggil ulang oleh layar penyelenggara setiap refresh_in detik.
+func (h *EventHandler) GetAttendanceQR(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.eventOrganizer(c, id); !ok {
+		return
+	}
+	occurrence, ok := occurrenceQuery(c)
+	if !ok {
+		return
+	}
+	qr, err := h.service.GetAttendanceQR(id, occurrence)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "QR presensi berlaku",
+		"data":    qr,
+	})
+}
+
+// POST /events/check-in
+// JSON: {"code": "<isi QR>", "latitude": 2.38, "longitude": 99.15}
+func (h *EventHandler) CheckIn(c *gin.Context) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Code      string   `json:"code" binding:"required"`
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	attendance, err := h.service.CheckIn(username, input.Code, input.Latitude, input.Longitude)
+	respondCheckIn(c, attendance, err)
+}
+
+// GET /events/:id/ticket
+func (h *EventHandler) GetAttendanceTicket(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	ticket, err := h.service.GetAttendanceTicket(id, username)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan tiket event",
+		"data":    ticket,
+	})
+}
+
+// POST /events/:id/attendance/ticket
+// JSON: {"code": "<isi QR tiket peserta>"}
+func (h *EventHandler) CheckInTicket(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	organizer, ok := h.eventOrganizer(c, id)
+	if !ok {
+		return
+	}
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	attendance, err := h.service.CheckInTicket(id, input.Code, organizer)
+	respondCheckIn(c, attendance, err)
+}
+
+// POST /events/:id/attendance
+// JSON: {"username": "ifs21001"} atau {"nim": "11S21001", "occurrence": "..."}
+func (h *EventHandler) ManualCheckIn(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	organizer, ok := h.eventOrganizer(c, id)
+	if !ok {
+		return
+	}
+	var input services.ManualCheckIn
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	attendance, err := h.service.ManualCheckInAttendee(id, input, organizer)
+	respondCheckIn(c, attendance, err)
+}
+
+// DELETE /events/:id/attendance/:username?occurrence=...
+func (h *EventHandler) RemoveAttendance(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.eventOrganizer(c, id); !ok {
+		return
+	}
+	occurrence, ok := occurrenceQuery(c)
+	if !ok {
+		return
+	}
+	if err := h.service.RemoveAttendance(id, c.Param("username"), occurrence); err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Kehadiran dihapus",
+	})
+}
+
+// GET /events/:id/attendance?occurrence=...
+func (h *EventHandler) GetAttendance(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.eventOrganizer(c, id); !ok {
+		return
+	}
+	occurrence, ok := occurrenceQuery(c)
+	if !ok {
+		return
+	}
+	attendees, summary, err := h.service.GetAttendance(id, occurrence)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan kehadiran event",
+		"summary": summary,
+		"data":    attendees,
+	})
+}
+
+// GET /events/:id/attendance/export?occurrence=...
+func (h *EventHandler) ExportAttendance(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.eventOrganizer(c, id); !ok {
+		return
+	}
+	occurrence, ok := occurrenceQuery(c)
+	if !ok {
+		return
+	}
+	content, filename, err := h.service.ExportAttendance(id, occurrence)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", content)
+}
+
+// GET /events/my-attendance
+func (h *EventHandler) GetMyAttendance(c *gin.Context) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	attendances, err := h.service.GetMyAttendance(username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan riwayat kegiatan",
+		"data":    attendances,
+	})
+}
+
+// GET /admin/students/:id/attendance
+func (h *EventHandler) GetStudentAttendance(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	attendances, err := h.service.GetStudentAttendance(id)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan riwayat kegiatan mahasiswa",
+		"data":    attendances,
+	})
+}
+
+// respondCheckIn menulis hasil check-in; check-in ulang dijawab 409 beserta data
+// kehadiran sebelumnya
+func respondCheckIn(c *gin.Context, attendance *models.EventAttendance, err error) {
+	if errors.Is(err, services.ErrAlreadyCheckedIn) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "data": attendance})
+		return
+	}
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Check-in berhasil",
+		"data":    attendance,
+	})
+}
+
+func eventErrorStatus(err error) int {
+	if errors.Is(err, services.ErrEventNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, services.ErrVenueConflict) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, services.ErrEventForbidden) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
+// eventOrganizer mengembalikan username pengguna yang login jika ia admin atau pengurus inti
+// organisasi penyelenggara event; selain itu request langsung dijawab 401/403
+func (h *EventHandler) eventOrganizer(c *gin.Context, eventID uint) (string, bool) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return "", false
+	}
+	if isAdmin(c) {
+		return username, true
+	}
+	if err := h.service.AuthorizeOrganizer(eventID, username); err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+		return "", false
+	}
+	return username, true
+}
@@ -4,15 +4,13 @@ import (
 	"fmt"
 	"math"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
-	"time"
 
 	"gorm.io/gorm"
 
 	"bem_be/internal/models"
 	"bem_be/internal/services"
+	"bem_be/internal/storage"
 	"bem_be/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -139,7 +137,7 @@ func (h *ClubHandler) CreateClub(c *gin.Context) {
 
 	// kirim ke service
 	if err := h.service.CreateClub(&club, file); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(utils.UploadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -153,7 +151,13 @@ func (h *ClubHandler) CreateClub(c *gin.Context) {
 	}
 
 	// Simpan ke database menggunakan service
-	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message)
+	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message, services.NotificationTarget{
+		Type:       models.NotificationTypeOrganization,
+		EntityType: models.NotificationTypeOrganization,
+		EntityID:   club.ID,
+		Event:      models.WebhookEventOrganizationCreated,
+		Entity:     services.OrganizationWebhookEntity(&club),
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -202,18 +206,10 @@ func (h *ClubHandler) UpdateClub(c *gin.Context) {
 	// Handle image upload if provided
 	file, err := c.FormFile("image")
 	if err == nil {
-		uploadPath := "uploads/clubs"
-		if err := os.MkdirAll(uploadPath, os.ModePerm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
-			return
-		}
-
-		// Generate unique filename
-		fileName := fmt.Sprintf("%d_%s", time.Now().Unix(), filepath.Base(file.Filename))
-		filePath := filepath.Join(uploadPath, fileName)
-
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		// Validasi isi, buang EXIF dan buat varian thumbnail/medium/original
+		fileName, err := utils.SaveImage(file, storage.FolderClubs, utils.ImagePolicy)
+		if err != nil {
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"error": "Failed to process image: " + err.Error()})
 			return
 		}
 
@@ -4,15 +4,13 @@ import (
 	"fmt"
 	"math"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
-	"time"
 
 	"gorm.io/gorm"
 
 	"bem_be/internal/models"
 	"bem_be/internal/services"
+	"bem_be/internal/storage"
 	"bem_be/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -177,7 +175,7 @@ func (h *DepartmentHandler) CreateDepartment(c *gin.Context) {
 
 	// kirim ke service
 	if err := h.service.CreateDepartment(&department, file); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(utils.UploadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -191,7 +189,13 @@ func (h *DepartmentHandler) CreateDepartment(c *gin.Context) {
 	}
 
 	// Simpan ke database menggunakan service
-	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message)
+	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message, services.NotificationTarget{
+		Type:       models.NotificationTypeOrganization,
+		EntityType: models.NotificationTypeOrganization,
+		EntityID:   department.ID,
+		Event:      models.WebhookEventOrganizationCreated,
+		Entity:     services.OrganizationWebhookEntity(&department),
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -239,18 +243,10 @@ func (h *DepartmentHandler) UpdateDepartment(c *gin.Context) {
 	// Handle image upload if provided
 	file, err := c.FormFile("image")
 	if err == nil {
-		uploadPath := "uploads/departments"
-		if err := os.MkdirAll(uploadPath, os.ModePerm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
-			return
-		}
-
-		// Generate unique filename
-		fileName := fmt.Sprintf("%d_%s", time.Now().Unix(), filepath.Base(file.Filename))
-		filePath := filepath.Join(uploadPath, fileName)
-
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		// Validasi isi, buang EXIF dan buat varian thumbnail/medium/original
+		fileName, err := utils.SaveImage(file, storage.FolderDepartments, utils.ImagePolicy)
+		if err != nil {
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"error": "Failed to process image: " + err.Error()})
 			return
 		}
 
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"bem_be/internal/services"
+	"bem_be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeedHandler menyajikan feed RSS/Atom untuk pelanggan di luar frontend
+type FeedHandler struct {
+	service *services.FeedService
+}
+
+func NewFeedHandler() *FeedHandler {
+	return &FeedHandler{service: services.NewFeedService()}
+}
+
+// GetNewsFeed GET /api/feeds/news/:format?category=
+func (h *FeedHandler) GetNewsFeed(c *gin.Context) {
+	format, ok := feedFormat(c)
+	if !ok {
+		return
+	}
+	feed, err := h.service.NewsFeed(format, c.Query("category"))
+	h.respond(c, format, feed, err)
+}
+
+// GetAnnouncementFeed GET /api/feeds/announcements/:format?organization=<short_name>
+func (h *FeedHandler) GetAnnouncementFeed(c *gin.Context) {
+	format, ok := feedFormat(c)
+	if !ok {
+		return
+	}
+	feed, err := h.service.AnnouncementFeed(format, c.Query("organization"))
+	h.respond(c, format, feed, err)
+}
+
+// feedFormat memvalidasi :format sebelum feed dibangun
+func feedFormat(c *gin.Context) (string, bool) {
+	format := c.Param("format")
+	if format != services.FeedFormatRSS && format != services.FeedFormatAtom {
+		c.JSON(http.StatusNotFound, utils.ResponseHandler("error", "Format feed harus rss atau atom", nil))
+		return "", false
+	}
+	return format, true
+}
+
+// respond menulis feed lewat serveCached
+func (h *FeedHandler) respond(c *gin.Context, format string, feed *services.Feed, err error) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+
+	contentType := "application/rss+xml; charset=utf-8"
+	if format == services.FeedFormatAtom {
+		contentType = "application/atom+xml; charset=utf-8"
+	}
+	serveCached(c, feed, contentType)
+}
+
+// serveCached menulis dokumen dengan ETag/Last-Modified dan membalas 304 jika klien sudah
+// punya versi terbaru. Cache-Control yang sudah diatur pemanggil tidak ditimpa.
+func serveCached(c *gin.Context, feed *services.Feed, contentType string) {
+	sum := sha1.Sum(feed.Body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	c.Header("ETag", etag)
+	if !feed.LastModified.IsZero() {
+		c.Header("Last-Modified", feed.LastModified.Format(http.TimeFormat))
+	}
+	if c.Writer.Header().Get("Cache-Control") == "" {
+		c.Header("Cache-Control", "public, max-age=300")
+	}
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				c.Status(http.StatusNotModified)
+				return
+			}
+		}
+	} else if since := c.GetHeader("If-Modified-Since"); since != "" && !feed.LastModified.IsZero() {
+		if t, err := http.ParseTime(since); err == nil && !feed.LastModified.After(t.Add(time.Second-1)) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.Data(http.StatusOK, contentType, feed.Body)
+}
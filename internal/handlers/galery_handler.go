@@ -1,19 +1,17 @@
-
 package handlers
 
 import (
 	"bem_be/internal/models"
+	"bem_be/internal/repositories"
 	"bem_be/internal/services"
+	"bem_be/internal/storage"
 	"bem_be/internal/utils"
 	"fmt"
-	"io"
 	"math"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -29,11 +27,6 @@ func NewGaleryHandler(db *gorm.DB) *GaleryHandler {
 	}
 }
 
-const (
-	maxUploadSize = 5 << 20
-	uploadDir     = "uploads/galery"
-)
-
 func parseIDParam(c *gin.Context, name string) (uint, bool) {
 	idStr := c.Param(name)
 	v, err := strconv.ParseUint(idStr, 10, 64)
@@ -45,166 +38,394 @@ func parseIDParam(c *gin.Context, name string) (uint, bool) {
 }
 
 func saveImage(c *gin.Context, field string) (string, error) {
-	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.ImagePolicy.MaxRequestSize())
 
 	file, err := c.FormFile(field)
 	if err != nil {
 		return "", err
 	}
+	return saveImageFile(file)
+}
+
+// saveImageFile menyimpan foto galeri lewat pipeline gambar dan mengembalikan nama filenya
+func saveImageFile(file *multipart.FileHeader) (string, error) {
+	return utils.SaveImage(file, storage.FolderGalery, utils.GaleryPhotoPolicy)
+}
+
+func (h *GaleryHandler) GetGaleryByID(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
 
-	src, err := file.Open()
+	result, err := h.service.GetGaleryWithStats(id)
 	if err != nil {
-		return "", fmt.Errorf("gagal membuka file")
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Galeri tidak ditemukan"})
+		return
 	}
-	defer src.Close()
 
-	head := make([]byte, 512)
-	_, _ = src.Read(head)
-	mimeType := http.DetectContentType(head)
-	if _, err := src.Seek(0, io.SeekStart); err != nil {
-		return "", fmt.Errorf("gagal membaca file")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Galeri berhasil didapatkan",
+		"data":    result,
+	})
+}
+
+// ===== Album =====
+
+const galeryScopeKey = "galeryOrganizationID"
+
+// OfficerScope membatasi pengelolaan album ke organisasi pengurus inti yang sedang login
+// (username dari token, dipasang setelah middleware auth). Dipasang di route mahasiswa;
+// route admin tidak memakai middleware ini sehingga tanpa batasan.
+func (h *GaleryHandler) OfficerScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, ok := currentUsername(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		orgID, err := h.service.GetOfficerOrganization(username)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+			c.Abort()
+			return
+		}
+		c.Set(galeryScopeKey, orgID)
+		c.Next()
 	}
+}
 
-	allowed := map[string]bool{
-		"image/jpeg": true,
-		"image/png":  true,
-		"image/webp": true,
+func galeryScope(c *gin.Context) *uint {
+	if v, ok := c.Get(galeryScopeKey); ok {
+		return v.(*uint)
 	}
-	if !allowed[mimeType] {
-		return "", fmt.Errorf("tipe file tidak didukung (hanya jpg/png/webp)")
+	return nil
+}
+
+func galeryError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if err == services.ErrGaleryForbidden {
+		status = http.StatusForbidden
 	}
+	c.JSON(status, gin.H{"status": "error", "message": err.Error()})
+}
 
-	if err := os.MkdirAll(uploadDir, 0o755); err != nil {
-		return "", fmt.Errorf("gagal membuat folder upload")
+// GetAlbums menampilkan daftar album (publik)
+// ?organization_id=&event_id=&title=&page=&per_page=
+func (h *GaleryHandler) GetAlbums(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "12"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 12
 	}
 
-	name := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
-	path := filepath.Join(uploadDir, name)
+	filter := repositories.GaleryAlbumFilter{
+		OrganizationID: parseOptionalUint(c.Query("organization_id")),
+		EventID:        parseOptionalUint(c.Query("event_id")),
+		Title:          c.Query("title"),
+	}
+	if scope := galeryScope(c); scope != nil {
+		filter.OrganizationID = scope
+	}
 
-	if err := c.SaveUploadedFile(file, path); err != nil {
-		return "", fmt.Errorf("gagal menyimpan file")
+	albums, total, err := h.service.GetAlbums(filter, perPage, (page-1)*perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", err.Error(), nil))
+		return
 	}
-	return path, nil
-}
 
-func (h *GaleryHandler) CreateGalery(c *gin.Context) {
-	ct := c.ContentType()
-	var galery models.Galery
+	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
+	metadata := utils.PaginationMetadata{
+		CurrentPage: page,
+		PerPage:     perPage,
+		TotalItems:  int(total),
+		TotalPages:  totalPages,
+		Links: utils.PaginationLinks{
+			First: fmt.Sprintf("/galery/albums?page=1&per_page=%d", perPage),
+			Last:  fmt.Sprintf("/galery/albums?page=%d&per_page=%d", totalPages, perPage),
+		},
+	}
 
-	switch {
-	case strings.HasPrefix(ct, "multipart/form-data"):
-		galery.Title = c.PostForm("title")
-		galery.Content = c.PostForm("content")
-		path, err := saveImage(c, "image_url")
-		if err != nil {
-			if err != http.ErrMissingFile {
-				c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Gagal memproses file: " + err.Error()})
-				return
-			}
-		} else {
-			galery.ImageURL = path
-		}
+	c.JSON(http.StatusOK, utils.MetadataFormatResponse("success", "Berhasil mendapatkan daftar album", metadata, albums))
+}
 
-	case strings.HasPrefix(ct, "application/json"):
-		if err := c.ShouldBindJSON(&galery); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Body JSON tidak valid"})
-			return
-		}
+// GetAlbumByID menampilkan album beserta fotonya (publik, foto dipaginasi)
+func (h *GaleryHandler) GetAlbumByID(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
 
-	default:
-		c.JSON(http.StatusUnsupportedMediaType, gin.H{"status": "error", "message": "Gunakan application/json atau multipart/form-data"})
+	album, err := h.service.GetAlbumByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
-	if err := h.service.CreateGalery(&galery); err != nil {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "30"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 30
+	}
+
+	photos, total, err := h.service.GetAlbumPhotos(album.ID, perPage, (page-1)*perPage)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
+	album.Photos = photos
+	album.PhotoCount = total
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Album berhasil didapatkan",
+		"metadata": gin.H{
+			"current_page": page,
+			"per_page":     perPage,
+			"total_items":  total,
+			"total_pages":  int(math.Ceil(float64(total) / float64(perPage))),
+		},
+		"data": album,
+	})
+}
+
+type albumRequest struct {
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	EventID        *uint  `json:"event_id"`
+	OrganizationID *uint  `json:"organization_id"`
+	SortOrder      *int   `json:"sort_order"`
+}
+
+// CreateAlbum membuat album baru
+func (h *GaleryHandler) CreateAlbum(c *gin.Context) {
+	var req albumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Body JSON tidak valid"})
+		return
+	}
+
+	album := models.GaleryAlbum{
+		Title:          strings.TrimSpace(req.Title),
+		Description:    req.Description,
+		EventID:        req.EventID,
+		OrganizationID: req.OrganizationID,
+	}
+	if req.SortOrder != nil {
+		album.SortOrder = *req.SortOrder
+	}
+
+	if err := h.service.CreateAlbum(&album, galeryScope(c)); err != nil {
+		galeryError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  "success",
-		"message": "Galeri berhasil dibuat",
-		"data":    galery,
+		"message": "Album berhasil dibuat",
+		"data":    album,
 	})
 }
-func (h *GaleryHandler) UpdateGalery(c *gin.Context) {
+
+// UpdateAlbum memperbarui album; field yang tidak dikirim tidak diubah
+func (h *GaleryHandler) UpdateAlbum(c *gin.Context) {
 	id, ok := parseIDParam(c, "id")
 	if !ok {
 		return
 	}
-	existing, err := h.service.GetGaleryByID(id)
+	scope := galeryScope(c)
+
+	album, err := h.service.GetManagedAlbum(id, scope)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Galeri tidak ditemukan"})
+		galeryError(c, err)
 		return
 	}
 
-	ct := c.ContentType()
+	var req albumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Body JSON tidak valid"})
+		return
+	}
+	if req.Title != "" {
+		album.Title = strings.TrimSpace(req.Title)
+	}
+	if req.Description != "" {
+		album.Description = req.Description
+	}
+	if req.EventID != nil {
+		album.EventID = req.EventID
+	}
+	if req.OrganizationID != nil && scope == nil {
+		album.OrganizationID = req.OrganizationID
+	}
+	if req.SortOrder != nil {
+		album.SortOrder = *req.SortOrder
+	}
 
-	switch {
-	case strings.HasPrefix(ct, "multipart/form-data"):
-		if v := c.PostForm("title"); v != "" {
-			existing.Title = v
-		}
-		if v := c.PostForm("description"); v != "" {
-			existing.Content = v
-		}
+	if err := h.service.UpdateAlbum(album, scope); err != nil {
+		galeryError(c, err)
+		return
+	}
 
-		// File opsional
-		path, err := saveImage(c, "image")
-		if err == nil {
-			// Hapus file lama jika ada
-			if existing.ImageURL != "" {
-				_ = os.Remove(existing.ImageURL)
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Album berhasil diperbarui",
+		"data":    album,
+	})
+}
+
+// DeleteAlbum menghapus album beserta seluruh fotonya
+func (h *GaleryHandler) DeleteAlbum(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	files, err := h.service.DeleteAlbum(id, galeryScope(c))
+	if err != nil {
+		galeryError(c, err)
+		return
+	}
+	for _, file := range files {
+		utils.RemoveImage(storage.FolderGalery, file)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Album berhasil dihapus",
+	})
+}
+
+// UploadAlbumPhotos mengunggah beberapa foto sekaligus ke album.
+// multipart: images[] (file), captions[] (opsional, sesuai urutan file)
+func (h *GaleryHandler) UploadAlbumPhotos(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	album, err := h.service.GetManagedAlbum(id, galeryScope(c))
+	if err != nil {
+		galeryError(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.GaleryPhotoPolicy.MaxRequestSize())
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Gagal membaca form: " + err.Error()})
+		return
+	}
+	files := form.File["images"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Minimal satu file pada field images"})
+		return
+	}
+	if err := utils.GaleryPhotoPolicy.CheckCount(len(files)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	captions := form.Value["captions"]
+
+	photos := make([]models.Galery, 0, len(files))
+	saved := make([]string, 0, len(files))
+	for i, file := range files {
+		path, err := saveImageFile(file)
+		if err != nil {
+			for _, p := range saved {
+				utils.RemoveImage(storage.FolderGalery, p)
 			}
-			existing.ImageURL = path
-		} else if err != http.ErrMissingFile {
 			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Gagal memproses file: " + err.Error()})
 			return
 		}
+		saved = append(saved, path)
 
-	case strings.HasPrefix(ct, "application/json"):
-		var payload models.Galery
-		if err := c.ShouldBindJSON(&payload); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Body JSON tidak valid"})
-			return
-		}
-		if payload.Title != "" {
-			existing.Title = payload.Title
+		photo := models.Galery{ImageURL: path}
+		if i < len(captions) {
+			photo.Caption = captions[i]
+			photo.Content = captions[i]
 		}
-		if payload.Content != "" {
-			existing.Content = payload.Content
+		photos = append(photos, photo)
+	}
+
+	photos, err = h.service.AddPhotos(album, photos)
+	if err != nil {
+		for _, p := range saved {
+			utils.RemoveImage(storage.FolderGalery, p)
 		}
-	default:
-		c.JSON(http.StatusUnsupportedMediaType, gin.H{"status": "error", "message": "Gunakan application/json atau multipart/form-data"})
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
-	if err := h.service.UpdateGalery(existing); err != nil {
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("%d foto berhasil diunggah", len(photos)),
+		"data":    photos,
+	})
+}
+
+// UpdatePhoto memperbarui keterangan foto
+func (h *GaleryHandler) UpdatePhoto(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	photo, err := h.service.GetManagedPhoto(id, galeryScope(c))
+	if err != nil {
+		galeryError(c, err)
+		return
+	}
+
+	var req struct {
+		Caption *string `json:"caption"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Body JSON tidak valid"})
+		return
+	}
+	if req.Caption != nil {
+		photo.Caption = *req.Caption
+		photo.Content = *req.Caption
+	}
+
+	if err := h.service.UpdatePhoto(photo); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
-		"message": "Galeri berhasil diperbarui",
-		"data":    existing,
+		"message": "Foto berhasil diperbarui",
+		"data":    photo,
 	})
 }
 
-func (h *GaleryHandler) DeleteGalery(c *gin.Context) {
+// DeletePhoto menghapus satu foto album
+func (h *GaleryHandler) DeletePhoto(c *gin.Context) {
 	id, ok := parseIDParam(c, "id")
 	if !ok {
 		return
 	}
-	gal, _ := h.service.GetGaleryByID(id)
 
-	if err := h.service.DeleteGalery(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+	photo, err := h.service.GetManagedPhoto(id, galeryScope(c))
+	if err != nil {
+		galeryError(c, err)
 		return
 	}
-	if gal != nil && gal.ImageURL != "" {
-		_ = os.Remove(gal.ImageURL)
+
+	if err := h.service.DeletePhoto(photo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
 	}
+	utils.RemoveImage(storage.FolderGalery, photo.ImageURL)
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
@@ -212,63 +433,67 @@ func (h *GaleryHandler) DeleteGalery(c *gin.Context) {
 	})
 }
 
-func (h *GaleryHandler) GetAllGalerys(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if perPage < 1 {
-		perPage = 10
+// ReorderAlbumPhotos mengatur urutan foto. JSON: {"photo_ids": [3, 1, 2]}
+func (h *GaleryHandler) ReorderAlbumPhotos(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
 	}
 
-	offset := (page - 1) * perPage
-
-	galerys, total, err := h.service.GetAllGalerys(perPage, offset)
+	album, err := h.service.GetManagedAlbum(id, galeryScope(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", err.Error(), nil))
+		galeryError(c, err)
 		return
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
-
-	metadata := utils.PaginationMetadata{
-		CurrentPage: page,
-		PerPage:     perPage,
-		TotalItems:  int(total),
-		TotalPages:  totalPages,
-		Links: utils.PaginationLinks{
-			First: fmt.Sprintf("/galerys?page=1&per_page=%d", perPage),
-			Last:  fmt.Sprintf("/galerys?page=%d&per_page=%d", totalPages, perPage),
-		},
+	var req struct {
+		PhotoIDs []uint `json:"photo_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Body JSON tidak valid"})
+		return
 	}
 
-	response := utils.MetadataFormatResponse(
-		"success",
-		"Berhasil mendapatkan daftar gambar",
-		metadata,
-		galerys,
-	)
+	if err := h.service.ReorderPhotos(album.ID, req.PhotoIDs); err != nil {
+		galeryError(c, err)
+		return
+	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Urutan foto berhasil disimpan",
+	})
 }
 
-func (h *GaleryHandler) GetGaleryByID(c *gin.Context) {
+// SetAlbumCover menjadikan foto sebagai sampul album. JSON: {"photo_id": 3}
+func (h *GaleryHandler) SetAlbumCover(c *gin.Context) {
 	id, ok := parseIDParam(c, "id")
 	if !ok {
 		return
 	}
 
-	result, err := h.service.GetGaleryWithStats(id)
+	album, err := h.service.GetManagedAlbum(id, galeryScope(c))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Galeri tidak ditemukan"})
+		galeryError(c, err)
+		return
+	}
+
+	var req struct {
+		PhotoID uint `json:"photo_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.PhotoID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "photo_id wajib diisi"})
+		return
+	}
+
+	if err := h.service.SetCover(album, req.PhotoID); err != nil {
+		galeryError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
-		"message": "Galeri berhasil didapatkan",
-		"data":    result,
+		"message": "Sampul album berhasil diubah",
+		"data":    album,
 	})
-}
\ No newline at end of file
+}
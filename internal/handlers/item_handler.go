@@ -49,7 +49,7 @@ func (h *ItemHandler) CreateItemSarpras(c *gin.Context) {
 
 	// kirim ke service
 	if err := h.service.CreateItemSarpras(&item, file); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(utils.UploadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -218,7 +218,7 @@ func (h *ItemHandler) CreateItemDepol(c *gin.Context) {
 
 	// kirim ke service
 	if err := h.service.CreateItemDepol(&item, file); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(utils.UploadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
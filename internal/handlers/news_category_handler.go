@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bem_be/internal/models"
+	"bem_be/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewsCategoryHandler menangani request HTTP kategori dan tag berita
+type NewsCategoryHandler struct {
+	service *services.NewsCategoryService
+}
+
+// NewNewsCategoryHandler membuat handler kategori berita baru
+func NewNewsCategoryHandler() *NewsCategoryHandler {
+	return &NewsCategoryHandler{
+		service: services.NewNewsCategoryService(),
+	}
+}
+
+// GetPublicCategories menampilkan kategori beserta jumlah berita terbit
+func (h *NewsCategoryHandler) GetPublicCategories(c *gin.Context) {
+	h.listCategories(c, true)
+}
+
+// GetCategories menampilkan kategori beserta jumlah semua berita (untuk editor)
+func (h *NewsCategoryHandler) GetCategories(c *gin.Context) {
+	h.listCategories(c, false)
+}
+
+func (h *NewsCategoryHandler) listCategories(c *gin.Context, publishedOnly bool) {
+	categories, err := h.service.GetCategories(publishedOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan kategori berita",
+		"data":    categories,
+	})
+}
+
+// CreateCategory membuat kategori berita
+// JSON: {"name": "Kegiatan Kampus", "slug": "kegiatan-kampus", "description": "", "sort_order": 1}
+func (h *NewsCategoryHandler) CreateCategory(c *gin.Context) {
+	var category models.NewsCategory
+	if err := c.ShouldBindJSON(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Input tidak valid: " + err.Error()})
+		return
+	}
+	category.ID = 0
+
+	if err := h.service.CreateCategory(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Kategori berita berhasil dibuat",
+		"data":    category,
+	})
+}
+
+// UpdateCategory memperbarui nama, slug, deskripsi dan urutan kategori
+func (h *NewsCategoryHandler) UpdateCategory(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var input models.NewsCategory
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	category, err := h.service.UpdateCategory(id, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Kategori berita berhasil diperbarui",
+		"data":    category,
+	})
+}
+
+// DeleteCategory menghapus kategori yang tidak lagi dipakai berita
+func (h *NewsCategoryHandler) DeleteCategory(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteCategory(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Kategori berita berhasil dihapus",
+	})
+}
+
+// ReorderCategories menyimpan urutan kategori
+// JSON: {"category_ids": [3, 1, 2]}
+func (h *NewsCategoryHandler) ReorderCategories(c *gin.Context) {
+	var input struct {
+		CategoryIDs []uint `json:"category_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Input tidak valid: " + err.Error()})
+		return
+	}
+	if err := h.service.ReorderCategories(input.CategoryIDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	h.listCategories(c, false)
+}
+
+// GetPublicTags menampilkan tag beserta jumlah berita terbit
+func (h *NewsCategoryHandler) GetPublicTags(c *gin.Context) {
+	h.listTags(c, true)
+}
+
+// GetTags menampilkan tag beserta jumlah semua berita (untuk editor)
+func (h *NewsCategoryHandler) GetTags(c *gin.Context) {
+	h.listTags(c, false)
+}
+
+func (h *NewsCategoryHandler) listTags(c *gin.Context, publishedOnly bool) {
+	tags, err := h.service.GetTags(publishedOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan tag berita",
+		"data":    tags,
+	})
+}
+
+// CreateTag membuat tag baru (atau mengembalikan tag dengan slug yang sama)
+// JSON: {"name": "Pemilu Raya"}
+func (h *NewsCategoryHandler) CreateTag(c *gin.Context) {
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	tags, err := h.service.ResolveTags([]string{input.Name})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if len(tags) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Nama tag harus mengandung huruf atau angka"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Tag berita berhasil dibuat",
+		"data":    tags[0],
+	})
+}
+
+// UpdateTag mengganti nama dan slug tag
+// JSON: {"name": "Pemira", "slug": "pemira"}
+func (h *NewsCategoryHandler) UpdateTag(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var input struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	tag, err := h.service.UpdateTag(id, input.Name, input.Slug)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Tag berita berhasil diperbarui",
+		"data":    tag,
+	})
+}
+
+// DeleteTag menghapus tag dari semua berita
+func (h *NewsCategoryHandler) DeleteTag(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteTag(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Tag berita berhasil dihapus",
+	})
+}
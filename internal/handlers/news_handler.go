@@ -2,13 +2,18 @@ package handlers
 
 import (
 	"bem_be/internal/models"
+	"bem_be/internal/repositories"
 	"bem_be/internal/services"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -26,7 +31,7 @@ type NewsHandler struct {
 // NewNewsHandler membuat handler berita baru
 func NewNewsHandler(db *gorm.DB, notificationService *services.NotificationService) *NewsHandler {
 	return &NewsHandler{
-		service:             services.NewNewsService(db),
+		service:             services.NewNewsService(db, notificationService),
 		notificationService: notificationService,
 	}
 }
@@ -44,8 +49,19 @@ func parseOptionalUint(value string) *uint {
 	return &u
 }
 
-// GetAllNews mengembalikan semua berita dengan pagination
+// GetAllNews mengembalikan semua berita dengan pagination (untuk editor, semua status)
+// ?status=&category=<slug>&tag=<slug>
 func (h *NewsHandler) GetAllNews(c *gin.Context) {
+	h.listNews(c, c.Query("status"))
+}
+
+// GetPublishedNews mengembalikan berita yang sudah terbit untuk halaman publik
+// ?category=<slug>&tag=<slug>
+func (h *NewsHandler) GetPublishedNews(c *gin.Context) {
+	h.listNews(c, models.NewsStatusPublished)
+}
+
+func (h *NewsHandler) listNews(c *gin.Context, status string) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
 
@@ -58,7 +74,11 @@ func (h *NewsHandler) GetAllNews(c *gin.Context) {
 
 	offset := (page - 1) * perPage
 
-	newsList, total, err := h.service.GetAllNews(perPage, offset)
+	newsList, total, err := h.service.GetAllNews(perPage, offset, repositories.NewsFilter{
+		Status:       status,
+		CategorySlug: c.Query("category"),
+		TagSlug:      c.Query("tag"),
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
@@ -112,11 +132,163 @@ func (h *NewsHandler) GetNewsByID(c *gin.Context) {
 	})
 }
 
+// GetPublishedNewsByID mengembalikan berita terbit berdasarkan ID atau slug (halaman publik).
+// Slug lama dialihkan (301) ke slug terbaru.
+func (h *NewsHandler) GetPublishedNewsByID(c *gin.Context) {
+	idStr := c.Param("id")
+	var news *models.News
+	if id, err := strconv.ParseUint(idStr, 10, 64); err == nil {
+		news, err = h.service.GetPublishedNewsByID(uint(id))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "News not found"})
+			return
+		}
+	} else {
+		var redirected bool
+		news, redirected, err = h.service.GetPublishedNewsBySlug(idStr)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "News not found"})
+			return
+		}
+		if redirected {
+			redirectToSlug(c, news.Slug)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "News retrieved successfully",
+		"data":    news,
+	})
+}
+
+// PreviewNews menampilkan berita apa pun statusnya (draft, review, terjadwal)
+// persis seperti yang akan dilihat pembaca setelah terbit; hanya untuk penulis dan penyunting
+func (h *NewsHandler) PreviewNews(c *gin.Context) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Format ID tidak valid"})
+		return
+	}
+
+	news, err := h.service.PreviewNews(uint(id), username, isAdmin(c))
+	if errors.Is(err, services.ErrNewsForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Pratinjau berita",
+		"preview": news.Status != models.NewsStatusPublished,
+		"data":    news,
+	})
+}
+
+// ChangeNewsStatus memindahkan berita dalam alur draft → in_review → scheduled/published → archived
+// Body: {"status": "scheduled", "publish_at": "2025-01-31T08:00:00+07:00"}
+// Penulis hanya boleh mengajukan/menarik review; menjadwalkan dan menerbitkan oleh penyunting.
+func (h *NewsHandler) ChangeNewsStatus(c *gin.Context) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Format ID tidak valid"})
+		return
+	}
+
+	var input struct {
+		Status    string     `json:"status" binding:"required"`
+		PublishAt *time.Time `json:"publish_at"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	news, err := h.service.ChangeStatus(uint(id), input.Status, input.PublishAt, username, isAdmin(c))
+	if errors.Is(err, services.ErrNewsForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Status berita berhasil diperbarui",
+		"data":    news,
+	})
+}
+
+// categoryInput mengambil kategori dari form: category_id lebih diutamakan daripada category
+func categoryInput(c *gin.Context) string {
+	if id := c.PostForm("category_id"); id != "" {
+		return id
+	}
+	return strings.TrimSpace(c.PostForm("category"))
+}
+
+// redirectToSlug mengarahkan permintaan slug lama ke slug terbaru (301) pada route yang sama
+func redirectToSlug(c *gin.Context, slug string) {
+	location := path.Join(path.Dir(c.Request.URL.Path), url.PathEscape(slug))
+	if c.Request.URL.RawQuery != "" {
+		location += "?" + c.Request.URL.RawQuery
+	}
+	c.Header("Location", location)
+	c.JSON(http.StatusMovedPermanently, gin.H{
+		"status":  "redirect",
+		"message": "Slug sudah diganti",
+		"slug":    slug,
+	})
+}
+
+// editorUsername mengembalikan username dari token bila ada; kosong untuk pengunjung tanpa
+// login. Hanya untuk penanda tampilan seperti status dukungan, bukan untuk mencatat pelaku.
+func editorUsername(c *gin.Context) string {
+	return c.GetString("username")
+}
+
+// currentUsername mengembalikan username pengguna yang login, diisi middleware auth dari token.
+// Tanpa token handler langsung dibalas 401.
+func currentUsername(c *gin.Context) (string, bool) {
+	username := c.GetString("username")
+	if username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login diperlukan untuk mengakses fitur ini"})
+		return "", false
+	}
+	return username, true
+}
+
+// isAdmin melaporkan apakah pengguna yang login ber-role admin
+func isAdmin(c *gin.Context) bool {
+	return strings.EqualFold(c.GetString("role"), "admin")
+}
+
 // buat sanitizer sekali untuk dipakai ulang
 var htmlSanitizer = bluemonday.UGCPolicy()
 
 // CreateNews membuat berita baru (dengan unggahan file opsional)
 func (h *NewsHandler) CreateNews(c *gin.Context) {
+	editor, ok := currentUsername(c)
+	if !ok {
+		return
+	}
 	var news models.News
 
 	news.Title = c.PostForm("title")
@@ -124,28 +296,31 @@ func (h *NewsHandler) CreateNews(c *gin.Context) {
 	rawContent := c.PostForm("content")
 	news.Content = htmlSanitizer.Sanitize(rawContent)
 
-	news.Category = c.PostForm("category")
-
-	file, err := c.FormFile("image")
-	if err == nil {
-		uploadPath := "uploads/news"
-		if err := os.MkdirAll(uploadPath, os.ModePerm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Tidak dapat membuat folder unggahan",
-			})
+	// kategori terkelola: category_id, atau category berisi slug/nama kategori
+	if category := categoryInput(c); category != "" {
+		if err := h.service.SetCategory(&news, category); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
 			return
 		}
+	}
+	if tags, ok := c.GetPostForm("tags"); ok {
+		if err := h.service.SetTags(&news, strings.Split(tags, ",")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+	}
+	news.Status = c.DefaultPostForm("status", models.NewsStatusDraft)
+	news.Slug = c.PostForm("slug")
+	news.MetaDescription = c.PostForm("meta_description")
 
-		// buat nama file unik
-		fileName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
-		filePath := filepath.Join(uploadPath, fileName)
-
-		// simpan file ke folder
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+	file, err := c.FormFile("image")
+	if err == nil {
+		// pipeline gambar: validasi isi, buang EXIF, buat varian thumbnail/medium/original
+		fileName, err := utils.SaveImage(file, storage.FolderNews, utils.ImagePolicy)
+		if err != nil {
+			c.JSON(utils.UploadErrorStatus(err), gin.H{
 				"status":  "error",
-				"message": "Gagal menyimpan file",
+				"message": "Gagal memproses gambar: " + err.Error(),
 			})
 			return
 		}
@@ -160,44 +335,45 @@ func (h *NewsHandler) CreateNews(c *gin.Context) {
 		return
 	}
 
-	// Simpan berita ke database
-	if err := h.service.CreateNews(&news); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
-		return
-	}
-
-	title := "Berita Baru: " + news.Title
-	message := fmt.Sprintf("Berita baru telah diterbitkan di kategori %s. Cek sekarang!", news.Category)
-
-	// Buat instance Notification
-	notification := &models.Notification{
-		Title:   title,
-		Message: message,
+	// gambar OpenGraph opsional, jika kosong dipakai gambar utama
+	if file, err := c.FormFile("og_image"); err == nil {
+		fileName, err := utils.SaveImage(file, storage.FolderNews, utils.ImagePolicy)
+		if err != nil {
+			utils.RemoveImage(storage.FolderNews, news.ImageURL)
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"status": "error", "message": "Gagal memproses gambar OpenGraph: " + err.Error()})
+			return
+		}
+		news.OGImage = fileName
 	}
 
-	// Simpan ke database menggunakan service
-	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message)
+	// Simpan berita ke database (sebagai draft/in_review, terbit lewat ChangeNewsStatus).
+	// Gambar yang sudah tersimpan dihapus lagi jika insert gagal.
+	var files utils.FileChanges
+	files.Added(storage.FolderNews, news.ImageURL)
+	files.Added(storage.FolderNews, news.OGImage)
+	err = h.service.CreateNews(&news, editor)
+	files.Done(err)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
-			"message": "Gagal membuat notifikasi berita",
+			"message": err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"status":       "success",
-		"message":      "Berita berhasil dibuat",
-		"data":         news,
-		"notification": createdNotif,
+		"status":  "success",
+		"message": "Berita berhasil dibuat",
+		"data":    news,
 	})
 }
 
 // UpdateNews memperbarui berita yang ada.
 func (h *NewsHandler) UpdateNews(c *gin.Context) {
+	editor, ok := currentUsername(c)
+	if !ok {
+		return
+	}
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
@@ -219,30 +395,60 @@ func (h *NewsHandler) UpdateNews(c *gin.Context) {
 	if title := c.PostForm("title"); title != "" {
 		existingNews.Title = title
 	}
-	if category := c.PostForm("category"); category != "" {
-		existingNews.Category = category
+	if category := categoryInput(c); category != "" {
+		if err := h.service.SetCategory(existingNews, category); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
 	}
-
-	file, err := c.FormFile("image")
-	if err == nil {
-		if existingNews.ImageURL != "" {
-			_ = os.Remove(existingNews.ImageURL)
+	// tags dikirim = ganti semua tag (string kosong menghapus semua tag)
+	if tags, ok := c.GetPostForm("tags"); ok {
+		if err := h.service.SetTags(existingNews, strings.Split(tags, ",")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+			return
 		}
+	}
 
-		uploadPath := "uploads/news"
-		_ = os.MkdirAll(uploadPath, os.ModePerm)
+	if slug, ok := c.GetPostForm("slug"); ok {
+		existingNews.Slug = slug
+	}
+	if meta, ok := c.GetPostForm("meta_description"); ok {
+		existingNews.MetaDescription = meta
+	}
 
-		fileName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
-		filePath := filepath.Join(uploadPath, fileName)
+	var files utils.FileChanges
+	// gambar OpenGraph tidak masuk riwayat revisi, jadi yang lama langsung diganti
+	if file, err := c.FormFile("og_image"); err == nil {
+		fileName, err := utils.SaveImage(file, storage.FolderNews, utils.ImagePolicy)
+		if err != nil {
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"status": "error", "message": "Gagal memproses gambar OpenGraph: " + err.Error()})
+			return
+		}
+		files.Added(storage.FolderNews, fileName)
+		files.Replaced(storage.FolderNews, existingNews.OGImage)
+		existingNews.OGImage = fileName
+	} else if c.PostForm("remove_og_image") == "true" {
+		files.Replaced(storage.FolderNews, existingNews.OGImage)
+		existingNews.OGImage = ""
+	}
 
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Gagal menyimpan file"})
+	file, err := c.FormFile("image")
+	if err == nil {
+		fileName, err := utils.SaveImage(file, storage.FolderNews, utils.ImagePolicy)
+		if err != nil {
+			files.Done(err)
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"status": "error", "message": "Gagal memproses gambar: " + err.Error()})
 			return
 		}
-		existingNews.ImageURL = filePath
+		// gambar lama tidak dihapus: revisi masih merujuknya agar rollback bisa memulihkannya,
+		// dan upload GC membersihkannya setelah tidak dirujuk revisi mana pun
+		files.Added(storage.FolderNews, fileName)
+		existingNews.ImageURL = fileName
 	}
 
-	if err := h.service.UpdateNews(existingNews); err != nil {
+	err = h.service.UpdateNews(existingNews, editor)
+	files.Done(err)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
@@ -295,3 +501,96 @@ func (h *NewsHandler) RestoreNews(c *gin.Context) {
 		"data":    restoredNews,
 	})
 }
+
+// GetNewsRevisions menampilkan riwayat revisi berita (tanpa isi lengkap), terbaru dulu
+func (h *NewsHandler) GetNewsRevisions(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	page, perPage := revisionPagination(c)
+
+	revisions, total, err := h.service.GetNewsRevisions(id, page, perPage)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan riwayat revisi berita",
+		"metadata": gin.H{
+			"current_page": page,
+			"per_page":     perPage,
+			"total_items":  total,
+			"total_pages":  int(math.Ceil(float64(total) / float64(perPage))),
+		},
+		"data": revisions,
+	})
+}
+
+// GetNewsRevision menampilkan isi lengkap berita pada satu revisi
+func (h *NewsHandler) GetNewsRevision(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Nomor revisi tidak valid"})
+		return
+	}
+
+	revision, err := h.service.GetNewsRevision(id, version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan revisi berita",
+		"data":    revision,
+	})
+}
+
+// RollbackNews mengembalikan isi berita ke revisi tertentu (dicatat sebagai revisi baru)
+func (h *NewsHandler) RollbackNews(c *gin.Context) {
+	editor, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Nomor revisi tidak valid"})
+		return
+	}
+
+	news, err := h.service.RollbackNews(id, version, editor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("Berita berhasil dikembalikan ke revisi #%d", version),
+		"data":    news,
+	})
+}
+
+func revisionPagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 20
+	}
+	return page, perPage
+}
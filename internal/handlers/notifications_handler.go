@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"bem_be/internal/repositories"
 	"bem_be/internal/services"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,15 +19,73 @@ func NewNotificationHandler(service *services.NotificationService) *Notification
 	return &NotificationHandler{service: service}
 }
 
-// Ambil semua notif untuk user (status read)
+// inboxOwner memastikan :username di path adalah pengguna yang login; inbox orang lain ditolak
+func inboxOwner(c *gin.Context) (string, bool) {
+    username, ok := currentUsername(c)
+    if !ok {
+        return "", false
+    }
+    if !strings.EqualFold(c.Param("username"), username) {
+        c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": "Hanya bisa mengakses notifikasi milik sendiri"})
+        return "", false
+    }
+    return username, true
+}
+
+// Ambil notif untuk user (status read) dengan pagination/cursor dan filter
+// GET /notifications/:username?page=1&per_page=20&cursor=&status=unread&type=news&entity_type=&entity_id=
 func (h *NotificationHandler) GetUserNotifications(c *gin.Context) {
-    username := c.Param("username")
-    if username == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Username diperlukan"})
+    username, ok := inboxOwner(c)
+    if !ok {
+        return
+    }
+
+    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+    perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+    if page < 1 {
+        page = 1
+    }
+    if perPage < 1 || perPage > 100 {
+        perPage = 20
+    }
+
+    status := c.Query("status")
+    if status != "" && status != "unread" && status != "read" && status != "archived" {
+        c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Status harus unread, read, atau archived"})
         return
     }
 
-    notifications, err := h.service.GetAllNotificationsForUser(username)
+    filter := repositories.NotificationFilter{
+        Type:       c.Query("type"),
+        EntityType: c.Query("entity_type"),
+        Status:     status,
+        Limit:      perPage,
+        Offset:     (page - 1) * perPage,
+    }
+    if entityID := c.Query("entity_id"); entityID != "" {
+        id, err := strconv.ParseUint(entityID, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "entity_id tidak valid"})
+            return
+        }
+        filter.EntityID = uint(id)
+    }
+    if cursor := c.Query("cursor"); cursor != "" {
+        id, err := strconv.ParseUint(cursor, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "cursor tidak valid"})
+            return
+        }
+        filter.Cursor = uint(id)
+    }
+
+    notifications, total, nextCursor, err := h.service.GetInbox(username, filter)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+        return
+    }
+
+    unread, err := h.service.CountUnread(username)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
         return
@@ -32,23 +94,52 @@ func (h *NotificationHandler) GetUserNotifications(c *gin.Context) {
     c.JSON(http.StatusOK, gin.H{
         "status":        "success",
         "notifications": notifications,
+        "metadata": gin.H{
+            "current_page": page,
+            "per_page":     perPage,
+            "total_items":  total,
+            "total_pages":  int(math.Ceil(float64(total) / float64(perPage))),
+            "next_cursor":  nextCursor,
+            "unread_count": unread,
+        },
     })
 }
 
-func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
-    username := c.Param("username")
-    notificationID := c.Param("notificationID")
+// GetUnreadCount mengembalikan jumlah notifikasi yang belum dibaca
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+    username, ok := inboxOwner(c)
+    if !ok {
+        return
+    }
+
+    count, err := h.service.CountUnread(username)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+        return
+    }
 
-    if username == "" || notificationID == "" {
+    c.JSON(http.StatusOK, gin.H{
+        "status":       "success",
+        "unread_count": count,
+    })
+}
+
+func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
+    username, ok := inboxOwner(c)
+    if !ok {
+        return
+    }
+    id, err := strconv.ParseUint(c.Param("notificationID"), 10, 64)
+    if err != nil || id == 0 {
         c.JSON(http.StatusBadRequest, gin.H{
             "status":  "error",
-            "message": "Username dan notificationID diperlukan",
+            "message": "notificationID tidak valid",
         })
         return
     }
 
-    if err := h.service.MarkNotificationAsRead(username, notificationID); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
+    if err := h.service.MarkNotificationAsRead(username, uint(id)); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{
             "status":  "error",
             "message": err.Error(),
         })
@@ -60,3 +151,68 @@ func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
         "message": "Notifikasi ditandai sebagai dibaca",
     })
 }
+
+// MarkAllAsRead menandai semua notifikasi user sebagai dibaca
+func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
+    username, ok := inboxOwner(c)
+    if !ok {
+        return
+    }
+
+    updated, err := h.service.MarkAllAsRead(username)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "status":  "success",
+        "message": "Semua notifikasi ditandai sebagai dibaca",
+        "updated": updated,
+    })
+}
+
+// ArchiveNotification mengarsipkan notifikasi; ?archived=false untuk mengeluarkan dari arsip
+func (h *NotificationHandler) ArchiveNotification(c *gin.Context) {
+    username, ok := inboxOwner(c)
+    if !ok {
+        return
+    }
+    id, err := strconv.ParseUint(c.Param("notificationID"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "notificationID tidak valid"})
+        return
+    }
+    archived := c.DefaultQuery("archived", "true") != "false"
+
+    if err := h.service.ArchiveNotification(username, uint(id), archived); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+        return
+    }
+
+    message := "Notifikasi diarsipkan"
+    if !archived {
+        message = "Notifikasi dikeluarkan dari arsip"
+    }
+    c.JSON(http.StatusOK, gin.H{"status": "success", "message": message})
+}
+
+// DeleteNotification menghapus notifikasi dari inbox user
+func (h *NotificationHandler) DeleteNotification(c *gin.Context) {
+    username, ok := inboxOwner(c)
+    if !ok {
+        return
+    }
+    id, err := strconv.ParseUint(c.Param("notificationID"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "notificationID tidak valid"})
+        return
+    }
+
+    if err := h.service.DeleteNotification(username, uint(id)); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Notifikasi dihapus"})
+}
@@ -5,7 +5,9 @@ import (
 	"strconv"
 	"gorm.io/gorm"
 
+	"bem_be/internal/models"
 	"bem_be/internal/services"
+	"bem_be/internal/utils"
 	"github.com/gin-gonic/gin"
 	
 )
@@ -49,4 +51,55 @@ func (h *OrganizationHandler) GetOrganizationByID(c *gin.Context) {
 		"message": "Organization retrieved successfully",
 		"data":    result,
 	})
-}
\ No newline at end of file
+}
+
+// UpdateOrganizationSEO updates the meta description and OpenGraph image of an
+// organization profile page
+// Multipart: meta_description, og_image (file), remove_og_image=true
+func (h *OrganizationHandler) UpdateOrganizationSEO(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	organization, err := h.service.GetOrganizationByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return
+	}
+	folder, ok := models.OrganizationImageFolder(organization.CategoryID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Organization category has no profile page"})
+		return
+	}
+
+	var metaDescription *string
+	if meta, ok := c.GetPostForm("meta_description"); ok {
+		metaDescription = &meta
+	}
+
+	var ogImage string
+	if file, err := c.FormFile("og_image"); err == nil {
+		ogImage, err = utils.SaveImage(file, folder, utils.ImagePolicy)
+		if err != nil {
+			c.JSON(utils.UploadErrorStatus(err), gin.H{"error": "Failed to process OpenGraph image: " + err.Error()})
+			return
+		}
+	}
+
+	if err := h.service.UpdateOrganizationSEO(organization, folder, metaDescription, ogImage, c.PostForm("remove_og_image") == "true"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.service.GetOrganizationByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Organization SEO updated successfully",
+		"data":    updated,
+	})
+}
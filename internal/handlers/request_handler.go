@@ -3,13 +3,13 @@ package handlers
 import (
 	"bem_be/internal/models"
 	"bem_be/internal/services"
+	"bem_be/internal/storage"
 	"bem_be/internal/utils"
 	"encoding/json"
 	"fmt"
+	"log"
 	"math"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -111,12 +111,6 @@ func (h *RequestHandler) GetRequestsByUserIDSapras(c *gin.Context) {
 func (h *RequestHandler) CreateRequestSarpras(c *gin.Context) {
 	username := c.PostForm("username")
 
-	uploadDir := filepath.Join("uploads", "requests")
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", "Failed to create upload directory", nil))
-		return
-	}
-
 	var request models.Request
 	request.RequesterID = username
 	request.RequestPlan = c.PostForm("startDate")
@@ -143,31 +137,34 @@ func (h *RequestHandler) CreateRequestSarpras(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.ResponseHandler("error", "KTM image is required", nil))
 		return
 	}
-	originalKtmFilename := strings.ReplaceAll(filepath.Base(ktmFile.Filename), " ", "_")
-	ktmFilename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), originalKtmFilename)
-	ktmPath := filepath.Join(uploadDir, ktmFilename)
-	if err := c.SaveUploadedFile(ktmFile, ktmPath); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", "Failed to save KTM image", nil))
+	// File yang sudah tersimpan dihapus lagi jika request gagal dibuat
+	var files utils.FileChanges
+	ktmFilename, err := utils.SaveImage(ktmFile, storage.FolderRequests, utils.KTMPolicy)
+	if err != nil {
+		c.JSON(utils.UploadErrorStatus(err), utils.ResponseHandler("error", "Failed to save KTM image: "+err.Error(), nil))
 		return
 	}
+	files.Added(storage.FolderRequests, ktmFilename)
 	request.ImageURLKTM = ktmFilename // ✅ hanya nama file
 
 	// === Upload file Barang ===
 	brgFile, err := c.FormFile("image_brg")
 	if err == nil {
-		originalBrgFilename := strings.ReplaceAll(filepath.Base(brgFile.Filename), " ", "_")
-		brgFilename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), originalBrgFilename)
-		brgPath := filepath.Join(uploadDir, brgFilename)
-		if err := c.SaveUploadedFile(brgFile, brgPath); err != nil {
-			c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", "Failed to save item image", nil))
+		brgFilename, err := utils.SaveImage(brgFile, storage.FolderRequests, utils.RequestItemPolicy)
+		if err != nil {
+			files.Done(err)
+			c.JSON(utils.UploadErrorStatus(err), utils.ResponseHandler("error", "Failed to save item image: "+err.Error(), nil))
 			return
 		}
+		files.Added(storage.FolderRequests, brgFilename)
 		request.ImageURLBRG = brgFilename // ✅ hanya nama file
 	}
 
 	request.Status = "pending"
 
-	if err := h.service.CreateRequestSarpras(&request); err != nil {
+	err = h.service.CreateRequestSarpras(&request)
+	files.Done(err)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", "Failed to create request: "+err.Error(), nil))
 		return
 	}
@@ -181,7 +178,13 @@ func (h *RequestHandler) CreateRequestSarpras(c *gin.Context) {
 	}
 
 	// Simpan ke database menggunakan service
-	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message)
+	createdNotif, err := h.notificationService.CreateNotification(notification.Title, notification.Message, services.NotificationTarget{
+		Type:       models.NotificationTypeRequest,
+		EntityType: "request_sarpras",
+		EntityID:   request.ID,
+		Event:      models.WebhookEventRequestCreated,
+		Entity:     services.RequestWebhookEntity(&request),
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -298,6 +301,9 @@ func (h *RequestHandler) UpdateRequestSarprasStatus(c *gin.Context) {
 		return
 	}
 
+	// Kabari peminjam tentang keputusan permintaannya
+	h.notifyRequestDecision(updatedRequest, "request_sarpras")
+
 	// 6. Response sukses
 	c.JSON(http.StatusOK, utils.ResponseHandler("success", "Request status updated successfully", updatedRequest))
 }
@@ -317,20 +323,10 @@ func (h *RequestHandler) UploadImageBarangSarpras(c *gin.Context) {
 		return
 	}
 
-	// Buat nama file unik
-	filename := fmt.Sprintf("%d_%s", id, file.Filename)
-	saveDir := "uploads/request/barang"
-	savePath := fmt.Sprintf("%s/%s", saveDir, filename)
-
-	// Pastikan folder ada
-	if err := os.MkdirAll(saveDir, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create directory"})
-		return
-	}
-
-	// Simpan file ke disk
-	if err := c.SaveUploadedFile(file, savePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+	// Simpan foto ke storage dengan nama acak (EXIF/GPS dibuang)
+	filename, err := utils.SaveImage(file, storage.FolderRequestItems, utils.RequestItemPolicy)
+	if err != nil {
+		c.JSON(utils.UploadErrorStatus(err), gin.H{"error": "Failed to save file: " + err.Error()})
 		return
 	}
 
@@ -480,12 +476,6 @@ func (h *RequestHandler) GetRequestsByUserIDDepol(c *gin.Context) {
 func (h *RequestHandler) CreateRequestDepol(c *gin.Context) {
 	username := c.PostForm("username")
 
-	uploadDir := filepath.Join("uploads", "requests")
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", "Failed to create upload directory", nil))
-		return
-	}
-
 	var request models.Request
 	request.RequesterID = username
 	request.RequestPlan = c.PostForm("startDate")
@@ -512,31 +502,34 @@ func (h *RequestHandler) CreateRequestDepol(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.ResponseHandler("error", "KTM image is required", nil))
 		return
 	}
-	originalKtmFilename := strings.ReplaceAll(filepath.Base(ktmFile.Filename), " ", "_")
-	ktmFilename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), originalKtmFilename)
-	ktmPath := filepath.Join(uploadDir, ktmFilename)
-	if err := c.SaveUploadedFile(ktmFile, ktmPath); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", "Failed to save KTM image", nil))
+	// File yang sudah tersimpan dihapus lagi jika request gagal dibuat
+	var files utils.FileChanges
+	ktmFilename, err := utils.SaveImage(ktmFile, storage.FolderRequests, utils.KTMPolicy)
+	if err != nil {
+		c.JSON(utils.UploadErrorStatus(err), utils.ResponseHandler("error", "Failed to save KTM image: "+err.Error(), nil))
 		return
 	}
+	files.Added(storage.FolderRequests, ktmFilename)
 	request.ImageURLKTM = ktmFilename // ✅ hanya nama file
 
 	// === Upload file Barang ===
 	brgFile, err := c.FormFile("image_brg")
 	if err == nil {
-		originalBrgFilename := strings.ReplaceAll(filepath.Base(brgFile.Filename), " ", "_")
-		brgFilename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), originalBrgFilename)
-		brgPath := filepath.Join(uploadDir, brgFilename)
-		if err := c.SaveUploadedFile(brgFile, brgPath); err != nil {
-			c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", "Failed to save item image", nil))
+		brgFilename, err := utils.SaveImage(brgFile, storage.FolderRequests, utils.RequestItemPolicy)
+		if err != nil {
+			files.Done(err)
+			c.JSON(utils.UploadErrorStatus(err), utils.ResponseHandler("error", "Failed to save item image: "+err.Error(), nil))
 			return
 		}
+		files.Added(storage.FolderRequests, brgFilename)
 		request.ImageURLBRG = brgFilename // ✅ hanya nama file
 	}
 
 	request.Status = "pending"
 
-	if err := h.service.CreateRequestDepol(&request); err != nil {
+	err = h.service.CreateRequestDepol(&request)
+	files.Done(err)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", "Failed to create request: "+err.Error(), nil))
 		return
 	}
@@ -644,6 +637,9 @@ func (h *RequestHandler) UpdateRequestDepolStatus(c *gin.Context) {
 		return
 	}
 
+	// Kabari peminjam tentang keputusan permintaannya
+	h.notifyRequestDecision(updatedRequest, "request_depol")
+
 	// 6. Response sukses
 	c.JSON(http.StatusOK, utils.ResponseHandler("success", "Request status updated successfully", updatedRequest))
 }
@@ -663,20 +659,10 @@ func (h *RequestHandler) UploadImageBarangDepol(c *gin.Context) {
 		return
 	}
 
-	// Buat nama file unik
-	filename := fmt.Sprintf("%d_%s", id, file.Filename)
-	saveDir := "uploads/request/barang"
-	savePath := fmt.Sprintf("%s/%s", saveDir, filename)
-
-	// Pastikan folder ada
-	if err := os.MkdirAll(saveDir, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create directory"})
-		return
-	}
-
-	// Simpan file ke disk
-	if err := c.SaveUploadedFile(file, savePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+	// Simpan foto ke storage dengan nama acak (EXIF/GPS dibuang)
+	filename, err := utils.SaveImage(file, storage.FolderRequestItems, utils.RequestItemPolicy)
+	if err != nil {
+		c.JSON(utils.UploadErrorStatus(err), gin.H{"error": "Failed to save file: " + err.Error()})
 		return
 	}
 
@@ -744,3 +730,26 @@ func (h *RequestHandler) EndRequestBarangDepol(c *gin.Context) {
 		"message": "Request status updated to 'selesai'",
 	})
 }
+
+// notifyRequestDecision mengirim notifikasi personal ke peminjam saat permintaan disetujui/ditolak
+func (h *RequestHandler) notifyRequestDecision(request *models.Request, entityType string) {
+	title := "Peminjaman Disetujui"
+	message := fmt.Sprintf("Permintaan peminjaman %s telah disetujui.", request.Name)
+	event := models.WebhookEventRequestApproved
+	if request.Status == "rejected" {
+		title = "Peminjaman Ditolak"
+		message = fmt.Sprintf("Permintaan peminjaman %s ditolak: %s", request.Name, request.Reason)
+		event = models.WebhookEventRequestRejected
+	}
+
+	if _, err := h.notificationService.CreateNotification(title, message, services.NotificationTarget{
+		Type:       models.NotificationTypeRequest,
+		EntityType: entityType,
+		EntityID:   request.ID,
+		Username:   request.RequesterID,
+		Event:      event,
+		Entity:     services.RequestWebhookEntity(request),
+	}); err != nil {
+		log.Printf("Gagal membuat notifikasi keputusan peminjaman %d: %v", request.ID, err)
+	}
+}
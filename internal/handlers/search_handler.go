@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"bem_be/internal/services"
+	"bem_be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchHandler menangani pencarian gabungan untuk halaman publik
+type SearchHandler struct {
+	service *services.SearchService
+}
+
+func NewSearchHandler() *SearchHandler {
+	return &SearchHandler{service: services.NewSearchService()}
+}
+
+// Search menjalankan full-text search
+// GET /api/search?q=beasiswa&type=news,event&page=1&per_page=10
+func (h *SearchHandler) Search(c *gin.Context) {
+	keyword := c.Query("q")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 50 {
+		perPage = 10
+	}
+
+	var types []string
+	for _, t := range strings.Split(c.Query("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	results, total, err := h.service.Search(keyword, types, page, perPage)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
+	base := "/api/search?q=" + url.QueryEscape(keyword)
+	if len(types) > 0 {
+		base += "&type=" + url.QueryEscape(strings.Join(types, ","))
+	}
+	metadata := utils.PaginationMetadata{
+		CurrentPage: page,
+		PerPage:     perPage,
+		TotalItems:  int(total),
+		TotalPages:  totalPages,
+		Links: utils.PaginationLinks{
+			First: base + "&page=1&per_page=" + strconv.Itoa(perPage),
+			Last:  base + "&page=" + strconv.Itoa(totalPages) + "&per_page=" + strconv.Itoa(perPage),
+		},
+	}
+
+	c.JSON(http.StatusOK, utils.MetadataFormatResponse("success", "Berhasil melakukan pencarian", metadata, results))
+}
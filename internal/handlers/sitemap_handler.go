@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"bem_be/internal/services"
+	"bem_be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SitemapHandler menyajikan sitemap.xml untuk mesin pencari
+type SitemapHandler struct {
+	service *services.SitemapService
+}
+
+func NewSitemapHandler() *SitemapHandler {
+	return &SitemapHandler{service: services.NewSitemapService()}
+}
+
+// GetSitemap GET /sitemap.xml
+func (h *SitemapHandler) GetSitemap(c *gin.Context) {
+	sitemap, err := h.service.Sitemap()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+	serveCached(c, sitemap, "application/xml; charset=utf-8")
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"bem_be/internal/models"
+	"bem_be/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// VenueHandler menangani venue, antrean persetujuan booking dan ketersediaan venue
+type VenueHandler struct {
+	service *services.VenueService
+}
+
+func NewVenueHandler(db *gorm.DB, notificationService *services.NotificationService) *VenueHandler {
+	return &VenueHandler{
+		service: services.NewVenueService(db, notificationService),
+	}
+}
+
+// GET /admin/venues (semua) dan /student/venues (hanya yang aktif)
+func (h *VenueHandler) GetAllVenues(c *gin.Context) {
+	h.listVenues(c, false)
+}
+
+func (h *VenueHandler) GetActiveVenues(c *gin.Context) {
+	h.listVenues(c, true)
+}
+
+func (h *VenueHandler) listVenues(c *gin.Context, activeOnly bool) {
+	venues, err := h.service.GetVenues(activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan venue",
+		"data":    venues,
+	})
+}
+
+// POST /admin/venues
+// JSON: {"name": "Auditorium", "capacity": 300, "approver_username": "sarpras", "active": true}
+func (h *VenueHandler) CreateVenue(c *gin.Context) {
+	var venue models.Venue
+	if err := c.ShouldBindJSON(&venue); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.CreateVenue(&venue); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Venue berhasil dibuat",
+		"data":    venue,
+	})
+}
+
+// PUT /admin/venues/:id
+func (h *VenueHandler) UpdateVenue(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var input models.Venue
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	venue, err := h.service.UpdateVenue(id, input)
+	if err != nil {
+		c.JSON(venueErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Venue berhasil diperbarui",
+		"data":    venue,
+	})
+}
+
+// DELETE /admin/venues/:id
+func (h *VenueHandler) DeleteVenue(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteVenue(id); err != nil {
+		c.JSON(venueErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Venue berhasil dihapus",
+	})
+}
+
+// GET /venues/:id/availability?start=<RFC3339>&end=<RFC3339>
+func (h *VenueHandler) GetAvailability(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	start, ok := timeQuery(c, "start")
+	if !ok {
+		return
+	}
+	end, ok := timeQuery(c, "end")
+	if !ok {
+		return
+	}
+	availability, err := h.service.GetAvailability(id, start, end)
+	if err != nil {
+		c.JSON(venueErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan ketersediaan venue",
+		"data":    availability,
+	})
+}
+
+// GET /admin/venues/reservations?status=pending (semua venue)
+func (h *VenueHandler) GetAllReservations(c *gin.Context) {
+	h.listReservations(c, "")
+}
+
+// GET /student/venues/reservations?status=pending (venue yang disetujui pengguna)
+func (h *VenueHandler) GetMyApprovalQueue(c *gin.Context) {
+	username, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	h.listReservations(c, username)
+}
+
+func (h *VenueHandler) listReservations(c *gin.Context, approver string) {
+	reservations, err := h.service.GetReservations(c.DefaultQuery("status", models.VenueReservationPending), approver)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Berhasil mendapatkan booking venue",
+		"data":    reservations,
+	})
+}
+
+// PUT /admin/venues/reservations/:id/approve dan /reject
+func (h *VenueHandler) AdminApproveReservation(c *gin.Context) {
+	h.decide(c, true, true)
+}
+
+func (h *VenueHandler) AdminRejectReservation(c *gin.Context) {
+	h.decide(c, false, true)
+}
+
+// PUT /student/venues/reservations/:id/approve dan /reject (hanya approver venue)
+func (h *VenueHandler) ApproveReservation(c *gin.Context) {
+	h.decide(c, true, false)
+}
+
+func (h *VenueHandler) RejectReservation(c *gin.Context) {
+	h.decide(c, false, false)
+}
+
+// decide memutuskan booking; JSON opsional: {"note": "alasan"}
+func (h *VenueHandler) decide(c *gin.Context, approve, isAdmin bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var input struct {
+		Note string `json:"note"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	approver, ok := currentUsername(c)
+	if !ok {
+		return
+	}
+	reservation, err := h.service.DecideReservation(id, approve, input.Note, approver, isAdmin)
+	if err != nil {
+		c.JSON(venueErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	message := "Booking venue disetujui"
+	if !approve {
+		message = "Booking venue ditolak"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    reservation,
+	})
+}
+
+// timeQuery membaca parameter query waktu RFC3339 opsional
+func timeQuery(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " harus berformat RFC3339"})
+		return nil, false
+	}
+	return &parsed, true
+}
+
+func venueErrorStatus(err error) int {
+	if errors.Is(err, services.ErrVenueNotFound) || errors.Is(err, services.ErrReservationNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
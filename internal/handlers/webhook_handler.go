@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bem_be/internal/models"
+	"bem_be/internal/services"
+	"bem_be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler menangani pengelolaan webhook oleh admin
+type WebhookHandler struct {
+	service *services.WebhookService
+}
+
+func NewWebhookHandler(service *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+type webhookRequest struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+// GetEventTypes mengembalikan daftar event yang bisa dilanggan
+func (h *WebhookHandler) GetEventTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, utils.ResponseHandler("success", "Daftar event webhook", models.WebhookEventTypes))
+}
+
+// GetAllWebhooks mengembalikan semua subscription webhook
+func (h *WebhookHandler) GetAllWebhooks(c *gin.Context) {
+	subscriptions, err := h.service.GetAllSubscriptions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+	c.JSON(http.StatusOK, utils.ResponseHandler("success", "Berhasil mendapatkan daftar webhook", subscriptions))
+}
+
+// CreateWebhook membuat subscription baru. Secret hanya ditampilkan sekali di response ini.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ResponseHandler("error", "Format JSON tidak valid: "+err.Error(), nil))
+		return
+	}
+
+	subscription := models.WebhookSubscription{
+		Name:       req.Name,
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: strings.Join(req.EventTypes, ","),
+		IsActive:   true,
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+
+	if err := h.service.CreateSubscription(&subscription); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Webhook berhasil dibuat",
+		"data":    subscription,
+		"secret":  subscription.Secret,
+	})
+}
+
+// UpdateWebhook memperbarui subscription webhook
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := h.service.GetSubscriptionByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ResponseHandler("error", "Format JSON tidak valid: "+err.Error(), nil))
+		return
+	}
+
+	if req.Name != "" {
+		subscription.Name = req.Name
+	}
+	if req.URL != "" {
+		subscription.URL = req.URL
+	}
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	if req.EventTypes != nil {
+		subscription.EventTypes = strings.Join(req.EventTypes, ",")
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+
+	if err := h.service.UpdateSubscription(subscription); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.ResponseHandler("success", "Webhook berhasil diperbarui", subscription))
+}
+
+// RotateWebhookSecret membuat secret baru untuk subscription
+func (h *WebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := h.service.RotateSecret(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Secret webhook berhasil diganti",
+		"data":    subscription,
+		"secret":  subscription.Secret,
+	})
+}
+
+// DeleteWebhook menghapus subscription webhook
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubscription(id); err != nil {
+		c.JSON(http.StatusNotFound, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.ResponseHandler("success", "Webhook berhasil dihapus", nil))
+}
+
+// GetWebhookDeliveries mengembalikan log pengiriman sebuah webhook
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 20
+	}
+
+	deliveries, total, err := h.service.GetDeliveries(id, c.Query("status"), perPage, (page-1)*perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
+	metadata := utils.PaginationMetadata{
+		CurrentPage: page,
+		PerPage:     perPage,
+		TotalItems:  int(total),
+		TotalPages:  totalPages,
+		Links: utils.PaginationLinks{
+			First: fmt.Sprintf("/admin/webhooks/%d/deliveries?page=1&per_page=%d", id, perPage),
+			Last:  fmt.Sprintf("/admin/webhooks/%d/deliveries?page=%d&per_page=%d", id, totalPages, perPage),
+		},
+	}
+
+	c.JSON(http.StatusOK, utils.MetadataFormatResponse("success", "Berhasil mendapatkan log pengiriman webhook", metadata, deliveries))
+}
+
+// RedeliverWebhook mengirim ulang sebuah pengiriman secara manual
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	id, ok := parseIDParam(c, "deliveryID")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.Redeliver(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ResponseHandler("error", err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.ResponseHandler("success", "Webhook dikirim ulang", delivery))
+}
@@ -1,23 +1,97 @@
 package models
 
 import (
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type Announcement struct {
-	ID             uint           `json:"id" gorm:"primaryKey"`
-	Title          string         `json:"title" gorm:"size:255;not null"`
-	Content        string         `json:"content" gorm:"type:text;not null"`
-	FileURL        string         `json:"file_url,omitempty" gorm:"type:varchar(255);column:file_url"`
-	OrganizationID *uint          `json:"organization_id,omitempty" gorm:"default:null"`
-	Organization   *Organization  `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
-	AuthorID       uint           `json:"author_id" gorm:"default:null"`
-	Author         *User          `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
-	StartDate      *time.Time     `json:"start_date,omitempty"`
-	EndDate        *time.Time     `json:"end_date,omitempty"`
-	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	ID             uint          `json:"id" gorm:"primaryKey"`
+	Title          string        `json:"title" gorm:"size:255;not null"`
+	Slug           string        `json:"slug" gorm:"type:varchar(255);default:''"` // unik, lihat database.setupSlugs
+	Content        string        `json:"content" gorm:"type:text;not null"`
+	FileURL        string        `json:"file_url,omitempty" gorm:"type:varchar(255);column:file_url"`
+	OrganizationID *uint         `json:"organization_id,omitempty" gorm:"default:null"`
+	Organization   *Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	AuthorID       uint          `json:"author_id" gorm:"default:null"`
+	Author         *User         `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+	StartDate      *time.Time    `json:"start_date,omitempty"`
+	EndDate        *time.Time    `json:"end_date,omitempty"`
+	IsPinned       bool          `json:"is_pinned" gorm:"default:false;index"`
+	Priority       int           `json:"priority" gorm:"default:0"`
+	// SEO: meta description dan gambar OpenGraph opsional (lampiran belum tentu gambar)
+	MetaDescription string `json:"meta_description" gorm:"type:varchar(300)"`
+	OGImage         string `json:"og_image,omitempty" gorm:"type:varchar(255)"`
+	SEODescription  string `json:"seo_description" gorm:"-"`
+	OGImageURL      string `json:"og_image_url,omitempty" gorm:"-"`
+	// Target audiens, dipisah koma; kosong berarti untuk semua mahasiswa
+	TargetFaculties     string         `json:"target_faculties" gorm:"type:text"`
+	TargetStudyPrograms string         `json:"target_study_programs" gorm:"type:text"`
+	TargetYears         string         `json:"target_years" gorm:"type:text"`
+	TargetDormitories   string         `json:"target_dormitories" gorm:"type:text"`
+	NotificationPending bool           `json:"-" gorm:"default:false;index"` // notifikasi dikirim saat pengumuman mulai aktif
+	NotifiedAt          *time.Time     `json:"notified_at,omitempty"`
+	CreatedAt           time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// AfterFind mengisi deskripsi dan URL gambar untuk meta tag halaman pengumuman
+func (a *Announcement) AfterFind(tx *gorm.DB) error {
+	a.SEODescription = a.MetaDescription
+	if a.SEODescription == "" {
+		a.SEODescription = utils.Excerpt(a.Content, MetaDescriptionLength)
+	}
+	a.OGImageURL = utils.FileURL(storage.FolderAnnouncements, a.OGImage)
+	return nil
+}
+
+// HasAudience mengembalikan true jika pengumuman hanya ditujukan ke sebagian mahasiswa
+func (a *Announcement) HasAudience() bool {
+	return a.TargetFaculties != "" || a.TargetStudyPrograms != "" || a.TargetYears != "" || a.TargetDormitories != ""
+}
+
+// Reaches mengembalikan true jika pengumuman ditujukan ke mahasiswa: tanpa target, atau
+// setiap daftar target yang diisi memuat data mahasiswa (sama dengan filter audiens daftar)
+func (a *Announcement) Reaches(student *Student) bool {
+	if !a.HasAudience() {
+		return true
+	}
+	if student == nil {
+		return false
+	}
+	return targetIncludes(a.TargetFaculties, student.Faculty) &&
+		targetIncludes(a.TargetStudyPrograms, student.StudyProgram) &&
+		targetIncludes(a.TargetYears, strconv.Itoa(student.YearEnrolled)) &&
+		targetIncludes(a.TargetDormitories, student.Dormitory)
+}
+
+// targetIncludes mengembalikan true jika daftar target kosong atau memuat value
+func targetIncludes(list, value string) bool {
+	if strings.TrimSpace(list) == "" {
+		return true
+	}
+	for _, item := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActiveAt mengembalikan true jika waktu t berada di jendela tayang pengumuman.
+// EndDate bersifat inklusif (pengumuman tetap tayang sepanjang hari terakhir).
+func (a *Announcement) IsActiveAt(t time.Time) bool {
+	if a.StartDate != nil && a.StartDate.After(t) {
+		return false
+	}
+	if a.EndDate != nil && !a.EndDate.AddDate(0, 0, 1).After(t) {
+		return false
+	}
+	return true
 }
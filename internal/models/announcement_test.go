@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestAnnouncementReaches(t *testing.T) {
+	student := &Student{Faculty: "FITE", StudyProgram: "Informatika", YearEnrolled: 2022, Dormitory: "Asrama Pniel"}
+	cases := []struct {
+		name string
+		a    Announcement
+		want bool
+	}{
+		{"tanpa target", Announcement{}, true},
+		{"fakultas cocok", Announcement{TargetFaculties: "FTI,fite"}, true},
+		{"fakultas lain", Announcement{TargetFaculties: "FTI"}, false},
+		{"semua target cocok", Announcement{TargetFaculties: "FITE", TargetYears: "2021,2022", TargetDormitories: "Asrama Pniel"}, true},
+		{"angkatan lain", Announcement{TargetFaculties: "FITE", TargetYears: "2023"}, false},
+		{"asrama lain", Announcement{TargetDormitories: "Asrama Kapernaum"}, false},
+	}
+	for _, c := range cases {
+		if got := c.a.Reaches(student); got != c.want {
+			t.Errorf("%s: Reaches = %v; ingin %v", c.name, got, c.want)
+		}
+	}
+	if (&Announcement{TargetYears: "2022"}).Reaches(nil) {
+		t.Error("pengunjung tanpa login menjangkau pengumuman bertarget")
+	}
+}
@@ -6,6 +6,16 @@ import (
 	"gorm.io/gorm"
 )
 
+// Status aspirasi. Aspirasi "forwarded" sudah ditugaskan ke organisasi/departemen
+// penanggung jawab; "responded" berarti sudah ada tanggapan resmi.
+const (
+	AspirationStatusSubmitted   = "submitted"
+	AspirationStatusUnderReview = "under_review"
+	AspirationStatusForwarded   = "forwarded"
+	AspirationStatusResponded   = "responded"
+	AspirationStatusClosed      = "closed"
+)
+
 type Aspiration struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
 	UserName      string         `json:"user_name" gorm:"type:varchar(20)"`
@@ -18,8 +28,116 @@ type Aspiration struct {
 	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 	Student       Student        `gorm:"foreignKey:UserName;references:UserName;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	WindowID      *uint          `json:"window_id" gorm:"index"` // jendela penerimaan saat dikirim
+	// Siklus tanggapan
+	Status         string        `json:"status" gorm:"type:varchar(20);not null;default:'submitted';index"`
+	OrganizationID *int          `json:"organization_id" gorm:"index"` // penanggung jawab
+	Organization   *Organization `json:"organization,omitempty" gorm:"foreignKey:ID;references:OrganizationID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	// AwaitingSince diisi saat aspirasi menunggu tanggapan resmi (dibuat atau ada balasan
+	// pengaju) dan dikosongkan saat ditanggapi; dasar perhitungan SLA
+	AwaitingSince   *time.Time `json:"awaiting_since,omitempty" gorm:"index"`
+	FirstResponseAt *time.Time `json:"first_response_at,omitempty"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+	SLABreachedAt   *time.Time `json:"sla_breached_at,omitempty"`
+	ResponseDueAt   *time.Time `json:"response_due_at,omitempty" gorm:"-"`
+	Overdue         bool       `json:"overdue" gorm:"-"`
+	// Aspirasi anonim tidak menyimpan UserName; pengaju memantaunya dengan kode pelacakan
+	// yang hanya disimpan dalam bentuk hash
+	Anonymous        bool    `json:"anonymous" gorm:"not null;default:false"`
+	TrackingCodeHash *string `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	// Papan aspirasi: aspirasi publik bisa didukung (upvote) dan dikomentari. Aspirasi
+	// duplikat digabung ke MergedIntoID beserta dukungannya.
+	Public         bool                      `json:"public" gorm:"not null;default:false;index"`
+	CommentsLocked bool                      `json:"comments_locked" gorm:"not null;default:false"`
+	VoteCount      int                       `json:"vote_count" gorm:"not null;default:0"`
+	MergedIntoID   *uint                     `json:"merged_into_id,omitempty" gorm:"index"`
+	Voted          bool                      `json:"voted" gorm:"-"`
+	Responses      []AspirationResponse      `json:"responses,omitempty" gorm:"foreignKey:AspirationID"`
+	History        []AspirationStatusHistory `json:"history,omitempty" gorm:"foreignKey:AspirationID"`
 }
 
 func (Aspiration) TableName() string {
 	return "aspirations"
 }
+
+// AspirationResponse adalah satu pesan di utas aspirasi: tanggapan resmi pengurus atau
+// balasan pengaju. ParentID diisi untuk balasan atas pesan lain.
+type AspirationResponse struct {
+	ID           uint                 `json:"id" gorm:"primaryKey"`
+	AspirationID uint                 `json:"aspiration_id" gorm:"not null;index"`
+	ParentID     *uint                `json:"parent_id,omitempty" gorm:"index"`
+	Username     string               `json:"username" gorm:"type:varchar(100);not null"`
+	Official     bool                 `json:"official"`
+	Message      string               `json:"message" gorm:"type:text;not null"`
+	CreatedAt    time.Time            `json:"created_at" gorm:"autoCreateTime"`
+	Replies      []AspirationResponse `json:"replies,omitempty" gorm:"-"`
+}
+
+func (AspirationResponse) TableName() string {
+	return "aspiration_responses"
+}
+
+// AspirationStatusHistory mencatat setiap perubahan status atau penugasan aspirasi
+type AspirationStatusHistory struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	AspirationID   uint      `json:"aspiration_id" gorm:"not null;index"`
+	FromStatus     string    `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus       string    `json:"to_status" gorm:"type:varchar(20);not null"`
+	OrganizationID *int      `json:"organization_id,omitempty"`
+	ChangedBy      string    `json:"changed_by" gorm:"type:varchar(100)"`
+	Note           string    `json:"note,omitempty" gorm:"type:text"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (AspirationStatusHistory) TableName() string {
+	return "aspiration_status_histories"
+}
+
+// AspirationVote adalah dukungan satu mahasiswa untuk aspirasi publik
+type AspirationVote struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	AspirationID uint      `json:"aspiration_id" gorm:"not null;uniqueIndex:idx_aspiration_vote"`
+	Username     string    `json:"username" gorm:"type:varchar(100);not null;uniqueIndex:idx_aspiration_vote;index"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+func (AspirationVote) TableName() string {
+	return "aspiration_votes"
+}
+
+// AspirationComment adalah komentar mahasiswa di aspirasi publik (terpisah dari
+// tanggapan resmi)
+type AspirationComment struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	AspirationID uint           `json:"aspiration_id" gorm:"not null;index"`
+	Username     string         `json:"username" gorm:"type:varchar(100);not null"`
+	Content      string         `json:"content" gorm:"type:text;not null"`
+	CreatedAt    time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (AspirationComment) TableName() string {
+	return "aspiration_comments"
+}
+
+// AspirationSupport adalah aspirasi beserta dukungannya pada papan trending atau laporan
+// dukungan terbanyak
+type AspirationSupport struct {
+	Aspiration Aspiration `json:"aspiration"`
+	Votes      int        `json:"votes"`
+	Score      float64    `json:"score,omitempty"`
+}
+
+// AspirationRateLimit menghitung pengajuan aspirasi per pengguna per hari. Key adalah
+// HMAC username dengan rahasia server dan tidak merujuk aspirasi mana pun, sehingga
+// peninjau tidak bisa mengaitkan aspirasi anonim dengan pengajunya.
+type AspirationRateLimit struct {
+	ID     uint   `json:"-" gorm:"primaryKey"`
+	Key    string `gorm:"type:varchar(64);not null;uniqueIndex:idx_aspiration_rate_limit"`
+	Period string `gorm:"type:varchar(10);not null;uniqueIndex:idx_aspiration_rate_limit"` // YYYY-MM-DD
+	Count  int    `gorm:"not null;default:0"`
+}
+
+func (AspirationRateLimit) TableName() string {
+	return "aspiration_rate_limits"
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status jendela aspirasi, dihitung dari waktu buka/tutupnya
+const (
+	AspirationWindowUpcoming = "upcoming"
+	AspirationWindowOpen     = "open"
+	AspirationWindowClosed   = "closed"
+)
+
+// AspirationWindow adalah periode penerimaan aspirasi. Aspirasi hanya bisa dikirim saat
+// ada jendela yang terbuka ([OpensAt, ClosesAt)); jendela tidak boleh beririsan.
+type AspirationWindow struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	Name           string         `json:"name" gorm:"type:varchar(150);not null"`
+	Description    string         `json:"description" gorm:"type:text"`
+	OpensAt        time.Time      `json:"opens_at" gorm:"not null;index"`
+	ClosesAt       time.Time      `json:"closes_at" gorm:"not null;index"`
+	Categories     string         `json:"categories" gorm:"type:text"`  // dipisah koma; kosong = semua kategori
+	OrganizationID *int           `json:"organization_id" gorm:"index"` // aspirasi langsung ditujukan ke organisasi ini
+	Organization   *Organization  `json:"organization,omitempty" gorm:"foreignKey:ID;references:OrganizationID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	CreatedBy      string         `json:"created_by" gorm:"type:varchar(100)"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	State          string         `json:"state" gorm:"-"`
+	Submissions    int64          `json:"submissions" gorm:"-"`
+}
+
+func (AspirationWindow) TableName() string {
+	return "aspiration_windows"
+}
+
+// StateAt mengembalikan status jendela pada waktu tertentu
+func (w *AspirationWindow) StateAt(at time.Time) string {
+	switch {
+	case at.Before(w.OpensAt):
+		return AspirationWindowUpcoming
+	case at.Before(w.ClosesAt):
+		return AspirationWindowOpen
+	default:
+		return AspirationWindowClosed
+	}
+}
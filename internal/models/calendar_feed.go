@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// CalendarToken adalah token rahasia per pengguna untuk berlangganan feed kalender
+// pribadi (.ics) tanpa login. Token bisa di-reset jika tautannya bocor.
+type CalendarToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Username  string    `json:"username" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Token     string    `json:"token" gorm:"type:varchar(64);uniqueIndex;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (CalendarToken) TableName() string {
+	return "calendar_tokens"
+}
+
+// Status pendaftaran event
+const (
+	EventRegistrationRegistered = "registered"
+	EventRegistrationWaitlisted = "waitlisted"
+)
+
+// EventRegistration mencatat mahasiswa yang mendaftar ke sebuah event. Pendaftar yang
+// masuk saat kuota penuh berstatus waitlisted dan dinaikkan berurutan saat ada kursi.
+type EventRegistration struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	EventID   uint      `json:"event_id" gorm:"not null;uniqueIndex:idx_event_registration"`
+	Event     *Calender `json:"event,omitempty" gorm:"foreignKey:EventID"`
+	Username  string    `json:"username" gorm:"type:varchar(100);not null;uniqueIndex:idx_event_registration;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	Status           string     `json:"status" gorm:"type:varchar(20);not null;default:'registered';index"`
+	PromotedAt       *time.Time `json:"promoted_at,omitempty"`                // naik dari daftar tunggu
+	WaitlistPosition int        `json:"waitlist_position,omitempty" gorm:"-"` // urutan di daftar tunggu
+}
+
+// EventRegistrant adalah pendaftar event beserta data mahasiswanya (untuk penyelenggara)
+type EventRegistrant struct {
+	ID           uint       `json:"id"`
+	Username     string     `json:"username"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	PromotedAt   *time.Time `json:"promoted_at,omitempty"`
+	NIM          string     `json:"nim"`
+	FullName     string     `json:"full_name"`
+	Email        string     `json:"email"`
+	Faculty      string     `json:"faculty"`
+	StudyProgram string     `json:"study_program"`
+	YearEnrolled int        `json:"year_enrolled"`
+}
+
+func (EventRegistration) TableName() string {
+	return "event_registrations"
+}
+
+// CalendarEventUID mengembalikan UID iCalendar sebuah event. Event hasil impor memakai
+// UID aslinya agar impor ulang memperbarui event yang sama.
+func CalendarEventUID(event *Calender, domain string) string {
+	if event.UID != "" {
+		return event.UID
+	}
+	if event.ParentID != nil {
+		// pengganti satu kemunculan memakai UID seri induknya (dengan RECURRENCE-ID)
+		return fmt.Sprintf("event-%d@%s", *event.ParentID, domain)
+	}
+	return fmt.Sprintf("event-%d@%s", event.ID, domain)
+}
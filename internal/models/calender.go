@@ -19,4 +19,40 @@ type Calender struct {
 	CreatedAt      time.Time      `json:"createdAt"`
 	UpdatedAt      time.Time      `json:"updatedAt"`
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+	// UID iCalendar event hasil impor; event buatan sendiri memakai CalendarEventUID
+	UID string `json:"uid,omitempty" gorm:"type:varchar(255);index"`
+	// Pengulangan (subset RRULE RFC 5545). Event induk menyimpan aturan dan tanggal
+	// pengecualiannya (EXDATE UTC dipisah koma); perubahan satu kemunculan disimpan
+	// sebagai event terpisah dengan ParentID dan OriginalStart kemunculan yang diganti.
+	// Kemunculan hasil ekspansi memakai ID induk dengan OriginalStart terisi.
+	RRule         string     `json:"rrule,omitempty" gorm:"type:varchar(255);default:''"`
+	ExDates       string     `json:"exdates,omitempty" gorm:"type:text"`
+	RecurrenceEnd *time.Time `json:"recurrence_end,omitempty" gorm:"index"` // nil = tanpa batas
+	ParentID      *uint      `json:"parent_id,omitempty" gorm:"index"`
+	OriginalStart *time.Time `json:"original_start,omitempty"`
+	// Pendaftaran peserta. Capacity 0 berarti tanpa batas; pendaftar setelah kuota penuh
+	// masuk daftar tunggu. Tanpa RegistrationClosesAt pendaftaran ditutup saat event
+	// dimulai. Filter kelayakan dipisah koma; kosong berarti semua mahasiswa.
+	Capacity             int        `json:"capacity"`
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at,omitempty"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at,omitempty"`
+	EligibleFaculties    string     `json:"eligible_faculties" gorm:"type:text"`
+	EligibleYears        string     `json:"eligible_years" gorm:"type:text"` // angkatan
+	MembersOnly          bool       `json:"members_only"`                    // hanya anggota organisasi penyelenggara
+	// Presensi. Check-in dibuka CheckInOpensBefore menit sebelum kemunculan dimulai dan
+	// ditutup CheckInClosesAfter menit setelah selesai. Geofence aktif jika radius (meter)
+	// lebih dari 0. AttendanceSecret menandatangani QR presensi dan tiket peserta.
+	CheckInOpensBefore int      `json:"check_in_opens_before" gorm:"default:30"`
+	CheckInClosesAfter int      `json:"check_in_closes_after" gorm:"default:30"`
+	Latitude           *float64 `json:"latitude,omitempty"`
+	Longitude          *float64 `json:"longitude,omitempty"`
+	GeofenceRadius     int      `json:"geofence_radius"`
+	AttendanceSecret   string   `json:"-" gorm:"type:varchar(64)"`
+	// Venue yang dipesan seri event (lihat VenueReservation); Location tetap teks bebas
+	VenueID *uint `json:"venue_id,omitempty" gorm:"index"`
+}
+
+// IsRecurring menandai event induk yang memiliki aturan pengulangan
+func (c *Calender) IsRecurring() bool {
+	return c.RRule != ""
 }
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Cara check-in presensi event
+const (
+	AttendanceMethodQR     = "qr"     // mahasiswa memindai QR berputar dari penyelenggara
+	AttendanceMethodTicket = "ticket" // penyelenggara memindai tiket peserta
+	AttendanceMethodManual = "manual" // dicatat penyelenggara (peserta on the spot)
+)
+
+// EventAttendance mencatat kehadiran mahasiswa pada satu kemunculan event. EventID
+// selalu seri induk; OccurrenceStart adalah waktu mulai asli kemunculannya (sama dengan
+// StartTime untuk event tunggal) sehingga pengganti kemunculan tetap terhitung sama.
+type EventAttendance struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	EventID         uint      `json:"event_id" gorm:"not null;uniqueIndex:idx_event_attendance"`
+	Event           *Calender `json:"event,omitempty" gorm:"foreignKey:EventID"`
+	OccurrenceStart time.Time `json:"occurrence_start" gorm:"not null;uniqueIndex:idx_event_attendance"`
+	Username        string    `json:"username" gorm:"type:varchar(100);not null;uniqueIndex:idx_event_attendance;index"`
+	Method          string    `json:"method" gorm:"type:varchar(20);not null"`
+	CheckedInBy     string    `json:"checked_in_by,omitempty" gorm:"type:varchar(100)"` // penyelenggara untuk tiket/manual
+	Latitude        *float64  `json:"latitude,omitempty"`
+	Longitude       *float64  `json:"longitude,omitempty"`
+	Distance        *float64  `json:"distance,omitempty"` // meter dari titik event saat geofence aktif
+	CheckedInAt     time.Time `json:"checked_in_at" gorm:"autoCreateTime"`
+}
+
+func (EventAttendance) TableName() string {
+	return "event_attendances"
+}
+
+// EventAttendee adalah kehadiran event beserta data mahasiswanya (untuk penyelenggara)
+type EventAttendee struct {
+	ID              uint      `json:"id"`
+	OccurrenceStart time.Time `json:"occurrence_start"`
+	Username        string    `json:"username"`
+	Method          string    `json:"method"`
+	CheckedInBy     string    `json:"checked_in_by,omitempty"`
+	Distance        *float64  `json:"distance,omitempty"`
+	CheckedInAt     time.Time `json:"checked_in_at"`
+	NIM             string    `json:"nim"`
+	FullName        string    `json:"full_name"`
+	Email           string    `json:"email"`
+	Faculty         string    `json:"faculty"`
+	StudyProgram    string    `json:"study_program"`
+	YearEnrolled    int       `json:"year_enrolled"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// EventReminder mencatat pengingat yang sudah dikirim untuk satu kemunculan event pada
+// satu offset. Baris dibuat sebelum notifikasi dikirim sehingga penjadwal yang
+// berjalan ulang (atau lebih dari satu instance) tidak mengirim dua kali. StartTime ikut
+// menjadi kunci: event yang dijadwal ulang mendapat pengingat baru sesuai jadwal barunya.
+type EventReminder struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	EventID         uint      `json:"event_id" gorm:"not null;uniqueIndex:idx_event_reminder"` // seri induk
+	OccurrenceStart time.Time `json:"occurrence_start" gorm:"not null;uniqueIndex:idx_event_reminder"`
+	StartTime       time.Time `json:"start_time" gorm:"not null;uniqueIndex:idx_event_reminder"`
+	OffsetMinutes   int       `json:"offset_minutes" gorm:"not null;uniqueIndex:idx_event_reminder"`
+	Recipients      int       `json:"recipients"`
+	SentAt          time.Time `json:"sent_at" gorm:"autoCreateTime"`
+}
+
+func (EventReminder) TableName() string {
+	return "event_reminders"
+}
@@ -1,22 +1,57 @@
-
 package models
 
 import (
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Galery adalah satu foto galeri. Foto baru selalu berada di dalam album.
 type Galery struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Title     string         `json:"title" gorm:"size:255;not null"`
-	Content   string         `json:"content" gorm:"not null"`
-	ImageURL  string         `json:"image_url" gorm:"type:varchar(255)"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index;uniqueIndex:idx_courses_code_deleted_at" json:"deleted_at,omitempty"`
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	AlbumID       *uint             `json:"album_id,omitempty" gorm:"index"`
+	Title         string            `json:"title" gorm:"size:255;not null"`
+	Content       string            `json:"content" gorm:"not null"`
+	Caption       string            `json:"caption" gorm:"type:text"`
+	ImageURL      string            `json:"image_url" gorm:"type:varchar(255)"`
+	ImageVariants map[string]string `json:"image_variants,omitempty" gorm:"-"`
+	SortOrder     int               `json:"sort_order" gorm:"default:0;index"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt    `gorm:"index;uniqueIndex:idx_courses_code_deleted_at" json:"deleted_at,omitempty"`
 }
 
 func (Galery) TableName() string {
 	return "galery"
-}
\ No newline at end of file
+}
+
+// AfterFind mengisi URL varian foto galeri
+func (g *Galery) AfterFind(tx *gorm.DB) error {
+	g.ImageVariants = utils.ImageVariantURLs(storage.FolderGalery, g.ImageURL)
+	return nil
+}
+
+// GaleryAlbum mengelompokkan foto galeri, opsional terhubung ke kegiatan kalender atau organisasi
+type GaleryAlbum struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	Title          string         `json:"title" gorm:"size:255;not null"`
+	Description    string         `json:"description" gorm:"type:text"`
+	EventID        *uint          `json:"event_id,omitempty" gorm:"index"`
+	Event          *Calender      `json:"event,omitempty" gorm:"foreignKey:EventID"`
+	OrganizationID *uint          `json:"organization_id,omitempty" gorm:"index"`
+	Organization   *Organization  `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	CoverPhotoID   *uint          `json:"cover_photo_id,omitempty"`
+	CoverPhoto     *Galery        `json:"cover_photo,omitempty" gorm:"foreignKey:CoverPhotoID"`
+	SortOrder      int            `json:"sort_order" gorm:"default:0"`
+	PhotoCount     int64          `json:"photo_count" gorm:"-"`
+	Photos         []Galery       `json:"photos,omitempty" gorm:"foreignKey:AlbumID"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (GaleryAlbum) TableName() string {
+	return "galery_albums"
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,20 +10,88 @@ import (
 
 // News represents an article or announcement.
 type News struct {
-	ID            uint           `json:"id" gorm:"primaryKey"`
-	Title         string         `json:"title" gorm:"type:varchar(255);not null"`
-	Content       string         `json:"content" gorm:"type:text;not null"`
-	Category      string         `json:"category" gorm:"type:varchar(100)"`
-	ImageURL      string         `json:"image_url" gorm:"type:varchar(255)"`
-	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	Title         string            `json:"title" gorm:"type:varchar(255);not null"`
+	Slug          string            `json:"slug" gorm:"type:varchar(255);default:''"` // unik, lihat database.setupSlugs
+	Content       string            `json:"content" gorm:"type:text;not null"`
+	Category      string            `json:"category" gorm:"type:varchar(100)"` // nama kategori, disalin dari NewsCategory
+	CategoryID    *uint             `json:"category_id,omitempty" gorm:"index"`
+	CategoryRef   *NewsCategory     `json:"category_detail,omitempty" gorm:"foreignKey:CategoryID"`
+	Tags          []NewsTag         `json:"tags" gorm:"many2many:news_tag_links;joinForeignKey:NewsID;joinReferences:TagID"`
+	ImageURL      string            `json:"image_url" gorm:"type:varchar(255)"`
+	ImageVariants map[string]string `json:"image_variants,omitempty" gorm:"-"`
+	Status        string            `json:"status" gorm:"type:varchar(20);default:'published';index"` // draft, in_review, scheduled, published, archived
+	PublishAt     *time.Time        `json:"publish_at,omitempty" gorm:"index"`
+	PublishedAt   *time.Time        `json:"published_at,omitempty"`
+	CreatedBy     string            `json:"created_by" gorm:"type:varchar(100);index"` // penulis: boleh mengajukan review dan melihat pratinjau
+	CreatedAt     time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt    `json:"-" gorm:"index"`
+	// SEO: meta description dan gambar OpenGraph opsional; jika kosong dipakai
+	// cuplikan konten dan gambar utama berita
+	MetaDescription string `json:"meta_description" gorm:"type:varchar(300)"`
+	OGImage         string `json:"og_image,omitempty" gorm:"type:varchar(255)"`
+	SEODescription  string `json:"seo_description" gorm:"-"`
+	OGImageURL      string `json:"og_image_url,omitempty" gorm:"-"`
 }
 
 func (News) TableName() string {
 	return "news"
 }
 
+// AfterFind mengisi URL varian gambar (thumbnail, medium, original, WebP) untuk srcset,
+// serta deskripsi dan gambar untuk meta tag SEO/OpenGraph
+func (n *News) AfterFind(tx *gorm.DB) error {
+	n.ImageVariants = utils.ImageVariantURLs(storage.FolderNews, n.ImageURL)
+	n.SEODescription = n.MetaDescription
+	if n.SEODescription == "" {
+		n.SEODescription = utils.Excerpt(n.Content, MetaDescriptionLength)
+	}
+	n.OGImageURL = utils.FileURL(storage.FolderNews, n.OGImage)
+	if n.OGImageURL == "" {
+		n.OGImageURL = utils.FileURL(storage.FolderNews, n.ImageURL)
+	}
+	return nil
+}
+
+// NewsCategory adalah kategori berita yang dikelola editor
+type NewsCategory struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Slug        string    `json:"slug" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	SortOrder   int       `json:"sort_order" gorm:"default:0;index"`
+	NewsCount   int64     `json:"news_count" gorm:"-"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (NewsCategory) TableName() string {
+	return "news_categories"
+}
+
+// NewsTag adalah tag berita; satu berita bisa memiliki banyak tag
+type NewsTag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Slug      string    `json:"slug" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null"`
+	NewsCount int64     `json:"news_count" gorm:"-"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (NewsTag) TableName() string {
+	return "news_tags"
+}
+
+// Status alur kerja berita
+const (
+	NewsStatusDraft     = "draft"
+	NewsStatusInReview  = "in_review"
+	NewsStatusScheduled = "scheduled"
+	NewsStatusPublished = "published"
+	NewsStatusArchived  = "archived"
+)
+
 // Aspiration represents feedback or suggestions from users.
 // type Aspiration struct {
 // 	ID        uint           `json:"id" gorm:"primaryKey"`
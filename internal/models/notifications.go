@@ -5,10 +5,14 @@ import (
 )
 
 type Notification struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Title     string    `json:"title"`
-	Message   string    `json:"message"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Title      string    `json:"title"`
+	Message    string    `json:"message"`
+	Type       string    `json:"type" gorm:"type:varchar(50);index"`        // news, announcement, event, request, aspiration, organization
+	EntityType string    `json:"entity_type" gorm:"type:varchar(50);index"` // target deep-link, mis. "request_sarpras"
+	EntityID   *uint     `json:"entity_id,omitempty" gorm:"index"`
+	Username   string    `json:"username,omitempty" gorm:"type:varchar(50);index"` // kosong = broadcast ke semua user
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime;index"`
 }
 
 type UserNotification struct {
@@ -17,4 +21,19 @@ type UserNotification struct {
 	NotificationID uint          `json:"notification_id"`
 	Notification   *Notification `json:"notification" gorm:"foreignKey:NotificationID;references:ID;constraint:OnDelete:CASCADE"`
 	IsRead         bool          `json:"is_read" gorm:"default:false"`
+	ReadAt         *time.Time    `json:"read_at,omitempty"`
+	IsArchived     bool          `json:"is_archived" gorm:"default:false"`
+	ArchivedAt     *time.Time    `json:"archived_at,omitempty"`
+	IsDeleted      bool          `json:"is_deleted" gorm:"default:false"`
+	UpdatedAt      time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
 }
+
+// Jenis notifikasi, dipakai juga sebagai entity_type untuk deep-link
+const (
+	NotificationTypeNews         = "news"
+	NotificationTypeAnnouncement = "announcement"
+	NotificationTypeEvent        = "event"
+	NotificationTypeRequest      = "request"
+	NotificationTypeAspiration   = "aspiration"
+	NotificationTypeOrganization = "organization"
+)
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,13 +10,48 @@ import (
 
 // Organization represents a club in the system
 type Organization struct {
-	ID         uint           `gorm:"primaryKey" json:"id"`
-	CategoryID int            `form:"category_id" json:"category_id" gorm:"not null"`
-	Category   *Category      `json:"category" gorm:"foreignKey:ID;references:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	Name       string         `form:"name" gorm:"not null" json:"name"`
-	ShortName  string         `form:"short_name" gorm:"not null" json:"short_name"`
-	Image      string         `form:"image" json:"image" gorm:"type:text"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `gorm:"index;uniqueIndex:idx_courses_code_deleted_at" json:"deleted_at,omitempty"`
+	ID            uint              `gorm:"primaryKey" json:"id"`
+	CategoryID    int               `form:"category_id" json:"category_id" gorm:"not null"`
+	Category      *Category         `json:"category" gorm:"foreignKey:ID;references:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Name          string            `form:"name" gorm:"not null" json:"name"`
+	ShortName     string            `form:"short_name" gorm:"not null" json:"short_name"`
+	Image         string            `form:"image" json:"image" gorm:"type:text"`
+	ImageVariants map[string]string `form:"-" json:"image_variants,omitempty" gorm:"-"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt    `gorm:"index;uniqueIndex:idx_courses_code_deleted_at" json:"deleted_at,omitempty"`
+	// SEO halaman profil: jika kosong dipakai nama organisasi dan logonya
+	MetaDescription string `form:"-" json:"meta_description" gorm:"type:varchar(300)"`
+	OGImage         string `form:"-" json:"og_image,omitempty" gorm:"type:varchar(255)"`
+	SEODescription  string `form:"-" json:"seo_description" gorm:"-"`
+	OGImageURL      string `form:"-" json:"og_image_url,omitempty" gorm:"-"`
+}
+
+// organizationImageFolders memetakan kategori organisasi ke folder storage logonya
+var organizationImageFolders = map[int]string{
+	1: storage.FolderClubs,
+	2: storage.FolderDepartments,
+	3: storage.FolderAssociations,
+}
+
+// OrganizationImageFolder mengembalikan folder storage logo untuk kategori organisasi
+func OrganizationImageFolder(categoryID int) (string, bool) {
+	folder, ok := organizationImageFolders[categoryID]
+	return folder, ok
+}
+
+// AfterFind mengisi URL varian logo organisasi serta deskripsi dan gambar meta tag profil
+func (o *Organization) AfterFind(tx *gorm.DB) error {
+	o.SEODescription = o.MetaDescription
+	if o.SEODescription == "" {
+		o.SEODescription = o.Name
+	}
+	if folder, ok := organizationImageFolders[o.CategoryID]; ok {
+		o.ImageVariants = utils.ImageVariantURLs(folder, o.Image)
+		o.OGImageURL = utils.FileURL(folder, o.OGImage)
+		if o.OGImageURL == "" {
+			o.OGImageURL = utils.FileURL(folder, o.Image)
+		}
+	}
+	return nil
 }
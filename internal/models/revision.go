@@ -0,0 +1,56 @@
+package models
+
+import (
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis konten yang memiliki riwayat revisi
+const (
+	RevisionEntityNews         = "news"
+	RevisionEntityAnnouncement = "announcement"
+)
+
+// ContentRevision adalah salinan isi berita/pengumuman setelah satu kali penyuntingan.
+// Revisi tidak pernah diubah; rollback membuat revisi baru dengan isi revisi lama.
+type ContentRevision struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	EntityType string `json:"entity_type" gorm:"type:varchar(20);not null;uniqueIndex:idx_revision_version"`
+	EntityID   uint   `json:"entity_id" gorm:"not null;uniqueIndex:idx_revision_version"`
+	Version    int    `json:"version" gorm:"not null;uniqueIndex:idx_revision_version"`
+
+	Title     string     `json:"title" gorm:"type:varchar(255)"`
+	Content   string     `json:"content" gorm:"type:text"`
+	Category  string     `json:"category,omitempty" gorm:"type:varchar(100)"` // hanya berita
+	FileURL   string     `json:"file_url,omitempty" gorm:"type:varchar(255)"` // gambar berita / lampiran pengumuman
+	FileLink  string     `json:"file_link,omitempty" gorm:"-"`
+	StartDate *time.Time `json:"start_date,omitempty"` // hanya pengumuman
+	EndDate   *time.Time `json:"end_date,omitempty"`   // hanya pengumuman
+
+	Editor        string    `json:"editor" gorm:"type:varchar(100)"`
+	Summary       string    `json:"summary" gorm:"type:text"`
+	ChangedFields string    `json:"changed_fields" gorm:"type:varchar(255)"` // dipisah koma
+	RestoredFrom  *int      `json:"restored_from,omitempty"`                 // versi sumber jika hasil rollback
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (ContentRevision) TableName() string {
+	return "content_revisions"
+}
+
+// RevisionFileFolder mengembalikan folder storage file yang dirujuk revisi jenis tersebut
+func RevisionFileFolder(entityType string) string {
+	if entityType == RevisionEntityAnnouncement {
+		return storage.FolderAnnouncements
+	}
+	return storage.FolderNews
+}
+
+// AfterFind mengisi URL file revisi agar bisa ditampilkan tanpa tahu layout storage
+func (r *ContentRevision) AfterFind(tx *gorm.DB) error {
+	r.FileLink = utils.FileURL(RevisionFileFolder(r.EntityType), r.FileURL)
+	return nil
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Jenis konten yang memiliki slug
+const (
+	SlugEntityNews         = "news"
+	SlugEntityAnnouncement = "announcement"
+)
+
+// MetaDescriptionLength adalah panjang maksimum meta description (karakter)
+const MetaDescriptionLength = 160
+
+// SlugRedirect mencatat slug lama konten yang sudah diganti, agar tautan lama tetap
+// diarahkan ke slug terbaru
+type SlugRedirect struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	EntityType string    `json:"entity_type" gorm:"type:varchar(20);not null;uniqueIndex:idx_slug_redirect"`
+	Slug       string    `json:"slug" gorm:"type:varchar(255);not null;uniqueIndex:idx_slug_redirect"`
+	EntityID   uint      `json:"entity_id" gorm:"not null;index"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (SlugRedirect) TableName() string {
+	return "slug_redirects"
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,31 +11,32 @@ import (
 
 // Student represents a student in the system
 type Student struct {
-	ID             uint           `json:"id" gorm:"primaryKey"`
-	DimID          int            `json:"dim_id" gorm:"not null"`
-	UserID         int            `json:"user_id" gorm:"uniqueIndex:idx_students_user_id;not null"`
-	User           *User          `json:"-" gorm:"foreignKey:ExternalUserID;references:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	OrganizationID *int           `form:"organization_id" json:"organization_id"` // nullable
-	Organization   *Organization  `json:"organization" gorm:"foreignKey:ID;references:OrganizationID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	UserName       string         `json:"user_name" gorm:"type:varchar(20)"`
-	NIM            string         `json:"nim" gorm:"type:varchar(20);uniqueIndex;not null"`
-	FullName       string         `json:"full_name" gorm:"type:varchar(100);not null"`
-	Email          string         `json:"email" gorm:"type:varchar(255)"`
-	StudyProgramID int            `json:"study_program_id" gorm:"type:int"`
-	StudyProgram   string         `json:"study_program" gorm:"type:varchar(100)"`
-	Faculty        string         `json:"faculty" gorm:"type:varchar(100)"`
-	YearEnrolled   int            `json:"year_enrolled" gorm:"type:int"`
-	Status         string         `json:"status" gorm:"type:varchar(20)"`
-	Dormitory      string         `json:"dormitory" gorm:"type:varchar(50)"`
-	Position       string         `json:"position" gorm:"type:varchar(50)"`
-	LinkedIn       string         `json:"linkedin" gorm:"type:varchar(150)"`
-	WhatsApp       string         `json:"whatsapp" gorm:"type:varchar(100)"`
-	Instagram      string         `json:"instagram" gorm:"type:varchar(100)"`
-	Image          string         `json:"image" gorm:"type:varchar(100)"`
-	LastSync       time.Time      `json:"last_sync" gorm:"autoCreateTime"`
-	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	DimID          int               `json:"dim_id" gorm:"not null"`
+	UserID         int               `json:"user_id" gorm:"uniqueIndex:idx_students_user_id;not null"`
+	User           *User             `json:"-" gorm:"foreignKey:ExternalUserID;references:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	OrganizationID *int              `form:"organization_id" json:"organization_id"` // nullable
+	Organization   *Organization     `json:"organization" gorm:"foreignKey:ID;references:OrganizationID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	UserName       string            `json:"user_name" gorm:"type:varchar(20)"`
+	NIM            string            `json:"nim" gorm:"type:varchar(20);uniqueIndex;not null"`
+	FullName       string            `json:"full_name" gorm:"type:varchar(100);not null"`
+	Email          string            `json:"email" gorm:"type:varchar(255)"`
+	StudyProgramID int               `json:"study_program_id" gorm:"type:int"`
+	StudyProgram   string            `json:"study_program" gorm:"type:varchar(100)"`
+	Faculty        string            `json:"faculty" gorm:"type:varchar(100)"`
+	YearEnrolled   int               `json:"year_enrolled" gorm:"type:int"`
+	Status         string            `json:"status" gorm:"type:varchar(20)"`
+	Dormitory      string            `json:"dormitory" gorm:"type:varchar(50)"`
+	Position       string            `json:"position" gorm:"type:varchar(50)"`
+	LinkedIn       string            `json:"linkedin" gorm:"type:varchar(150)"`
+	WhatsApp       string            `json:"whatsapp" gorm:"type:varchar(100)"`
+	Instagram      string            `json:"instagram" gorm:"type:varchar(100)"`
+	Image          string            `json:"image" gorm:"type:varchar(100)"`
+	ImageVariants  map[string]string `json:"image_variants,omitempty" gorm:"-"`
+	LastSync       time.Time         `json:"last_sync" gorm:"autoCreateTime"`
+	CreatedAt      time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt      gorm.DeletedAt    `json:"-" gorm:"index"`
 }
 
 // TableName returns the table name for the Student model
@@ -40,6 +44,60 @@ func (Student) TableName() string {
 	return "students"
 }
 
+// officerRolePrefixes adalah awalan jabatan pengurus inti yang diberikan lewat AssignStudent
+// (ketua_ukm, wakil_ketua_bem, sekretaris_himpunan_1, bendahara_department_2, ...).
+// Anggota biasa tidak punya jabatan atau jabatannya di luar daftar ini.
+var officerRolePrefixes = []string{"ketua_", "wakil_ketua_", "sekretaris_", "bendahara_"}
+
+// OfficerPositionPatterns mengembalikan pola LIKE untuk memfilter jabatan pengurus inti di SQL
+func OfficerPositionPatterns() []string {
+	patterns := make([]string, len(officerRolePrefixes))
+	for i, prefix := range officerRolePrefixes {
+		patterns[i] = strings.ReplaceAll(prefix, "_", `\_`) + "%"
+	}
+	return patterns
+}
+
+// IsOfficerPosition melaporkan apakah jabatan termasuk pengurus inti
+func IsOfficerPosition(position string) bool {
+	position = strings.ToLower(strings.TrimSpace(position))
+	for _, prefix := range officerRolePrefixes {
+		if strings.HasPrefix(position, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOfficer melaporkan apakah mahasiswa pengurus inti sebuah organisasi
+func (s *Student) IsOfficer() bool {
+	return s.OrganizationID != nil && *s.OrganizationID != 0 && IsOfficerPosition(s.Position)
+}
+
+// IsOfficerOf melaporkan apakah mahasiswa pengurus inti organisasi tertentu
+func (s *Student) IsOfficerOf(organizationID int) bool {
+	return s.IsOfficer() && *s.OrganizationID == organizationID
+}
+
+// IsExecutive melaporkan apakah mahasiswa pengurus inti BEM atau MPM (ketua_bem, sekretaris_mpm, ...)
+func (s *Student) IsExecutive() bool {
+	if !IsOfficerPosition(s.Position) {
+		return false
+	}
+	for _, part := range strings.Split(strings.ToLower(s.Position), "_") {
+		if part == "bem" || part == "mpm" {
+			return true
+		}
+	}
+	return false
+}
+
+// AfterFind fills the profile photo variant URLs
+func (s *Student) AfterFind(tx *gorm.DB) error {
+	s.ImageVariants = utils.ImageVariantURLs(storage.FolderUsers, s.Image)
+	return nil
+}
+
 // CampusStudentResponse represents the response from the campus API for students
 type CampusStudentResponse struct {
 	Result string `json:"result"`
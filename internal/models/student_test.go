@@ -0,0 +1,44 @@
+package models
+
+import "testing"
+
+func TestStudentOfficer(t *testing.T) {
+	org := 7
+	other := 8
+	cases := []struct {
+		position           string
+		orgID              *int
+		officer, executive bool
+	}{
+		{"ketua_ukm", &org, true, false},
+		{"wakil_ketua_himpunan", &org, true, false},
+		{"sekretaris_department_2", &org, true, false},
+		{"Bendahara_UKM_1", &org, true, false},
+		{"anggota", &org, false, false},
+		{"", &org, false, false},
+		{"ketua_ukm", nil, false, false},
+		{"ketua_bem", nil, false, true},
+		{"sekretaris_mpm", nil, false, true},
+		{"anggota_bem", nil, false, false},
+	}
+	for _, c := range cases {
+		s := Student{Position: c.position, OrganizationID: c.orgID}
+		if got := s.IsOfficer(); got != c.officer {
+			t.Errorf("%q IsOfficer = %v; ingin %v", c.position, got, c.officer)
+		}
+		if got := s.IsExecutive(); got != c.executive {
+			t.Errorf("%q IsExecutive = %v; ingin %v", c.position, got, c.executive)
+		}
+		if c.officer && (!s.IsOfficerOf(org) || s.IsOfficerOf(other)) {
+			t.Errorf("%q IsOfficerOf salah", c.position)
+		}
+	}
+}
+
+func TestOfficerPositionPatterns(t *testing.T) {
+	for _, pattern := range OfficerPositionPatterns() {
+		if pattern[len(pattern)-1] != '%' {
+			t.Errorf("pola %q harus diakhiri %%", pattern)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Venue adalah ruangan atau tempat kegiatan yang bisa dipesan lewat event kalender.
+// Tanpa approver, booking langsung disetujui.
+type Venue struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	Name             string         `json:"name" gorm:"type:varchar(150);not null;index"`
+	Description      string         `json:"description" gorm:"type:text"`
+	Capacity         int            `json:"capacity"` // 0 = tidak dibatasi
+	ApproverUsername string         `json:"approver_username" gorm:"type:varchar(100);index"`
+	Active           bool           `json:"active" gorm:"default:true"`
+	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (Venue) TableName() string {
+	return "venues"
+}
+
+// Status booking venue. Booking pending dan approved sama-sama menahan slot venue.
+const (
+	VenueReservationPending  = "pending"
+	VenueReservationApproved = "approved"
+	VenueReservationRejected = "rejected"
+)
+
+// VenueReservation adalah booking venue oleh satu event (seri induk untuk event
+// berulang; seluruh kemunculannya ikut dipesan)
+type VenueReservation struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	VenueID   uint       `json:"venue_id" gorm:"not null;index"`
+	Venue     *Venue     `json:"venue,omitempty" gorm:"foreignKey:VenueID"`
+	EventID   uint       `json:"event_id" gorm:"not null;uniqueIndex"`
+	Event     *Calender  `json:"event,omitempty" gorm:"foreignKey:EventID"`
+	Status    string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	DecidedBy string     `json:"decided_by,omitempty" gorm:"type:varchar(100)"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	Note      string     `json:"note,omitempty" gorm:"type:text"` // alasan penolakan atau catatan approver
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (VenueReservation) TableName() string {
+	return "venue_reservations"
+}
+
+// VenueBooking adalah satu slot terpakai di venue (per kemunculan event)
+type VenueBooking struct {
+	ReservationID uint      `json:"reservation_id"`
+	EventID       uint      `json:"event_id"`
+	Title         string    `json:"title"`
+	Organization  string    `json:"organization,omitempty"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	Status        string    `json:"status"`
+}
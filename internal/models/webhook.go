@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription adalah endpoint eksternal (bot Discord/Telegram, portal fakultas)
+// yang menerima event platform
+type WebhookSubscription struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	Name       string         `json:"name" gorm:"type:varchar(100);not null"`
+	URL        string         `json:"url" gorm:"type:text;not null"`
+	Secret     string         `json:"-" gorm:"type:varchar(255);not null"`
+	EventTypes string         `json:"event_types" gorm:"type:text"` // dipisah koma, kosong atau "*" = semua event
+	IsActive   bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WebhookDelivery mencatat setiap pengiriman event ke sebuah subscription
+type WebhookDelivery struct {
+	ID             uint                 `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint                 `json:"subscription_id" gorm:"index;not null"`
+	Subscription   *WebhookSubscription `json:"subscription,omitempty" gorm:"foreignKey:SubscriptionID"`
+	EventID        string               `json:"event_id" gorm:"type:varchar(64);index"`
+	EventType      string               `json:"event_type" gorm:"type:varchar(100);index"`
+	Payload        string               `json:"payload" gorm:"type:text"`
+	Status         string               `json:"status" gorm:"type:varchar(20);default:'pending';index"` // pending, success, failed
+	Attempts       int                  `json:"attempts" gorm:"default:0"`
+	ResponseStatus int                  `json:"response_status"`
+	ResponseBody   string               `json:"response_body" gorm:"type:text"`
+	LastError      string               `json:"last_error" gorm:"type:text"`
+	NextAttemptAt  *time.Time           `json:"next_attempt_at,omitempty" gorm:"index"`
+	DeliveredAt    *time.Time           `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time            `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Status pengiriman webhook
+const (
+	WebhookStatusPending = "pending"
+	WebhookStatusSuccess = "success"
+	WebhookStatusFailed  = "failed"
+)
+
+// Event platform yang bisa dilanggan lewat webhook
+const (
+	WebhookEventNewsPublished       = "news.published"
+	WebhookEventAnnouncementCreated = "announcement.created"
+	WebhookEventEventCreated        = "event.created"
+	WebhookEventRequestCreated      = "request.created"
+	WebhookEventRequestApproved     = "request.approved"
+	WebhookEventRequestRejected     = "request.rejected"
+	WebhookEventAspirationCreated   = "aspiration.created"
+	WebhookEventAspirationUpdated   = "aspiration.updated"
+	WebhookEventOrganizationCreated = "organization.created"
+)
+
+// WebhookEventTypes adalah daftar semua event yang valid
+var WebhookEventTypes = []string{
+	WebhookEventNewsPublished,
+	WebhookEventAnnouncementCreated,
+	WebhookEventEventCreated,
+	WebhookEventRequestCreated,
+	WebhookEventRequestApproved,
+	WebhookEventRequestRejected,
+	WebhookEventAspirationCreated,
+	WebhookEventAspirationUpdated,
+	WebhookEventOrganizationCreated,
+}
@@ -3,6 +3,10 @@ package repositories
 import (
 	"bem_be/internal/database"
 	"bem_be/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -60,8 +64,19 @@ func (r *AnnouncementRepository) FindByName(code string) (*models.Announcement,
 	return &announcement, nil
 }
 
+// AnnouncementFilter holds the optional filters for listing announcements
+type AnnouncementFilter struct {
+	Title        string
+	Content      string
+	Category     string
+	View         string          // all (default), active, archive
+	Public       bool            // only announcements without a target audience
+	Audience     *models.Student // only announcements targeted at this student (or untargeted)
+	Organization string          // organization short name
+}
+
 // GetAllAnnouncements finds all announcements with pagination and optional filters
-func (r *AnnouncementRepository) GetAllAnnouncements(limit, offset int, title, content, category string) ([]models.Announcement, int64, error) {
+func (r *AnnouncementRepository) GetAllAnnouncements(limit, offset int, filter AnnouncementFilter) ([]models.Announcement, int64, error) {
     var announcements []models.Announcement
     var total int64
 
@@ -69,26 +84,52 @@ func (r *AnnouncementRepository) GetAllAnnouncements(limit, offset int, title, c
     query := r.db.Model(&models.Announcement{})
 
     // 🔹 Tambahkan filter pencarian opsional
-    if title != "" {
-        query = query.Where("title ILIKE ?", "%"+title+"%")
+    if filter.Title != "" {
+        query = query.Where("title ILIKE ?", "%"+filter.Title+"%")
+    }
+    if filter.Content != "" {
+        query = query.Where("content ILIKE ?", "%"+filter.Content+"%")
+    }
+    if filter.Category != "" {
+        query = query.Where("category ILIKE ?", "%"+filter.Category+"%")
+    }
+
+    if filter.Organization != "" {
+        query = query.Where("organization_id IN (SELECT id FROM organizations WHERE LOWER(short_name) = LOWER(?) AND deleted_at IS NULL)", filter.Organization)
     }
-    if content != "" {
-        query = query.Where("content ILIKE ?", "%"+content+"%")
+
+    // 🔹 Jendela tayang: end_date inklusif sampai akhir hari
+    now := time.Now()
+    today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+    switch filter.View {
+    case "active":
+        query = query.Where("(start_date IS NULL OR start_date <= ?) AND (end_date IS NULL OR end_date >= ?)", now, today)
+    case "archive":
+        query = query.Where("end_date < ?", today)
     }
-    if category != "" {
-        query = query.Where("category ILIKE ?", "%"+category+"%")
+
+    // 🔹 Target audiens
+    if filter.Public {
+        query = query.Where(noAudienceCondition)
+    } else if filter.Audience != nil {
+        query = applyAudienceFilter(query, filter.Audience)
     }
 
     if err := query.Count(&total).Error; err != nil {
         return nil, 0, err
     }
 
+    order := "is_pinned DESC, priority DESC, COALESCE(start_date, created_at) DESC, created_at DESC"
+    if filter.View == "archive" {
+        order = "end_date DESC, created_at DESC"
+    }
+
     if err := query.
         Preload("Organization"). 
         Preload("Author"). 
         Limit(limit).
         Offset(offset).
-        Order("created_at DESC").
+        Order(order).
         Find(&announcements).Error; err != nil {
         return nil, 0, err
     }
@@ -96,6 +137,65 @@ func (r *AnnouncementRepository) GetAllAnnouncements(limit, offset int, title, c
     return announcements, total, nil
 }
 
+const noAudienceCondition = "COALESCE(target_faculties, '') = '' AND COALESCE(target_study_programs, '') = '' " +
+	"AND COALESCE(target_years, '') = '' AND COALESCE(target_dormitories, '') = ''"
+
+// applyAudienceFilter keeps announcements whose every non-empty target list contains the student's value
+func applyAudienceFilter(query *gorm.DB, student *models.Student) *gorm.DB {
+	targets := []struct {
+		column string
+		value  string
+	}{
+		{"target_faculties", student.Faculty},
+		{"target_study_programs", student.StudyProgram},
+		{"target_years", strconv.Itoa(student.YearEnrolled)},
+		{"target_dormitories", student.Dormitory},
+	}
+	for _, t := range targets {
+		query = query.Where(
+			"(COALESCE("+t.column+", '') = '' OR ',' || LOWER("+t.column+") || ',' LIKE ?)",
+			"%,"+strings.ToLower(t.value)+",%",
+		)
+	}
+	return query
+}
+
+// announcementLastChangeSQL adalah perubahan terakhir pengumuman: update dan hapus, pengumuman
+// yang mulai tayang, dan pengumuman yang berakhir (keluar dari daftar aktif pada tengah malam
+// setelah end_date), serta organisasi yang nama singkatnya tampil sebagai kategori
+const announcementLastChangeSQL = `SELECT GREATEST(
+	(SELECT MAX(GREATEST(updated_at, deleted_at)) FROM announcements),
+	(SELECT MAX(start_date) FROM announcements WHERE start_date <= @now),
+	(SELECT MAX(date_trunc('day', end_date) + INTERVAL '1 day') FROM announcements
+		WHERE date_trunc('day', end_date) + INTERVAL '1 day' <= @now),
+	(SELECT MAX(GREATEST(updated_at, deleted_at)) FROM organizations))`
+
+// LastChange mengembalikan waktu perubahan terakhir pengumuman publik untuk Last-Modified feed
+func (r *AnnouncementRepository) LastChange(now time.Time) (time.Time, error) {
+	return lastChange(r.db, announcementLastChangeSQL, map[string]interface{}{"now": now})
+}
+
+// FindPendingActivation finds announcements whose notification is waiting for the start date
+func (r *AnnouncementRepository) FindPendingActivation(now time.Time) ([]models.Announcement, error) {
+	var announcements []models.Announcement
+	err := r.db.Where("notification_pending = ? AND (start_date IS NULL OR start_date <= ?)", true, now).
+		Find(&announcements).Error
+	return announcements, err
+}
+
+// UpdateFields updates the given columns of an announcement (including zero values)
+func (r *AnnouncementRepository) UpdateFields(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.Announcement{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ClaimPendingNotification clears the pending flag; returns false if another worker already did
+func (r *AnnouncementRepository) ClaimPendingNotification(id uint, now time.Time) (bool, error) {
+	res := r.db.Model(&models.Announcement{}).
+		Where("id = ? AND notification_pending = ?", id, true).
+		Updates(map[string]interface{}{"notification_pending": false, "notified_at": now})
+	return res.RowsAffected == 1, res.Error
+}
+
 // DeleteByID deletes a announcement by ID
 func (r *AnnouncementRepository) DeleteByID(id uint) error {
 	// Use soft delete (don't use Unscoped())
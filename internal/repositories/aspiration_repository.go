@@ -1,28 +1,40 @@
 package repositories
 
 import (
-	"bem_be/internal/database"
+	"time"
+
 	"bem_be/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AspirationRepository struct {
 	db *gorm.DB
 }
 
-func NewAspirationRepository() *AspirationRepository {
+func NewAspirationRepository(db *gorm.DB) *AspirationRepository {
 	return &AspirationRepository{
-		db: database.GetDB(),
+		db: db,
 	}
 }
 
+// AspirationFilter membatasi daftar aspirasi; field kosong berarti tidak difilter.
+// OverdueBefore diisi untuk hanya mengambil aspirasi yang menunggu tanggapan sejak
+// sebelum waktu tersebut.
+type AspirationFilter struct {
+	Status         string
+	OrganizationID int
+	UserName       string
+	OverdueBefore  *time.Time
+}
+
 func (r *AspirationRepository) Create(aspiration *models.Aspiration) error {
 	return r.db.Create(aspiration).Error
 }
 
 func (r *AspirationRepository) Update(aspiration *models.Aspiration) error {
-	return r.db.Save(aspiration).Error
+	return r.db.Omit(clause.Associations).Save(aspiration).Error
 }
 
 func (r *AspirationRepository) FindByID(id uint) (*models.Aspiration, error) {
@@ -34,22 +46,267 @@ func (r *AspirationRepository) FindByID(id uint) (*models.Aspiration, error) {
 	return &aspiration, nil
 }
 
-func (r *AspirationRepository) GetAllAspirations(limit, offset int) ([]models.Aspiration, int64, error) {
+// Lock mengambil aspirasi dengan SELECT ... FOR UPDATE; hanya bermakna di dalam transaksi
+func (r *AspirationRepository) Lock(id uint) (*models.Aspiration, error) {
+	var aspiration models.Aspiration
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&aspiration, id).Error; err != nil {
+		return nil, err
+	}
+	return &aspiration, nil
+}
+
+// FindDetail mengambil aspirasi beserta pengaju, penanggung jawab, utas tanggapan dan
+// riwayat statusnya (urut waktu)
+func (r *AspirationRepository) FindDetail(id uint) (*models.Aspiration, error) {
+	var aspiration models.Aspiration
+	err := r.db.Preload("Student").Preload("Organization").
+		Preload("Responses", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC, id ASC") }).
+		Preload("History", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC, id ASC") }).
+		First(&aspiration, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &aspiration, nil
+}
+
+func (r *AspirationRepository) GetAllAspirations(filter AspirationFilter, limit, offset int) ([]models.Aspiration, int64, error) {
 	var aspirations []models.Aspiration
 	var total int64
 
 	query := r.db.Model(&models.Aspiration{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.OrganizationID != 0 {
+		query = query.Where("organization_id = ?", filter.OrganizationID)
+	}
+	if filter.UserName != "" {
+		query = query.Where("user_name = ?", filter.UserName)
+	}
+	if filter.OverdueBefore != nil {
+		query = query.Where("status <> ? AND awaiting_since <= ?", models.AspirationStatusClosed, *filter.OverdueBefore)
+	}
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	if err := query.Limit(limit).Offset(offset).Find(&aspirations).Error; err != nil {
+	if err := query.Preload("Student").Preload("Organization").Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).Find(&aspirations).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return aspirations, total, nil
 }
 
+// GetUnflaggedOverdue mengambil aspirasi yang menunggu tanggapan sejak sebelum cutoff
+// dan belum ditandai melewati SLA
+func (r *AspirationRepository) GetUnflaggedOverdue(cutoff time.Time) ([]models.Aspiration, error) {
+	var aspirations []models.Aspiration
+	err := r.db.Where("status <> ? AND awaiting_since <= ? AND sla_breached_at IS NULL", models.AspirationStatusClosed, cutoff).
+		Order("awaiting_since ASC").Find(&aspirations).Error
+	return aspirations, err
+}
+
+// MarkSLABreached menandai aspirasi yang masih belum ditanggapi sejak sebelum cutoff;
+// kondisi diulang agar aspirasi yang baru saja ditanggapi tidak ikut ditandai
+func (r *AspirationRepository) MarkSLABreached(ids []uint, cutoff, at time.Time) (int64, error) {
+	result := r.db.Model(&models.Aspiration{}).
+		Where("id IN ? AND awaiting_since <= ? AND sla_breached_at IS NULL", ids, cutoff).
+		Update("sla_breached_at", at)
+	return result.RowsAffected, result.Error
+}
+
 func (r *AspirationRepository) DeleteByID(id uint) error {
 	return r.db.Delete(&models.Aspiration{}, id).Error
 }
+
+func (r *AspirationRepository) CreateResponse(response *models.AspirationResponse) error {
+	return r.db.Create(response).Error
+}
+
+func (r *AspirationRepository) FindResponse(id uint) (*models.AspirationResponse, error) {
+	var response models.AspirationResponse
+	if err := r.db.First(&response, id).Error; err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (r *AspirationRepository) CreateHistory(history *models.AspirationStatusHistory) error {
+	return r.db.Create(history).Error
+}
+
+// FindByTrackingHash mengambil aspirasi anonim berdasarkan hash kode pelacakannya
+func (r *AspirationRepository) FindByTrackingHash(hash string) (*models.Aspiration, error) {
+	var aspiration models.Aspiration
+	if err := r.db.Where("tracking_code_hash = ?", hash).First(&aspiration).Error; err != nil {
+		return nil, err
+	}
+	return &aspiration, nil
+}
+
+// IncrementRateLimit menambah hitungan pengajuan key pada periode selama belum mencapai
+// limit; false jika kuota periode itu sudah habis
+func (r *AspirationRepository) IncrementRateLimit(key, period string, limit int) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("aspiration_rate_limits.count + 1")}),
+		Where:     clause.Where{Exprs: []clause.Expression{gorm.Expr("aspiration_rate_limits.count < ?", limit)}},
+	}).Create(&models.AspirationRateLimit{Key: key, Period: period, Count: 1})
+	return result.RowsAffected > 0, result.Error
+}
+
+// PruneRateLimits menghapus hitungan periode sebelum period
+func (r *AspirationRepository) PruneRateLimits(period string) (int64, error) {
+	result := r.db.Where("period < ?", period).Delete(&models.AspirationRateLimit{})
+	return result.RowsAffected, result.Error
+}
+
+// AddVote menyimpan dukungan; false jika pengguna sudah mendukung aspirasi ini
+func (r *AspirationRepository) AddVote(vote *models.AspirationVote) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(vote)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RemoveVote menghapus dukungan; false jika pengguna belum mendukung
+func (r *AspirationRepository) RemoveVote(aspirationID uint, username string) (bool, error) {
+	result := r.db.Where("aspiration_id = ? AND username = ?", aspirationID, username).Delete(&models.AspirationVote{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RefreshVoteCount menyamakan vote_count dengan jumlah dukungan tersimpan
+func (r *AspirationRepository) RefreshVoteCount(aspirationID uint) error {
+	return r.db.Model(&models.Aspiration{}).Where("id = ?", aspirationID).
+		UpdateColumn("vote_count", r.db.Model(&models.AspirationVote{}).Select("COUNT(*)").Where("aspiration_id = ?", aspirationID)).Error
+}
+
+// MoveVotes memindahkan dukungan dari satu aspirasi ke aspirasi lain; pendukung yang sudah
+// mendukung keduanya hanya dihitung sekali
+func (r *AspirationRepository) MoveVotes(fromID, toID uint) error {
+	err := r.db.Exec(`INSERT INTO aspiration_votes (aspiration_id, username, created_at)
+		SELECT ?, username, created_at FROM aspiration_votes WHERE aspiration_id = ?
+		ON CONFLICT (aspiration_id, username) DO NOTHING`, toID, fromID).Error
+	if err != nil {
+		return err
+	}
+	return r.db.Where("aspiration_id = ?", fromID).Delete(&models.AspirationVote{}).Error
+}
+
+// GetVoterUsernames mengambil username pendukung aspirasi-aspirasi tersebut (tanpa duplikat)
+func (r *AspirationRepository) GetVoterUsernames(aspirationIDs []uint) ([]string, error) {
+	var usernames []string
+	err := r.db.Model(&models.AspirationVote{}).Where("aspiration_id IN ?", aspirationIDs).
+		Distinct().Pluck("username", &usernames).Error
+	return usernames, err
+}
+
+// GetVotedIDs mengambil id aspirasi (dari ids) yang sudah didukung username
+func (r *AspirationRepository) GetVotedIDs(username string, aspirationIDs []uint) ([]uint, error) {
+	var ids []uint
+	if username == "" || len(aspirationIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.Model(&models.AspirationVote{}).Where("username = ? AND aspiration_id IN ?", username, aspirationIDs).
+		Pluck("aspiration_id", &ids).Error
+	return ids, err
+}
+
+// publicAspirations membatasi query ke aspirasi publik yang belum digabung
+func (r *AspirationRepository) publicAspirations() *gorm.DB {
+	return r.db.Model(&models.Aspiration{}).Where("aspirations.public = ? AND aspirations.merged_into_id IS NULL", true)
+}
+
+// GetPublic mengambil papan aspirasi publik; sort "votes" (dukungan terbanyak) atau
+// terbaru
+func (r *AspirationRepository) GetPublic(sort string, limit, offset int) ([]models.Aspiration, int64, error) {
+	var aspirations []models.Aspiration
+	var total int64
+	query := r.publicAspirations()
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	order := "created_at DESC, id DESC"
+	if sort == "votes" {
+		order = "vote_count DESC, created_at DESC, id DESC"
+	}
+	if err := query.Preload("Student").Preload("Organization").Order(order).
+		Limit(limit).Offset(offset).Find(&aspirations).Error; err != nil {
+		return nil, 0, err
+	}
+	return aspirations, total, nil
+}
+
+// FindPublicByIDs mengambil aspirasi publik yang belum digabung berdasarkan id
+func (r *AspirationRepository) FindPublicByIDs(ids []uint) ([]models.Aspiration, error) {
+	var aspirations []models.Aspiration
+	if len(ids) == 0 {
+		return aspirations, nil
+	}
+	err := r.publicAspirations().Preload("Student").Preload("Organization").Where("id IN ?", ids).Find(&aspirations).Error
+	return aspirations, err
+}
+
+// GetPublicVotesSince mengambil dukungan untuk aspirasi publik sejak waktu tertentu
+func (r *AspirationRepository) GetPublicVotesSince(since time.Time) ([]models.AspirationVote, error) {
+	var votes []models.AspirationVote
+	err := r.db.Model(&models.AspirationVote{}).
+		Joins("JOIN aspirations ON aspirations.id = aspiration_votes.aspiration_id AND aspirations.deleted_at IS NULL").
+		Where("aspirations.public = ? AND aspirations.merged_into_id IS NULL", true).
+		Where("aspiration_votes.created_at >= ?", since).
+		Select("aspiration_votes.aspiration_id, aspiration_votes.created_at").
+		Find(&votes).Error
+	return votes, err
+}
+
+// AspirationVoteCount adalah jumlah dukungan satu aspirasi dalam satu rentang
+type AspirationVoteCount struct {
+	AspirationID uint
+	Votes        int
+}
+
+// GetMostSupported menghitung dukungan yang masuk pada [start, end) per aspirasi publik,
+// terbanyak dulu
+func (r *AspirationRepository) GetMostSupported(start, end time.Time, limit int) ([]AspirationVoteCount, error) {
+	var counts []AspirationVoteCount
+	err := r.db.Model(&models.AspirationVote{}).
+		Joins("JOIN aspirations ON aspirations.id = aspiration_votes.aspiration_id AND aspirations.deleted_at IS NULL").
+		Where("aspirations.public = ? AND aspirations.merged_into_id IS NULL", true).
+		Where("aspiration_votes.created_at >= ? AND aspiration_votes.created_at < ?", start, end).
+		Select("aspiration_votes.aspiration_id, COUNT(*) AS votes").
+		Group("aspiration_votes.aspiration_id").
+		Order("votes DESC, aspiration_votes.aspiration_id ASC").
+		Limit(limit).Scan(&counts).Error
+	return counts, err
+}
+
+func (r *AspirationRepository) CreateComment(comment *models.AspirationComment) error {
+	return r.db.Create(comment).Error
+}
+
+func (r *AspirationRepository) FindComment(id uint) (*models.AspirationComment, error) {
+	var comment models.AspirationComment
+	if err := r.db.First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *AspirationRepository) DeleteComment(id uint) error {
+	return r.db.Delete(&models.AspirationComment{}, id).Error
+}
+
+// GetComments mengambil komentar aspirasi, terlama dulu
+func (r *AspirationRepository) GetComments(aspirationID uint) ([]models.AspirationComment, error) {
+	var comments []models.AspirationComment
+	err := r.db.Where("aspiration_id = ?", aspirationID).Order("created_at ASC, id ASC").Find(&comments).Error
+	return comments, err
+}
+
+// GetForAnalytics mengambil aspirasi yang dikirim pada [start, end) untuk laporan, tanpa
+// aspirasi duplikat yang sudah digabung
+func (r *AspirationRepository) GetForAnalytics(start, end time.Time) ([]models.Aspiration, error) {
+	var aspirations []models.Aspiration
+	err := r.db.Where("created_at >= ? AND created_at < ? AND merged_into_id IS NULL", start, end).
+		Order("created_at ASC").Find(&aspirations).Error
+	return aspirations, err
+}
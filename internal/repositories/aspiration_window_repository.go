@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"bem_be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AspirationWindowRepository struct {
+	db *gorm.DB
+}
+
+func NewAspirationWindowRepository(db *gorm.DB) *AspirationWindowRepository {
+	return &AspirationWindowRepository{db: db}
+}
+
+func (r *AspirationWindowRepository) Create(window *models.AspirationWindow) error {
+	return r.db.Omit(clause.Associations).Create(window).Error
+}
+
+func (r *AspirationWindowRepository) Update(window *models.AspirationWindow) error {
+	return r.db.Omit(clause.Associations).Save(window).Error
+}
+
+func (r *AspirationWindowRepository) Delete(id uint) error {
+	return r.db.Delete(&models.AspirationWindow{}, id).Error
+}
+
+// FindByID mengambil jendela beserta organisasi tujuannya; nil jika tidak ada
+func (r *AspirationWindowRepository) FindByID(id uint) (*models.AspirationWindow, error) {
+	return r.first(r.db.Where("id = ?", id))
+}
+
+// FindOpen mengambil jendela yang terbuka pada waktu at; nil jika penerimaan tutup
+func (r *AspirationWindowRepository) FindOpen(at time.Time) (*models.AspirationWindow, error) {
+	return r.first(r.db.Where("opens_at <= ? AND closes_at > ?", at, at))
+}
+
+// FindNext mengambil jendela berikutnya yang dibuka setelah at; nil jika belum dijadwalkan
+func (r *AspirationWindowRepository) FindNext(at time.Time) (*models.AspirationWindow, error) {
+	return r.first(r.db.Where("opens_at > ?", at).Order("opens_at ASC"))
+}
+
+func (r *AspirationWindowRepository) first(query *gorm.DB) (*models.AspirationWindow, error) {
+	var window models.AspirationWindow
+	if err := query.Preload("Organization").First(&window).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &window, nil
+}
+
+// FindOverlapping mengambil jendela lain yang beririsan dengan [opensAt, closesAt)
+func (r *AspirationWindowRepository) FindOverlapping(opensAt, closesAt time.Time, excludeID uint) (*models.AspirationWindow, error) {
+	return r.first(r.db.Where("opens_at < ? AND closes_at > ? AND id <> ?", closesAt, opensAt, excludeID))
+}
+
+// FindAll mengambil semua jendela, terbaru dulu
+func (r *AspirationWindowRepository) FindAll() ([]models.AspirationWindow, error) {
+	var windows []models.AspirationWindow
+	err := r.db.Preload("Organization").Order("opens_at DESC, id DESC").Find(&windows).Error
+	return windows, err
+}
+
+// CountSubmissions menghitung aspirasi yang masuk per jendela
+func (r *AspirationWindowRepository) CountSubmissions(windowIDs []uint) (map[uint]int64, error) {
+	counts := map[uint]int64{}
+	if len(windowIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		WindowID uint
+		Total    int64
+	}
+	err := r.db.Model(&models.Aspiration{}).Select("window_id, COUNT(*) AS total").
+		Where("window_id IN ?", windowIDs).Group("window_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.WindowID] = row.Total
+	}
+	return counts, nil
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"errors"
+
+	"bem_be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CalendarTokenRepository menyimpan token langganan kalender pribadi
+type CalendarTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarTokenRepository(db *gorm.DB) *CalendarTokenRepository {
+	return &CalendarTokenRepository{db: db}
+}
+
+// FindByUsername mengambil token milik pengguna; nil jika belum pernah dibuat
+func (r *CalendarTokenRepository) FindByUsername(username string) (*models.CalendarToken, error) {
+	return r.find("username = ?", username)
+}
+
+// FindByToken mengambil pemilik token; nil jika token tidak dikenal
+func (r *CalendarTokenRepository) FindByToken(token string) (*models.CalendarToken, error) {
+	return r.find("token = ?", token)
+}
+
+func (r *CalendarTokenRepository) find(query string, value string) (*models.CalendarToken, error) {
+	var token models.CalendarToken
+	if err := r.db.Where(query, value).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Save membuat token pengguna atau menggantinya jika sudah ada
+func (r *CalendarTokenRepository) Save(token *models.CalendarToken) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "username"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token", "updated_at"}),
+	}).Create(token).Error
+}
@@ -6,12 +6,17 @@ import (
 	"bem_be/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CalenderRepository struct {
 	db *gorm.DB
 }
 
+// eventActiveSince mencocokkan event yang masih berlangsung setelah waktu tertentu;
+// event berulang dicocokkan dengan akhir seluruh serinya (recurrence_end)
+const eventActiveSince = "(end_time >= ? OR (rrule <> '' AND (recurrence_end IS NULL OR recurrence_end >= ?)))"
+
 func NewCalenderRepository(db *gorm.DB) *CalenderRepository {
 	return &CalenderRepository{db: db}
 }
@@ -55,40 +60,19 @@ func (r *CalenderRepository) GetByID(id uint) (*models.Calender, error) {
 // ✅ Get Events in Range (pakai tipe time.Time)
 func (r *CalenderRepository) GetEventsInRange(start, end time.Time) ([]models.Calender, error) {
 	var events []models.Calender
-	if err := r.db.Where("end_time >= ? AND start_time <= ?", start, end).Find(&events).Error; err != nil {
+	if err := r.db.Where(eventActiveSince+" AND start_time <= ?", start, start, end).Find(&events).Error; err != nil {
 		return nil, err
 	}
 	return events, nil
 }
 
-// ✅ Get Events in Range (pakai string input — helper untuk service)
-func (r *CalenderRepository) GetEventsInRangeString(startStr, endStr string) ([]models.Calender, error) {
-	var start, end time.Time
-	var err error
-
-	if startStr != "" {
-		start, err = time.Parse(time.RFC3339, startStr)
-		if err != nil {
-			return nil, errors.New("format start time tidak valid")
-		}
-	}
-	if endStr != "" {
-		end, err = time.Parse(time.RFC3339, endStr)
-		if err != nil {
-			return nil, errors.New("format end time tidak valid")
-		}
-	}
-
-	return r.GetEventsInRange(start, end)
-}
-
 // ✅ Get Events by Month & Year
 func (r *CalenderRepository) GetEventsByMonthYear(month, year int) ([]models.Calender, error) {
 	var events []models.Calender
 	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
 	end := start.AddDate(0, 1, 0) // Awal bulan berikutnya
 
-	if err := r.db.Where("end_time >= ? AND start_time < ?", start, end).Find(&events).Error; err != nil {
+	if err := r.db.Where(eventActiveSince+" AND start_time < ?", start, start, end).Find(&events).Error; err != nil {
 		return nil, err
 	}
 	return events, nil
@@ -97,7 +81,7 @@ func (r *CalenderRepository) GetEventsByMonthYear(month, year int) ([]models.Cal
 func (r *CalenderRepository) GetEventsCurrentMonth(start, end time.Time) ([]models.Calender, error) {
 	var events []models.Calender
 	if err := r.db.Preload("Organization").
-		Where("end_time >= ? AND start_time <= ?", start, end).
+		Where(eventActiveSince+" AND start_time <= ?", start, start, end).
 		Find(&events).Error; err != nil {
 		return nil, err
 	}
@@ -111,3 +95,320 @@ func (r *CalenderRepository) GetAllEvents() ([]models.Calender, error) {
 	}
 	return events, nil
 }
+
+// GetFeedEvents mengambil event yang selesai setelah since untuk feed iCalendar.
+// organizationID 0 berarti semua organisasi.
+func (r *CalenderRepository) GetFeedEvents(since time.Time, organizationID uint) ([]models.Calender, error) {
+	var events []models.Calender
+	query := r.db.Preload("Organization").Where(eventActiveSince, since, since)
+	if organizationID != 0 {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+	if err := query.Order("start_time ASC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetRegisteredEvents mengambil event yang didaftari pengguna (bukan daftar tunggu) dan
+// selesai setelah since
+func (r *CalenderRepository) GetRegisteredEvents(username string, since time.Time) ([]models.Calender, error) {
+	var events []models.Calender
+	if err := r.db.Preload("Organization").
+		Where(eventActiveSince, since, since).
+		Where("(id IN (SELECT event_id FROM event_registrations WHERE username = ? AND status = ?) OR parent_id IN (SELECT event_id FROM event_registrations WHERE username = ? AND status = ?))",
+			username, models.EventRegistrationRegistered, username, models.EventRegistrationRegistered).
+		Order("start_time ASC").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetByUID mencari event hasil impor (bukan pengganti kemunculan) berdasarkan UID iCalendar
+// di organisasi tertentu (nil = event tanpa organisasi); nil jika tidak ada
+func (r *CalenderRepository) GetByUID(uid string, organizationID *int) (*models.Calender, error) {
+	query := r.db.Where("uid = ? AND parent_id IS NULL", uid)
+	if organizationID != nil {
+		query = query.Where("organization_id = ?", *organizationID)
+	} else {
+		query = query.Where("organization_id IS NULL")
+	}
+	var event models.Calender
+	if err := query.First(&event).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// FindRegistration mencari pendaftaran pengguna pada event; nil jika belum mendaftar
+func (r *CalenderRepository) FindRegistration(eventID uint, username string) (*models.EventRegistration, error) {
+	var registration models.EventRegistration
+	if err := r.db.Where("event_id = ? AND username = ?", eventID, username).First(&registration).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &registration, nil
+}
+
+func (r *CalenderRepository) CreateRegistration(registration *models.EventRegistration) error {
+	return r.db.Create(registration).Error
+}
+
+func (r *CalenderRepository) DeleteRegistration(eventID uint, username string) error {
+	return r.db.Where("event_id = ? AND username = ?", eventID, username).Delete(&models.EventRegistration{}).Error
+}
+
+// GetOverrides mengambil semua pengganti kemunculan milik event-event berulang
+func (r *CalenderRepository) GetOverrides(parentIDs []uint) ([]models.Calender, error) {
+	var overrides []models.Calender
+	if len(parentIDs) == 0 {
+		return overrides, nil
+	}
+	if err := r.db.Where("parent_id IN ?", parentIDs).Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// GetOverridesFrom mengambil pengganti kemunculan seri yang kemunculan aslinya mulai
+// pada atau setelah from
+func (r *CalenderRepository) GetOverridesFrom(parentID uint, from time.Time) ([]models.Calender, error) {
+	var overrides []models.Calender
+	if err := r.db.Where("parent_id = ? AND original_start >= ?", parentID, from).Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// GetOverride mengambil pengganti satu kemunculan; nil jika kemunculan belum diubah
+func (r *CalenderRepository) GetOverride(parentID uint, originalStart time.Time) (*models.Calender, error) {
+	var event models.Calender
+	if err := r.db.Where("parent_id = ? AND original_start = ?", parentID, originalStart).First(&event).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// DeleteOverridesFrom menghapus pengganti kemunculan seri mulai dari from
+func (r *CalenderRepository) DeleteOverridesFrom(parentID uint, from time.Time) error {
+	return r.db.Where("parent_id = ? AND original_start >= ?", parentID, from).Delete(&models.Calender{}).Error
+}
+
+// DeleteOverride menghapus pengganti satu kemunculan
+func (r *CalenderRepository) DeleteOverride(parentID uint, originalStart time.Time) error {
+	return r.db.Where("parent_id = ? AND original_start = ?", parentID, originalStart).Delete(&models.Calender{}).Error
+}
+
+// DeleteOverrides menghapus semua pengganti kemunculan seri
+func (r *CalenderRepository) DeleteOverrides(parentID uint) error {
+	return r.db.Where("parent_id = ?", parentID).Delete(&models.Calender{}).Error
+}
+
+// LockEvent mengambil event dengan SELECT ... FOR UPDATE agar pendaftaran serentak
+// tidak melampaui kuota; hanya bermakna di dalam transaksi
+func (r *CalenderRepository) LockEvent(id uint) (*models.Calender, error) {
+	var event models.Calender
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// CountRegistrations menghitung pendaftar event dengan status tertentu
+func (r *CalenderRepository) CountRegistrations(eventID uint, status string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.EventRegistration{}).Where("event_id = ? AND status = ?", eventID, status).Count(&count).Error
+	return count, err
+}
+
+// GetWaitlist mengambil daftar tunggu event sesuai urutan mendaftar; limit <= 0 berarti semua
+func (r *CalenderRepository) GetWaitlist(eventID uint, limit int) ([]models.EventRegistration, error) {
+	var registrations []models.EventRegistration
+	query := r.db.Where("event_id = ? AND status = ?", eventID, models.EventRegistrationWaitlisted).Order("created_at ASC, id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&registrations).Error; err != nil {
+		return nil, err
+	}
+	return registrations, nil
+}
+
+// WaitlistPosition menghitung urutan pendaftaran di daftar tunggu event (mulai dari 1)
+func (r *CalenderRepository) WaitlistPosition(registration *models.EventRegistration) (int, error) {
+	var ahead int64
+	err := r.db.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND status = ?", registration.EventID, models.EventRegistrationWaitlisted).
+		Where("created_at < ? OR (created_at = ? AND id < ?)", registration.CreatedAt, registration.CreatedAt, registration.ID).
+		Count(&ahead).Error
+	return int(ahead) + 1, err
+}
+
+// PromoteRegistrations menaikkan pendaftaran dari daftar tunggu menjadi terdaftar
+func (r *CalenderRepository) PromoteRegistrations(ids []uint, at time.Time) error {
+	return r.db.Model(&models.EventRegistration{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"status": models.EventRegistrationRegistered, "promoted_at": at}).Error
+}
+
+// GetRegistrants mengambil pendaftar event beserta data mahasiswanya; status kosong
+// berarti semua status
+func (r *CalenderRepository) GetRegistrants(eventID uint, status string) ([]models.EventRegistrant, error) {
+	var registrants []models.EventRegistrant
+	query := r.db.Table("event_registrations AS er").
+		Select("er.id, er.username, er.status, er.created_at, er.promoted_at, " +
+			"s.nim, s.full_name, s.email, s.faculty, s.study_program, s.year_enrolled").
+		Joins("LEFT JOIN students AS s ON s.user_name = er.username AND s.deleted_at IS NULL").
+		Where("er.event_id = ?", eventID)
+	if status != "" {
+		query = query.Where("er.status = ?", status)
+	}
+	if err := query.Order("er.status ASC, er.created_at ASC, er.id ASC").Scan(&registrants).Error; err != nil {
+		return nil, err
+	}
+	return registrants, nil
+}
+
+// GetUserRegistrations mengambil pendaftaran event milik pengguna, event terdekat dulu
+func (r *CalenderRepository) GetUserRegistrations(username string) ([]models.EventRegistration, error) {
+	var registrations []models.EventRegistration
+	if err := r.db.Preload("Event").Preload("Event.Organization").
+		Joins("JOIN calenders ON calenders.id = event_registrations.event_id AND calenders.deleted_at IS NULL").
+		Where("event_registrations.username = ?", username).
+		Order("calenders.start_time DESC").
+		Find(&registrations).Error; err != nil {
+		return nil, err
+	}
+	return registrations, nil
+}
+
+// FindRegistrationByID mengambil pendaftaran berdasarkan ID; nil jika tidak ada
+func (r *CalenderRepository) FindRegistrationByID(id uint) (*models.EventRegistration, error) {
+	var registration models.EventRegistration
+	if err := r.db.First(&registration, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &registration, nil
+}
+
+// SetAttendanceSecret menyimpan secret presensi event jika belum ada dan mengembalikan
+// secret yang berlaku (bisa milik permintaan lain yang lebih dulu menyimpan)
+func (r *CalenderRepository) SetAttendanceSecret(id uint, secret string) (string, error) {
+	if err := r.db.Model(&models.Calender{}).
+		Where("id = ? AND (attendance_secret IS NULL OR attendance_secret = '')", id).
+		Update("attendance_secret", secret).Error; err != nil {
+		return "", err
+	}
+	var event models.Calender
+	if err := r.db.Select("attendance_secret").First(&event, id).Error; err != nil {
+		return "", err
+	}
+	return event.AttendanceSecret, nil
+}
+
+// FindAttendance mengambil kehadiran pengguna pada satu kemunculan; nil jika belum hadir
+func (r *CalenderRepository) FindAttendance(eventID uint, occurrenceStart time.Time, username string) (*models.EventAttendance, error) {
+	var attendance models.EventAttendance
+	err := r.db.Where("event_id = ? AND occurrence_start = ? AND username = ?", eventID, occurrenceStart, username).
+		First(&attendance).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attendance, nil
+}
+
+func (r *CalenderRepository) CreateAttendance(attendance *models.EventAttendance) error {
+	return r.db.Create(attendance).Error
+}
+
+// DeleteAttendance menghapus kehadiran pengguna pada satu kemunculan; false jika tidak ada
+func (r *CalenderRepository) DeleteAttendance(eventID uint, occurrenceStart time.Time, username string) (bool, error) {
+	result := r.db.Where("event_id = ? AND occurrence_start = ? AND username = ?", eventID, occurrenceStart, username).
+		Delete(&models.EventAttendance{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountAttendance menghitung kehadiran event; occurrenceStart nil berarti semua kemunculan
+func (r *CalenderRepository) CountAttendance(eventID uint, occurrenceStart *time.Time) (int64, error) {
+	var count int64
+	query := r.db.Model(&models.EventAttendance{}).Where("event_id = ?", eventID)
+	if occurrenceStart != nil {
+		query = query.Where("occurrence_start = ?", *occurrenceStart)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// GetAttendees mengambil kehadiran event beserta data mahasiswanya; occurrenceStart nil
+// berarti semua kemunculan
+func (r *CalenderRepository) GetAttendees(eventID uint, occurrenceStart *time.Time) ([]models.EventAttendee, error) {
+	var attendees []models.EventAttendee
+	query := r.db.Table("event_attendances AS ea").
+		Select("ea.id, ea.occurrence_start, ea.username, ea.method, ea.checked_in_by, ea.distance, ea.checked_in_at, " +
+			"s.nim, s.full_name, s.email, s.faculty, s.study_program, s.year_enrolled").
+		Joins("LEFT JOIN students AS s ON s.user_name = ea.username AND s.deleted_at IS NULL").
+		Where("ea.event_id = ?", eventID)
+	if occurrenceStart != nil {
+		query = query.Where("ea.occurrence_start = ?", *occurrenceStart)
+	}
+	if err := query.Order("ea.occurrence_start ASC, ea.checked_in_at ASC, ea.id ASC").Scan(&attendees).Error; err != nil {
+		return nil, err
+	}
+	return attendees, nil
+}
+
+// GetUserAttendance mengambil riwayat kehadiran event pengguna, terbaru dulu
+func (r *CalenderRepository) GetUserAttendance(username string) ([]models.EventAttendance, error) {
+	var attendances []models.EventAttendance
+	if err := r.db.Preload("Event").Preload("Event.Organization").
+		Where("username = ?", username).
+		Order("occurrence_start DESC").
+		Find(&attendances).Error; err != nil {
+		return nil, err
+	}
+	return attendances, nil
+}
+
+// ClaimReminder mencatat pengingat sebelum dikirim; false jika pengingat yang sama sudah
+// pernah dicatat (oleh putaran sebelumnya atau instance lain)
+func (r *CalenderRepository) ClaimReminder(reminder *models.EventReminder) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateReminderRecipients menyimpan jumlah penerima pengingat yang terkirim
+func (r *CalenderRepository) UpdateReminderRecipients(id uint, recipients int) error {
+	return r.db.Model(&models.EventReminder{}).Where("id = ?", id).Update("recipients", recipients).Error
+}
+
+// WasRescheduled memeriksa apakah kemunculan pernah diingatkan dengan waktu mulai lain
+func (r *CalenderRepository) WasRescheduled(eventID uint, occurrenceStart, startTime time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.EventReminder{}).
+		Where("event_id = ? AND occurrence_start = ? AND start_time <> ?", eventID, occurrenceStart, startTime).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetRegisteredUsernames mengambil username peserta terdaftar (bukan daftar tunggu) event
+func (r *CalenderRepository) GetRegisteredUsernames(eventID uint) ([]string, error) {
+	var usernames []string
+	err := r.db.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND status = ?", eventID, models.EventRegistrationRegistered).
+		Pluck("username", &usernames).Error
+	return usernames, err
+}
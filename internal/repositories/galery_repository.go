@@ -34,22 +34,155 @@ func (r *GaleryRepository) FindByID(id uint) (*models.Galery, error) {
 	return &galery, nil
 }
 
-func (r *GaleryRepository) GetAllGalerys(limit, offset int) ([]models.Galery, int64, error) {
-	var galerys []models.Galery
+func (r *GaleryRepository) DeleteByID(id uint) error {
+	return r.db.Delete(&models.Galery{}, id).Error
+}
+
+// GaleryAlbumFilter adalah filter opsional daftar album
+type GaleryAlbumFilter struct {
+	OrganizationID *uint
+	EventID        *uint
+	Title          string
+}
+
+func (r *GaleryRepository) CreateAlbum(album *models.GaleryAlbum) error {
+	return r.db.Create(album).Error
+}
+
+// UpdateAlbum menyimpan kolom album tanpa menyentuh relasi
+func (r *GaleryRepository) UpdateAlbum(album *models.GaleryAlbum) error {
+	return r.db.Model(&models.GaleryAlbum{}).Where("id = ?", album.ID).
+		Select("title", "description", "event_id", "organization_id", "cover_photo_id", "sort_order").
+		Updates(album).Error
+}
+
+func (r *GaleryRepository) FindAlbumByID(id uint) (*models.GaleryAlbum, error) {
+	var album models.GaleryAlbum
+	err := r.db.Preload("Event").Preload("Organization").Preload("CoverPhoto").First(&album, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// GetAlbums mengambil album dengan pagination beserta jumlah foto masing-masing
+func (r *GaleryRepository) GetAlbums(filter GaleryAlbumFilter, limit, offset int) ([]models.GaleryAlbum, int64, error) {
+	var albums []models.GaleryAlbum
 	var total int64
 
-	query := r.db.Model(&models.Galery{})
+	query := r.db.Model(&models.GaleryAlbum{})
+	if filter.OrganizationID != nil {
+		query = query.Where("organization_id = ?", *filter.OrganizationID)
+	}
+	if filter.EventID != nil {
+		query = query.Where("event_id = ?", *filter.EventID)
+	}
+	if filter.Title != "" {
+		query = query.Where("title ILIKE ?", "%"+filter.Title+"%")
+	}
+
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
+	if err := query.Preload("Organization").Preload("CoverPhoto").
+		Order("sort_order ASC, created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&albums).Error; err != nil {
+		return nil, 0, err
+	}
 
-	if err := query.Limit(limit).Offset(offset).Find(&galerys).Error; err != nil {
+	if len(albums) > 0 {
+		ids := make([]uint, len(albums))
+		for i, album := range albums {
+			ids[i] = album.ID
+		}
+		var counts []struct {
+			AlbumID uint
+			Count   int64
+		}
+		if err := r.db.Model(&models.Galery{}).
+			Select("album_id, COUNT(*) AS count").
+			Where("album_id IN ?", ids).
+			Group("album_id").
+			Scan(&counts).Error; err != nil {
+			return nil, 0, err
+		}
+		byAlbum := make(map[uint]int64, len(counts))
+		for _, c := range counts {
+			byAlbum[c.AlbumID] = c.Count
+		}
+		for i := range albums {
+			albums[i].PhotoCount = byAlbum[albums[i].ID]
+		}
+	}
+
+	return albums, total, nil
+}
+
+// DeleteAlbum menghapus album beserta fotonya dalam satu transaksi
+func (r *GaleryRepository) DeleteAlbum(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("album_id = ?", id).Delete(&models.Galery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.GaleryAlbum{}, id).Error
+	})
+}
+
+// GetAlbumPhotos mengambil foto album sesuai urutan
+func (r *GaleryRepository) GetAlbumPhotos(albumID uint, limit, offset int) ([]models.Galery, int64, error) {
+	var photos []models.Galery
+	var total int64
+
+	query := r.db.Model(&models.Galery{}).Where("album_id = ?", albumID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("sort_order ASC, id ASC").Limit(limit).Offset(offset).Find(&photos).Error; err != nil {
 		return nil, 0, err
 	}
+	return photos, total, nil
+}
 
-	return galerys, total, nil
+// NextSortOrder mengembalikan urutan berikutnya untuk foto baru di album
+func (r *GaleryRepository) NextSortOrder(albumID uint) (int, error) {
+	var max *int
+	err := r.db.Model(&models.Galery{}).Where("album_id = ?", albumID).
+		Select("MAX(sort_order)").Scan(&max).Error
+	if err != nil || max == nil {
+		return 0, err
+	}
+	return *max + 1, nil
 }
 
-func (r *GaleryRepository) DeleteByID(id uint) error {
-	return r.db.Delete(&models.Galery{}, id).Error
+// CreatePhotos menyimpan beberapa foto sekaligus
+func (r *GaleryRepository) CreatePhotos(photos []models.Galery) error {
+	if len(photos) == 0 {
+		return nil
+	}
+	return r.db.Create(&photos).Error
+}
+
+// ReorderPhotos menyimpan urutan foto sesuai posisi ID pada slice
+func (r *GaleryRepository) ReorderPhotos(albumID uint, photoIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range photoIDs {
+			res := tx.Model(&models.Galery{}).
+				Where("id = ? AND album_id = ?", id, albumID).
+				Update("sort_order", i)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		return nil
+	})
+}
+
+// ClearCover mengosongkan sampul album yang menunjuk ke foto yang dihapus
+func (r *GaleryRepository) ClearCover(photoID uint) error {
+	return r.db.Model(&models.GaleryAlbum{}).Where("cover_photo_id = ?", photoID).
+		Update("cover_photo_id", nil).Error
 }
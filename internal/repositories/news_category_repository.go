@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"bem_be/internal/database"
+	"bem_be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// NewsCategoryRepository adalah repository untuk kategori dan tag berita
+type NewsCategoryRepository struct {
+	db *gorm.DB
+}
+
+// NewNewsCategoryRepository membuat instance repository kategori berita baru
+func NewNewsCategoryRepository() *NewsCategoryRepository {
+	return &NewsCategoryRepository{
+		db: database.GetDB(),
+	}
+}
+
+// ===== Kategori =====
+
+// GetCategories mengambil semua kategori sesuai urutan beserta jumlah beritanya.
+// Jika status diisi, hanya berita dengan status tersebut yang dihitung.
+func (r *NewsCategoryRepository) GetCategories(status string) ([]models.NewsCategory, error) {
+	var categories []models.NewsCategory
+	if err := r.db.Order("sort_order ASC, name ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		CategoryID uint
+		Count      int64
+	}
+	query := r.db.Model(&models.News{}).
+		Select("category_id, COUNT(*) AS count").
+		Where("category_id IS NOT NULL")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Group("category_id").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	byCategory := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		byCategory[c.CategoryID] = c.Count
+	}
+	for i := range categories {
+		categories[i].NewsCount = byCategory[categories[i].ID]
+	}
+	return categories, nil
+}
+
+func (r *NewsCategoryRepository) FindCategoryByID(id uint) (*models.NewsCategory, error) {
+	var category models.NewsCategory
+	if err := r.db.First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// FindCategory mencari kategori berdasarkan slug, lalu berdasarkan nama (tanpa
+// membedakan huruf besar) jika slug tidak cocok
+func (r *NewsCategoryRepository) FindCategory(slug, name string) (*models.NewsCategory, error) {
+	var category models.NewsCategory
+	err := r.db.Where("slug = ?", slug).First(&category).Error
+	if err == gorm.ErrRecordNotFound {
+		err = r.db.Where("LOWER(name) = LOWER(?)", name).First(&category).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// CategorySlugExists memeriksa apakah slug kategori sudah dipakai kategori lain
+func (r *NewsCategoryRepository) CategorySlugExists(slug string, excludeID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.NewsCategory{}).Where("slug = ? AND id <> ?", slug, excludeID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *NewsCategoryRepository) CreateCategory(category *models.NewsCategory) error {
+	return r.db.Create(category).Error
+}
+
+// UpdateCategory menyimpan kategori dan menyalin nama barunya ke kolom category berita
+func (r *NewsCategoryRepository) UpdateCategory(category *models.NewsCategory) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(category).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.News{}).
+			Where("category_id = ?", category.ID).
+			UpdateColumn("category", category.Name).Error
+	})
+}
+
+// CountCategoryNews menghitung berita (termasuk yang di-soft-delete) di sebuah kategori
+func (r *NewsCategoryRepository) CountCategoryNews(id uint) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.News{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *NewsCategoryRepository) DeleteCategory(id uint) error {
+	return r.db.Delete(&models.NewsCategory{}, id).Error
+}
+
+// ReorderCategories menyimpan urutan kategori sesuai posisi ID pada slice
+func (r *NewsCategoryRepository) ReorderCategories(ids []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			res := tx.Model(&models.NewsCategory{}).Where("id = ?", id).Update("sort_order", i)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		return nil
+	})
+}
+
+// ===== Tag =====
+
+// GetTags mengambil semua tag beserta jumlah beritanya, terbanyak dulu.
+// Jika status diisi, hanya berita dengan status tersebut yang dihitung.
+func (r *NewsCategoryRepository) GetTags(status string) ([]models.NewsTag, error) {
+	var tags []models.NewsTag
+	if err := r.db.Order("name ASC").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		TagID uint
+		Count int64
+	}
+	query := r.db.Table("news_tag_links").
+		Select("news_tag_links.tag_id, COUNT(*) AS count").
+		Joins("JOIN news ON news.id = news_tag_links.news_id AND news.deleted_at IS NULL")
+	if status != "" {
+		query = query.Where("news.status = ?", status)
+	}
+	if err := query.Group("news_tag_links.tag_id").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	byTag := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		byTag[c.TagID] = c.Count
+	}
+	for i := range tags {
+		tags[i].NewsCount = byTag[tags[i].ID]
+	}
+	return tags, nil
+}
+
+func (r *NewsCategoryRepository) FindTagByID(id uint) (*models.NewsTag, error) {
+	var tag models.NewsTag
+	if err := r.db.First(&tag, id).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *NewsCategoryRepository) TagSlugExists(slug string, excludeID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.NewsTag{}).Where("slug = ? AND id <> ?", slug, excludeID).Count(&count).Error
+	return count > 0, err
+}
+
+// FindOrCreateTag mengambil tag berdasarkan slug, atau membuatnya jika belum ada
+func (r *NewsCategoryRepository) FindOrCreateTag(slug, name string) (*models.NewsTag, error) {
+	tag := models.NewsTag{Slug: slug, Name: name}
+	err := r.db.Where(models.NewsTag{Slug: slug}).FirstOrCreate(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *NewsCategoryRepository) UpdateTag(tag *models.NewsTag) error {
+	return r.db.Save(tag).Error
+}
+
+// DeleteTag menghapus tag beserta kaitannya ke berita
+func (r *NewsCategoryRepository) DeleteTag(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM news_tag_links WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.NewsTag{}, id).Error
+	})
+}
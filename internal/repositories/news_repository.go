@@ -3,9 +3,12 @@ package repositories
 import (
 	"bem_be/internal/database"
 	"bem_be/internal/models"
+	"database/sql"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // NewsRepository adalah repository untuk operasi terkait berita.
@@ -20,20 +23,39 @@ func NewNewsRepository() *NewsRepository {
 	}
 }
 
-// Create membuat item berita baru.
+// NewsFilter adalah filter opsional daftar berita
+type NewsFilter struct {
+	Status       string
+	CategorySlug string
+	TagSlug      string
+}
+
+// Create membuat item berita baru. Tag disimpan terpisah lewat ReplaceTags.
 func (r *NewsRepository) Create(news *models.News) error {
-	return r.db.Create(news).Error
+	return r.db.Omit(clause.Associations).Create(news).Error
 }
 
-// Update menyimpan perubahan pada item berita yang ada.
+// Update menyimpan perubahan pada item berita yang ada (tanpa relasi).
 func (r *NewsRepository) Update(news *models.News) error {
-	return r.db.Save(news).Error
+	return r.db.Omit(clause.Associations).Save(news).Error
+}
+
+// ReplaceTags mengganti seluruh tag berita
+func (r *NewsRepository) ReplaceTags(news *models.News, tags []models.NewsTag) error {
+	return r.db.Model(news).Association("Tags").Replace(tags)
+}
+
+// withTaxonomy memuat kategori dan tag berita
+func withTaxonomy(query *gorm.DB) *gorm.DB {
+	return query.Preload("CategoryRef").Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("news_tags.name ASC")
+	})
 }
 
 // FindByID mencari item berita berdasarkan ID (hanya yang aktif).
 func (r *NewsRepository) FindByID(id uint) (*models.News, error) {
 	var news models.News
-	err := r.db.First(&news, id).Error
+	err := withTaxonomy(r.db).First(&news, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -41,23 +63,92 @@ func (r *NewsRepository) FindByID(id uint) (*models.News, error) {
 }
 
 // GetAllNews mengambil semua berita dengan pagination (hanya yang aktif).
-func (r *NewsRepository) GetAllNews(limit, offset int) ([]models.News, int64, error) {
+// Filter status, slug kategori dan slug tag bersifat opsional.
+func (r *NewsRepository) GetAllNews(limit, offset int, filter NewsFilter) ([]models.News, int64, error) {
 	var newsList []models.News
 	var total int64
 
+	query := r.db.Model(&models.News{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CategorySlug != "" {
+		query = query.Where("category_id IN (?)",
+			r.db.Model(&models.NewsCategory{}).Select("id").Where("slug = ?", filter.CategorySlug))
+	}
+	if filter.TagSlug != "" {
+		query = query.Where("id IN (?)",
+			r.db.Table("news_tag_links").Select("news_tag_links.news_id").
+				Joins("JOIN news_tags ON news_tags.id = news_tag_links.tag_id").
+				Where("news_tags.slug = ?", filter.TagSlug))
+	}
+
 	// Query untuk menghitung total data yang aktif
-	if err := r.db.Model(&models.News{}).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Query untuk mengambil data dengan limit, offset, dan pengurutan
-	if err := r.db.Limit(limit).Offset(offset).Order("created_at DESC").Find(&newsList).Error; err != nil {
+	if err := withTaxonomy(query).Limit(limit).Offset(offset).Order("COALESCE(published_at, created_at) DESC").Find(&newsList).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return newsList, total, nil
 }
 
+// FindPublishedByID mencari berita yang sudah terbit berdasarkan ID.
+func (r *NewsRepository) FindPublishedByID(id uint) (*models.News, error) {
+	var news models.News
+	err := withTaxonomy(r.db).Where("status = ?", models.NewsStatusPublished).First(&news, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &news, nil
+}
+
+// GetPublishedForFeed mengambil berita terbit terbaru untuk feed RSS/Atom, opsional per kategori
+// (slug atau nama kategori).
+func (r *NewsRepository) GetPublishedForFeed(category string, limit int) ([]models.News, error) {
+	var news []models.News
+	query := r.db.Where("status = ?", models.NewsStatusPublished)
+	if category != "" {
+		query = query.Where("LOWER(category) = LOWER(?) OR category_id IN (?)", category,
+			r.db.Model(&models.NewsCategory{}).Select("id").Where("slug = ?", category))
+	}
+	err := withTaxonomy(query).Order("COALESCE(published_at, created_at) DESC").Limit(limit).Find(&news).Error
+	return news, err
+}
+
+// newsLastChangeSQL adalah perubahan terakhir di tabel berita, termasuk berita yang dihapus
+// atau ditarik dari terbit, serta kategori yang namanya tampil di feed
+const newsLastChangeSQL = `SELECT GREATEST(
+	(SELECT MAX(GREATEST(updated_at, deleted_at)) FROM news),
+	(SELECT MAX(updated_at) FROM news_categories))`
+
+// LastChange mengembalikan waktu perubahan terakhir berita untuk Last-Modified feed. Berbeda
+// dengan updated_at item yang tersisa, nilai ini ikut maju saat berita dihapus atau ditarik.
+func (r *NewsRepository) LastChange() (time.Time, error) {
+	return lastChange(r.db, newsLastChangeSQL)
+}
+
+// FindDueScheduled mengambil berita terjadwal yang waktu terbitnya sudah lewat.
+func (r *NewsRepository) FindDueScheduled(now time.Time) ([]models.News, error) {
+	var newsList []models.News
+	err := r.db.Where("status = ? AND publish_at <= ?", models.NewsStatusScheduled, now).
+		Order("publish_at ASC").
+		Find(&newsList).Error
+	return newsList, err
+}
+
+// UpdateStatusIf mengubah status berita hanya jika status saat ini masih sama
+// (mencegah berita diterbitkan dua kali oleh scheduler dan editor bersamaan).
+func (r *NewsRepository) UpdateStatusIf(id uint, fromStatus string, updates map[string]interface{}) (bool, error) {
+	res := r.db.Model(&models.News{}).
+		Where("id = ? AND status = ?", id, fromStatus).
+		Updates(updates)
+	return res.RowsAffected == 1, res.Error
+}
+
 // DeleteByID menghapus item berita berdasarkan ID (soft delete).
 func (r *NewsRepository) DeleteByID(id uint) error {
 	return r.db.Delete(&models.News{}, id).Error
@@ -95,3 +186,16 @@ func (r *NewsRepository) RestoreByID(id uint) (*models.News, error) {
 	// 3. Kembalikan record yang sudah dipulihkan (sekarang sudah aktif)
 	return r.FindByID(deletedNews.ID)
 }
+
+// lastChange menjalankan query yang mengembalikan satu timestamp (boleh NULL); NULL berarti
+// belum pernah ada perubahan dan dikembalikan sebagai waktu nol
+func lastChange(db *gorm.DB, query string, args ...interface{}) (time.Time, error) {
+	var last sql.NullTime
+	if err := db.Raw(query, args...).Row().Scan(&last); err != nil {
+		return time.Time{}, err
+	}
+	if !last.Valid {
+		return time.Time{}, nil
+	}
+	return last.Time, nil
+}
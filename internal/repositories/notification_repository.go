@@ -2,7 +2,7 @@ package repositories
 
 import (
 	"bem_be/internal/models"
-	"strconv"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -19,6 +19,14 @@ func (r *NotificationRepository) CreateNotification(notification *models.Notific
 	return r.db.Create(notification).Error
 }
 
+// CreateNotifications menyimpan banyak notifikasi personal sekaligus
+func (r *NotificationRepository) CreateNotifications(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(notifications, 500).Error
+}
+
 func (r *NotificationRepository) GetUserNotifications(username string) ([]models.UserNotification, error) {
 	var userNotifs []models.UserNotification
 	err := r.db.Preload("Notification").
@@ -31,7 +39,7 @@ func (r *NotificationRepository) GetUserNotifications(username string) ([]models
 func (r *NotificationRepository) MarkAsRead(username string, notificationID uint) error {
 	return r.db.Model(&models.UserNotification{}).
 		Where("username = ? AND notification_id = ?", username, notificationID).
-		Update("is_read", true).Error
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()}).Error
 }
 
 func (r *NotificationRepository) ExistsUserNotification(username string, notificationID uint) (bool, error) {
@@ -71,13 +79,20 @@ func (r *NotificationRepository) GetUserNotificationsMap(username string) (map[u
 	return result, nil
 }
 
-func (r *NotificationRepository) CreateUserNotificationIfNotExists(username, notificationID string) error {
-    // cek apakah sudah ada
+func (r *NotificationRepository) CreateUserNotificationIfNotExists(username string, notificationID uint) error {
+    return r.UpsertUserState(username, notificationID, map[string]interface{}{
+        "is_read": true,
+        "read_at": time.Now(),
+    })
+}
+
+// UpsertUserState mengubah status notifikasi milik user (read/archived/deleted),
+// membuat record UserNotification bila belum ada
+func (r *NotificationRepository) UpsertUserState(username string, notificationID uint, updates map[string]interface{}) error {
     var existing models.UserNotification
     err := r.db.Where("username = ? AND notification_id = ?", username, notificationID).First(&existing).Error
     if err == nil {
-        // sudah ada, tidak perlu buat baru
-        return nil
+        return r.db.Model(&existing).Updates(updates).Error
     }
     if err != gorm.ErrRecordNotFound {
         return err
@@ -86,15 +101,200 @@ func (r *NotificationRepository) CreateUserNotificationIfNotExists(username, not
     // buat record baru karena belum ada
     userNotif := models.UserNotification{
         Username:       username,
-        NotificationID: parseUint(notificationID),
-        IsRead:         true,
+        NotificationID: notificationID,
+    }
+    if err := r.db.Create(&userNotif).Error; err != nil {
+        return err
     }
+    return r.db.Model(&userNotif).Updates(updates).Error
+}
+
+// NotificationFilter menampung filter untuk inbox notifikasi user
+type NotificationFilter struct {
+	Type       string
+	EntityType string
+	EntityID   uint
+	Status     string // unread, read, archived; kosong = semua yang belum diarsipkan
+	Cursor     uint   // hanya ambil notifikasi dengan id < cursor
+	Limit      int
+	Offset     int
+}
+
+// NotificationInboxRow adalah satu baris inbox: notifikasi + status baca milik user
+type NotificationInboxRow struct {
+	ID         uint
+	Title      string
+	Message    string
+	Type       string
+	EntityType string
+	EntityID   *uint
+	CreatedAt  time.Time
+	IsRead     bool
+	IsArchived bool
+	ReadAt     *time.Time
+}
 
-    return r.db.Create(&userNotif).Error
+// inboxQuery membangun query notifikasi yang terlihat oleh user
+// (broadcast atau ditujukan ke user tersebut) dan belum dihapus olehnya
+func (r *NotificationRepository) inboxQuery(username string, filter NotificationFilter) *gorm.DB {
+	query := r.db.Table("notifications AS n").
+		Joins("LEFT JOIN user_notifications un ON un.notification_id = n.id AND un.username = ?", username).
+		Where("(n.username = '' OR n.username IS NULL OR n.username = ?)", username).
+		Where("COALESCE(un.is_deleted, false) = false")
+
+	switch filter.Status {
+	case "unread":
+		query = query.Where("COALESCE(un.is_read, false) = false AND COALESCE(un.is_archived, false) = false")
+	case "read":
+		query = query.Where("un.is_read = true AND COALESCE(un.is_archived, false) = false")
+	case "archived":
+		query = query.Where("un.is_archived = true")
+	default:
+		query = query.Where("COALESCE(un.is_archived, false) = false")
+	}
+
+	if filter.Type != "" {
+		query = query.Where("n.type = ?", filter.Type)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("n.entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("n.entity_id = ?", filter.EntityID)
+	}
+
+	return query
 }
 
-// helper untuk konversi string ke uint
-func parseUint(s string) uint {
-    id, _ := strconv.ParseUint(s, 10, 64)
-    return uint(id)
-}
\ No newline at end of file
+// GetInbox mengambil notifikasi user dengan filter, pagination offset atau cursor
+func (r *NotificationRepository) GetInbox(username string, filter NotificationFilter) ([]NotificationInboxRow, int64, error) {
+	var rows []NotificationInboxRow
+	var total int64
+
+	if err := r.inboxQuery(username, filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := r.inboxQuery(username, filter).
+		Select("n.id, n.title, n.message, n.type, n.entity_type, n.entity_id, n.created_at, " +
+			"COALESCE(un.is_read, false) AS is_read, COALESCE(un.is_archived, false) AS is_archived, un.read_at").
+		Order("n.id DESC")
+
+	if filter.Cursor > 0 {
+		query = query.Where("n.id < ?", filter.Cursor)
+	} else if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// CountUnread menghitung notifikasi yang belum dibaca user
+func (r *NotificationRepository) CountUnread(username string) (int64, error) {
+	var count int64
+	err := r.inboxQuery(username, NotificationFilter{Status: "unread"}).Count(&count).Error
+	return count, err
+}
+
+// FindVisibleByID mencari notifikasi yang boleh dilihat user
+func (r *NotificationRepository) FindVisibleByID(username string, notificationID uint) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.db.Where("id = ? AND (username = '' OR username IS NULL OR username = ?)", notificationID, username).
+		First(&notification).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkAllAsRead menandai semua notifikasi user sebagai dibaca
+func (r *NotificationRepository) MarkAllAsRead(username string) (int64, error) {
+	now := time.Now()
+	var affected int64
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// record yang sudah ada tinggal di-update
+		res := tx.Model(&models.UserNotification{}).
+			Where("username = ? AND is_read = false AND is_deleted = false", username).
+			Updates(map[string]interface{}{"is_read": true, "read_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		affected += res.RowsAffected
+
+		// notifikasi yang belum punya record untuk user ini dibuatkan record baru
+		res = tx.Exec(`
+			INSERT INTO user_notifications (username, notification_id, is_read, read_at, is_archived, is_deleted, updated_at)
+			SELECT ?, n.id, true, ?, false, false, ?
+			FROM notifications n
+			WHERE (n.username = '' OR n.username IS NULL OR n.username = ?)
+			AND NOT EXISTS (
+				SELECT 1 FROM user_notifications un
+				WHERE un.notification_id = n.id AND un.username = ?
+			)`, username, now, now, username, username)
+		if res.Error != nil {
+			return res.Error
+		}
+		affected += res.RowsAffected
+		return nil
+	})
+
+	return affected, err
+}
+
+// prunableState memilih status user yang dibaca atau diarsipkan sebelum batas retensi.
+// Baris lama yang dibaca sebelum read_at dicatat memakai updated_at.
+const prunableState = "((un.is_read = true AND COALESCE(un.read_at, un.updated_at) < ?) OR " +
+	"(un.is_archived = true AND COALESCE(un.archived_at, un.updated_at) < ?))"
+
+// PruneReadNotifications membersihkan notifikasi yang sudah dibaca atau diarsipkan sebelum before.
+// Umur dihitung dari read_at/archived_at, bukan created_at, sehingga notifikasi lama yang baru
+// dibaca tetap tersimpan selama masa retensi. Notifikasi personal dihapus permanen, sedangkan
+// notifikasi broadcast hanya disembunyikan dari inbox user tersebut.
+func (r *NotificationRepository) PruneReadNotifications(before time.Time) (int64, error) {
+	var pruned int64
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var personalIDs []uint
+		if err := tx.Table("notifications AS n").
+			Joins("JOIN user_notifications un ON un.notification_id = n.id AND un.username = n.username").
+			Where("n.username <> ''").
+			Where(prunableState, before, before).
+			Pluck("n.id", &personalIDs).Error; err != nil {
+			return err
+		}
+
+		if len(personalIDs) > 0 {
+			if err := tx.Where("notification_id IN ?", personalIDs).
+				Delete(&models.UserNotification{}).Error; err != nil {
+				return err
+			}
+			res := tx.Where("id IN ?", personalIDs).Delete(&models.Notification{})
+			if res.Error != nil {
+				return res.Error
+			}
+			pruned += res.RowsAffected
+		}
+
+		res := tx.Table("user_notifications AS un").
+			Where("un.is_deleted = false").
+			Where(prunableState, before, before).
+			Where("un.notification_id IN (?)", tx.Table("notifications AS n").
+				Select("n.id").
+				Where("n.username = '' OR n.username IS NULL")).
+			Update("is_deleted", true)
+		if res.Error != nil {
+			return res.Error
+		}
+		pruned += res.RowsAffected
+		return nil
+	})
+
+	return pruned, err
+}
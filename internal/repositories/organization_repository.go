@@ -12,8 +12,8 @@ type OrganizationRepository struct {
 }
 
 // NewOrganizationRepository creates a new Organization repository
-func NewOrganizationRepository() *AssociationRepository {
-	return &AssociationRepository{
+func NewOrganizationRepository() *OrganizationRepository {
+	return &OrganizationRepository{
 		db: database.GetDB(),
 	}
 }
@@ -26,4 +26,19 @@ func (r *OrganizationRepository) FindOrganizationByID(id uint) (*models.Organiza
 		return nil, err
 	}
 	return &association, nil
-}
\ No newline at end of file
+}
+
+// UpdateFields updates the given columns of an organization (zero values included)
+func (r *OrganizationRepository) UpdateFields(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.Organization{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// FindOrganizationByShortName finds an organization by its short name (case-insensitive)
+func (r *OrganizationRepository) FindOrganizationByShortName(shortName string) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.db.Where("LOWER(short_name) = LOWER(?)", shortName).First(&organization).Error
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"bem_be/internal/database"
+	"bem_be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RevisionRepository adalah repository untuk riwayat revisi berita dan pengumuman
+type RevisionRepository struct {
+	db *gorm.DB
+}
+
+// NewRevisionRepository membuat instance revision repository baru
+func NewRevisionRepository() *RevisionRepository {
+	return &RevisionRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create menyimpan revisi dengan nomor versi berikutnya untuk konten tersebut.
+// Nomor versi dijaga unik oleh index (entity_type, entity_id, version).
+func (r *RevisionRepository) Create(revision *models.ContentRevision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var max *int
+		err := tx.Model(&models.ContentRevision{}).
+			Where("entity_type = ? AND entity_id = ?", revision.EntityType, revision.EntityID).
+			Select("MAX(version)").Scan(&max).Error
+		if err != nil {
+			return err
+		}
+		revision.Version = 1
+		if max != nil {
+			revision.Version = *max + 1
+		}
+		return tx.Create(revision).Error
+	})
+}
+
+// Count menghitung jumlah revisi sebuah konten
+func (r *RevisionRepository) Count(entityType string, entityID uint) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.ContentRevision{}).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Count(&total).Error
+	return total, err
+}
+
+// FindByEntity mengambil revisi sebuah konten dari yang terbaru, dengan pagination.
+// Isi lengkap tidak ikut diambil; gunakan FindVersion untuk melihat satu revisi.
+func (r *RevisionRepository) FindByEntity(entityType string, entityID uint, limit, offset int) ([]models.ContentRevision, int64, error) {
+	var revisions []models.ContentRevision
+	var total int64
+
+	query := r.db.Model(&models.ContentRevision{}).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Omit("content").
+		Order("version DESC").
+		Limit(limit).Offset(offset).
+		Find(&revisions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return revisions, total, nil
+}
+
+// FindVersion mengambil satu revisi berdasarkan nomor versinya
+func (r *RevisionRepository) FindVersion(entityType string, entityID uint, version int) (*models.ContentRevision, error) {
+	var revision models.ContentRevision
+	err := r.db.Where("entity_type = ? AND entity_id = ? AND version = ?", entityType, entityID, version).
+		First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
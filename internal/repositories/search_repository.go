@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"bem_be/internal/database"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis hasil pencarian
+const (
+	SearchTypeNews         = "news"
+	SearchTypeAnnouncement = "announcement"
+	SearchTypeEvent        = "event"
+	SearchTypeOrganization = "organization"
+	SearchTypeAspiration   = "aspiration"
+)
+
+// SearchTypes adalah semua jenis konten yang bisa dicari
+var SearchTypes = []string{SearchTypeNews, SearchTypeAnnouncement, SearchTypeEvent, SearchTypeOrganization, SearchTypeAspiration}
+
+// SearchResult adalah satu baris hasil pencarian gabungan
+type SearchResult struct {
+	Type    string    `json:"type"`
+	ID      uint      `json:"id"`
+	Title   string    `json:"title"`
+	Snippet string    `json:"snippet"`
+	Rank    float64   `json:"rank"`
+	Date    time.Time `json:"date"`
+	Total   int64     `json:"-"`
+}
+
+// SearchRepository menjalankan full-text search di atas kolom search_vector
+type SearchRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository() *SearchRepository {
+	return &SearchRepository{
+		db: database.GetDB(),
+	}
+}
+
+// searchSources adalah query per jenis konten. Setiap query memakai kolom generated
+// search_vector dan hanya mengembalikan konten yang boleh dilihat publik.
+var searchSources = map[string]string{
+	SearchTypeNews: `SELECT 'news' AS type, id, title, {content_snippet} AS snippet, ts_rank_cd(search_vector, sq.tsq) AS rank,
+		COALESCE(published_at, created_at) AS date
+		FROM news, search_query sq WHERE deleted_at IS NULL AND status = 'published' AND search_vector @@ sq.tsq`,
+	SearchTypeAnnouncement: `SELECT 'announcement' AS type, id, title, {content_snippet} AS snippet, ts_rank_cd(search_vector, sq.tsq) AS rank,
+		COALESCE(start_date, created_at) AS date
+		FROM announcements, search_query sq WHERE deleted_at IS NULL AND search_vector @@ sq.tsq
+		AND (start_date IS NULL OR start_date <= NOW()) AND (end_date IS NULL OR end_date >= CURRENT_DATE)
+		AND COALESCE(target_faculties, '') = '' AND COALESCE(target_study_programs, '') = ''
+		AND COALESCE(target_years, '') = '' AND COALESCE(target_dormitories, '') = ''`,
+	SearchTypeEvent: `SELECT 'event' AS type, id, title, {description_snippet} AS snippet, ts_rank_cd(search_vector, sq.tsq) AS rank,
+		start_time AS date
+		FROM calenders, search_query sq WHERE deleted_at IS NULL AND search_vector @@ sq.tsq`,
+	SearchTypeOrganization: `SELECT 'organization' AS type, id, name AS title, {organization_snippet} AS snippet, ts_rank_cd(search_vector, sq.tsq) AS rank,
+		created_at AS date
+		FROM organizations, search_query sq WHERE deleted_at IS NULL AND search_vector @@ sq.tsq`,
+	// hanya aspirasi di papan publik; aspirasi anonim dan yang sudah digabung tidak ikut
+	SearchTypeAspiration: `SELECT 'aspiration' AS type, id, title, {aspiration_snippet} AS snippet, ts_rank_cd(search_vector, sq.tsq) AS rank,
+		created_at AS date
+		FROM aspirations, search_query sq WHERE deleted_at IS NULL AND public = true AND anonymous = false
+		AND merged_into_id IS NULL AND search_vector @@ sq.tsq`,
+}
+
+// Search mencari kata kunci di jenis konten yang diminta, diurutkan berdasarkan relevansi.
+// Snippet memakai ts_headline dengan kata yang cocok dibungkus <mark>...</mark>.
+func (r *SearchRepository) Search(keyword string, types []string, limit, offset int) ([]SearchResult, int64, error) {
+	config := database.SearchConfig
+	headline := func(column string) string {
+		// Tag HTML dibuang dulu agar snippet tidak memotong markup konten berita
+		return fmt.Sprintf(
+			"ts_headline('%s', regexp_replace(coalesce(%s, ''), '<[^>]*>', ' ', 'g'), sq.tsq, 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2')",
+			config, column,
+		)
+	}
+
+	snippets := strings.NewReplacer(
+		"{content_snippet}", headline("content"),
+		"{description_snippet}", headline("description"),
+		"{organization_snippet}", headline("concat_ws(' ', name, short_name, meta_description)"),
+		"{aspiration_snippet}", headline("concat_ws(' ', description, content)"),
+	)
+
+	var parts []string
+	for _, t := range types {
+		source, ok := searchSources[t]
+		if !ok {
+			continue
+		}
+		parts = append(parts, snippets.Replace(source))
+	}
+	if len(parts) == 0 {
+		return []SearchResult{}, 0, nil
+	}
+
+	sql := fmt.Sprintf(`WITH search_query AS (SELECT websearch_to_tsquery('%s', ?) AS tsq),
+		results AS (%s)
+		SELECT *, COUNT(*) OVER() AS total FROM results
+		ORDER BY rank DESC, date DESC
+		LIMIT ? OFFSET ?`, config, strings.Join(parts, " UNION ALL "))
+
+	var results []SearchResult
+	if err := r.db.Raw(sql, keyword, limit, offset).Scan(&results).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if len(results) > 0 {
+		total = results[0].Total
+	}
+	return results, total, nil
+}
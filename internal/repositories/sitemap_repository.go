@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"bem_be/internal/database"
+	"bem_be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SitemapEntry adalah satu halaman publik beserta waktu perubahan terakhirnya
+type SitemapEntry struct {
+	ID         uint
+	Slug       string
+	CategoryID int // hanya organisasi
+	UpdatedAt  time.Time
+}
+
+// SitemapRepository membaca daftar konten publik untuk sitemap.xml
+type SitemapRepository struct {
+	db *gorm.DB
+}
+
+func NewSitemapRepository() *SitemapRepository {
+	return &SitemapRepository{
+		db: database.GetDB(),
+	}
+}
+
+// PublishedNews mengambil slug semua berita terbit
+func (r *SitemapRepository) PublishedNews() ([]SitemapEntry, error) {
+	var entries []SitemapEntry
+	err := r.db.Model(&models.News{}).
+		Select("id, slug, updated_at").
+		Where("status = ?", models.NewsStatusPublished).
+		Order("COALESCE(published_at, created_at) DESC").
+		Scan(&entries).Error
+	return entries, err
+}
+
+// PublicAnnouncements mengambil pengumuman tanpa target audiens yang sudah mulai tayang
+// (termasuk yang sudah diarsipkan)
+func (r *SitemapRepository) PublicAnnouncements(now time.Time) ([]SitemapEntry, error) {
+	var entries []SitemapEntry
+	err := r.db.Model(&models.Announcement{}).
+		Select("id, slug, updated_at").
+		Where(noAudienceCondition).
+		Where("start_date IS NULL OR start_date <= ?", now).
+		Order("created_at DESC").
+		Scan(&entries).Error
+	return entries, err
+}
+
+// Organizations mengambil short name semua organisasi sebagai slug halaman profilnya
+func (r *SitemapRepository) Organizations() ([]SitemapEntry, error) {
+	var entries []SitemapEntry
+	err := r.db.Model(&models.Organization{}).
+		Select("id, short_name AS slug, category_id, updated_at").
+		Where("COALESCE(short_name, '') <> ''").
+		Order("category_id ASC, short_name ASC").
+		Scan(&entries).Error
+	return entries, err
+}
+
+// LastChange mengembalikan waktu perubahan terakhir seluruh isi sitemap, termasuk konten yang
+// dihapus, ditarik dari terbit, atau mulai tayang, untuk Last-Modified sitemap.xml
+func (r *SitemapRepository) LastChange(now time.Time) (time.Time, error) {
+	return lastChange(r.db,
+		"SELECT GREATEST(("+newsLastChangeSQL+"), ("+announcementLastChangeSQL+"))",
+		map[string]interface{}{"now": now},
+	)
+}
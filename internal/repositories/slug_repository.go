@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"bem_be/internal/database"
+	"bem_be/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// slugModels memetakan jenis konten ke model yang memiliki kolom slug
+var slugModels = map[string]interface{}{
+	models.SlugEntityNews:         &models.News{},
+	models.SlugEntityAnnouncement: &models.Announcement{},
+}
+
+// SlugRepository adalah repository untuk slug konten dan peta pengalihan slug lama
+type SlugRepository struct {
+	db *gorm.DB
+}
+
+// NewSlugRepository membuat instance slug repository baru
+func NewSlugRepository() *SlugRepository {
+	return &SlugRepository{
+		db: database.GetDB(),
+	}
+}
+
+func slugModel(entityType string) (interface{}, error) {
+	model, ok := slugModels[entityType]
+	if !ok {
+		return nil, fmt.Errorf("jenis konten %q tidak memiliki slug", entityType)
+	}
+	return model, nil
+}
+
+// SlugTaken memeriksa apakah slug sudah dipakai konten lain (termasuk yang di-soft-delete)
+// atau masih menjadi slug lama konten lain di peta pengalihan
+func (r *SlugRepository) SlugTaken(entityType, slug string, excludeID uint) (bool, error) {
+	model, err := slugModel(entityType)
+	if err != nil {
+		return false, err
+	}
+
+	var count int64
+	if err := r.db.Unscoped().Model(model).Where("slug = ? AND id <> ?", slug, excludeID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	err = r.db.Model(&models.SlugRedirect{}).
+		Where("entity_type = ? AND slug = ? AND entity_id <> ?", entityType, slug, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindIDBySlug mencari ID konten aktif berdasarkan slug saat ini
+func (r *SlugRepository) FindIDBySlug(entityType, slug string) (uint, error) {
+	model, err := slugModel(entityType)
+	if err != nil {
+		return 0, err
+	}
+	var ids []uint
+	if err := r.db.Model(model).Where("slug = ?", slug).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return ids[0], nil
+}
+
+// FindRedirect mencari pengalihan untuk slug lama
+func (r *SlugRepository) FindRedirect(entityType, slug string) (*models.SlugRedirect, error) {
+	var redirect models.SlugRedirect
+	err := r.db.Where("entity_type = ? AND slug = ?", entityType, slug).First(&redirect).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redirect, nil
+}
+
+// SaveRedirect mengarahkan slug lama ke konten; slug yang sudah ada dipindahkan
+func (r *SlugRepository) SaveRedirect(entityType, slug string, entityID uint) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity_type"}, {Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"entity_id"}),
+	}).Create(&models.SlugRedirect{EntityType: entityType, Slug: slug, EntityID: entityID}).Error
+}
+
+// DeleteRedirect menghapus pengalihan slug (slug dipakai lagi sebagai slug aktif)
+func (r *SlugRepository) DeleteRedirect(entityType, slug string) error {
+	return r.db.Where("entity_type = ? AND slug = ?", entityType, slug).Delete(&models.SlugRedirect{}).Error
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"bem_be/internal/database"
 	"bem_be/internal/models"
@@ -223,4 +224,60 @@ func (r *StudentRepository) FindByUsername(username string) (*models.Student, er
 		return nil, result.Error
 	}
 	return &student, nil
-}
\ No newline at end of file
+}
+// FindUsernamesByAudience returns usernames of students matching every non-empty criterion
+func (r *StudentRepository) FindUsernamesByAudience(faculties, studyPrograms []string, years []int, dormitories []string) ([]string, error) {
+	var usernames []string
+	query := r.db.Model(&models.Student{}).Where("user_name <> ''")
+	if len(faculties) > 0 {
+		query = query.Where("LOWER(faculty) IN ?", lowerAll(faculties))
+	}
+	if len(studyPrograms) > 0 {
+		query = query.Where("LOWER(study_program) IN ?", lowerAll(studyPrograms))
+	}
+	if len(years) > 0 {
+		query = query.Where("year_enrolled IN ?", years)
+	}
+	if len(dormitories) > 0 {
+		query = query.Where("LOWER(dormitory) IN ?", lowerAll(dormitories))
+	}
+	err := query.Distinct().Pluck("user_name", &usernames).Error
+	return usernames, err
+}
+
+// FindUsernamesByOrganization returns usernames of an organization's core officers
+// (ketua, wakil ketua, sekretaris, bendahara); ordinary members are excluded
+func (r *StudentRepository) FindUsernamesByOrganization(organizationID int) ([]string, error) {
+	patterns := models.OfficerPositionPatterns()
+	conditions := make([]string, len(patterns))
+	args := make([]interface{}, len(patterns))
+	for i, pattern := range patterns {
+		conditions[i] = "LOWER(position) LIKE ?"
+		args[i] = pattern
+	}
+
+	var usernames []string
+	err := r.db.Model(&models.Student{}).
+		Where("organization_id = ? AND user_name <> ''", organizationID).
+		Where("("+strings.Join(conditions, " OR ")+")", args...).
+		Distinct().Pluck("user_name", &usernames).Error
+	return usernames, err
+}
+
+// FindByUsernames returns students with any of the given usernames
+func (r *StudentRepository) FindByUsernames(usernames []string) ([]models.Student, error) {
+	var students []models.Student
+	if len(usernames) == 0 {
+		return students, nil
+	}
+	err := r.db.Where("user_name IN ?", usernames).Order("id ASC").Find(&students).Error
+	return students, err
+}
+
+func lowerAll(values []string) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = strings.ToLower(v)
+	}
+	return result
+}
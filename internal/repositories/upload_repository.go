@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"bem_be/internal/database"
+	"bem_be/internal/models"
+	"bem_be/internal/storage"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// FileReference adalah satu nilai kolom database yang menunjuk ke file unggahan
+type FileReference struct {
+	Table   string   `json:"table"`
+	Column  string   `json:"column"`
+	ID      uint     `json:"id"`
+	Value   string   `json:"value"`
+	Folders []string `json:"folders"` // folder storage tempat file boleh berada
+}
+
+// fileColumns adalah setiap kolom yang menyimpan nama file unggahan beserta foldernya.
+// Logo organisasi dan file di riwayat revisi ditangani terpisah karena foldernya
+// bergantung pada kategori / jenis konten.
+var fileColumns = []struct {
+	model   interface{}
+	field   string
+	folders []string
+}{
+	{&models.News{}, "ImageURL", []string{storage.FolderNews}},
+	{&models.News{}, "OGImage", []string{storage.FolderNews}},
+	{&models.Announcement{}, "FileURL", []string{storage.FolderAnnouncements}},
+	{&models.Announcement{}, "OGImage", []string{storage.FolderAnnouncements}},
+	{&models.Galery{}, "ImageURL", []string{storage.FolderGalery}},
+	{&models.Item{}, "Image", []string{storage.FolderItems}},
+	{&models.Student{}, "Image", []string{storage.FolderUsers}},
+	{&models.Request{}, "ImageURLKTM", []string{storage.FolderRequests}},
+	// Foto barang bisa dari form peminjaman atau dari unggahan saat barang diambil
+	{&models.Request{}, "ImageURLBRG", []string{storage.FolderRequests, storage.FolderRequestItems}},
+}
+
+// UploadRepository membaca referensi file dari seluruh tabel untuk rekonsiliasi storage
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadRepository() *UploadRepository {
+	return &UploadRepository{
+		db: database.GetDB(),
+	}
+}
+
+// FindFileReferences mengembalikan semua nilai kolom file yang tidak kosong. Baris yang
+// di-soft-delete ikut dihitung karena masih bisa dipulihkan beserta filenya.
+func (r *UploadRepository) FindFileReferences() ([]FileReference, error) {
+	var refs []FileReference
+
+	for _, c := range fileColumns {
+		stmt := &gorm.Statement{DB: r.db}
+		if err := stmt.Parse(c.model); err != nil {
+			return nil, err
+		}
+		field := stmt.Schema.LookUpField(c.field)
+		if field == nil {
+			return nil, fmt.Errorf("kolom %s tidak ditemukan di %s", c.field, stmt.Schema.Table)
+		}
+
+		var rows []struct {
+			ID    uint
+			Value string
+		}
+		err := r.db.Unscoped().Model(c.model).
+			Select(fmt.Sprintf("id, %s AS value", field.DBName)).
+			Where(fmt.Sprintf("COALESCE(%s, '') <> ''", field.DBName)).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			refs = append(refs, FileReference{
+				Table:   stmt.Schema.Table,
+				Column:  field.DBName,
+				ID:      row.ID,
+				Value:   row.Value,
+				Folders: c.folders,
+			})
+		}
+	}
+
+	var organizations []struct {
+		ID         uint
+		Image      string
+		OGImage    string
+		CategoryID int
+	}
+	err := r.db.Unscoped().Model(&models.Organization{}).
+		Select("id, image, og_image, category_id").
+		Where("COALESCE(image, '') <> '' OR COALESCE(og_image, '') <> ''").
+		Scan(&organizations).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range organizations {
+		folders := []string{storage.FolderClubs, storage.FolderDepartments, storage.FolderAssociations}
+		if folder, ok := models.OrganizationImageFolder(org.CategoryID); ok {
+			folders = []string{folder}
+		}
+		for column, value := range map[string]string{"image": org.Image, "og_image": org.OGImage} {
+			if value == "" {
+				continue
+			}
+			refs = append(refs, FileReference{
+				Table:   "organizations",
+				Column:  column,
+				ID:      org.ID,
+				Value:   value,
+				Folders: folders,
+			})
+		}
+	}
+
+	// File lama berita/pengumuman tetap dipertahankan selama masih dirujuk revisi
+	var revisions []struct {
+		ID         uint
+		FileURL    string
+		EntityType string
+	}
+	err = r.db.Model(&models.ContentRevision{}).
+		Select("id, file_url, entity_type").
+		Where("COALESCE(file_url, '') <> ''").
+		Scan(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, rev := range revisions {
+		refs = append(refs, FileReference{
+			Table:   "content_revisions",
+			Column:  "file_url",
+			ID:      rev.ID,
+			Value:   rev.FileURL,
+			Folders: []string{models.RevisionFileFolder(rev.EntityType)},
+		})
+	}
+
+	return refs, nil
+}
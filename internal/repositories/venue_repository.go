@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"bem_be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VenueRepository struct {
+	db *gorm.DB
+}
+
+func NewVenueRepository(db *gorm.DB) *VenueRepository {
+	return &VenueRepository{db: db}
+}
+
+func (r *VenueRepository) Create(venue *models.Venue) error {
+	return r.db.Create(venue).Error
+}
+
+func (r *VenueRepository) Update(venue *models.Venue) error {
+	return r.db.Save(venue).Error
+}
+
+func (r *VenueRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Venue{}, id).Error
+}
+
+// FindByID mengambil venue; nil jika tidak ada
+func (r *VenueRepository) FindByID(id uint) (*models.Venue, error) {
+	var venue models.Venue
+	if err := r.db.First(&venue, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &venue, nil
+}
+
+// FindByName mengambil venue berdasarkan nama (tanpa membedakan huruf besar); nil jika tidak ada
+func (r *VenueRepository) FindByName(name string) (*models.Venue, error) {
+	var venue models.Venue
+	if err := r.db.Where("LOWER(name) = LOWER(?)", name).First(&venue).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &venue, nil
+}
+
+// FindAll mengambil venue urut nama; activeOnly menyembunyikan venue nonaktif
+func (r *VenueRepository) FindAll(activeOnly bool) ([]models.Venue, error) {
+	var venues []models.Venue
+	query := r.db.Order("name ASC")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Find(&venues).Error; err != nil {
+		return nil, err
+	}
+	return venues, nil
+}
+
+// Lock mengambil venue dengan SELECT ... FOR UPDATE agar booking serentak di venue yang
+// sama diperiksa bergantian; hanya bermakna di dalam transaksi
+func (r *VenueRepository) Lock(id uint) (*models.Venue, error) {
+	var venue models.Venue
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&venue, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &venue, nil
+}
+
+// FindReservation mengambil booking milik event; nil jika event tidak memesan venue
+func (r *VenueRepository) FindReservation(eventID uint) (*models.VenueReservation, error) {
+	var reservation models.VenueReservation
+	if err := r.db.Where("event_id = ?", eventID).First(&reservation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// FindReservationByID mengambil booking beserta venue dan event-nya; nil jika tidak ada
+func (r *VenueRepository) FindReservationByID(id uint) (*models.VenueReservation, error) {
+	var reservation models.VenueReservation
+	if err := r.db.Preload("Venue").Preload("Event").Preload("Event.Organization").First(&reservation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (r *VenueRepository) SaveReservation(reservation *models.VenueReservation) error {
+	return r.db.Omit(clause.Associations).Save(reservation).Error
+}
+
+func (r *VenueRepository) DeleteReservation(eventID uint) error {
+	return r.db.Where("event_id = ?", eventID).Delete(&models.VenueReservation{}).Error
+}
+
+// GetReservations mengambil booking event yang masih ada, terlama dulu. Status kosong
+// berarti semua status; approver diisi untuk membatasi ke venue yang disetujuinya.
+func (r *VenueRepository) GetReservations(status, approver string) ([]models.VenueReservation, error) {
+	var reservations []models.VenueReservation
+	query := r.db.Preload("Venue").Preload("Event").Preload("Event.Organization").
+		Joins("JOIN calenders ON calenders.id = venue_reservations.event_id AND calenders.deleted_at IS NULL").
+		Joins("JOIN venues ON venues.id = venue_reservations.venue_id AND venues.deleted_at IS NULL")
+	if status != "" {
+		query = query.Where("venue_reservations.status = ?", status)
+	}
+	if approver != "" {
+		query = query.Where("venues.approver_username = ?", approver)
+	}
+	if err := query.Order("venue_reservations.created_at ASC, venue_reservations.id ASC").Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// GetActiveBookings mengambil booking pending/approved di venue yang event-nya (atau
+// serinya) beririsan dengan [from, to], beserta event dan organisasinya
+func (r *VenueRepository) GetActiveBookings(venueID uint, from, to time.Time) ([]models.VenueReservation, error) {
+	var reservations []models.VenueReservation
+	if err := r.db.Preload("Event").Preload("Event.Organization").
+		Joins("JOIN calenders ON calenders.id = venue_reservations.event_id AND calenders.deleted_at IS NULL").
+		Where("venue_reservations.venue_id = ? AND venue_reservations.status IN ?", venueID,
+			[]string{models.VenueReservationPending, models.VenueReservationApproved}).
+		Where("calenders.start_time <= ?", to).
+		Where(eventActiveSince, from, from).
+		Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
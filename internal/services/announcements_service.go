@@ -3,26 +3,40 @@ package services
 import (
 	"gorm.io/gorm"
 	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"bem_be/internal/models"
 	"bem_be/internal/repositories"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 )
 
 // announcementService is a service for announcement operations
 type AnnouncementService struct {
-	repository *repositories.AnnouncementRepository
+	repository          *repositories.AnnouncementRepository
+	studentRepo         *repositories.StudentRepository
+	revisions           *RevisionService
+	slugs               *SlugService
+	notificationService *NotificationService
 	db *gorm.DB
 }
 
 // NewannouncementService creates a new announcement service
-func NewAnnouncementService(db *gorm.DB) *AnnouncementService {
+func NewAnnouncementService(db *gorm.DB, notificationService *NotificationService) *AnnouncementService {
     return &AnnouncementService{
-        repository: repositories.NewAnnouncementRepository(),
+        repository:          repositories.NewAnnouncementRepository(),
+        studentRepo:         repositories.NewStudentRepository(),
+        revisions:           NewRevisionService(),
+        slugs:               NewSlugService(),
+        notificationService: notificationService,
     }
 }
 
-// Createannouncement creates a new announcement
-func (s *AnnouncementService) Createannouncement(announcement *models.Announcement) error {
+// Createannouncement creates a new announcement and records it as the first revision
+func (s *AnnouncementService) Createannouncement(announcement *models.Announcement, editor string) error {
 	// Check if code exists (including soft-deleted)
 	// exists, err := s.repository.CheckNameExists(announcement.Name, 0)
 	// if err != nil {
@@ -55,12 +69,37 @@ func (s *AnnouncementService) Createannouncement(announcement *models.Announceme
 	// 	return errors.New("kode gedung sudah digunakan")
 	// }
 
+	if err := normalizeAudience(announcement); err != nil {
+		return err
+	}
+	if err := s.prepareSEO(announcement, ""); err != nil {
+		return err
+	}
+
+	// Notifikasi dikirim saat pengumuman mulai aktif (langsung atau oleh scheduler)
+	announcement.NotificationPending = true
+
 	// Create announcement
-	return s.repository.Create(announcement)
+	if err := s.repository.Create(announcement); err != nil {
+		return err
+	}
+	s.revisions.RecordCreated(AnnouncementSnapshot(announcement), editor)
+
+	if announcement.IsActiveAt(time.Now()) {
+		s.notifyActivated(announcement)
+	}
+	return nil
 }
 
-// Updateannouncement updates an existing announcement
-func (s *AnnouncementService) Updateannouncement(announcement *models.Announcement) error {
+// Updateannouncement updates an existing announcement and records the revision
+func (s *AnnouncementService) Updateannouncement(announcement *models.Announcement, editor string) (err error) {
+	// Lampiran baru (jika ada) dihapus jika update gagal. Lampiran lama tetap disimpan
+	// karena masih dirujuk riwayat revisi (rollback).
+	var files utils.FileChanges
+	files.Added(storage.FolderAnnouncements, announcement.FileURL)
+	files.Added(storage.FolderAnnouncements, announcement.OGImage)
+	defer func() { files.Done(err) }()
+
 	// Check if announcement exists
 	existingAnnouncement, err := s.repository.FindByID(announcement.ID)
 	if err != nil {
@@ -69,9 +108,106 @@ func (s *AnnouncementService) Updateannouncement(announcement *models.Announceme
 	if existingAnnouncement == nil {
 		return errors.New("himpunan tidak ditemukan")
 	}
+	// Gambar OpenGraph tidak masuk riwayat revisi, jadi yang lama langsung dihapus
+	if announcement.OGImage != "" {
+		files.Replaced(storage.FolderAnnouncements, existingAnnouncement.OGImage)
+	}
+	// Slug hanya diganti jika dikirim editor (field kosong tidak ikut di-update)
+	if announcement.Slug != "" {
+		if err := s.prepareSEO(announcement, existingAnnouncement.Slug); err != nil {
+			return err
+		}
+	}
 
 	// Update announcement
-	return s.repository.Update(announcement)
+	if err := s.repository.Update(announcement); err != nil {
+		return err
+	}
+	if announcement.Slug != "" {
+		s.slugs.Renamed(models.SlugEntityAnnouncement, announcement.ID, existingAnnouncement.Slug, announcement.Slug)
+	}
+	if updated, err := s.repository.FindByID(announcement.ID); err == nil {
+		s.revisions.RecordUpdate(AnnouncementSnapshot(existingAnnouncement), AnnouncementSnapshot(updated), editor, nil)
+	}
+	return nil
+}
+
+// GetAnnouncementRevisions gets the revision history of an announcement, newest first
+func (s *AnnouncementService) GetAnnouncementRevisions(id uint, page, perPage int) ([]models.ContentRevision, int64, error) {
+	if _, err := s.GetAnnouncementByID(id); err != nil {
+		return nil, 0, err
+	}
+	return s.revisions.GetRevisions(models.RevisionEntityAnnouncement, id, page, perPage)
+}
+
+// GetAnnouncementRevision gets the announcement content at a given revision
+func (s *AnnouncementService) GetAnnouncementRevision(id uint, version int) (*models.ContentRevision, error) {
+	return s.revisions.GetRevision(models.RevisionEntityAnnouncement, id, version)
+}
+
+// RollbackAnnouncement restores title, content, attachment and display window from a
+// revision. The rollback itself is recorded as a new revision; pinning and audience
+// settings are not part of the history and stay as they are.
+func (s *AnnouncementService) RollbackAnnouncement(id uint, version int, editor string) (*models.Announcement, error) {
+	existing, err := s.GetAnnouncementByID(id)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.GetAnnouncementRevision(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// UpdateFields agar nilai kosong (lampiran/tanggal dihapus) ikut dikembalikan
+	err = s.repository.UpdateFields(id, map[string]interface{}{
+		"title":      revision.Title,
+		"content":    revision.Content,
+		"file_url":   revision.FileURL,
+		"start_date": revision.StartDate,
+		"end_date":   revision.EndDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.revisions.RecordUpdate(AnnouncementSnapshot(existing), AnnouncementSnapshot(updated), editor, &revision.Version)
+	return updated, nil
+}
+
+// prepareSEO assigns the slug and tidies the meta description before saving.
+// The slug only changes when the editor sends a new one, so a new title keeps the URL.
+func (s *AnnouncementService) prepareSEO(announcement *models.Announcement, currentSlug string) error {
+	if announcement.Slug == "" || announcement.Slug != currentSlug {
+		slug, err := s.slugs.AssignSlug(models.SlugEntityAnnouncement, announcement.ID, announcement.Title, announcement.Slug)
+		if err != nil {
+			return err
+		}
+		announcement.Slug = slug
+	}
+	meta, err := normalizeMetaDescription(announcement.MetaDescription)
+	if err != nil {
+		return err
+	}
+	announcement.MetaDescription = meta
+	return nil
+}
+
+// GetAnnouncementBySlug gets an announcement by its slug. redirected is true when the
+// slug is an old one; the current slug is in announcement.Slug.
+func (s *AnnouncementService) GetAnnouncementBySlug(slug string) (announcement *models.Announcement, redirected bool, err error) {
+	id, redirected, err := s.slugs.Resolve(models.SlugEntityAnnouncement, slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, errors.New("pengumuman tidak ditemukan")
+		}
+		return nil, false, err
+	}
+	announcement, err = s.GetAnnouncementByID(id)
+	return announcement, redirected, err
 }
 
 // GetannouncementByID gets a announcement by ID
@@ -85,11 +221,189 @@ func (s *AnnouncementService) GetAnnouncementByID(id uint) (*models.Announcement
 	}
 	return announcement, nil
 }
+// CanView reports whether a viewer may open an announcement outside the admin panel, using the
+// same rules as the listings: it must have started (expired ones stay readable as archive) and
+// target the viewer. A nil student is an anonymous visitor who only sees untargeted
+// announcements; officers of the announcing organization always see their own.
+func CanView(announcement *models.Announcement, student *models.Student, now time.Time) bool {
+	if student != nil && announcement.OrganizationID != nil && student.IsOfficerOf(int(*announcement.OrganizationID)) {
+		return true
+	}
+	if announcement.StartDate != nil && announcement.StartDate.After(now) {
+		return false
+	}
+	return announcement.Reaches(student)
+}
+
 // GetAllannouncements gets all announcements
 // GetAllAnnouncements gets all announcements with filters and pagination
-func (s *AnnouncementService) GetAllAnnouncements(page, limit int, title, content, category string) ([]models.Announcement, int64, error) {
+func (s *AnnouncementService) GetAllAnnouncements(page, limit int, filter repositories.AnnouncementFilter) ([]models.Announcement, int64, error) {
 	offset := (page - 1) * limit
-	return s.repository.GetAllAnnouncements(limit, offset, title, content, category)
+	return s.repository.GetAllAnnouncements(limit, offset, filter)
+}
+
+// GetStudentByUsername gets the student used for audience targeting
+func (s *AnnouncementService) GetStudentByUsername(username string) (*models.Student, error) {
+	student, err := s.studentRepo.FindByUsername(username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("mahasiswa tidak ditemukan")
+		}
+		return nil, err
+	}
+	return student, nil
+}
+
+// UpdateAnnouncementSettings updates pinning, priority, audience and SEO fields (zero values included)
+func (s *AnnouncementService) UpdateAnnouncementSettings(id uint, updates map[string]interface{}) (*models.Announcement, error) {
+	existing, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if meta, ok := updates["meta_description"].(string); ok {
+		normalized, err := normalizeMetaDescription(meta)
+		if err != nil {
+			return nil, err
+		}
+		updates["meta_description"] = normalized
+	}
+	if years, ok := updates["target_years"].(string); ok {
+		normalized, err := normalizeYears(years)
+		if err != nil {
+			return nil, err
+		}
+		updates["target_years"] = normalized
+	}
+	for _, column := range []string{"target_faculties", "target_study_programs", "target_dormitories"} {
+		if value, ok := updates[column].(string); ok {
+			updates[column] = strings.Join(splitList(value), ",")
+		}
+	}
+	if len(updates) > 0 {
+		if err := s.repository.UpdateFields(id, updates); err != nil {
+			return nil, err
+		}
+	}
+	if ogImage, ok := updates["og_image"].(string); ok && ogImage == "" {
+		utils.RemoveImage(storage.FolderAnnouncements, existing.OGImage)
+	}
+	return s.repository.FindByID(id)
+}
+
+// NotifyActivatedAnnouncements sends the notification of announcements whose
+// start date has been reached. Called periodically by the scheduler.
+func (s *AnnouncementService) NotifyActivatedAnnouncements() (int, error) {
+	now := time.Now()
+	announcements, err := s.repository.FindPendingActivation(now)
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for i := range announcements {
+		announcement := &announcements[i]
+		if !announcement.IsActiveAt(now) {
+			// Sudah lewat masa tayang sebelum sempat diumumkan, cukup bersihkan penandanya
+			_, _ = s.repository.ClaimPendingNotification(announcement.ID, now)
+			continue
+		}
+		if s.notifyActivated(announcement) {
+			notified++
+		}
+	}
+	return notified, nil
+}
+
+// notifyActivated sends the announcement notification once: broadcast for
+// untargeted announcements, personal notifications for the targeted audience.
+func (s *AnnouncementService) notifyActivated(announcement *models.Announcement) bool {
+	claimed, err := s.repository.ClaimPendingNotification(announcement.ID, time.Now())
+	if err != nil || !claimed || s.notificationService == nil {
+		return false
+	}
+
+	title := "Pengumuman Baru: " + announcement.Title
+	message := "Pengumuman baru telah dibuat. Cek sekarang!"
+	target := NotificationTarget{
+		Type:       models.NotificationTypeAnnouncement,
+		EntityType: models.NotificationTypeAnnouncement,
+		EntityID:   announcement.ID,
+		Event:      models.WebhookEventAnnouncementCreated,
+		Entity:     AnnouncementWebhookEntity(announcement),
+	}
+
+	if !announcement.HasAudience() {
+		if _, err := s.notificationService.CreateNotification(title, message, target); err != nil {
+			log.Printf("Gagal membuat notifikasi pengumuman %d: %v", announcement.ID, err)
+			return false
+		}
+		return true
+	}
+
+	years, _ := parseYears(announcement.TargetYears)
+	usernames, err := s.studentRepo.FindUsernamesByAudience(
+		splitList(announcement.TargetFaculties),
+		splitList(announcement.TargetStudyPrograms),
+		years,
+		splitList(announcement.TargetDormitories),
+	)
+	if err != nil {
+		log.Printf("Gagal mengambil audiens pengumuman %d: %v", announcement.ID, err)
+		return false
+	}
+	if _, err := s.notificationService.CreateNotificationForUsers(title, message, target, usernames); err != nil {
+		log.Printf("Gagal membuat notifikasi pengumuman %d: %v", announcement.ID, err)
+		return false
+	}
+	return true
+}
+
+// normalizeAudience trims the comma separated audience lists and validates the years
+func normalizeAudience(announcement *models.Announcement) error {
+	years, err := normalizeYears(announcement.TargetYears)
+	if err != nil {
+		return err
+	}
+	announcement.TargetYears = years
+	announcement.TargetFaculties = strings.Join(splitList(announcement.TargetFaculties), ",")
+	announcement.TargetStudyPrograms = strings.Join(splitList(announcement.TargetStudyPrograms), ",")
+	announcement.TargetDormitories = strings.Join(splitList(announcement.TargetDormitories), ",")
+	return nil
+}
+
+func normalizeYears(value string) (string, error) {
+	years, err := parseYears(value)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, len(years))
+	for i, y := range years {
+		parts[i] = strconv.Itoa(y)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+func parseYears(value string) ([]int, error) {
+	var years []int
+	for _, part := range splitList(value) {
+		year, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, errors.New("angkatan harus berupa tahun, contoh: 2022,2023")
+		}
+		years = append(years, year)
+	}
+	return years, nil
+}
+
+// splitList splits a comma separated list and drops empty entries
+func splitList(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
 }
 
 
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"bem_be/internal/models"
+)
+
+func TestCanViewAnnouncement(t *testing.T) {
+	now := time.Date(2026, 5, 10, 9, 0, 0, 0, time.UTC)
+	past, future := now.AddDate(0, 0, -3), now.AddDate(0, 0, 3)
+	org := uint(4)
+	orgID := int(org)
+	student := &models.Student{Faculty: "FITE", YearEnrolled: 2022}
+	officer := &models.Student{Faculty: "FTI", Position: "ketua_ukm", OrganizationID: &orgID}
+
+	cases := []struct {
+		name    string
+		a       models.Announcement
+		student *models.Student
+		want    bool
+	}{
+		{"publik aktif untuk pengunjung", models.Announcement{StartDate: &past}, nil, true},
+		{"publik kedaluwarsa tetap di arsip", models.Announcement{EndDate: &past}, nil, true},
+		{"terjadwal disembunyikan", models.Announcement{StartDate: &future}, student, false},
+		{"bertarget disembunyikan dari pengunjung", models.Announcement{TargetFaculties: "FITE"}, nil, false},
+		{"bertarget untuk mahasiswa sasaran", models.Announcement{TargetFaculties: "FITE"}, student, true},
+		{"bertarget untuk mahasiswa lain", models.Announcement{TargetYears: "2023"}, student, false},
+		{"pengurus melihat pengumuman terjadwal organisasinya", models.Announcement{StartDate: &future, TargetFaculties: "FITE", OrganizationID: &org}, officer, true},
+	}
+	for _, c := range cases {
+		if got := CanView(&c.a, c.student, now); got != c.want {
+			t.Errorf("%s: CanView = %v; ingin %v", c.name, got, c.want)
+		}
+	}
+}
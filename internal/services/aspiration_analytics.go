@@ -0,0 +1,377 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tealeg/xlsx/v3"
+
+	"bem_be/internal/models"
+)
+
+// AnalyticsCount adalah jumlah aspirasi untuk satu nilai (kategori, fakultas, dst.)
+type AnalyticsCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// KeywordCount adalah frekuensi satu kata di isi aspirasi
+type KeywordCount struct {
+	Word        string `json:"word"`
+	Count       int    `json:"count"`       // total kemunculan
+	Aspirations int    `json:"aspirations"` // jumlah aspirasi yang memuatnya
+}
+
+// AspirationResponseStats merangkum waktu tanggapan resmi pertama
+type AspirationResponseStats struct {
+	SLAHours     int     `json:"sla_hours"`
+	Responded    int     `json:"responded"`
+	Pending      int     `json:"pending"` // belum pernah ditanggapi dan belum ditutup
+	Overdue      int     `json:"overdue"` // sedang menunggu tanggapan melewati SLA
+	WithinSLA    int     `json:"within_sla"`
+	AverageHours float64 `json:"average_hours"`
+	MedianHours  float64 `json:"median_hours"`
+}
+
+// AspirationAnalytics adalah laporan agregat aspirasi dalam satu rentang waktu. Aspirasi
+// anonim dihitung di kelompok "Anonim" untuk fakultas, prodi dan angkatan.
+type AspirationAnalytics struct {
+	Start          time.Time               `json:"start"`
+	End            time.Time               `json:"end"`
+	Total          int                     `json:"total"`
+	ByCategory     []AnalyticsCount        `json:"by_category"`
+	ByPriority     []AnalyticsCount        `json:"by_priority"`
+	ByStatus       []AnalyticsCount        `json:"by_status"`
+	ByFaculty      []AnalyticsCount        `json:"by_faculty"`
+	ByStudyProgram []AnalyticsCount        `json:"by_study_program"`
+	ByYear         []AnalyticsCount        `json:"by_year"` // angkatan
+	ResponseTime   AspirationResponseStats `json:"response_time"`
+	Keywords       []KeywordCount          `json:"keywords"`
+}
+
+const (
+	analyticsAnonymous = "Anonim"
+	analyticsUnknown   = "Tidak diketahui"
+)
+
+// keywordStopwords adalah kata umum (Indonesia dan Inggris) yang diabaikan di frekuensi kata
+var keywordStopwords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`yang dan di ke dari untuk dengan ini itu pada dalam tidak ada juga akan atau
+		karena agar bisa dapat sudah belum lebih sangat saya kami kita mereka kamu anda apa bagaimana kenapa
+		mengapa seperti oleh sebagai adalah jadi namun tetapi tapi saat ketika bahwa kalau jika maka pun lagi
+		masih hanya banyak semua setiap para mohon tolong harap terima kasih nya per hal sehingga supaya yg dgn
+		utk tdk sih dong lah kah mau ingin harus perlu sering kurang terlalu baik tersebut dll dst secara antara
+		bagi hingga sampai sejak tentang terhadap atas bawah antar kepada bila selalu pernah telah sedang begitu
+		sini sana bapak ibu kak teman mahasiswa the and for you are with this that have from not but was`) {
+		keywordStopwords[word] = true
+	}
+}
+
+// analyticsRange mengisi rentang laporan; default 6 bulan terakhir
+func analyticsRange(start, end *time.Time) (time.Time, time.Time, error) {
+	to := time.Now()
+	if end != nil {
+		to = *end
+	}
+	from := to.AddDate(0, -6, 0)
+	if start != nil {
+		from = *start
+	}
+	if !to.After(from) {
+		return from, to, errors.New("akhir rentang harus setelah awal rentang")
+	}
+	return from, to, nil
+}
+
+// GetAnalytics menyusun laporan aspirasi yang dikirim pada [start, end) untuk pengurus.
+// keywords membatasi jumlah kata teratas.
+func (s *AspirationService) GetAnalytics(start, end *time.Time, keywords int, username string, isAdmin bool) (*AspirationAnalytics, error) {
+	staff, err := s.isAspirationStaff(nil, username, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if !staff {
+		return nil, ErrAspirationForbidden
+	}
+	from, to, err := analyticsRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	aspirations, err := s.repository.GetForAnalytics(from, to)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := s.submitterProfiles(aspirations)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AspirationAnalytics{Start: from, End: to, Total: len(aspirations)}
+	var categories, priorities, statuses, faculties, programs, years []string
+	for _, aspiration := range aspirations {
+		categories = append(categories, aspiration.Category)
+		priorities = append(priorities, aspiration.PriorityLevel)
+		statuses = append(statuses, aspiration.Status)
+
+		faculty, program, year := analyticsUnknown, analyticsUnknown, analyticsUnknown
+		if aspiration.Anonymous {
+			faculty, program, year = analyticsAnonymous, analyticsAnonymous, analyticsAnonymous
+		} else if student, ok := profiles[strings.ToLower(aspiration.UserName)]; ok {
+			faculty, program = student.Faculty, student.StudyProgram
+			if student.YearEnrolled > 0 {
+				year = strconv.Itoa(student.YearEnrolled)
+			}
+		}
+		faculties = append(faculties, faculty)
+		programs = append(programs, program)
+		years = append(years, year)
+	}
+	report.ByCategory = countLabels(categories)
+	report.ByPriority = countLabels(priorities)
+	report.ByStatus = countLabels(statuses)
+	report.ByFaculty = countLabels(faculties)
+	report.ByStudyProgram = countLabels(programs)
+	report.ByYear = countLabels(years)
+	report.ResponseTime = responseStats(aspirations, time.Now())
+	report.Keywords = keywordFrequency(aspirations, keywords)
+	return report, nil
+}
+
+// submitterProfiles mengambil data mahasiswa pengaju (bukan anonim), per username huruf kecil
+func (s *AspirationService) submitterProfiles(aspirations []models.Aspiration) (map[string]models.Student, error) {
+	seen := map[string]bool{}
+	var usernames []string
+	for _, aspiration := range aspirations {
+		if aspiration.Anonymous || aspiration.UserName == "" || seen[aspiration.UserName] {
+			continue
+		}
+		seen[aspiration.UserName] = true
+		usernames = append(usernames, aspiration.UserName)
+	}
+	students, err := s.studentRepo.FindByUsernames(usernames)
+	if err != nil {
+		return nil, err
+	}
+	profiles := make(map[string]models.Student, len(students))
+	for _, student := range students {
+		key := strings.ToLower(student.UserName)
+		if _, ok := profiles[key]; !ok {
+			profiles[key] = student
+		}
+	}
+	return profiles, nil
+}
+
+// countLabels menghitung nilai tanpa membedakan huruf besar dan spasi berlebih, terbanyak
+// dulu; ejaan pertama yang ditemukan dipakai sebagai label
+func countLabels(values []string) []AnalyticsCount {
+	index := map[string]int{}
+	counts := []AnalyticsCount{}
+	for _, value := range values {
+		label := strings.Join(strings.Fields(value), " ")
+		if label == "" {
+			label = analyticsUnknown
+		}
+		key := strings.ToLower(label)
+		if i, ok := index[key]; ok {
+			counts[i].Count++
+			continue
+		}
+		index[key] = len(counts)
+		counts = append(counts, AnalyticsCount{Label: label, Count: 1})
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Label < counts[j].Label
+	})
+	return counts
+}
+
+// responseStats menghitung waktu dari aspirasi dikirim sampai tanggapan resmi pertama
+func responseStats(aspirations []models.Aspiration, now time.Time) AspirationResponseStats {
+	sla := aspirationSLA()
+	stats := AspirationResponseStats{SLAHours: int(sla / time.Hour)}
+	var hours []float64
+	for i := range aspirations {
+		aspiration := aspirations[i]
+		if aspiration.FirstResponseAt != nil {
+			elapsed := aspiration.FirstResponseAt.Sub(aspiration.CreatedAt)
+			hours = append(hours, elapsed.Hours())
+			if elapsed <= sla {
+				stats.WithinSLA++
+			}
+		} else if aspiration.Status != models.AspirationStatusClosed {
+			stats.Pending++
+		}
+		applySLA(&aspiration, now)
+		if aspiration.Overdue {
+			stats.Overdue++
+		}
+	}
+	stats.Responded = len(hours)
+	if len(hours) == 0 {
+		return stats
+	}
+	sort.Float64s(hours)
+	total := 0.0
+	for _, h := range hours {
+		total += h
+	}
+	median := hours[len(hours)/2]
+	if len(hours)%2 == 0 {
+		median = (hours[len(hours)/2-1] + hours[len(hours)/2]) / 2
+	}
+	stats.AverageHours = math.Round(total/float64(len(hours))*10) / 10
+	stats.MedianHours = math.Round(median*10) / 10
+	return stats
+}
+
+// keywordFrequency menghitung kata terbanyak di judul, deskripsi dan isi aspirasi, tanpa
+// kata umum, angka dan kata di bawah 3 huruf
+func keywordFrequency(aspirations []models.Aspiration, limit int) []KeywordCount {
+	counts := map[string]*KeywordCount{}
+	for _, aspiration := range aspirations {
+		text := strings.ToLower(aspiration.Title + " " + aspiration.Description + " " + aspiration.Content)
+		words := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		mentioned := map[string]bool{}
+		for _, word := range words {
+			if len([]rune(word)) < 3 || keywordStopwords[word] || isNumber(word) {
+				continue
+			}
+			keyword, ok := counts[word]
+			if !ok {
+				keyword = &KeywordCount{Word: word}
+				counts[word] = keyword
+			}
+			keyword.Count++
+			if !mentioned[word] {
+				mentioned[word] = true
+				keyword.Aspirations++
+			}
+		}
+	}
+
+	keywords := make([]KeywordCount, 0, len(counts))
+	for _, keyword := range counts {
+		keywords = append(keywords, *keyword)
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Count != keywords[j].Count {
+			return keywords[i].Count > keywords[j].Count
+		}
+		return keywords[i].Word < keywords[j].Word
+	})
+	if len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+	return keywords
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExportAnalytics menulis laporan aspirasi ke file xlsx (satu sheet per pengelompokan)
+func (s *AspirationService) ExportAnalytics(start, end *time.Time, keywords int, username string, isAdmin bool) ([]byte, string, error) {
+	report, err := s.GetAnalytics(start, end, keywords, username, isAdmin)
+	if err != nil {
+		return nil, "", err
+	}
+	loc := calendarLocation()
+
+	file := xlsx.NewFile()
+	summary, err := file.AddSheet("Ringkasan")
+	if err != nil {
+		return nil, "", err
+	}
+	rows := [][2]string{
+		{"Periode", report.Start.In(loc).Format("02-01-2006") + " - " + report.End.In(loc).Format("02-01-2006")},
+		{"Total Aspirasi", strconv.Itoa(report.Total)},
+		{"Sudah Ditanggapi", strconv.Itoa(report.ResponseTime.Responded)},
+		{"Belum Ditanggapi", strconv.Itoa(report.ResponseTime.Pending)},
+		{"Melewati SLA Saat Ini", strconv.Itoa(report.ResponseTime.Overdue)},
+		{"Ditanggapi dalam SLA (" + strconv.Itoa(report.ResponseTime.SLAHours) + " jam)", strconv.Itoa(report.ResponseTime.WithinSLA)},
+		{"Rata-rata Waktu Tanggapan (jam)", strconv.FormatFloat(report.ResponseTime.AverageHours, 'f', 1, 64)},
+		{"Median Waktu Tanggapan (jam)", strconv.FormatFloat(report.ResponseTime.MedianHours, 'f', 1, 64)},
+	}
+	for _, values := range rows {
+		row := summary.AddRow()
+		row.AddCell().SetString(values[0])
+		row.AddCell().SetString(values[1])
+	}
+	summary.SetColWidth(1, 1, 36)
+	summary.SetColWidth(2, 2, 28)
+
+	statuses := make([]AnalyticsCount, len(report.ByStatus))
+	for i, count := range report.ByStatus {
+		statuses[i] = count
+		if label, ok := aspirationStatusLabels[count.Label]; ok {
+			statuses[i].Label = label
+		}
+	}
+	groups := []struct {
+		sheet  string
+		header string
+		counts []AnalyticsCount
+	}{
+		{"Kategori", "Kategori", report.ByCategory},
+		{"Prioritas", "Prioritas", report.ByPriority},
+		{"Status", "Status", statuses},
+		{"Fakultas", "Fakultas", report.ByFaculty},
+		{"Program Studi", "Program Studi", report.ByStudyProgram},
+		{"Angkatan", "Angkatan", report.ByYear},
+	}
+	for _, group := range groups {
+		sheet, err := file.AddSheet(group.sheet)
+		if err != nil {
+			return nil, "", err
+		}
+		header := sheet.AddRow()
+		header.AddCell().SetString(group.header)
+		header.AddCell().SetString("Jumlah")
+		for _, count := range group.counts {
+			row := sheet.AddRow()
+			row.AddCell().SetString(count.Label)
+			row.AddCell().SetInt(count.Count)
+		}
+		sheet.SetColWidth(1, 1, 36)
+	}
+
+	sheet, err := file.AddSheet("Kata Kunci")
+	if err != nil {
+		return nil, "", err
+	}
+	header := sheet.AddRow()
+	for _, title := range []string{"Kata", "Kemunculan", "Jumlah Aspirasi"} {
+		header.AddCell().SetString(title)
+	}
+	for _, keyword := range report.Keywords {
+		row := sheet.AddRow()
+		row.AddCell().SetString(keyword.Word)
+		row.AddCell().SetInt(keyword.Count)
+		row.AddCell().SetInt(keyword.Aspirations)
+	}
+	sheet.SetColWidth(1, 1, 24)
+
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		return nil, "", err
+	}
+	filename := "laporan-aspirasi-" + report.Start.In(loc).Format("20060102") + "-" + report.End.In(loc).Format("20060102")
+	return buf.Bytes(), filename + ".xlsx", nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
+)
+
+var (
+	ErrAspirationRateLimited = errors.New("batas pengajuan aspirasi hari ini sudah tercapai, coba lagi besok")
+	ErrAspirationLoginNeeded = errors.New("login diperlukan untuk mengirim aspirasi")
+	ErrTrackingCodeInvalid   = errors.New("kode pelacakan tidak valid")
+	ErrAspirationAnonSecret  = errors.New("ASPIRATION_ANON_SECRET atau JWT_SECRET belum diatur; pengajuan aspirasi dinonaktifkan")
+)
+
+// trackingCodeAlphabet tanpa huruf/angka yang mudah tertukar (0/O, 1/I)
+const trackingCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// trackingCodeLength karakter acak (masing-masing 5 bit, total 80 bit)
+const trackingCodeLength = 16
+
+// AnonymityPolicy menjelaskan apa yang disimpan untuk aspirasi anonim dan siapa yang
+// bisa membuka identitas pengajunya
+type AnonymityPolicy struct {
+	IdentityStored    bool     `json:"identity_stored"`
+	AdminCanIdentify  bool     `json:"admin_can_identify"`
+	DailyLimit        int      `json:"daily_limit"`
+	TrackingCodeShown string   `json:"tracking_code_shown"`
+	Statements        []string `json:"statements"`
+}
+
+// AspirationAnonymityPolicy adalah kebijakan de-anonimisasi aspirasi: tidak ada jalur
+// resmi untuk membuka identitas karena sistem tidak menyimpan tautan yang bisa dibalik
+func AspirationAnonymityPolicy() AnonymityPolicy {
+	return AnonymityPolicy{
+		IdentityStored:    false,
+		AdminCanIdentify:  false,
+		DailyLimit:        aspirationDailyLimit(),
+		TrackingCodeShown: "sekali, saat aspirasi dikirim",
+		Statements: []string{
+			"Aspirasi anonim disimpan tanpa username, NIM, atau tautan lain ke akun pengaju.",
+			"Kode pelacakan hanya disimpan sebagai hash; kode yang hilang tidak bisa dipulihkan atau diterbitkan ulang.",
+			"Batas pengajuan harian dihitung dengan HMAC username per hari yang tidak merujuk aspirasi mana pun dan dihapus setelah 7 hari.",
+			"Admin, pengurus, dan pengembang tidak punya fitur maupun data untuk membuka identitas pengaju anonim.",
+			"Isi aspirasi tetap bisa memuat identitas jika pengaju menuliskannya sendiri.",
+		},
+	}
+}
+
+// aspirationDailyLimit membaca ASPIRATION_DAILY_LIMIT (0 = tanpa batas)
+func aspirationDailyLimit() int {
+	limit := utils.GetEnvAsInt("ASPIRATION_DAILY_LIMIT", 5)
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// aspirationAnonSecret membaca ASPIRATION_ANON_SECRET (default JWT_SECRET)
+func aspirationAnonSecret() string {
+	return utils.GetEnvWithDefault("ASPIRATION_ANON_SECRET", utils.GetEnvWithDefault("JWT_SECRET", ""))
+}
+
+// CheckAspirationAnonSecret dipanggil saat server mulai: tanpa rahasia, HMAC batas harian
+// bisa dihitung ulang siapa pun dari daftar username sehingga anonimitas bocor
+func CheckAspirationAnonSecret() error {
+	if aspirationDailyLimit() > 0 && aspirationAnonSecret() == "" {
+		return ErrAspirationAnonSecret
+	}
+	return nil
+}
+
+// aspirationRateLimitKey menyamarkan username dengan HMAC berkunci rahasia server;
+// menolak bila rahasia kosong
+func aspirationRateLimitKey(username string) (string, error) {
+	secret := aspirationAnonSecret()
+	if secret == "" {
+		return "", ErrAspirationAnonSecret
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(username))))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// checkAspirationRateLimit memakai satu kuota pengajuan harian username
+func checkAspirationRateLimit(repo *repositories.AspirationRepository, username string, now time.Time) error {
+	limit := aspirationDailyLimit()
+	if limit == 0 {
+		return nil
+	}
+	if strings.TrimSpace(username) == "" {
+		return ErrAspirationLoginNeeded
+	}
+	key, err := aspirationRateLimitKey(username)
+	if err != nil {
+		return err
+	}
+	allowed, err := repo.IncrementRateLimit(key, now.UTC().Format("2006-01-02"), limit)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrAspirationRateLimited
+	}
+	return nil
+}
+
+// newTrackingCode membuat kode pelacakan acak berformat ASP-XXXX-XXXX-XXXX-XXXX
+func newTrackingCode() (string, error) {
+	b := make([]byte, trackingCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	var code strings.Builder
+	code.WriteString("ASP")
+	for i, v := range b {
+		if i%4 == 0 {
+			code.WriteByte('-')
+		}
+		code.WriteByte(trackingCodeAlphabet[int(v)%len(trackingCodeAlphabet)])
+	}
+	return code.String(), nil
+}
+
+// trackingCodeHash menormalkan kode (huruf besar, tanpa prefix, spasi dan tanda hubung)
+// lalu meng-hash-nya; string kosong jika formatnya salah
+func trackingCodeHash(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	normalized = strings.TrimPrefix(normalized, "ASP")
+	if len(normalized) != trackingCodeLength {
+		return ""
+	}
+	for _, r := range normalized {
+		if !strings.ContainsRune(trackingCodeAlphabet, r) {
+			return ""
+		}
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// maskAnonymous memastikan aspirasi anonim tidak membawa data mahasiswa apa pun
+func maskAnonymous(aspiration *models.Aspiration) {
+	if !aspiration.Anonymous {
+		return
+	}
+	aspiration.UserName = ""
+	aspiration.Student = models.Student{FullName: "Anonim"}
+}
+
+// findByTrackingCode mengambil id aspirasi anonim milik kode pelacakan
+func (s *AspirationService) findByTrackingCode(code string) (uint, error) {
+	hash := trackingCodeHash(code)
+	if hash == "" {
+		return 0, ErrTrackingCodeInvalid
+	}
+	aspiration, err := s.repository.FindByTrackingHash(hash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrTrackingCodeInvalid
+		}
+		return 0, err
+	}
+	return aspiration.ID, nil
+}
+
+// TrackAspiration mengambil status, utas tanggapan dan riwayat aspirasi anonim
+func (s *AspirationService) TrackAspiration(code string) (*models.Aspiration, error) {
+	id, err := s.findByTrackingCode(code)
+	if err != nil {
+		return nil, err
+	}
+	aspiration, err := s.repository.FindDetail(id)
+	if err != nil {
+		return nil, err
+	}
+	maskAnonymous(aspiration)
+	aspiration.Responses = aspirationThread(aspiration.Responses, nil)
+	applySLA(aspiration, time.Now())
+	return aspiration, nil
+}
+
+// ReplyByTrackingCode menambahkan balasan pengaju anonim ke utas aspirasinya
+func (s *AspirationService) ReplyByTrackingCode(code string, parentID *uint, message string) (*models.AspirationResponse, error) {
+	id, err := s.findByTrackingCode(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.addResponse(id, parentID, message, "", true, false)
+}
+
+// PruneRateLimits menghapus hitungan pengajuan yang lebih lama dari 7 hari
+func (s *AspirationService) PruneRateLimits(now time.Time) (int64, error) {
+	return s.repository.PruneRateLimits(now.UTC().AddDate(0, 0, -7).Format("2006-01-02"))
+}
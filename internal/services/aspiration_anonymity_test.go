@@ -0,0 +1,84 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrackingCodeHash(t *testing.T) {
+	code, err := newTrackingCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(code, "ASP-") || len(code) != len("ASP")+trackingCodeLength+trackingCodeLength/4 {
+		t.Fatalf("format kode pelacakan tidak sesuai: %q", code)
+	}
+
+	hash := trackingCodeHash(code)
+	if len(hash) != 64 {
+		t.Fatalf("hash = %q; ingin hex SHA-256", hash)
+	}
+
+	// kode yang diketik ulang pengguna tetap cocok
+	variants := []string{
+		strings.ToLower(code),
+		strings.ReplaceAll(code, "-", ""),
+		strings.ReplaceAll(code, "-", " "),
+		strings.TrimPrefix(code, "ASP-"),
+	}
+	for _, v := range variants {
+		if got := trackingCodeHash(v); got != hash {
+			t.Errorf("trackingCodeHash(%q) = %q; ingin sama dengan kode asli", v, got)
+		}
+	}
+
+	other, _ := newTrackingCode()
+	if trackingCodeHash(other) == hash {
+		t.Error("dua kode berbeda menghasilkan hash yang sama")
+	}
+}
+
+func TestTrackingCodeHashRejectsMalformed(t *testing.T) {
+	invalid := []string{
+		"",
+		"ASP",
+		"ASP-ABCD-EFGH-JKLM",           // terlalu pendek
+		"ASP-ABCD-EFGH-JKLM-NPQR-STUV", // terlalu panjang
+		"ASP-ABCD-EFGH-JKLM-NPQ0",      // 0 tidak ada di alfabet
+		"ASP-ABCD-EFGH-JKLM-NPQI",      // I tidak ada di alfabet
+		"ASP-ABCD-EFGH-JKLM-NPQ!",
+	}
+	for _, code := range invalid {
+		if got := trackingCodeHash(code); got != "" {
+			t.Errorf("trackingCodeHash(%q) = %q; ingin kosong", code, got)
+		}
+	}
+}
+
+func TestAspirationRateLimitKeyRequiresSecret(t *testing.T) {
+	t.Setenv("ASPIRATION_ANON_SECRET", "")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("ASPIRATION_DAILY_LIMIT", "5")
+	if _, err := aspirationRateLimitKey("mahasiswa"); err != ErrAspirationAnonSecret {
+		t.Fatalf("aspirationRateLimitKey tanpa rahasia: err = %v; ingin ErrAspirationAnonSecret", err)
+	}
+	if err := CheckAspirationAnonSecret(); err != ErrAspirationAnonSecret {
+		t.Fatalf("CheckAspirationAnonSecret tanpa rahasia: err = %v; ingin ErrAspirationAnonSecret", err)
+	}
+
+	// tanpa batas harian HMAC tidak dipakai, jadi server boleh berjalan
+	t.Setenv("ASPIRATION_DAILY_LIMIT", "0")
+	if err := CheckAspirationAnonSecret(); err != nil {
+		t.Fatalf("CheckAspirationAnonSecret dengan batas 0: err = %v", err)
+	}
+
+	t.Setenv("JWT_SECRET", "rahasia")
+	a, err := aspirationRateLimitKey("Mahasiswa ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := aspirationRateLimitKey("mahasiswa")
+	if a != b || len(a) != 64 {
+		t.Fatalf("kunci tidak dinormalkan: %q vs %q", a, b)
+	}
+}
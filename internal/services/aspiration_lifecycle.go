@@ -0,0 +1,456 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
+)
+
+var (
+	ErrAspirationNotFound  = errors.New("aspirasi tidak ditemukan")
+	ErrAspirationForbidden = errors.New("anda tidak berhak mengelola aspirasi ini")
+	ErrAspirationClosed    = errors.New("aspirasi sudah ditutup")
+	ErrAspirationStatus    = errors.New("status aspirasi tidak valid")
+)
+
+// aspirationStatusLabels adalah nama status yang ditampilkan ke pengaju
+var aspirationStatusLabels = map[string]string{
+	models.AspirationStatusSubmitted:   "Diajukan",
+	models.AspirationStatusUnderReview: "Sedang Ditinjau",
+	models.AspirationStatusForwarded:   "Diteruskan",
+	models.AspirationStatusResponded:   "Ditanggapi",
+	models.AspirationStatusClosed:      "Ditutup",
+}
+
+// aspirationTransitions adalah perubahan status yang boleh dilakukan lewat ChangeStatus.
+// Status forwarded hanya lewat penugasan dan responded hanya lewat tanggapan resmi.
+var aspirationTransitions = map[string][]string{
+	models.AspirationStatusSubmitted:   {models.AspirationStatusUnderReview, models.AspirationStatusClosed},
+	models.AspirationStatusUnderReview: {models.AspirationStatusClosed},
+	models.AspirationStatusForwarded:   {models.AspirationStatusUnderReview, models.AspirationStatusClosed},
+	models.AspirationStatusResponded:   {models.AspirationStatusUnderReview, models.AspirationStatusClosed},
+	models.AspirationStatusClosed:      {models.AspirationStatusUnderReview},
+}
+
+func validAspirationStatus(status string) bool {
+	_, ok := aspirationStatusLabels[status]
+	return ok
+}
+
+// aspirationSLA membaca ASPIRATION_SLA_HOURS: batas waktu aspirasi menunggu tanggapan resmi
+func aspirationSLA() time.Duration {
+	hours := utils.GetEnvAsInt("ASPIRATION_SLA_HOURS", 72)
+	if hours < 1 {
+		hours = 1
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// applySLA mengisi tenggat tanggapan dan penanda overdue aspirasi yang masih menunggu
+func applySLA(aspiration *models.Aspiration, now time.Time) {
+	aspiration.ResponseDueAt = nil
+	aspiration.Overdue = false
+	if aspiration.AwaitingSince == nil || aspiration.Status == models.AspirationStatusClosed {
+		return
+	}
+	due := aspiration.AwaitingSince.Add(aspirationSLA())
+	aspiration.ResponseDueAt = &due
+	aspiration.Overdue = now.After(due)
+}
+
+// isAspirationStaff menentukan apakah pengguna boleh menanggapi dan mengelola aspirasi:
+// admin, pengurus inti BEM/MPM, atau pengurus inti organisasi penanggung jawab aspirasi.
+// Untuk operasi yang tidak terikat satu aspirasi, aspiration bernilai nil sehingga hanya
+// admin dan pengurus BEM/MPM yang lolos.
+func (s *AspirationService) isAspirationStaff(aspiration *models.Aspiration, username string, isAdmin bool) (bool, error) {
+	if isAdmin {
+		return true, nil
+	}
+	if username == "" {
+		return false, nil
+	}
+	student, err := s.studentRepo.FindByUserID(username)
+	if err != nil {
+		return false, err
+	}
+	if student == nil {
+		return false, nil
+	}
+	if student.IsExecutive() {
+		return true, nil
+	}
+	return aspiration != nil && aspiration.OrganizationID != nil && student.IsOfficerOf(*aspiration.OrganizationID), nil
+}
+
+// GetAspirationDetail mengambil aspirasi beserta utas tanggapan dan riwayat statusnya.
+// Aspirasi publik bisa dilihat siapa pun; selainnya hanya pengaju dan pengurus.
+func (s *AspirationService) GetAspirationDetail(id uint, username string, isAdmin bool) (*models.Aspiration, error) {
+	aspiration, err := s.repository.FindDetail(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAspirationNotFound
+		}
+		return nil, err
+	}
+	if !aspiration.Public && !isAspirationSubmitter(aspiration, username) {
+		staff, err := s.isAspirationStaff(aspiration, username, isAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if !staff {
+			return nil, ErrAspirationForbidden
+		}
+	}
+	voted, err := s.repository.GetVotedIDs(username, []uint{aspiration.ID})
+	if err != nil {
+		return nil, err
+	}
+	aspiration.Voted = len(voted) > 0
+	maskAnonymous(aspiration)
+	aspiration.Responses = aspirationThread(aspiration.Responses, nil)
+	applySLA(aspiration, time.Now())
+	return aspiration, nil
+}
+
+// aspirationThread menyusun tanggapan menjadi utas bersarang di bawah parent
+func aspirationThread(responses []models.AspirationResponse, parent *uint) []models.AspirationResponse {
+	var thread []models.AspirationResponse
+	for _, response := range responses {
+		if (parent == nil) != (response.ParentID == nil) || (parent != nil && *parent != *response.ParentID) {
+			continue
+		}
+		id := response.ID
+		response.Replies = aspirationThread(responses, &id)
+		thread = append(thread, response)
+	}
+	return thread
+}
+
+// ChangeStatus mengubah status aspirasi (ditinjau, ditutup, atau dibuka kembali).
+// Pengaju hanya boleh menutup aspirasinya sendiri.
+func (s *AspirationService) ChangeStatus(id uint, status, note, username string, isAdmin bool) (*models.Aspiration, error) {
+	if !validAspirationStatus(status) {
+		return nil, ErrAspirationStatus
+	}
+	var aspiration *models.Aspiration
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewAspirationRepository(tx)
+		var err error
+		if aspiration, err = lockAspiration(repo, id); err != nil {
+			return err
+		}
+		submitter := isAspirationSubmitter(aspiration, username)
+		staff, err := s.isAspirationStaff(aspiration, username, isAdmin)
+		if err != nil {
+			return err
+		}
+		if !staff && !(submitter && status == models.AspirationStatusClosed) {
+			return ErrAspirationForbidden
+		}
+		if !containsFold(aspirationTransitions[aspiration.Status], status) {
+			return fmt.Errorf("status %s tidak bisa diubah menjadi %s", aspiration.Status, status)
+		}
+		return transitionAspiration(repo, aspiration, status, nil, username, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifySubmitter(aspiration, "Status Aspirasi Diperbarui",
+		fmt.Sprintf("Aspirasi \"%s\" kini berstatus %s.", aspiration.Title, aspirationStatusLabels[status]), note)
+	return aspiration, nil
+}
+
+// AssignAspiration meneruskan aspirasi ke organisasi/departemen penanggung jawab
+func (s *AspirationService) AssignAspiration(id uint, organizationID int, note, username string, isAdmin bool) (*models.Aspiration, error) {
+	staff, err := s.isAspirationStaff(nil, username, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if !staff {
+		return nil, ErrAspirationForbidden
+	}
+	if organizationID <= 0 {
+		return nil, errors.New("organization_id wajib diisi")
+	}
+	organization, err := s.organizationRepo.FindOrganizationByID(uint(organizationID))
+	if err != nil || organization == nil {
+		return nil, errors.New("organisasi tidak ditemukan")
+	}
+
+	var aspiration *models.Aspiration
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewAspirationRepository(tx)
+		var err error
+		if aspiration, err = lockAspiration(repo, id); err != nil {
+			return err
+		}
+		if aspiration.Status == models.AspirationStatusClosed {
+			return ErrAspirationClosed
+		}
+		if aspiration.OrganizationID != nil && *aspiration.OrganizationID == organizationID &&
+			aspiration.Status == models.AspirationStatusForwarded {
+			return errors.New("aspirasi sudah diteruskan ke organisasi tersebut")
+		}
+		return transitionAspiration(repo, aspiration, models.AspirationStatusForwarded, &organizationID, username, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+	aspiration.Organization = organization
+
+	s.notifySubmitter(aspiration, "Aspirasi Diteruskan",
+		fmt.Sprintf("Aspirasi \"%s\" diteruskan ke %s untuk ditindaklanjuti.", aspiration.Title, organization.Name), note)
+	s.notifyOrganization(organizationID, aspiration.ID, "Aspirasi Baru untuk Ditindaklanjuti",
+		fmt.Sprintf("Aspirasi \"%s\" diteruskan ke %s dan menunggu tanggapan.", aspiration.Title, organization.Name))
+	return aspiration, nil
+}
+
+// AddResponse menambahkan pesan ke utas aspirasi. Pesan pengurus adalah tanggapan resmi
+// (status menjadi responded dan SLA berhenti); balasan pengaju membuat aspirasi kembali
+// menunggu tanggapan.
+func (s *AspirationService) AddResponse(id uint, parentID *uint, message, username string, isAdmin bool) (*models.AspirationResponse, error) {
+	if username == "" {
+		return nil, errors.New("username tidak ditemukan")
+	}
+	return s.addResponse(id, parentID, message, username, false, isAdmin)
+}
+
+// addResponse menyimpan pesan di utas; anonymous berarti pesan dari pengaju anonim yang
+// sudah dibuktikan dengan kode pelacakan
+func (s *AspirationService) addResponse(id uint, parentID *uint, message, username string, anonymous, isAdmin bool) (*models.AspirationResponse, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, errors.New("pesan tanggapan wajib diisi")
+	}
+
+	var aspiration *models.Aspiration
+	var response *models.AspirationResponse
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewAspirationRepository(tx)
+		var err error
+		if aspiration, err = lockAspiration(repo, id); err != nil {
+			return err
+		}
+		if aspiration.Status == models.AspirationStatusClosed {
+			return ErrAspirationClosed
+		}
+		official := !anonymous && !isAspirationSubmitter(aspiration, username)
+		if official {
+			staff, err := s.isAspirationStaff(aspiration, username, isAdmin)
+			if err != nil {
+				return err
+			}
+			if !staff {
+				return ErrAspirationForbidden
+			}
+		}
+		if parentID != nil {
+			parent, err := repo.FindResponse(*parentID)
+			if err != nil || parent.AspirationID != aspiration.ID {
+				return errors.New("tanggapan yang dibalas tidak ditemukan")
+			}
+		}
+
+		response = &models.AspirationResponse{
+			AspirationID: aspiration.ID,
+			ParentID:     parentID,
+			Username:     username,
+			Official:     official,
+			Message:      message,
+		}
+		if err := repo.CreateResponse(response); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if official {
+			if aspiration.FirstResponseAt == nil {
+				aspiration.FirstResponseAt = &now
+			}
+			aspiration.AwaitingSince = nil
+			if aspiration.Status != models.AspirationStatusResponded {
+				return transitionAspiration(repo, aspiration, models.AspirationStatusResponded, nil, username, "")
+			}
+			return repo.Update(aspiration)
+		}
+		if aspiration.AwaitingSince == nil {
+			aspiration.AwaitingSince = &now
+			aspiration.SLABreachedAt = nil
+		}
+		if aspiration.Status == models.AspirationStatusResponded {
+			return transitionAspiration(repo, aspiration, models.AspirationStatusUnderReview, nil, username, "balasan pengaju")
+		}
+		return repo.Update(aspiration)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Official {
+		s.notifySubmitter(aspiration, "Aspirasi Ditanggapi",
+			fmt.Sprintf("Aspirasi \"%s\" mendapat tanggapan resmi.", aspiration.Title), "")
+	} else if aspiration.OrganizationID != nil {
+		s.notifyOrganization(*aspiration.OrganizationID, aspiration.ID, "Balasan Aspirasi",
+			fmt.Sprintf("Pengaju aspirasi \"%s\" mengirim balasan.", aspiration.Title))
+	} else {
+		s.notifyStaff(aspiration.ID, "Balasan Aspirasi",
+			fmt.Sprintf("Pengaju aspirasi \"%s\" mengirim balasan.", aspiration.Title))
+	}
+	return response, nil
+}
+
+// FlagOverdueAspirations menandai aspirasi yang menunggu tanggapan melebihi SLA dan
+// mengirim satu ringkasan per organisasi penanggung jawab (aspirasi yang belum
+// ditugaskan dikabarkan lewat notifikasi aspirasi umum). Tiap aspirasi hanya ditandai
+// sekali sampai kembali menunggu tanggapan.
+func (s *AspirationService) FlagOverdueAspirations(now time.Time) (int, error) {
+	sla := aspirationSLA()
+	cutoff := now.Add(-sla)
+	aspirations, err := s.repository.GetUnflaggedOverdue(cutoff)
+	if err != nil || len(aspirations) == 0 {
+		return 0, err
+	}
+	ids := make([]uint, len(aspirations))
+	for i, aspiration := range aspirations {
+		ids[i] = aspiration.ID
+	}
+	flagged, err := s.repository.MarkSLABreached(ids, cutoff, now)
+	if err != nil {
+		return 0, err
+	}
+
+	unassigned := 0
+	byOrganization := map[int]int{}
+	for _, aspiration := range aspirations {
+		if aspiration.OrganizationID == nil {
+			unassigned++
+		} else {
+			byOrganization[*aspiration.OrganizationID]++
+		}
+	}
+	limit := fmt.Sprintf("%d jam", int(sla/time.Hour))
+	for organizationID, count := range byOrganization {
+		s.notifyOrganization(organizationID, 0, "Aspirasi Melewati Batas Waktu",
+			fmt.Sprintf("%d aspirasi yang diteruskan ke organisasi Anda belum ditanggapi lebih dari %s.", count, limit))
+	}
+	if unassigned > 0 {
+		s.notifyStaff(0, "Aspirasi Melewati Batas Waktu",
+			fmt.Sprintf("%d aspirasi belum ditanggapi lebih dari %s.", unassigned, limit))
+	}
+	return int(flagged), nil
+}
+
+// isAspirationSubmitter memeriksa apakah username adalah pengaju (aspirasi anonim tidak
+// punya pengaju yang bisa dicocokkan)
+func isAspirationSubmitter(aspiration *models.Aspiration, username string) bool {
+	return aspiration.UserName != "" && strings.EqualFold(aspiration.UserName, username)
+}
+
+// lockAspiration mengunci aspirasi di dalam transaksi
+func lockAspiration(repo *repositories.AspirationRepository, id uint) (*models.Aspiration, error) {
+	aspiration, err := repo.Lock(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAspirationNotFound
+		}
+		return nil, err
+	}
+	return aspiration, nil
+}
+
+// transitionAspiration mengubah status (dan penanggung jawab jika organizationID diisi),
+// menyesuaikan pencatatan SLA lalu menyimpan riwayatnya
+func transitionAspiration(repo *repositories.AspirationRepository, aspiration *models.Aspiration, status string, organizationID *int, username, note string) error {
+	now := time.Now()
+	from := aspiration.Status
+	switch status {
+	case models.AspirationStatusClosed:
+		aspiration.ClosedAt = &now
+		aspiration.AwaitingSince = nil
+	case models.AspirationStatusUnderReview, models.AspirationStatusForwarded:
+		aspiration.ClosedAt = nil
+		if aspiration.AwaitingSince == nil {
+			aspiration.AwaitingSince = &now
+			aspiration.SLABreachedAt = nil
+		}
+	}
+	if organizationID != nil {
+		aspiration.OrganizationID = organizationID
+		aspiration.Organization = nil
+	}
+	aspiration.Status = status
+	if err := repo.Update(aspiration); err != nil {
+		return err
+	}
+	return repo.CreateHistory(&models.AspirationStatusHistory{
+		AspirationID:   aspiration.ID,
+		FromStatus:     from,
+		ToStatus:       status,
+		OrganizationID: organizationID,
+		ChangedBy:      username,
+		Note:           strings.TrimSpace(note),
+	})
+}
+
+// notifySubmitter mengabari pengaju tentang perubahan aspirasinya
+func (s *AspirationService) notifySubmitter(aspiration *models.Aspiration, title, message, note string) {
+	if s.notificationService == nil || aspiration.UserName == "" {
+		return
+	}
+	if note = strings.TrimSpace(note); note != "" {
+		message += " Catatan: " + note
+	}
+	_, err := s.notificationService.CreateNotification(title, message, NotificationTarget{
+		Type:       models.NotificationTypeAspiration,
+		EntityType: models.NotificationTypeAspiration,
+		EntityID:   aspiration.ID,
+		Username:   aspiration.UserName,
+		Event:      models.WebhookEventAspirationUpdated,
+		Entity:     AspirationWebhookEntity(aspiration),
+	})
+	if err != nil {
+		log.Printf("Gagal mengirim notifikasi aspirasi %d: %v", aspiration.ID, err)
+	}
+}
+
+// notifyOrganization mengabari pengurus organisasi penanggung jawab
+func (s *AspirationService) notifyOrganization(organizationID int, aspirationID uint, title, message string) {
+	if s.notificationService == nil {
+		return
+	}
+	officers, err := s.studentRepo.FindUsernamesByOrganization(organizationID)
+	if err != nil || len(officers) == 0 {
+		return
+	}
+	_, err = s.notificationService.CreateNotificationForUsers(title, message, NotificationTarget{
+		Type:       models.NotificationTypeAspiration,
+		EntityType: models.NotificationTypeAspiration,
+		EntityID:   aspirationID,
+	}, officers)
+	if err != nil {
+		log.Printf("Gagal mengirim notifikasi aspirasi ke organisasi %d: %v", organizationID, err)
+	}
+}
+
+// notifyStaff mengirim notifikasi aspirasi umum, sama seperti notifikasi aspirasi baru
+func (s *AspirationService) notifyStaff(aspirationID uint, title, message string) {
+	if s.notificationService == nil {
+		return
+	}
+	_, err := s.notificationService.CreateNotification(title, message, NotificationTarget{
+		Type:       models.NotificationTypeAspiration,
+		EntityType: models.NotificationTypeAspiration,
+		EntityID:   aspirationID,
+	})
+	if err != nil {
+		log.Printf("Gagal mengirim notifikasi aspirasi: %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -10,19 +12,85 @@ import (
 )
 
 type AspirationService struct {
-	repository *repositories.AspirationRepository
-	db         *gorm.DB
+	repository          *repositories.AspirationRepository
+	studentRepo         *repositories.StudentRepository
+	organizationRepo    *repositories.OrganizationRepository
+	notificationService *NotificationService
+	db                  *gorm.DB
 }
 
-func NewAspirationService(db *gorm.DB) *AspirationService {
+func NewAspirationService(db *gorm.DB, notificationService *NotificationService) *AspirationService {
 	return &AspirationService{
-		repository: repositories.NewAspirationRepository(),
-		db:         db, // ✅ tambahkan ini biar Preload jalan
+		repository:          repositories.NewAspirationRepository(db),
+		studentRepo:         repositories.NewStudentRepository(),
+		organizationRepo:    repositories.NewOrganizationRepository(),
+		notificationService: notificationService,
+		db:                  db, // ✅ tambahkan ini biar Preload jalan
 	}
 }
 
-func (s *AspirationService) CreateAspiration(aspiration *models.Aspiration) error {
-	return s.repository.Create(aspiration)
+// CreateAspiration menyimpan aspirasi baru dengan status submitted selama ada jendela
+// penerimaan yang terbuka (aspirasi ditujukan ke organisasi tujuan jendela, jika ada);
+// field siklus tanggapan dari input diabaikan. submitter dipakai untuk batas pengajuan
+// harian. Untuk aspirasi anonim username tidak disimpan dan kode pelacakan dikembalikan
+// (sekali ini saja).
+func (s *AspirationService) CreateAspiration(aspiration *models.Aspiration, submitter string) (string, error) {
+	now := time.Now()
+	aspiration.ID = 0
+	aspiration.Status = models.AspirationStatusSubmitted
+	aspiration.OrganizationID = nil
+	aspiration.WindowID = nil
+	aspiration.AwaitingSince = &now
+	aspiration.FirstResponseAt = nil
+	aspiration.ClosedAt = nil
+	aspiration.SLABreachedAt = nil
+	aspiration.TrackingCodeHash = nil
+	aspiration.VoteCount = 0
+	aspiration.MergedIntoID = nil
+	aspiration.CommentsLocked = false
+	aspiration.Responses = nil
+	aspiration.History = nil
+
+	var trackingCode string
+	if aspiration.Anonymous {
+		var err error
+		if trackingCode, err = newTrackingCode(); err != nil {
+			return "", err
+		}
+		hash := trackingCodeHash(trackingCode)
+		aspiration.TrackingCodeHash = &hash
+		aspiration.UserName = ""
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		window, err := checkAspirationWindow(repositories.NewAspirationWindowRepository(tx), aspiration.Category, now)
+		if err != nil {
+			return err
+		}
+		aspiration.WindowID = &window.ID
+		aspiration.OrganizationID = window.OrganizationID
+
+		repo := repositories.NewAspirationRepository(tx)
+		if err := checkAspirationRateLimit(repo, submitter, now); err != nil {
+			return err
+		}
+		if err := repo.Create(aspiration); err != nil {
+			return err
+		}
+		return repo.CreateHistory(&models.AspirationStatusHistory{
+			AspirationID: aspiration.ID,
+			ToStatus:     models.AspirationStatusSubmitted,
+			ChangedBy:    aspiration.UserName,
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	if aspiration.OrganizationID != nil {
+		s.notifyOrganization(*aspiration.OrganizationID, aspiration.ID, "Aspirasi Baru",
+			fmt.Sprintf("Aspirasi \"%s\" masuk untuk organisasi Anda.", aspiration.Title))
+	}
+	return trackingCode, nil
 }
 
 func (s *AspirationService) UpdateAspiration(aspiration *models.Aspiration) error {
@@ -44,25 +112,31 @@ func (s *AspirationService) GetAspirationByID(id uint) (*models.Aspiration, erro
 	return &aspiration, nil
 }
 
-func (s *AspirationService) GetAllAspirations(limit, offset int) ([]models.Aspiration, int64, error) {
-	var aspirations []models.Aspiration
-	var total int64
-
-	// ✅ Hitung total aspirasi
-	if err := s.db.Model(&models.Aspiration{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+// GetAllAspirations mengambil aspirasi sesuai filter; overdue hanya mengambil aspirasi
+// yang melewati SLA tanggapan
+func (s *AspirationService) GetAllAspirations(filter repositories.AspirationFilter, overdue bool, limit, offset int) ([]models.Aspiration, int64, error) {
+	if filter.Status != "" && !validAspirationStatus(filter.Status) {
+		return nil, 0, ErrAspirationStatus
+	}
+	now := time.Now()
+	if overdue {
+		cutoff := now.Add(-aspirationSLA())
+		filter.OverdueBefore = &cutoff
 	}
 
-	// ✅ Ambil aspirasi + preload student (join ke tabel students)
-	if err := s.db.Preload("Student").Limit(limit).Offset(offset).Find(&aspirations).Error; err != nil {
+	aspirations, total, err := s.repository.GetAllAspirations(filter, limit, offset)
+	if err != nil {
 		return nil, 0, err
 	}
 
 	// ✅ Kalau ada aspirasi yang belum punya student, hindari null pointer
 	for i := range aspirations {
-		if aspirations[i].Student.FullName == "" {
+		if aspirations[i].Anonymous {
+			maskAnonymous(&aspirations[i])
+		} else if aspirations[i].Student.FullName == "" {
 			aspirations[i].Student.FullName = "-"
 		}
+		applySLA(&aspirations[i], now)
 	}
 
 	return aspirations, total, nil
@@ -0,0 +1,529 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
+)
+
+var (
+	ErrAspirationNotPublic = errors.New("aspirasi ini tidak publik")
+	ErrCommentNotFound     = errors.New("komentar tidak ditemukan")
+	ErrNotStudent          = errors.New("hanya mahasiswa terdaftar yang bisa mendukung atau berkomentar")
+)
+
+// MostSupportedReport adalah aspirasi dengan dukungan terbanyak pada satu periode
+type MostSupportedReport struct {
+	Start time.Time                  `json:"start"`
+	End   time.Time                  `json:"end"`
+	Items []models.AspirationSupport `json:"items"`
+}
+
+// aspirationTrendingWindow membaca ASPIRATION_TRENDING_DAYS: hanya dukungan dalam rentang
+// ini yang dihitung untuk trending
+func aspirationTrendingWindow() time.Duration {
+	days := utils.GetEnvAsInt("ASPIRATION_TRENDING_DAYS", 7)
+	if days < 1 {
+		days = 1
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// aspirationTrendingHalfLife membaca ASPIRATION_TRENDING_HALF_LIFE_HOURS: bobot dukungan
+// berkurang separuhnya setiap rentang ini
+func aspirationTrendingHalfLife() time.Duration {
+	hours := utils.GetEnvAsInt("ASPIRATION_TRENDING_HALF_LIFE_HOURS", 48)
+	if hours < 1 {
+		hours = 1
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// Vote mendukung aspirasi publik; setiap mahasiswa hanya dihitung sekali
+func (s *AspirationService) Vote(id uint, username string) (*models.Aspiration, error) {
+	return s.setVote(id, username, true)
+}
+
+// Unvote membatalkan dukungan
+func (s *AspirationService) Unvote(id uint, username string) (*models.Aspiration, error) {
+	return s.setVote(id, username, false)
+}
+
+// checkParticipant memastikan pendukung atau komentator adalah mahasiswa terdaftar
+func (s *AspirationService) checkParticipant(username string) error {
+	if username == "" {
+		return ErrNotStudent
+	}
+	student, err := s.studentRepo.FindByUserID(username)
+	if err != nil {
+		return err
+	}
+	if student == nil {
+		return ErrNotStudent
+	}
+	return nil
+}
+
+func (s *AspirationService) setVote(id uint, username string, vote bool) (*models.Aspiration, error) {
+	if err := s.checkParticipant(username); err != nil {
+		return nil, err
+	}
+	var aspiration *models.Aspiration
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewAspirationRepository(tx)
+		var err error
+		if aspiration, err = lockAspiration(repo, id); err != nil {
+			return err
+		}
+		if err := checkBoardOpen(aspiration); err != nil {
+			return err
+		}
+		var changed bool
+		if vote {
+			changed, err = repo.AddVote(&models.AspirationVote{AspirationID: id, Username: username})
+		} else {
+			changed, err = repo.RemoveVote(id, username)
+		}
+		if err != nil || !changed {
+			return err
+		}
+		if err := repo.RefreshVoteCount(id); err != nil {
+			return err
+		}
+		aspiration, err = repo.FindByID(id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	aspiration.Voted = vote
+	maskAnonymous(aspiration)
+	return aspiration, nil
+}
+
+// checkBoardOpen memastikan aspirasi masih bisa didukung: publik, belum digabung dan
+// belum ditutup
+func checkBoardOpen(aspiration *models.Aspiration) error {
+	if aspiration.MergedIntoID != nil {
+		return fmt.Errorf("aspirasi ini sudah digabung ke aspirasi #%d", *aspiration.MergedIntoID)
+	}
+	if !aspiration.Public {
+		return ErrAspirationNotPublic
+	}
+	if aspiration.Status == models.AspirationStatusClosed {
+		return ErrAspirationClosed
+	}
+	return nil
+}
+
+// GetBoard mengambil papan aspirasi publik (sort "votes" atau terbaru) dengan penanda
+// dukungan pengguna
+func (s *AspirationService) GetBoard(username, sort string, limit, offset int) ([]models.Aspiration, int64, error) {
+	aspirations, total, err := s.repository.GetPublic(sort, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.markVoted(username, aspirations); err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	for i := range aspirations {
+		maskAnonymous(&aspirations[i])
+		applySLA(&aspirations[i], now)
+	}
+	return aspirations, total, nil
+}
+
+// markVoted mengisi penanda Voted untuk aspirasi yang sudah didukung username
+func (s *AspirationService) markVoted(username string, aspirations []models.Aspiration) error {
+	ids := make([]uint, len(aspirations))
+	for i, aspiration := range aspirations {
+		ids[i] = aspiration.ID
+	}
+	voted, err := s.repository.GetVotedIDs(username, ids)
+	if err != nil {
+		return err
+	}
+	for i := range aspirations {
+		aspirations[i].Voted = containsUint(voted, aspirations[i].ID)
+	}
+	return nil
+}
+
+// GetTrending mengurutkan aspirasi publik berdasarkan dukungan terbaru. Tiap dukungan
+// dalam ASPIRATION_TRENDING_DAYS terakhir berbobot 0.5^(umur/half-life), sehingga
+// aspirasi yang sedang ramai didukung naik di atas aspirasi lama dengan total lebih besar.
+func (s *AspirationService) GetTrending(username string, limit int, now time.Time) ([]models.AspirationSupport, error) {
+	votes, err := s.repository.GetPublicVotesSince(now.Add(-aspirationTrendingWindow()))
+	if err != nil {
+		return nil, err
+	}
+	halfLife := aspirationTrendingHalfLife().Hours()
+	scores := map[uint]float64{}
+	counts := map[uint]int{}
+	for _, vote := range votes {
+		age := math.Max(now.Sub(vote.CreatedAt).Hours(), 0)
+		scores[vote.AspirationID] += math.Pow(0.5, age/halfLife)
+		counts[vote.AspirationID]++
+	}
+
+	ids := make([]uint, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] > ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	items := make([]models.AspirationSupport, 0, len(ids))
+	aspirations, err := s.supportedAspirations(username, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if aspiration, ok := aspirations[id]; ok {
+			items = append(items, models.AspirationSupport{
+				Aspiration: aspiration,
+				Votes:      counts[id],
+				Score:      math.Round(scores[id]*100) / 100,
+			})
+		}
+	}
+	return items, nil
+}
+
+// GetMostSupported melaporkan aspirasi publik dengan dukungan terbanyak yang masuk pada
+// [start, end); default bulan berjalan
+func (s *AspirationService) GetMostSupported(username string, start, end *time.Time, limit int) (*MostSupportedReport, error) {
+	now := time.Now().In(calendarLocation())
+	report := &MostSupportedReport{
+		Start: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()),
+	}
+	if start != nil {
+		report.Start = *start
+	}
+	report.End = report.Start.AddDate(0, 1, 0)
+	if end != nil {
+		report.End = *end
+	}
+	if !report.End.After(report.Start) {
+		return nil, errors.New("akhir periode harus setelah awal periode")
+	}
+
+	counts, err := s.repository.GetMostSupported(report.Start, report.End, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(counts))
+	for i, count := range counts {
+		ids[i] = count.AspirationID
+	}
+	aspirations, err := s.supportedAspirations(username, ids)
+	if err != nil {
+		return nil, err
+	}
+	report.Items = make([]models.AspirationSupport, 0, len(counts))
+	for _, count := range counts {
+		if aspiration, ok := aspirations[count.AspirationID]; ok {
+			report.Items = append(report.Items, models.AspirationSupport{Aspiration: aspiration, Votes: count.Votes})
+		}
+	}
+	return report, nil
+}
+
+// supportedAspirations mengambil aspirasi publik berdasarkan id, siap ditampilkan
+func (s *AspirationService) supportedAspirations(username string, ids []uint) (map[uint]models.Aspiration, error) {
+	aspirations, err := s.repository.FindPublicByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.markVoted(username, aspirations); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := make(map[uint]models.Aspiration, len(aspirations))
+	for i := range aspirations {
+		maskAnonymous(&aspirations[i])
+		applySLA(&aspirations[i], now)
+		result[aspirations[i].ID] = aspirations[i]
+	}
+	return result, nil
+}
+
+// AspirationVisibility adalah perubahan visibilitas aspirasi; nil berarti tidak diubah
+type AspirationVisibility struct {
+	Public         *bool `json:"public"`
+	CommentsLocked *bool `json:"comments_locked"`
+}
+
+// SetVisibility mengubah apakah aspirasi tampil di papan publik dan apakah komentar
+// dikunci. Pengaju boleh mengubah visibilitas aspirasinya; penguncian komentar hanya
+// oleh pengurus.
+func (s *AspirationService) SetVisibility(id uint, input AspirationVisibility, username string, isAdmin bool) (*models.Aspiration, error) {
+	var aspiration *models.Aspiration
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewAspirationRepository(tx)
+		var err error
+		if aspiration, err = lockAspiration(repo, id); err != nil {
+			return err
+		}
+		submitter := isAspirationSubmitter(aspiration, username)
+		staff, err := s.isAspirationStaff(aspiration, username, isAdmin)
+		if err != nil {
+			return err
+		}
+		if !staff && (!submitter || input.CommentsLocked != nil) {
+			return ErrAspirationForbidden
+		}
+		if input.Public != nil {
+			if *input.Public && aspiration.MergedIntoID != nil {
+				return fmt.Errorf("aspirasi ini sudah digabung ke aspirasi #%d", *aspiration.MergedIntoID)
+			}
+			aspiration.Public = *input.Public
+		}
+		if input.CommentsLocked != nil {
+			aspiration.CommentsLocked = *input.CommentsLocked
+		}
+		return repo.Update(aspiration)
+	})
+	if err != nil {
+		return nil, err
+	}
+	maskAnonymous(aspiration)
+	return aspiration, nil
+}
+
+// GetComments mengambil komentar aspirasi publik (pengaju dan pengurus juga bisa melihat
+// komentar aspirasi yang sudah tidak publik)
+func (s *AspirationService) GetComments(id uint, username string, isAdmin bool) ([]models.AspirationComment, error) {
+	aspiration, err := s.findAspiration(id)
+	if err != nil {
+		return nil, err
+	}
+	if !aspiration.Public && !isAspirationSubmitter(aspiration, username) {
+		staff, err := s.isAspirationStaff(aspiration, username, isAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if !staff {
+			return nil, ErrAspirationNotPublic
+		}
+	}
+	return s.repository.GetComments(id)
+}
+
+// AddComment menambahkan komentar selama aspirasi publik, belum ditutup atau digabung,
+// dan komentarnya tidak dikunci
+func (s *AspirationService) AddComment(id uint, username, content string) (*models.AspirationComment, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.New("komentar wajib diisi")
+	}
+	if err := s.checkParticipant(username); err != nil {
+		return nil, err
+	}
+	aspiration, err := s.findAspiration(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBoardOpen(aspiration); err != nil {
+		return nil, err
+	}
+	if aspiration.CommentsLocked {
+		return nil, errors.New("komentar aspirasi ini dikunci")
+	}
+	comment := &models.AspirationComment{AspirationID: id, Username: username, Content: content}
+	if err := s.repository.CreateComment(comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteComment menghapus komentar milik sendiri; pengurus boleh menghapus komentar siapa pun
+func (s *AspirationService) DeleteComment(id, commentID uint, username string, isAdmin bool) error {
+	comment, err := s.repository.FindComment(commentID)
+	if err != nil || comment.AspirationID != id {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCommentNotFound
+		}
+		return err
+	}
+	if !strings.EqualFold(comment.Username, username) {
+		aspiration, err := s.findAspiration(id)
+		if err != nil {
+			return err
+		}
+		staff, err := s.isAspirationStaff(aspiration, username, isAdmin)
+		if err != nil {
+			return err
+		}
+		if !staff {
+			return ErrAspirationForbidden
+		}
+	}
+	return s.repository.DeleteComment(commentID)
+}
+
+// MergeAspiration menggabungkan aspirasi duplikat ke aspirasi tujuan: dukungan dipindah
+// (pendukung keduanya dihitung sekali), aspirasi duplikat ditutup dengan rujukan ke
+// tujuan, lalu semua pendukung dan pengaju keduanya dikabari.
+func (s *AspirationService) MergeAspiration(sourceID, targetID uint, note, username string, isAdmin bool) (*models.Aspiration, error) {
+	staff, err := s.isAspirationStaff(nil, username, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if !staff {
+		return nil, ErrAspirationForbidden
+	}
+	if sourceID == targetID {
+		return nil, errors.New("aspirasi tidak bisa digabung ke dirinya sendiri")
+	}
+
+	var source, target *models.Aspiration
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewAspirationRepository(tx)
+		// Kunci berurutan berdasarkan id agar dua penggabungan serentak tidak saling menunggu
+		first, second := sourceID, targetID
+		if first > second {
+			first, second = second, first
+		}
+		locked := map[uint]*models.Aspiration{}
+		for _, id := range []uint{first, second} {
+			aspiration, err := lockAspiration(repo, id)
+			if err != nil {
+				return err
+			}
+			locked[id] = aspiration
+		}
+		source, target = locked[sourceID], locked[targetID]
+
+		if source.MergedIntoID != nil {
+			return fmt.Errorf("aspirasi #%d sudah digabung ke aspirasi #%d", source.ID, *source.MergedIntoID)
+		}
+		if target.MergedIntoID != nil {
+			return fmt.Errorf("aspirasi tujuan sudah digabung ke aspirasi #%d", *target.MergedIntoID)
+		}
+		if source.Public && !target.Public {
+			return errors.New("aspirasi tujuan harus publik agar dukungannya tetap terlihat")
+		}
+
+		if err := repo.MoveVotes(source.ID, target.ID); err != nil {
+			return err
+		}
+		for _, id := range []uint{source.ID, target.ID} {
+			if err := repo.RefreshVoteCount(id); err != nil {
+				return err
+			}
+		}
+		refreshed, err := repo.FindByID(target.ID)
+		if err != nil {
+			return err
+		}
+		target.VoteCount = refreshed.VoteCount
+
+		source.MergedIntoID = &target.ID
+		source.VoteCount = 0
+		mergeNote := fmt.Sprintf("Digabung ke aspirasi #%d", target.ID)
+		if note = strings.TrimSpace(note); note != "" {
+			mergeNote += ": " + note
+		}
+		if source.Status == models.AspirationStatusClosed {
+			if err := repo.Update(source); err != nil {
+				return err
+			}
+			return repo.CreateHistory(&models.AspirationStatusHistory{
+				AspirationID: source.ID,
+				FromStatus:   source.Status,
+				ToStatus:     source.Status,
+				ChangedBy:    username,
+				Note:         mergeNote,
+			})
+		}
+		return transitionAspiration(repo, source, models.AspirationStatusClosed, nil, username, mergeNote)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyMerge(source, target)
+	maskAnonymous(target)
+	return target, nil
+}
+
+// notifyMerge mengabari pendukung (kini tercatat di aspirasi tujuan) dan pengaju kedua
+// aspirasi tentang penggabungan
+func (s *AspirationService) notifyMerge(source, target *models.Aspiration) {
+	if s.notificationService == nil {
+		return
+	}
+	usernames, err := s.repository.GetVoterUsernames([]uint{target.ID})
+	if err != nil {
+		log.Printf("Gagal mengambil pendukung aspirasi %d: %v", target.ID, err)
+	}
+	usernames = append(usernames, source.UserName, target.UserName)
+
+	seen := map[string]bool{}
+	recipients := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		key := strings.ToLower(username)
+		if username == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		recipients = append(recipients, username)
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	title := "Aspirasi Digabung"
+	message := fmt.Sprintf("Aspirasi \"%s\" digabung dengan \"%s\" karena membahas hal yang sama. Dukungan kini dihitung bersama (%d dukungan).",
+		source.Title, target.Title, target.VoteCount)
+	_, err = s.notificationService.CreateNotificationForUsers(title, message, NotificationTarget{
+		Type:       models.NotificationTypeAspiration,
+		EntityType: models.NotificationTypeAspiration,
+		EntityID:   target.ID,
+		Event:      models.WebhookEventAspirationUpdated,
+		Entity:     AspirationWebhookEntity(target),
+	}, recipients)
+	if err != nil {
+		log.Printf("Gagal mengirim notifikasi penggabungan aspirasi %d: %v", source.ID, err)
+	}
+}
+
+// findAspiration mengambil aspirasi tanpa relasi
+func (s *AspirationService) findAspiration(id uint) (*models.Aspiration, error) {
+	aspiration, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAspirationNotFound
+		}
+		return nil, err
+	}
+	return aspiration, nil
+}
+
+func containsUint(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
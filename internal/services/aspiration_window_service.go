@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+)
+
+var (
+	ErrAspirationWindowNotFound = errors.New("jendela aspirasi tidak ditemukan")
+	ErrAspirationIntakeClosed   = errors.New("penerimaan aspirasi sedang ditutup")
+	ErrAspirationCategory       = errors.New("kategori tidak diterima pada periode aspirasi ini")
+)
+
+// AspirationWindowService mengelola jendela penerimaan aspirasi
+type AspirationWindowService struct {
+	repository       *repositories.AspirationWindowRepository
+	organizationRepo *repositories.OrganizationRepository
+}
+
+func NewAspirationWindowService(db *gorm.DB) *AspirationWindowService {
+	return &AspirationWindowService{
+		repository:       repositories.NewAspirationWindowRepository(db),
+		organizationRepo: repositories.NewOrganizationRepository(),
+	}
+}
+
+// AspirationIntakeStatus adalah status penerimaan aspirasi saat ini
+type AspirationIntakeStatus struct {
+	Open       bool                     `json:"open"`
+	Status     int                      `json:"status"` // 1 = buka, 0 = tutup (kompatibel dengan toggle lama)
+	Window     *models.AspirationWindow `json:"window"`
+	NextWindow *models.AspirationWindow `json:"next_window"`
+}
+
+// GetIntakeStatus mengembalikan jendela yang sedang terbuka dan jendela berikutnya
+func (s *AspirationWindowService) GetIntakeStatus(now time.Time) (*AspirationIntakeStatus, error) {
+	window, err := s.repository.FindOpen(now)
+	if err != nil {
+		return nil, err
+	}
+	next, err := s.repository.FindNext(now)
+	if err != nil {
+		return nil, err
+	}
+	status := &AspirationIntakeStatus{Window: window, NextWindow: next}
+	for _, w := range []*models.AspirationWindow{window, next} {
+		if w != nil {
+			w.State = w.StateAt(now)
+		}
+	}
+	if window != nil {
+		status.Open = true
+		status.Status = 1
+	}
+	return status, nil
+}
+
+// GetWindows mengambil riwayat jendela beserta jumlah aspirasi yang masuk
+func (s *AspirationWindowService) GetWindows() ([]models.AspirationWindow, error) {
+	windows, err := s.repository.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(windows))
+	for i, window := range windows {
+		ids[i] = window.ID
+	}
+	counts, err := s.repository.CountSubmissions(ids)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range windows {
+		windows[i].State = windows[i].StateAt(now)
+		windows[i].Submissions = counts[windows[i].ID]
+	}
+	return windows, nil
+}
+
+// GetWindow mengambil satu jendela beserta jumlah aspirasinya
+func (s *AspirationWindowService) GetWindow(id uint) (*models.AspirationWindow, error) {
+	window, err := s.findWindow(id)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.repository.CountSubmissions([]uint{id})
+	if err != nil {
+		return nil, err
+	}
+	window.State = window.StateAt(time.Now())
+	window.Submissions = counts[id]
+	return window, nil
+}
+
+func (s *AspirationWindowService) CreateWindow(window *models.AspirationWindow, username string) error {
+	window.ID = 0
+	window.CreatedBy = username
+	if err := s.validateWindow(window); err != nil {
+		return err
+	}
+	if err := s.repository.Create(window); err != nil {
+		return err
+	}
+	window.State = window.StateAt(time.Now())
+	return nil
+}
+
+// UpdateWindow memperbarui jendela; mengubah closes_at dipakai untuk memperpanjang atau
+// memajukan penutupan. Waktu jendela yang sudah berjalan tidak bisa ditulis ulang (lihat
+// checkWindowTimes).
+func (s *AspirationWindowService) UpdateWindow(id uint, input models.AspirationWindow) (*models.AspirationWindow, error) {
+	window, err := s.findWindow(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWindowTimes(window, input, time.Now()); err != nil {
+		return nil, err
+	}
+	window.Name = input.Name
+	window.Description = input.Description
+	window.OpensAt = input.OpensAt
+	window.ClosesAt = input.ClosesAt
+	window.Categories = input.Categories
+	window.OrganizationID = input.OrganizationID
+	window.Organization = nil
+	if err := s.validateWindow(window); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Update(window); err != nil {
+		return nil, err
+	}
+	return s.GetWindow(id)
+}
+
+// checkWindowTimes menjaga riwayat penerimaan: opens_at jendela yang sudah dibuka tidak
+// bisa diubah, dan jendela yang sudah tertutup tidak bisa diubah waktunya sama sekali
+func checkWindowTimes(window *models.AspirationWindow, input models.AspirationWindow, now time.Time) error {
+	switch window.StateAt(now) {
+	case models.AspirationWindowClosed:
+		if !input.OpensAt.Equal(window.OpensAt) || !input.ClosesAt.Equal(window.ClosesAt) {
+			return errors.New("waktu jendela aspirasi yang sudah tertutup tidak bisa diubah")
+		}
+	case models.AspirationWindowOpen:
+		if !input.OpensAt.Equal(window.OpensAt) {
+			return errors.New("opens_at jendela aspirasi yang sedang terbuka tidak bisa diubah")
+		}
+	}
+	return nil
+}
+
+// CloseWindow menutup jendela yang sedang terbuka sekarang juga
+func (s *AspirationWindowService) CloseWindow(id uint) (*models.AspirationWindow, error) {
+	window, err := s.findWindow(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if window.StateAt(now) != models.AspirationWindowOpen {
+		return nil, errors.New("jendela aspirasi ini sedang tidak terbuka")
+	}
+	window.ClosesAt = now
+	window.Organization = nil
+	if err := s.repository.Update(window); err != nil {
+		return nil, err
+	}
+	return s.GetWindow(id)
+}
+
+// DeleteWindow menghapus jendela yang belum menerima aspirasi; jendela yang sudah
+// menerima aspirasi disimpan sebagai riwayat
+func (s *AspirationWindowService) DeleteWindow(id uint) error {
+	if _, err := s.findWindow(id); err != nil {
+		return err
+	}
+	counts, err := s.repository.CountSubmissions([]uint{id})
+	if err != nil {
+		return err
+	}
+	if counts[id] > 0 {
+		return fmt.Errorf("jendela aspirasi sudah menerima %d aspirasi; tutup jendela alih-alih menghapusnya", counts[id])
+	}
+	return s.repository.Delete(id)
+}
+
+func (s *AspirationWindowService) findWindow(id uint) (*models.AspirationWindow, error) {
+	window, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if window == nil {
+		return nil, ErrAspirationWindowNotFound
+	}
+	return window, nil
+}
+
+// validateWindow memeriksa nama, rentang waktu, organisasi tujuan dan irisan dengan
+// jendela lain, serta merapikan daftar kategori
+func (s *AspirationWindowService) validateWindow(window *models.AspirationWindow) error {
+	window.Name = strings.TrimSpace(window.Name)
+	if window.Name == "" {
+		return errors.New("nama jendela aspirasi wajib diisi")
+	}
+	if window.OpensAt.IsZero() || window.ClosesAt.IsZero() {
+		return errors.New("opens_at dan closes_at wajib diisi")
+	}
+	if !window.ClosesAt.After(window.OpensAt) {
+		return errors.New("closes_at harus setelah opens_at")
+	}
+	window.Categories = strings.Join(splitList(window.Categories), ", ")
+	if window.OrganizationID != nil {
+		if *window.OrganizationID <= 0 {
+			window.OrganizationID = nil
+		} else if _, err := s.organizationRepo.FindOrganizationByID(uint(*window.OrganizationID)); err != nil {
+			return errors.New("organisasi tujuan tidak ditemukan")
+		}
+	}
+	overlap, err := s.repository.FindOverlapping(window.OpensAt, window.ClosesAt, window.ID)
+	if err != nil {
+		return err
+	}
+	if overlap != nil {
+		return fmt.Errorf("jendela beririsan dengan %q (%s - %s)", overlap.Name,
+			overlap.OpensAt.In(calendarLocation()).Format("02-01-2006 15:04"),
+			overlap.ClosesAt.In(calendarLocation()).Format("02-01-2006 15:04"))
+	}
+	return nil
+}
+
+// checkAspirationWindow memastikan aspirasi dikirim saat ada jendela terbuka dan
+// kategorinya diterima; mengembalikan jendela tersebut
+func checkAspirationWindow(repo *repositories.AspirationWindowRepository, category string, now time.Time) (*models.AspirationWindow, error) {
+	window, err := repo.FindOpen(now)
+	if err != nil {
+		return nil, err
+	}
+	if window == nil {
+		return nil, ErrAspirationIntakeClosed
+	}
+	if categories := splitList(window.Categories); len(categories) > 0 && !containsFold(categories, strings.TrimSpace(category)) {
+		return nil, fmt.Errorf("%w (kategori yang diterima: %s)", ErrAspirationCategory, window.Categories)
+	}
+	return window, nil
+}
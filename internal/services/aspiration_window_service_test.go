@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"bem_be/internal/models"
+)
+
+func TestCheckWindowTimes(t *testing.T) {
+	opens := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	closes := opens.Add(7 * 24 * time.Hour)
+	window := &models.AspirationWindow{OpensAt: opens, ClosesAt: closes}
+	later := closes.Add(24 * time.Hour)
+
+	cases := []struct {
+		name    string
+		now     time.Time
+		input   models.AspirationWindow
+		wantErr bool
+	}{
+		{"belum dibuka boleh digeser", opens.Add(-time.Hour), models.AspirationWindow{OpensAt: opens.Add(time.Hour), ClosesAt: later}, false},
+		{"terbuka boleh diperpanjang", opens.Add(time.Hour), models.AspirationWindow{OpensAt: opens, ClosesAt: later}, false},
+		{"terbuka tidak boleh mengubah opens_at", opens.Add(time.Hour), models.AspirationWindow{OpensAt: opens.Add(-time.Hour), ClosesAt: closes}, true},
+		{"tertutup tanpa perubahan waktu", later, models.AspirationWindow{OpensAt: opens, ClosesAt: closes}, false},
+		{"tertutup tidak boleh dibuka ulang", later, models.AspirationWindow{OpensAt: opens, ClosesAt: later.Add(time.Hour)}, true},
+		{"tertutup tidak boleh digeser", later, models.AspirationWindow{OpensAt: opens.Add(time.Hour), ClosesAt: closes}, true},
+	}
+	for _, tc := range cases {
+		err := checkWindowTimes(window, tc.input, tc.now)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: err = %v; ingin error %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
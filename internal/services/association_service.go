@@ -3,13 +3,12 @@ package services
 import (
 	"gorm.io/gorm"
 	"errors"
-	"fmt"
 	"mime/multipart"
-	"os"
-	"time"
 
 	"bem_be/internal/models"
 	"bem_be/internal/repositories"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 )
 
 // AssociationService is a service for association operations
@@ -27,47 +26,31 @@ func NewAssociationService(db *gorm.DB) *AssociationService {
 
 // CreateAssociation creates a new association
 func (s *AssociationService) CreateAssociation(association *models.Organization, file *multipart.FileHeader) error {
-	// bikin folder kalau belum ada
-	if err := os.MkdirAll("uploads/associations", os.ModePerm); err != nil {
-		return err
-	}
-
-	// nama file unik
-	filename := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-	filepath := "uploads/associations/" + filename
-
-	// simpan file
-	if err := saveUploadedFile(file, filepath); err != nil {
+	// simpan gambar lewat pipeline (validasi isi, buang EXIF, buat varian)
+	filename, err := utils.SaveImage(file, storage.FolderAssociations, utils.ImagePolicy)
+	if err != nil {
 		return err
 	}
 
 	// simpan path/filename ke struct
 	association.Image = filename
 
-	// simpan ke DB
-	return s.repository.Create(association)
-}
-
-// helper function simpan file
-func saveUploadedFile(file *multipart.FileHeader, dst string) error {
-	src, err := file.Open()
-	if err != nil {
-		return err
-	}
-	defer src.Close()
-
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = out.ReadFrom(src)
+	// simpan ke DB; gambar dihapus lagi jika insert gagal
+	var files utils.FileChanges
+	files.Added(storage.FolderAssociations, filename)
+	err = s.repository.Create(association)
+	files.Done(err)
 	return err
 }
 
 // UpdateAssociation updates an existing association
-func (s *AssociationService) UpdateAssociation(association *models.Organization) error {
+func (s *AssociationService) UpdateAssociation(association *models.Organization) (err error) {
+	// Gambar baru (jika ada) sudah disimpan handler: dihapus jika update gagal,
+	// sebaliknya gambar lama yang digantikan yang dihapus
+	var files utils.FileChanges
+	files.Added(storage.FolderAssociations, association.Image)
+	defer func() { files.Done(err) }()
+
 	// Check if association exists
 	existingAssociation, err := s.repository.FindByID(association.ID)
 	if err != nil {
@@ -77,6 +60,10 @@ func (s *AssociationService) UpdateAssociation(association *models.Organization)
 		return errors.New("himpunan tidak ditemukan")
 	}
 
+	if association.Image != "" {
+		files.Replaced(storage.FolderAssociations, existingAssociation.Image)
+	}
+
 	// Update association
 	return s.repository.Update(association)
 }
@@ -0,0 +1,599 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bem_be/internal/models"
+	"bem_be/internal/utils"
+
+	"github.com/tealeg/xlsx/v3"
+	"gorm.io/gorm"
+)
+
+// Error presensi event
+var (
+	ErrAttendanceCodeInvalid = errors.New("kode presensi tidak valid atau sudah kedaluwarsa")
+	ErrAlreadyCheckedIn      = errors.New("sudah tercatat hadir di kemunculan event ini")
+)
+
+// maxCheckInMinutes membatasi jendela check-in sebelum dan sesudah event (24 jam)
+const maxCheckInMinutes = 24 * 60
+
+// AttendanceSettings adalah pengaturan presensi event dari penyelenggara
+type AttendanceSettings struct {
+	CheckInOpensBefore int      `json:"check_in_opens_before"`
+	CheckInClosesAfter int      `json:"check_in_closes_after"`
+	Latitude           *float64 `json:"latitude"`
+	Longitude          *float64 `json:"longitude"`
+	GeofenceRadius     int      `json:"geofence_radius"`
+}
+
+// AttendanceQR adalah isi QR presensi yang sedang berlaku untuk satu kemunculan
+type AttendanceQR struct {
+	Code            string    `json:"code"`
+	OccurrenceStart time.Time `json:"occurrence_start"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	RefreshIn       int       `json:"refresh_in"` // detik sampai QR berganti
+}
+
+// AttendanceTicket adalah tiket pribadi peserta yang dipindai penyelenggara saat check-in
+type AttendanceTicket struct {
+	Code     string `json:"code"`
+	EventID  uint   `json:"event_id"`
+	Username string `json:"username"`
+}
+
+// ManualCheckIn adalah data check-in yang dicatat penyelenggara. Mahasiswa dikenali
+// dari username atau NIM; Occurrence kosong berarti kemunculan yang sedang berlangsung.
+type ManualCheckIn struct {
+	Username   string     `json:"username"`
+	NIM        string     `json:"nim"`
+	Occurrence *time.Time `json:"occurrence"`
+}
+
+// AttendanceSummary merangkum kehadiran event (atau satu kemunculannya)
+type AttendanceSummary struct {
+	OccurrenceStart *time.Time `json:"occurrence_start,omitempty"`
+	Registered      int64      `json:"registered"`
+	Present         int64      `json:"present"`
+}
+
+// validateAttendanceSettings memeriksa jendela check-in dan geofence event
+func validateAttendanceSettings(event *models.Calender) error {
+	if event.CheckInOpensBefore < 0 || event.CheckInOpensBefore > maxCheckInMinutes ||
+		event.CheckInClosesAfter < 0 || event.CheckInClosesAfter > maxCheckInMinutes {
+		return fmt.Errorf("jendela check-in harus antara 0 dan %d menit", maxCheckInMinutes)
+	}
+	if event.GeofenceRadius < 0 {
+		return errors.New("radius geofence tidak boleh negatif")
+	}
+	if (event.Latitude == nil) != (event.Longitude == nil) {
+		return errors.New("latitude dan longitude harus diisi bersamaan")
+	}
+	if event.Latitude != nil && !utils.ValidCoordinate(*event.Latitude, *event.Longitude) {
+		return errors.New("koordinat lokasi event tidak valid")
+	}
+	if event.GeofenceRadius > 0 && event.Latitude == nil {
+		return errors.New("geofence membutuhkan koordinat lokasi event")
+	}
+	return nil
+}
+
+// attendanceQRPeriod adalah lama satu QR presensi berlaku (ATTENDANCE_QR_PERIOD detik,
+// default 30)
+func attendanceQRPeriod() int64 {
+	period := utils.GetEnvAsInt("ATTENDANCE_QR_PERIOD", 30)
+	if period < 10 {
+		period = 10
+	}
+	return int64(period)
+}
+
+// UpdateAttendanceSettings mengubah jendela check-in dan geofence event
+func (s *CalenderService) UpdateAttendanceSettings(eventID uint, settings AttendanceSettings) (*models.Calender, error) {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return nil, err
+	}
+	event.CheckInOpensBefore = settings.CheckInOpensBefore
+	event.CheckInClosesAfter = settings.CheckInClosesAfter
+	event.Latitude = settings.Latitude
+	event.Longitude = settings.Longitude
+	event.GeofenceRadius = settings.GeofenceRadius
+	if err := validateAttendanceSettings(event); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Update(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetAttendanceQR membuat QR presensi yang berlaku sekarang. QR berganti setiap
+// ATTENDANCE_QR_PERIOD detik sehingga foto QR yang dibagikan cepat kedaluwarsa.
+func (s *CalenderService) GetAttendanceQR(eventID uint, occurrence *time.Time) (*AttendanceQR, error) {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	current, err := s.resolveOccurrence(event, occurrence, now)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.attendanceSecret(event)
+	if err != nil {
+		return nil, err
+	}
+
+	period := attendanceQRPeriod()
+	step := now.Unix() / period
+	start := occurrenceKey(current)
+	payload := fmt.Sprintf("q.%d.%d.%d", event.ID, start.Unix(), step)
+	expiresAt := time.Unix((step+1)*period, 0)
+	return &AttendanceQR{
+		Code:            payload + "." + attendanceSignature(secret, payload),
+		OccurrenceStart: start,
+		ExpiresAt:       expiresAt,
+		RefreshIn:       int(expiresAt.Sub(now).Seconds()) + 1,
+	}, nil
+}
+
+// CheckIn mencatat kehadiran mahasiswa dari QR yang dipindai. QR periode sebelumnya
+// masih diterima untuk menutup jeda pemindaian. Jika geofence aktif, lokasi perangkat
+// wajib dikirim dan berada di dalam radius.
+func (s *CalenderService) CheckIn(username, code string, latitude, longitude *float64) (*models.EventAttendance, error) {
+	if username == "" {
+		return nil, errors.New("username wajib diisi")
+	}
+	parts := strings.Split(strings.TrimSpace(code), ".")
+	if len(parts) != 5 || parts[0] != "q" {
+		return nil, ErrAttendanceCodeInvalid
+	}
+	eventID, err1 := strconv.ParseUint(parts[1], 10, 64)
+	occurrenceUnix, err2 := strconv.ParseInt(parts[2], 10, 64)
+	step, err3 := strconv.ParseInt(parts[3], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, ErrAttendanceCodeInvalid
+	}
+
+	event, err := s.findEvent(uint(eventID))
+	if err != nil {
+		if errors.Is(err, ErrEventNotFound) {
+			return nil, ErrAttendanceCodeInvalid
+		}
+		return nil, err
+	}
+	payload := strings.Join(parts[:4], ".")
+	if event.ParentID != nil || !verifyAttendanceSignature(event.AttendanceSecret, payload, parts[4]) {
+		return nil, ErrAttendanceCodeInvalid
+	}
+	now := time.Now()
+	if current := now.Unix() / attendanceQRPeriod(); step != current && step != current-1 {
+		return nil, ErrAttendanceCodeInvalid
+	}
+
+	occurrence, err := s.currentOccurrence(event, now)
+	if err != nil {
+		return nil, err
+	}
+	if occurrenceKey(occurrence).Unix() != occurrenceUnix {
+		return nil, ErrAttendanceCodeInvalid
+	}
+	if err := s.checkAttendeeRegistered(event, username); err != nil {
+		return nil, err
+	}
+
+	attendance := &models.EventAttendance{
+		Username:  username,
+		Method:    models.AttendanceMethodQR,
+		Latitude:  latitude,
+		Longitude: longitude,
+	}
+	if event.GeofenceRadius > 0 {
+		// geofence tanpa titik lokasi tidak pernah dilewati diam-diam
+		if event.Latitude == nil || event.Longitude == nil {
+			return nil, errors.New("lokasi event belum diatur penyelenggara, check-in dengan geofence tidak bisa dilakukan")
+		}
+		if latitude == nil || longitude == nil {
+			return nil, errors.New("lokasi perangkat wajib dikirim untuk check-in event ini")
+		}
+		if !utils.ValidCoordinate(*latitude, *longitude) {
+			return nil, errors.New("koordinat lokasi tidak valid")
+		}
+		distance := utils.DistanceMeters(*event.Latitude, *event.Longitude, *latitude, *longitude)
+		if distance > float64(event.GeofenceRadius) {
+			return nil, fmt.Errorf("kamu berada %.0f m dari lokasi event (maksimal %d m)", distance, event.GeofenceRadius)
+		}
+		attendance.Distance = &distance
+	}
+	return s.recordAttendance(event, occurrence, attendance)
+}
+
+// GetAttendanceTicket mengambil tiket presensi peserta terdaftar
+func (s *CalenderService) GetAttendanceTicket(eventID uint, username string) (*AttendanceTicket, error) {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return nil, err
+	}
+	registration, err := s.repository.FindRegistration(event.ID, username)
+	if err != nil {
+		return nil, err
+	}
+	if registration == nil || registration.Status != models.EventRegistrationRegistered {
+		return nil, errors.New("tiket hanya tersedia untuk peserta terdaftar")
+	}
+	secret, err := s.attendanceSecret(event)
+	if err != nil {
+		return nil, err
+	}
+	payload := fmt.Sprintf("t.%d", registration.ID)
+	return &AttendanceTicket{
+		Code:     payload + "." + attendanceSignature(secret, payload),
+		EventID:  event.ID,
+		Username: username,
+	}, nil
+}
+
+// CheckInTicket mencatat kehadiran dari tiket peserta yang dipindai penyelenggara pada
+// kemunculan yang sedang berlangsung
+func (s *CalenderService) CheckInTicket(eventID uint, code, organizer string) (*models.EventAttendance, error) {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(strings.TrimSpace(code), ".")
+	if len(parts) != 3 || parts[0] != "t" {
+		return nil, ErrAttendanceCodeInvalid
+	}
+	registrationID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || !verifyAttendanceSignature(event.AttendanceSecret, parts[0]+"."+parts[1], parts[2]) {
+		return nil, ErrAttendanceCodeInvalid
+	}
+	registration, err := s.repository.FindRegistrationByID(uint(registrationID))
+	if err != nil {
+		return nil, err
+	}
+	if registration == nil || registration.EventID != event.ID {
+		return nil, ErrAttendanceCodeInvalid
+	}
+	if registration.Status != models.EventRegistrationRegistered {
+		return nil, fmt.Errorf("%s masih di daftar tunggu", registration.Username)
+	}
+
+	occurrence, err := s.currentOccurrence(event, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.recordAttendance(event, occurrence, &models.EventAttendance{
+		Username:    registration.Username,
+		Method:      models.AttendanceMethodTicket,
+		CheckedInBy: organizer,
+	})
+}
+
+// ManualCheckInAttendee mencatat kehadiran oleh penyelenggara, misalnya peserta on the
+// spot. Tidak memeriksa pendaftaran maupun geofence.
+func (s *CalenderService) ManualCheckInAttendee(eventID uint, input ManualCheckIn, organizer string) (*models.EventAttendance, error) {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return nil, err
+	}
+	username := strings.TrimSpace(input.Username)
+	if nim := strings.TrimSpace(input.NIM); nim != "" {
+		student, err := s.studentRepo.FindByNIM(nim)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("mahasiswa dengan NIM %s tidak ditemukan", nim)
+			}
+			return nil, err
+		}
+		username = student.UserName
+	}
+	if username == "" {
+		return nil, errors.New("username atau NIM wajib diisi")
+	}
+
+	occurrence, err := s.resolveOccurrence(event, input.Occurrence, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.recordAttendance(event, occurrence, &models.EventAttendance{
+		Username:    username,
+		Method:      models.AttendanceMethodManual,
+		CheckedInBy: organizer,
+	})
+}
+
+// RemoveAttendance menghapus kehadiran yang salah dicatat
+func (s *CalenderService) RemoveAttendance(eventID uint, username string, occurrence *time.Time) error {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return err
+	}
+	current, err := s.resolveOccurrence(event, occurrence, time.Now())
+	if err != nil {
+		return err
+	}
+	deleted, err := s.repository.DeleteAttendance(event.ID, occurrenceKey(current), username)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%s belum tercatat hadir di kemunculan ini", username)
+	}
+	return nil
+}
+
+// GetAttendance mengambil daftar hadir event beserta ringkasannya; occurrence nil
+// berarti semua kemunculan
+func (s *CalenderService) GetAttendance(eventID uint, occurrence *time.Time) ([]models.EventAttendee, *AttendanceSummary, error) {
+	event, start, err := s.attendanceFilter(eventID, occurrence)
+	if err != nil {
+		return nil, nil, err
+	}
+	attendees, err := s.repository.GetAttendees(event.ID, start)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summary := &AttendanceSummary{OccurrenceStart: start}
+	if summary.Registered, err = s.repository.CountRegistrations(event.ID, models.EventRegistrationRegistered); err != nil {
+		return nil, nil, err
+	}
+	if summary.Present, err = s.repository.CountAttendance(event.ID, start); err != nil {
+		return nil, nil, err
+	}
+	return attendees, summary, nil
+}
+
+// ExportAttendance membuat file xlsx daftar hadir event; mengembalikan isi file dan nama
+// file yang disarankan
+func (s *CalenderService) ExportAttendance(eventID uint, occurrence *time.Time) ([]byte, string, error) {
+	event, start, err := s.attendanceFilter(eventID, occurrence)
+	if err != nil {
+		return nil, "", err
+	}
+	attendees, err := s.repository.GetAttendees(event.ID, start)
+	if err != nil {
+		return nil, "", err
+	}
+
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet("Kehadiran")
+	if err != nil {
+		return nil, "", err
+	}
+	header := sheet.AddRow()
+	for _, title := range []string{"No", "Kemunculan", "NIM", "Nama", "Username", "Email", "Fakultas", "Program Studi", "Angkatan", "Metode", "Waktu Hadir", "Dicatat Oleh"} {
+		header.AddCell().SetString(title)
+	}
+
+	loc := calendarLocation()
+	for i, attendee := range attendees {
+		row := sheet.AddRow()
+		row.AddCell().SetInt(i + 1)
+		row.AddCell().SetString(attendee.OccurrenceStart.In(loc).Format("02-01-2006 15:04"))
+		row.AddCell().SetString(attendee.NIM)
+		row.AddCell().SetString(attendee.FullName)
+		row.AddCell().SetString(attendee.Username)
+		row.AddCell().SetString(attendee.Email)
+		row.AddCell().SetString(attendee.Faculty)
+		row.AddCell().SetString(attendee.StudyProgram)
+		if attendee.YearEnrolled > 0 {
+			row.AddCell().SetInt(attendee.YearEnrolled)
+		} else {
+			row.AddCell().SetString("")
+		}
+		row.AddCell().SetString(attendanceMethodLabel(attendee.Method))
+		row.AddCell().SetString(attendee.CheckedInAt.In(loc).Format("02-01-2006 15:04"))
+		row.AddCell().SetString(attendee.CheckedInBy)
+	}
+	sheet.SetColWidth(2, 4, 24)
+	sheet.SetColWidth(6, 8, 24)
+
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		return nil, "", err
+	}
+	filename := "kehadiran-event-" + strconv.FormatUint(uint64(event.ID), 10)
+	if start != nil {
+		filename += "-" + start.In(loc).Format("20060102-1504")
+	}
+	return buf.Bytes(), filename + ".xlsx", nil
+}
+
+func attendanceMethodLabel(method string) string {
+	switch method {
+	case models.AttendanceMethodTicket:
+		return "Tiket"
+	case models.AttendanceMethodManual:
+		return "Manual"
+	default:
+		return "QR"
+	}
+}
+
+// GetMyAttendance mengambil riwayat kegiatan (kehadiran event) pengguna
+func (s *CalenderService) GetMyAttendance(username string) ([]models.EventAttendance, error) {
+	if username == "" {
+		return nil, errors.New("username wajib diisi")
+	}
+	return s.repository.GetUserAttendance(username)
+}
+
+// GetStudentAttendance mengambil riwayat kehadiran event seorang mahasiswa
+func (s *CalenderService) GetStudentAttendance(studentID uint) ([]models.EventAttendance, error) {
+	student, err := s.studentRepo.FindByID(studentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.GetUserAttendance(student.UserName)
+}
+
+// recordAttendance menyimpan kehadiran pada kemunculan occurrence dari seri event
+func (s *CalenderService) recordAttendance(event, occurrence *models.Calender, attendance *models.EventAttendance) (*models.EventAttendance, error) {
+	attendance.EventID = event.ID
+	attendance.OccurrenceStart = occurrenceKey(occurrence)
+	existing, err := s.repository.FindAttendance(event.ID, attendance.OccurrenceStart, attendance.Username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, ErrAlreadyCheckedIn
+	}
+	if err := s.repository.CreateAttendance(attendance); err != nil {
+		return nil, err
+	}
+	return attendance, nil
+}
+
+// checkAttendeeRegistered mewajibkan pendaftaran untuk event berkuota atau berfilter;
+// event terbuka bisa dihadiri semua mahasiswa yang login
+func (s *CalenderService) checkAttendeeRegistered(event *models.Calender, username string) error {
+	if event.Capacity == 0 && event.EligibleFaculties == "" && event.EligibleYears == "" && !event.MembersOnly {
+		return nil
+	}
+	registration, err := s.repository.FindRegistration(event.ID, username)
+	if err != nil {
+		return err
+	}
+	if registration == nil {
+		return errors.New("check-in hanya untuk peserta yang terdaftar")
+	}
+	if registration.Status != models.EventRegistrationRegistered {
+		return errors.New("kamu masih di daftar tunggu event ini")
+	}
+	return nil
+}
+
+// attendanceFilter mengambil seri event dan waktu mulai asli kemunculan yang diminta
+func (s *CalenderService) attendanceFilter(eventID uint, occurrence *time.Time) (*models.Calender, *time.Time, error) {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if occurrence == nil {
+		return event, nil, nil
+	}
+	found, err := s.findOccurrence(event, *occurrence)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := occurrenceKey(found)
+	return event, &start, nil
+}
+
+// resolveOccurrence mengambil kemunculan yang diminta, atau kemunculan yang jendela
+// check-in-nya sedang terbuka jika occurrence nil
+func (s *CalenderService) resolveOccurrence(event *models.Calender, occurrence *time.Time, now time.Time) (*models.Calender, error) {
+	if occurrence != nil {
+		return s.findOccurrence(event, *occurrence)
+	}
+	return s.currentOccurrence(event, now)
+}
+
+// currentOccurrence mencari kemunculan seri yang jendela check-in-nya memuat now; jika
+// lebih dari satu, dipilih yang waktu mulainya paling dekat
+func (s *CalenderService) currentOccurrence(event *models.Calender, now time.Time) (*models.Calender, error) {
+	before := time.Duration(event.CheckInOpensBefore) * time.Minute
+	after := time.Duration(event.CheckInClosesAfter) * time.Minute
+	from, to := now.Add(-after), now.Add(before)
+
+	candidates, err := seriesOccurrences(s.repository, event, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *models.Calender
+	for i := range candidates {
+		candidate := &candidates[i]
+		if now.Before(candidate.StartTime.Add(-before)) || now.After(candidate.EndTime.Add(after)) {
+			continue
+		}
+		if best == nil || absDuration(candidate.StartTime.Sub(now)) < absDuration(best.StartTime.Sub(now)) {
+			best = candidate
+		}
+	}
+	if best == nil {
+		return nil, errors.New("check-in event belum dibuka atau sudah ditutup")
+	}
+	return best, nil
+}
+
+// findOccurrence mengambil kemunculan seri berdasarkan waktu mulai aslinya
+func (s *CalenderService) findOccurrence(event *models.Calender, at time.Time) (*models.Calender, error) {
+	if !event.IsRecurring() {
+		if !at.Equal(event.StartTime) {
+			return nil, errors.New("kemunculan event tidak ditemukan")
+		}
+		return event, nil
+	}
+	override, err := s.repository.GetOverride(event.ID, at)
+	if err != nil {
+		return nil, err
+	}
+	if override != nil {
+		return override, nil
+	}
+	if !isOccurrence(event, at) {
+		return nil, errors.New("kemunculan event tidak ditemukan")
+	}
+	occurrence := *event
+	occurrence.StartTime = at
+	occurrence.EndTime = at.Add(event.EndTime.Sub(event.StartTime))
+	occurrence.OriginalStart = &at
+	return &occurrence, nil
+}
+
+// occurrenceKey adalah waktu mulai asli kemunculan, dipakai sebagai kunci kehadiran
+func occurrenceKey(occurrence *models.Calender) time.Time {
+	if occurrence.OriginalStart != nil {
+		return occurrence.OriginalStart.UTC()
+	}
+	return occurrence.StartTime.UTC()
+}
+
+// attendanceSecret mengambil secret penanda tangan QR dan tiket event, membuatnya jika
+// belum ada
+func (s *CalenderService) attendanceSecret(event *models.Calender) (string, error) {
+	if event.AttendanceSecret != "" {
+		return event.AttendanceSecret, nil
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	if event.AttendanceSecret, err = s.repository.SetAttendanceSecret(event.ID, secret); err != nil {
+		return "", err
+	}
+	return event.AttendanceSecret, nil
+}
+
+func attendanceSignature(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+func verifyAttendanceSignature(secret, payload, signature string) bool {
+	if secret == "" {
+		return false
+	}
+	return hmac.Equal([]byte(attendanceSignature(secret, payload)), []byte(signature))
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// calendarFeedPastDays adalah batas event lampau yang masih dimuat di feed .ics
+const calendarFeedPastDays = 180
+
+// ErrCalendarTokenInvalid dikembalikan jika token feed pribadi tidak dikenal
+var ErrCalendarTokenInvalid = errors.New("token kalender tidak valid")
+
+// CalendarFeedService membangun feed iCalendar (.ics) untuk kalender kegiatan
+type CalendarFeedService struct {
+	events        *repositories.CalenderRepository
+	tokens        *repositories.CalendarTokenRepository
+	organizations *repositories.OrganizationRepository
+	baseURL       string // URL publik API, dipakai untuk tautan langganan
+}
+
+func NewCalendarFeedService(db *gorm.DB) *CalendarFeedService {
+	return &CalendarFeedService{
+		events:        repositories.NewCalenderRepository(db),
+		tokens:        repositories.NewCalendarTokenRepository(db),
+		organizations: repositories.NewOrganizationRepository(),
+		baseURL:       utils.PublicBaseURL(),
+	}
+}
+
+// CalendarSubscription adalah token dan tautan langganan feed pribadi pengguna
+type CalendarSubscription struct {
+	Token     string `json:"token"`
+	FeedURL   string `json:"feed_url"`
+	WebcalURL string `json:"webcal_url"`
+}
+
+// PublicFeed membangun feed semua event
+func (s *CalendarFeedService) PublicFeed() (*Feed, error) {
+	events, err := s.events.GetFeedEvents(calendarFeedSince(), 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.render("Kalender Kegiatan BEM", events), nil
+}
+
+// OrganizationFeed membangun feed event milik satu organisasi (berdasarkan short name)
+func (s *CalendarFeedService) OrganizationFeed(shortName string) (*Feed, error) {
+	organization, err := s.organizations.FindOrganizationByShortName(shortName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("organisasi %q tidak ditemukan", shortName)
+		}
+		return nil, err
+	}
+	events, err := s.events.GetFeedEvents(calendarFeedSince(), organization.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.render("Kalender "+organization.Name, events), nil
+}
+
+// PersonalFeed membangun feed event yang didaftari pemilik token
+func (s *CalendarFeedService) PersonalFeed(token string) (*Feed, error) {
+	owner, err := s.tokens.FindByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		return nil, ErrCalendarTokenInvalid
+	}
+	events, err := s.events.GetRegisteredEvents(owner.Username, calendarFeedSince())
+	if err != nil {
+		return nil, err
+	}
+	return s.render("Kegiatan Saya", events), nil
+}
+
+// GetSubscription mengambil token feed pribadi pengguna, membuatnya jika belum ada
+func (s *CalendarFeedService) GetSubscription(username string) (*CalendarSubscription, error) {
+	token, err := s.tokens.FindByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return s.ResetSubscription(username)
+	}
+	return s.subscription(token.Token), nil
+}
+
+// ResetSubscription mengganti token feed pribadi; tautan lama langsung tidak berlaku
+func (s *CalendarFeedService) ResetSubscription(username string) (*CalendarSubscription, error) {
+	if username == "" {
+		return nil, errors.New("username wajib diisi")
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.Save(&models.CalendarToken{Username: username, Token: secret}); err != nil {
+		return nil, err
+	}
+	return s.subscription(secret), nil
+}
+
+func (s *CalendarFeedService) subscription(token string) *CalendarSubscription {
+	feedURL := s.baseURL + "/api/calendar/personal/" + token + ".ics"
+	webcal := feedURL
+	if i := strings.Index(webcal, "://"); i >= 0 {
+		webcal = "webcal" + webcal[i:]
+	}
+	return &CalendarSubscription{Token: token, FeedURL: feedURL, WebcalURL: webcal}
+}
+
+func (s *CalendarFeedService) render(name string, events []models.Calender) *Feed {
+	domain := calendarUIDDomain(s.baseURL)
+	lastModified := time.Unix(0, 0).UTC()
+	items := make([]utils.ICalEvent, 0, len(events))
+	for i := range events {
+		event := &events[i]
+		item := utils.ICalEvent{
+			UID:         models.CalendarEventUID(event, domain),
+			Summary:     event.Title,
+			Description: event.Description,
+			Location:    event.Location,
+			Start:       event.StartTime,
+			End:         event.EndTime,
+			Updated:     event.UpdatedAt,
+		}
+		if event.Organization != nil {
+			item.Categories = event.Organization.ShortName
+		}
+		if event.IsRecurring() {
+			item.RRule = event.RRule
+			item.ExDates = parseExDates(event.ExDates)
+		}
+		if event.OriginalStart != nil {
+			item.RecurrenceID = *event.OriginalStart
+		}
+		if event.UpdatedAt.After(lastModified) {
+			lastModified = event.UpdatedAt
+		}
+		items = append(items, item)
+	}
+
+	return &Feed{
+		Body:         utils.WriteICal(name, items),
+		LastModified: lastModified.UTC().Truncate(time.Second),
+	}
+}
+
+func calendarFeedSince() time.Time {
+	return time.Now().AddDate(0, 0, -calendarFeedPastDays)
+}
+
+// calendarUIDDomain mengambil host API sebagai domain UID event buatan sendiri
+func calendarUIDDomain(baseURL string) string {
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Hostname() != "" {
+		return parsed.Hostname()
+	}
+	return "bem.local"
+}
@@ -0,0 +1,679 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// Cakupan perubahan atau penghapusan event berulang
+const (
+	EventScopeAll        = "all"        // seluruh seri
+	EventScopeOccurrence = "occurrence" // satu kemunculan saja
+	EventScopeFollowing  = "following"  // kemunculan ini dan sesudahnya
+)
+
+// ErrEventNotFound dikembalikan jika event tidak ada
+var ErrEventNotFound = errors.New("event tidak ditemukan")
+
+const exDateLayout = "20060102T150405Z"
+
+// EventChanges adalah isi baru event dari editor. RRule nil berarti aturan pengulangan
+// tidak diubah; string kosong menghapus pengulangan. VenueID berlaku sama: nil tidak
+// diubah, 0 melepas venue.
+type EventChanges struct {
+	Title       string
+	Description string
+	Location    string
+	StartTime   time.Time
+	EndTime     time.Time
+	RRule       *string
+	VenueID     *uint
+}
+
+func (c EventChanges) apply(event *models.Calender) {
+	event.Title = c.Title
+	event.Description = c.Description
+	event.Location = c.Location
+	event.StartTime = c.StartTime
+	event.EndTime = c.EndTime
+}
+
+// applyVenue mengganti venue seri jika dikirim editor
+func (c EventChanges) applyVenue(event *models.Calender) {
+	if c.VenueID == nil {
+		return
+	}
+	if *c.VenueID == 0 {
+		event.VenueID = nil
+		return
+	}
+	venueID := *c.VenueID
+	event.VenueID = &venueID
+}
+
+// changesVenue memeriksa apakah editor memindahkan seri ke venue lain
+func (c EventChanges) changesVenue(series *models.Calender) bool {
+	if c.VenueID == nil {
+		return false
+	}
+	if series.VenueID == nil {
+		return *c.VenueID != 0
+	}
+	return *c.VenueID != *series.VenueID
+}
+
+// rescheduled memeriksa apakah jadwal kemunculan berubah dari start-end semula
+func (c EventChanges) rescheduled(start, end time.Time) bool {
+	return !c.StartTime.Equal(start) || !c.EndTime.Equal(end)
+}
+
+// prepareRecurrence menormalkan RRULE dan EXDATE lalu menghitung akhir seri
+func prepareRecurrence(event *models.Calender) error {
+	event.RRule = strings.TrimSpace(event.RRule)
+	if event.RRule == "" {
+		event.ExDates = ""
+		event.RecurrenceEnd = nil
+		return nil
+	}
+	if event.ParentID != nil {
+		return errors.New("kemunculan pengganti tidak bisa memiliki aturan pengulangan")
+	}
+
+	rule, err := utils.ParseRRule(event.RRule, calendarLocation())
+	if err != nil {
+		return err
+	}
+	event.RRule = rule.String()
+	event.ExDates = formatExDates(parseExDates(event.ExDates))
+	event.RecurrenceEnd = nil
+	if last, ok := rule.Last(event.StartTime.In(calendarLocation())); ok {
+		end := last.Add(event.EndTime.Sub(event.StartTime))
+		event.RecurrenceEnd = &end
+	}
+	return nil
+}
+
+// parseExDates membaca EXDATE tersimpan; nilai yang rusak diabaikan
+func parseExDates(value string) []time.Time {
+	var dates []time.Time
+	for _, part := range strings.Split(value, ",") {
+		if t, err := time.Parse(exDateLayout, strings.TrimSpace(part)); err == nil {
+			dates = append(dates, t)
+		}
+	}
+	return dates
+}
+
+func formatExDates(dates []time.Time) string {
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	parts := make([]string, 0, len(dates))
+	for _, date := range dates {
+		formatted := date.UTC().Format(exDateLayout)
+		if len(parts) == 0 || parts[len(parts)-1] != formatted {
+			parts = append(parts, formatted)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitExDates memisahkan EXDATE sebelum at dan mulai dari at
+func splitExDates(value string, at time.Time) (before, after []time.Time) {
+	for _, date := range parseExDates(value) {
+		if date.Before(at) {
+			before = append(before, date)
+		} else {
+			after = append(after, date)
+		}
+	}
+	return before, after
+}
+
+func shiftTimes(times []time.Time, delta time.Duration) []time.Time {
+	shifted := make([]time.Time, len(times))
+	for i, t := range times {
+		shifted[i] = t.Add(delta)
+	}
+	return shifted
+}
+
+// expandEvents mengganti event berulang dengan kemunculannya yang beririsan dengan
+// [from, to]. Kemunculan yang dikecualikan atau sudah punya pengganti dilewati; event
+// pengganti sendiri sudah ikut di events bila waktunya beririsan.
+func (s *CalenderService) expandEvents(events []models.Calender, from, to time.Time) ([]models.Calender, error) {
+	return expandOccurrences(s.repository, events, from, to)
+}
+
+// expandOccurrences adalah expandEvents dengan repository tertentu (mis. di dalam transaksi)
+func expandOccurrences(repo *repositories.CalenderRepository, events []models.Calender, from, to time.Time) ([]models.Calender, error) {
+	var parentIDs []uint
+	for _, event := range events {
+		if event.IsRecurring() {
+			parentIDs = append(parentIDs, event.ID)
+		}
+	}
+	if len(parentIDs) == 0 {
+		return events, nil
+	}
+
+	overrides, err := repo.GetOverrides(parentIDs)
+	if err != nil {
+		return nil, err
+	}
+	replaced := map[uint]map[int64]bool{}
+	for _, override := range overrides {
+		if override.ParentID == nil || override.OriginalStart == nil {
+			continue
+		}
+		if replaced[*override.ParentID] == nil {
+			replaced[*override.ParentID] = map[int64]bool{}
+		}
+		replaced[*override.ParentID][override.OriginalStart.Unix()] = true
+	}
+
+	loc := calendarLocation()
+	result := make([]models.Calender, 0, len(events))
+	for _, event := range events {
+		if !event.IsRecurring() {
+			result = append(result, event)
+			continue
+		}
+		rule, err := utils.ParseRRule(event.RRule, loc)
+		if err != nil {
+			log.Printf("RRULE event %d tidak valid: %v", event.ID, err)
+			result = append(result, event)
+			continue
+		}
+
+		excluded := map[int64]bool{}
+		for _, date := range parseExDates(event.ExDates) {
+			excluded[date.Unix()] = true
+		}
+		duration := event.EndTime.Sub(event.StartTime)
+		for _, start := range rule.Between(event.StartTime.In(loc), from.Add(-duration), to) {
+			if excluded[start.Unix()] || replaced[event.ID][start.Unix()] {
+				continue
+			}
+			occurrence := event
+			originalStart := start
+			occurrence.StartTime = start
+			occurrence.EndTime = start.Add(duration)
+			occurrence.OriginalStart = &originalStart
+			result = append(result, occurrence)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].StartTime.Before(result[j].StartTime) })
+	return result, nil
+}
+
+// seriesOccurrences mengambil semua kemunculan satu seri (termasuk penggantinya) yang
+// beririsan dengan [from, to]; event tunggal dikembalikan apa adanya
+func seriesOccurrences(repo *repositories.CalenderRepository, event *models.Calender, from, to time.Time) ([]models.Calender, error) {
+	if !event.IsRecurring() {
+		return []models.Calender{*event}, nil
+	}
+	occurrences, err := expandOccurrences(repo, []models.Calender{*event}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := repo.GetOverrides([]uint{event.ID})
+	if err != nil {
+		return nil, err
+	}
+	for _, override := range overrides {
+		if !override.EndTime.Before(from) && !override.StartTime.After(to) {
+			occurrences = append(occurrences, override)
+		}
+	}
+	return occurrences, nil
+}
+
+// isOccurrence memeriksa apakah at adalah kemunculan seri yang tidak dikecualikan
+func isOccurrence(event *models.Calender, at time.Time) bool {
+	rule, err := utils.ParseRRule(event.RRule, calendarLocation())
+	if err != nil {
+		return false
+	}
+	if len(rule.Between(event.StartTime.In(calendarLocation()), at, at)) == 0 {
+		return false
+	}
+	for _, date := range parseExDates(event.ExDates) {
+		if date.Equal(at) {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateEvent mengubah event. Untuk event berulang, scope menentukan apakah yang diubah
+// seluruh seri, satu kemunculan (occurrence wajib diisi waktu mulai aslinya), atau
+// kemunculan tersebut dan sesudahnya (seri dipecah menjadi dua).
+func (s *CalenderService) UpdateEvent(id uint, changes EventChanges, scope string, occurrence *time.Time) (*models.Calender, error) {
+	if changes.EndTime.Before(changes.StartTime) {
+		return nil, errors.New("waktu selesai harus setelah waktu mulai")
+	}
+	event, err := s.findEvent(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.ParentID != nil {
+		// event ini pengganti satu kemunculan; "all" dan "following" berlaku pada seri induknya
+		if scope == EventScopeAll || scope == EventScopeFollowing {
+			parent, err := s.findEvent(*event.ParentID)
+			if err != nil {
+				return nil, err
+			}
+			if scope == EventScopeAll {
+				return s.updateSeries(parent, changes, event.OriginalStart)
+			}
+			return s.updateFollowing(parent, *event.OriginalStart, changes)
+		}
+		if changes.RRule != nil && *changes.RRule != "" {
+			return nil, errors.New("aturan pengulangan tidak bisa diatur pada satu kemunculan")
+		}
+		if err := s.checkOccurrenceVenue(*event.ParentID, changes); err != nil {
+			return nil, err
+		}
+		rescheduled := changes.rescheduled(event.StartTime, event.EndTime)
+		changes.apply(event)
+		event.Organization = nil
+		reservation, queued, err := s.saveOccurrence(event, rescheduled)
+		if err != nil {
+			return nil, err
+		}
+		s.notifyVenueApprover(reservation, queued)
+		return event, nil
+	}
+
+	switch scope {
+	case "", EventScopeAll:
+		return s.updateSeries(event, changes, occurrence)
+	case EventScopeOccurrence, EventScopeFollowing:
+		if !event.IsRecurring() {
+			return s.updateSeries(event, changes, nil)
+		}
+		if occurrence == nil {
+			return nil, errors.New("waktu kemunculan (occurrence) wajib diisi")
+		}
+		if scope == EventScopeOccurrence {
+			return s.updateOccurrence(event, *occurrence, changes)
+		}
+		return s.updateFollowing(event, *occurrence, changes)
+	default:
+		return nil, errors.New("scope harus all, occurrence, atau following")
+	}
+}
+
+// updateSeries mengubah seluruh seri. Jika editor mengubah lewat salah satu kemunculan,
+// pergeseran waktunya diterapkan ke awal seri, tanggal pengecualian dan penggantinya.
+func (s *CalenderService) updateSeries(event *models.Calender, changes EventChanges, occurrence *time.Time) (*models.Calender, error) {
+	wasRecurring := event.IsRecurring()
+	anchor := event.StartTime
+	if wasRecurring && occurrence != nil {
+		anchor = *occurrence
+	}
+	delta := changes.StartTime.Sub(anchor)
+	duration := changes.EndTime.Sub(changes.StartTime)
+	previousRule := event.RRule
+
+	changes.StartTime = event.StartTime.Add(delta)
+	changes.EndTime = changes.StartTime.Add(duration)
+	rescheduled := changes.rescheduled(event.StartTime, event.EndTime)
+	changes.apply(event)
+	changes.applyVenue(event)
+	if changes.RRule != nil {
+		event.RRule = *changes.RRule
+	}
+	if wasRecurring && delta != 0 {
+		event.ExDates = formatExDates(shiftTimes(parseExDates(event.ExDates), delta))
+	}
+	if err := prepareRecurrence(event); err != nil {
+		return nil, err
+	}
+	rescheduled = rescheduled || event.RRule != previousRule
+	event.Organization = nil
+
+	var reservation *models.VenueReservation
+	var queued bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewCalenderRepository(tx)
+		if err := repo.Update(event); err != nil {
+			return err
+		}
+		if wasRecurring && !event.IsRecurring() {
+			if err := repo.DeleteOverrides(event.ID); err != nil {
+				return err
+			}
+		} else if wasRecurring && delta != 0 {
+			if err := moveOverrides(repo, event.ID, event, time.Time{}, delta); err != nil {
+				return err
+			}
+		}
+		var err error
+		reservation, queued, err = bookVenue(tx, event.ID, rescheduled)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifyVenueApprover(reservation, queued)
+	return event, nil
+}
+
+// updateOccurrence menyimpan perubahan satu kemunculan sebagai event pengganti
+func (s *CalenderService) updateOccurrence(parent *models.Calender, at time.Time, changes EventChanges) (*models.Calender, error) {
+	if changes.RRule != nil && *changes.RRule != "" {
+		return nil, errors.New("aturan pengulangan tidak bisa diatur pada satu kemunculan")
+	}
+	if !isOccurrence(parent, at) {
+		return nil, errors.New("kemunculan tidak ditemukan di seri ini")
+	}
+	if err := s.checkOccurrenceVenue(parent.ID, changes); err != nil {
+		return nil, err
+	}
+
+	override, err := s.repository.GetOverride(parent.ID, at)
+	if err != nil {
+		return nil, err
+	}
+	if override == nil {
+		originalStart := at
+		override = &models.Calender{
+			OrganizationID: parent.OrganizationID,
+			UID:            parent.UID,
+			ParentID:       &parent.ID,
+			OriginalStart:  &originalStart,
+			StartTime:      at,
+			EndTime:        at.Add(parent.EndTime.Sub(parent.StartTime)),
+		}
+	}
+	rescheduled := changes.rescheduled(override.StartTime, override.EndTime)
+	changes.apply(override)
+	override.Organization = nil
+
+	reservation, queued, err := s.saveOccurrence(override, rescheduled)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyVenueApprover(reservation, queued)
+	return override, nil
+}
+
+// saveOccurrence menyimpan event pengganti kemunculan lalu memeriksa ulang booking venue
+// seri induknya terhadap jadwal barunya
+func (s *CalenderService) saveOccurrence(override *models.Calender, rescheduled bool) (*models.VenueReservation, bool, error) {
+	var reservation *models.VenueReservation
+	var queued bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewCalenderRepository(tx)
+		var err error
+		if override.ID == 0 {
+			err = repo.Create(override)
+		} else {
+			err = repo.Update(override)
+		}
+		if err != nil {
+			return err
+		}
+		reservation, queued, err = bookVenue(tx, *override.ParentID, rescheduled)
+		return err
+	})
+	return reservation, queued, err
+}
+
+// checkOccurrenceVenue menolak pindah venue lewat satu kemunculan; venue dipesan per seri
+func (s *CalenderService) checkOccurrenceVenue(parentID uint, changes EventChanges) error {
+	if changes.VenueID == nil {
+		return nil
+	}
+	parent, err := s.findEvent(parentID)
+	if err != nil {
+		return err
+	}
+	if changes.changesVenue(parent) {
+		return errors.New("venue hanya bisa diubah untuk seluruh seri")
+	}
+	return nil
+}
+
+// updateFollowing memecah seri: seri lama berhenti sebelum at, kemunculan at dan
+// sesudahnya menjadi seri baru dengan isi perubahan. Tanggal pengecualian dan pengganti
+// setelah at ikut pindah ke seri baru.
+func (s *CalenderService) updateFollowing(parent *models.Calender, at time.Time, changes EventChanges) (*models.Calender, error) {
+	if !isOccurrence(parent, at) {
+		return nil, errors.New("kemunculan tidak ditemukan di seri ini")
+	}
+	if at.Equal(parent.StartTime) {
+		return s.updateSeries(parent, changes, &at)
+	}
+
+	loc := calendarLocation()
+	rule, err := utils.ParseRRule(parent.RRule, loc)
+	if err != nil {
+		return nil, err
+	}
+	before := rule.CountBefore(parent.StartTime.In(loc), at)
+
+	// pengaturan pendaftaran dan presensi ikut ke seri baru; pendaftar dan kehadirannya
+	// tetap di seri lama
+	next := &models.Calender{
+		OrganizationID:       parent.OrganizationID,
+		Capacity:             parent.Capacity,
+		RegistrationOpensAt:  parent.RegistrationOpensAt,
+		RegistrationClosesAt: parent.RegistrationClosesAt,
+		EligibleFaculties:    parent.EligibleFaculties,
+		EligibleYears:        parent.EligibleYears,
+		MembersOnly:          parent.MembersOnly,
+		CheckInOpensBefore:   parent.CheckInOpensBefore,
+		CheckInClosesAfter:   parent.CheckInClosesAfter,
+		Latitude:             parent.Latitude,
+		Longitude:            parent.Longitude,
+		GeofenceRadius:       parent.GeofenceRadius,
+		VenueID:              parent.VenueID,
+	}
+	rescheduled := changes.rescheduled(at, at.Add(parent.EndTime.Sub(parent.StartTime))) || changes.RRule != nil
+	changes.apply(next)
+	changes.applyVenue(next)
+	if changes.RRule != nil {
+		next.RRule = *changes.RRule
+	} else {
+		nextRule := followingRule(*rule, before)
+		next.RRule = nextRule.String()
+	}
+
+	delta := changes.StartTime.Sub(at)
+	kept, moved := splitExDates(parent.ExDates, at)
+	next.ExDates = formatExDates(shiftTimes(moved, delta))
+	if err := prepareRecurrence(next); err != nil {
+		return nil, err
+	}
+
+	truncateRule(rule, before, at)
+	parent.RRule = rule.String()
+	parent.ExDates = formatExDates(kept)
+	if err := prepareRecurrence(parent); err != nil {
+		return nil, err
+	}
+	parent.Organization = nil
+
+	var reservation *models.VenueReservation
+	var queued bool
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewCalenderRepository(tx)
+		if err := repo.Update(parent); err != nil {
+			return err
+		}
+		if err := repo.Create(next); err != nil {
+			return err
+		}
+		// kemunculan at sekarang menjadi awal seri baru dengan isi perubahan
+		if err := repo.DeleteOverride(parent.ID, at); err != nil {
+			return err
+		}
+		if !next.IsRecurring() {
+			err = repo.DeleteOverridesFrom(parent.ID, at)
+		} else {
+			err = moveOverrides(repo, parent.ID, next, at, delta)
+		}
+		if err != nil {
+			return err
+		}
+		// seri baru meneruskan status booking seri lama selama jadwalnya tidak berubah
+		if err := inheritReservation(tx, parent.ID, next.ID); err != nil {
+			return err
+		}
+		reservation, queued, err = bookVenue(tx, next.ID, rescheduled)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifyVenueApprover(reservation, queued)
+	return next, nil
+}
+
+// truncateRule menghentikan aturan sebelum kemunculan at (before = jumlah kemunculan
+// sebelum at)
+func truncateRule(rule *utils.RRule, before int, at time.Time) {
+	if rule.Count > 0 {
+		rule.Count = before
+		return
+	}
+	rule.Until = at.Add(-time.Second)
+}
+
+// followingRule adalah aturan seri baru yang dimulai dari kemunculan ke-(before+1); sisa
+// COUNT dibawa agar jumlah kemunculan kedua seri sama dengan seri aslinya
+func followingRule(rule utils.RRule, before int) utils.RRule {
+	if rule.Count > 0 {
+		rule.Count -= before
+	}
+	return rule
+}
+
+// moveOverrides memindahkan pengganti kemunculan seri fromID mulai dari from ke seri
+// target dan menggeser waktu kemunculan aslinya sebesar delta
+func moveOverrides(repo *repositories.CalenderRepository, fromID uint, target *models.Calender, from time.Time, delta time.Duration) error {
+	overrides, err := repo.GetOverridesFrom(fromID, from)
+	if err != nil {
+		return err
+	}
+	for i := range overrides {
+		override := &overrides[i]
+		originalStart := override.OriginalStart.Add(delta)
+		override.OriginalStart = &originalStart
+		override.ParentID = &target.ID
+		override.UID = target.UID
+		if err := repo.Update(override); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteEvent menghapus event. Untuk event berulang, scope occurrence hanya membatalkan
+// satu kemunculan dan following menghentikan seri mulai kemunculan tersebut. Menghapus
+// event pengganti berarti membatalkan kemunculannya.
+func (s *CalenderService) DeleteEvent(id uint, scope string, occurrence *time.Time) error {
+	event, err := s.findEvent(id)
+	if err != nil {
+		return err
+	}
+
+	if event.ParentID != nil {
+		parent, err := s.findEvent(*event.ParentID)
+		if err != nil {
+			return err
+		}
+		if scope == EventScopeFollowing {
+			return s.deleteFollowing(parent, *event.OriginalStart)
+		}
+		return s.cancelOccurrence(parent, *event.OriginalStart)
+	}
+
+	if !event.IsRecurring() || scope == "" || scope == EventScopeAll {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			repo := repositories.NewCalenderRepository(tx)
+			if err := repo.DeleteOverrides(event.ID); err != nil {
+				return err
+			}
+			return repo.Delete(event.ID)
+		})
+	}
+	if occurrence == nil {
+		return errors.New("waktu kemunculan (occurrence) wajib diisi")
+	}
+	if !isOccurrence(event, *occurrence) {
+		return errors.New("kemunculan tidak ditemukan di seri ini")
+	}
+	switch scope {
+	case EventScopeOccurrence:
+		return s.cancelOccurrence(event, *occurrence)
+	case EventScopeFollowing:
+		return s.deleteFollowing(event, *occurrence)
+	default:
+		return errors.New("scope harus all, occurrence, atau following")
+	}
+}
+
+// cancelOccurrence menambahkan EXDATE dan menghapus pengganti kemunculan tersebut
+func (s *CalenderService) cancelOccurrence(parent *models.Calender, at time.Time) error {
+	parent.ExDates = formatExDates(append(parseExDates(parent.ExDates), at))
+	parent.Organization = nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewCalenderRepository(tx)
+		if err := repo.DeleteOverride(parent.ID, at); err != nil {
+			return err
+		}
+		return repo.Update(parent)
+	})
+}
+
+// deleteFollowing menghentikan seri sebelum kemunculan at
+func (s *CalenderService) deleteFollowing(parent *models.Calender, at time.Time) error {
+	if !at.After(parent.StartTime) {
+		return s.DeleteEvent(parent.ID, EventScopeAll, nil)
+	}
+	loc := calendarLocation()
+	rule, err := utils.ParseRRule(parent.RRule, loc)
+	if err != nil {
+		return err
+	}
+	truncateRule(rule, rule.CountBefore(parent.StartTime.In(loc), at), at)
+	kept, _ := splitExDates(parent.ExDates, at)
+	parent.RRule = rule.String()
+	parent.ExDates = formatExDates(kept)
+	if err := prepareRecurrence(parent); err != nil {
+		return err
+	}
+	parent.Organization = nil
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewCalenderRepository(tx)
+		if err := repo.DeleteOverridesFrom(parent.ID, at); err != nil {
+			return err
+		}
+		return repo.Update(parent)
+	})
+}
+
+func (s *CalenderService) findEvent(id uint) (*models.Calender, error) {
+	event, err := s.repository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, ErrEventNotFound
+	}
+	return event, nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"bem_be/internal/utils"
+)
+
+// Memecah seri "kemunculan ini dan berikutnya": seri lama berhenti sebelum titik pecah dan
+// seri baru membawa sisa COUNT, sehingga gabungan keduanya sama dengan seri asli.
+func TestSplitSeriesCarriesCount(t *testing.T) {
+	start := time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)
+	farFuture := start.AddDate(5, 0, 0)
+
+	for _, value := range []string{
+		"FREQ=WEEKLY;BYDAY=MO,TH;COUNT=7",
+		"FREQ=MONTHLY;BYDAY=2MO;COUNT=5",
+		"FREQ=DAILY;INTERVAL=3;UNTIL=20240201T090000Z",
+	} {
+		rule, err := utils.ParseRRule(value, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all := rule.Between(start, start, farFuture)
+		at := all[2]
+		before := rule.CountBefore(start, at)
+
+		head := *rule
+		truncateRule(&head, before, at)
+		tail := followingRule(*rule, before)
+
+		got := append(head.Between(start, start, farFuture), tail.Between(at, at, farFuture)...)
+		if len(got) != len(all) {
+			t.Fatalf("%s: gabungan %d kemunculan; ingin %d", value, len(got), len(all))
+		}
+		for i := range all {
+			if !got[i].Equal(all[i]) {
+				t.Errorf("%s: kemunculan %d = %v; ingin %v", value, i, got[i], all[i])
+			}
+		}
+		if rule.Count > 0 && head.Count+tail.Count != rule.Count {
+			t.Errorf("%s: COUNT %d + %d; ingin total %d", value, head.Count, tail.Count, rule.Count)
+		}
+	}
+}
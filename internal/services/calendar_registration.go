@@ -0,0 +1,458 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+
+	"github.com/tealeg/xlsx/v3"
+	"gorm.io/gorm"
+)
+
+// ErrEventForbidden dikembalikan jika pengguna bukan pengurus organisasi penyelenggara event
+var ErrEventForbidden = errors.New("hanya pengurus organisasi penyelenggara yang dapat mengelola event ini")
+
+// RegistrationSettings adalah pengaturan pendaftaran event dari penyelenggara
+type RegistrationSettings struct {
+	Capacity             int        `json:"capacity"`
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at"`
+	EligibleFaculties    string     `json:"eligible_faculties"`
+	EligibleYears        string     `json:"eligible_years"`
+	MembersOnly          bool       `json:"members_only"`
+}
+
+// RegistrationSummary merangkum jumlah pendaftar event
+type RegistrationSummary struct {
+	Capacity   int   `json:"capacity"`
+	Registered int64 `json:"registered"`
+	Waitlisted int64 `json:"waitlisted"`
+}
+
+// validateRegistrationSettings memeriksa pengaturan pendaftaran pada event
+func validateRegistrationSettings(event *models.Calender) error {
+	if event.Capacity < 0 {
+		return errors.New("kapasitas tidak boleh negatif")
+	}
+	if event.RegistrationOpensAt != nil && event.RegistrationClosesAt != nil &&
+		!event.RegistrationClosesAt.After(*event.RegistrationOpensAt) {
+		return errors.New("waktu tutup pendaftaran harus setelah waktu buka")
+	}
+	if _, err := parseYears(event.EligibleYears); err != nil {
+		return err
+	}
+	event.EligibleFaculties = strings.Join(splitList(event.EligibleFaculties), ",")
+	event.EligibleYears = strings.Join(splitList(event.EligibleYears), ",")
+	return nil
+}
+
+// registrationOpen memeriksa jendela pendaftaran event
+func registrationOpen(event *models.Calender, now time.Time) error {
+	if event.RegistrationOpensAt != nil && now.Before(*event.RegistrationOpensAt) {
+		return fmt.Errorf("pendaftaran baru dibuka %s", event.RegistrationOpensAt.In(calendarLocation()).Format("02-01-2006 15:04"))
+	}
+	if event.RegistrationClosesAt != nil {
+		if now.After(*event.RegistrationClosesAt) {
+			return errors.New("pendaftaran sudah ditutup")
+		}
+		return nil
+	}
+	if eventFinished(event, now) || (!event.IsRecurring() && now.After(event.StartTime)) {
+		return errors.New("pendaftaran sudah ditutup karena event sudah dimulai")
+	}
+	return nil
+}
+
+// checkEligibility memastikan mahasiswa memenuhi filter fakultas, angkatan dan
+// keanggotaan organisasi event
+func (s *CalenderService) checkEligibility(event *models.Calender, username string) error {
+	faculties := splitList(event.EligibleFaculties)
+	years, _ := parseYears(event.EligibleYears)
+	membersOnly := event.MembersOnly && event.OrganizationID != nil
+	if len(faculties) == 0 && len(years) == 0 && !membersOnly {
+		return nil
+	}
+
+	student, err := s.studentRepo.FindByUserID(username)
+	if err != nil {
+		return err
+	}
+	if student == nil {
+		return errors.New("event ini hanya untuk mahasiswa yang memenuhi syarat")
+	}
+	if len(faculties) > 0 && !containsFold(faculties, student.Faculty) {
+		return fmt.Errorf("event ini hanya untuk fakultas %s", strings.Join(faculties, ", "))
+	}
+	if len(years) > 0 && !containsInt(years, student.YearEnrolled) {
+		return fmt.Errorf("event ini hanya untuk angkatan %s", event.EligibleYears)
+	}
+	if membersOnly && (student.OrganizationID == nil || *student.OrganizationID != *event.OrganizationID) {
+		return errors.New("event ini hanya untuk anggota organisasi penyelenggara")
+	}
+	return nil
+}
+
+// RegisterEvent mendaftarkan pengguna ke event. Jika kuota penuh, pendaftar masuk
+// daftar tunggu. Pendaftaran ke kemunculan pengganti dicatat pada seri induknya.
+func (s *CalenderService) RegisterEvent(eventID uint, username string) (*models.EventRegistration, error) {
+	if username == "" {
+		return nil, errors.New("username wajib diisi")
+	}
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if err := registrationOpen(event, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.checkEligibility(event, username); err != nil {
+		return nil, err
+	}
+
+	var registration *models.EventRegistration
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewCalenderRepository(tx)
+		locked, err := repo.LockEvent(event.ID)
+		if err != nil {
+			return err
+		}
+		existing, err := repo.FindRegistration(event.ID, username)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("sudah terdaftar di event %q", event.Title)
+		}
+
+		registration = &models.EventRegistration{EventID: event.ID, Username: username, Status: models.EventRegistrationRegistered}
+		if locked.Capacity > 0 {
+			registered, err := repo.CountRegistrations(event.ID, models.EventRegistrationRegistered)
+			if err != nil {
+				return err
+			}
+			if registered >= int64(locked.Capacity) {
+				registration.Status = models.EventRegistrationWaitlisted
+			}
+		}
+		return repo.CreateRegistration(registration)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if registration.Status == models.EventRegistrationWaitlisted {
+		if registration.WaitlistPosition, err = s.repository.WaitlistPosition(registration); err != nil {
+			log.Printf("Gagal menghitung posisi daftar tunggu event %d: %v", event.ID, err)
+		}
+	}
+	return registration, nil
+}
+
+// CancelRegistration membatalkan pendaftaran pengguna. Kursi yang kosong langsung diisi
+// pendaftar pertama di daftar tunggu.
+func (s *CalenderService) CancelRegistration(eventID uint, username string) error {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return err
+	}
+
+	var promoted []models.EventRegistration
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewCalenderRepository(tx)
+		locked, err := repo.LockEvent(event.ID)
+		if err != nil {
+			return err
+		}
+		existing, err := repo.FindRegistration(event.ID, username)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return errors.New("belum terdaftar di event ini")
+		}
+		if err := repo.DeleteRegistration(event.ID, username); err != nil {
+			return err
+		}
+		if existing.Status != models.EventRegistrationRegistered {
+			return nil
+		}
+		promoted, err = promoteWaitlist(repo, locked)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.notifyPromoted(event, promoted)
+	return nil
+}
+
+// UpdateRegistrationSettings mengubah kapasitas, jendela dan filter pendaftaran.
+// Kenaikan kapasitas langsung menaikkan pendaftar dari daftar tunggu.
+func (s *CalenderService) UpdateRegistrationSettings(eventID uint, settings RegistrationSettings) (*models.Calender, error) {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var promoted []models.EventRegistration
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewCalenderRepository(tx)
+		locked, err := repo.LockEvent(event.ID)
+		if err != nil {
+			return err
+		}
+		locked.Capacity = settings.Capacity
+		locked.RegistrationOpensAt = settings.RegistrationOpensAt
+		locked.RegistrationClosesAt = settings.RegistrationClosesAt
+		locked.EligibleFaculties = settings.EligibleFaculties
+		locked.EligibleYears = settings.EligibleYears
+		locked.MembersOnly = settings.MembersOnly
+		if err := validateRegistrationSettings(locked); err != nil {
+			return err
+		}
+		if err := repo.Update(locked); err != nil {
+			return err
+		}
+		// kapasitas baru tidak boleh melebihi kapasitas venue yang dipesan
+		if _, _, err := bookVenue(tx, locked.ID, false); err != nil {
+			return err
+		}
+		event = locked
+		promoted, err = promoteWaitlist(repo, locked)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifyPromoted(event, promoted)
+	return event, nil
+}
+
+// promoteWaitlist mengisi kursi kosong dengan pendaftar terlama di daftar tunggu
+func promoteWaitlist(repo *repositories.CalenderRepository, event *models.Calender) ([]models.EventRegistration, error) {
+	limit := 0 // kapasitas tanpa batas: semua daftar tunggu naik
+	if event.Capacity > 0 {
+		registered, err := repo.CountRegistrations(event.ID, models.EventRegistrationRegistered)
+		if err != nil {
+			return nil, err
+		}
+		limit = event.Capacity - int(registered)
+		if limit <= 0 {
+			return nil, nil
+		}
+	}
+
+	waitlist, err := repo.GetWaitlist(event.ID, limit)
+	if err != nil || len(waitlist) == 0 {
+		return nil, err
+	}
+	ids := make([]uint, len(waitlist))
+	for i, registration := range waitlist {
+		ids[i] = registration.ID
+	}
+	if err := repo.PromoteRegistrations(ids, time.Now()); err != nil {
+		return nil, err
+	}
+	return waitlist, nil
+}
+
+// notifyPromoted memberi tahu pendaftar yang naik dari daftar tunggu
+func (s *CalenderService) notifyPromoted(event *models.Calender, promoted []models.EventRegistration) {
+	if len(promoted) == 0 || s.notificationService == nil {
+		return
+	}
+	usernames := make([]string, len(promoted))
+	for i, registration := range promoted {
+		usernames[i] = registration.Username
+	}
+
+	title := "Pendaftaran Dikonfirmasi: " + event.Title
+	message := fmt.Sprintf("Ada kursi kosong di %s. Kamu naik dari daftar tunggu dan sekarang terdaftar sebagai peserta.", event.Title)
+	target := NotificationTarget{
+		Type:       models.NotificationTypeEvent,
+		EntityType: models.NotificationTypeEvent,
+		EntityID:   event.ID,
+	}
+	if _, err := s.notificationService.CreateNotificationForUsers(title, message, target, usernames); err != nil {
+		log.Printf("Gagal mengirim notifikasi daftar tunggu event %d: %v", event.ID, err)
+	}
+}
+
+// registrationTarget mengambil event tujuan pendaftaran (seri induk untuk kemunculan pengganti)
+func (s *CalenderService) registrationTarget(eventID uint) (*models.Calender, error) {
+	event, err := s.findEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.ParentID != nil {
+		return s.findEvent(*event.ParentID)
+	}
+	return event, nil
+}
+
+// AuthorizeOrganizer memastikan username adalah pengurus inti organisasi penyelenggara event.
+// Event tanpa organisasi dikelola pengurus inti BEM/MPM. Admin diperiksa di handler.
+func (s *CalenderService) AuthorizeOrganizer(eventID uint, username string) error {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return err
+	}
+	return s.authorizeOrganization(event.OrganizationID, username)
+}
+
+// AuthorizeImport memastikan username boleh mengimpor event ke organizationID dengan aturan
+// yang sama seperti AuthorizeOrganizer. Admin diperiksa di handler.
+func (s *CalenderService) AuthorizeImport(organizationID *int, username string) error {
+	return s.authorizeOrganization(organizationID, username)
+}
+
+// authorizeOrganization: pengurus inti organisasi, atau pengurus inti BEM/MPM untuk event
+// tanpa organisasi
+func (s *CalenderService) authorizeOrganization(organizationID *int, username string) error {
+	student, err := s.studentRepo.FindByUsername(username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEventForbidden
+		}
+		return err
+	}
+	if organizationID != nil {
+		if !student.IsOfficerOf(*organizationID) {
+			return ErrEventForbidden
+		}
+		return nil
+	}
+	if !student.IsExecutive() {
+		return ErrEventForbidden
+	}
+	return nil
+}
+
+// GetRegistrants mengambil pendaftar event (status kosong = semua) beserta ringkasannya
+func (s *CalenderService) GetRegistrants(eventID uint, status string) ([]models.EventRegistrant, *RegistrationSummary, error) {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	registrants, err := s.repository.GetRegistrants(event.ID, status)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summary := &RegistrationSummary{Capacity: event.Capacity}
+	if summary.Registered, err = s.repository.CountRegistrations(event.ID, models.EventRegistrationRegistered); err != nil {
+		return nil, nil, err
+	}
+	if summary.Waitlisted, err = s.repository.CountRegistrations(event.ID, models.EventRegistrationWaitlisted); err != nil {
+		return nil, nil, err
+	}
+	return registrants, summary, nil
+}
+
+// ExportRegistrants membuat file xlsx daftar pendaftar event; mengembalikan isi file
+// dan nama file yang disarankan
+func (s *CalenderService) ExportRegistrants(eventID uint) ([]byte, string, error) {
+	event, err := s.registrationTarget(eventID)
+	if err != nil {
+		return nil, "", err
+	}
+	registrants, err := s.repository.GetRegistrants(event.ID, "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet("Pendaftar")
+	if err != nil {
+		return nil, "", err
+	}
+	header := sheet.AddRow()
+	for _, title := range []string{"No", "NIM", "Nama", "Username", "Email", "Fakultas", "Program Studi", "Angkatan", "Status", "Waktu Daftar", "Naik dari Daftar Tunggu"} {
+		header.AddCell().SetString(title)
+	}
+
+	loc := calendarLocation()
+	for i, registrant := range registrants {
+		row := sheet.AddRow()
+		row.AddCell().SetInt(i + 1)
+		row.AddCell().SetString(registrant.NIM)
+		row.AddCell().SetString(registrant.FullName)
+		row.AddCell().SetString(registrant.Username)
+		row.AddCell().SetString(registrant.Email)
+		row.AddCell().SetString(registrant.Faculty)
+		row.AddCell().SetString(registrant.StudyProgram)
+		if registrant.YearEnrolled > 0 {
+			row.AddCell().SetInt(registrant.YearEnrolled)
+		} else {
+			row.AddCell().SetString("")
+		}
+		row.AddCell().SetString(registrationStatusLabel(registrant.Status))
+		row.AddCell().SetString(registrant.CreatedAt.In(loc).Format("02-01-2006 15:04"))
+		promoted := ""
+		if registrant.PromotedAt != nil {
+			promoted = registrant.PromotedAt.In(loc).Format("02-01-2006 15:04")
+		}
+		row.AddCell().SetString(promoted)
+	}
+	sheet.SetColWidth(2, 3, 28)
+	sheet.SetColWidth(5, 7, 24)
+
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "pendaftar-event-" + strconv.FormatUint(uint64(event.ID), 10) + ".xlsx", nil
+}
+
+func registrationStatusLabel(status string) string {
+	if status == models.EventRegistrationWaitlisted {
+		return "Daftar tunggu"
+	}
+	return "Terdaftar"
+}
+
+// GetMyRegistrations mengambil event yang didaftari pengguna beserta posisi daftar tunggunya
+func (s *CalenderService) GetMyRegistrations(username string) ([]models.EventRegistration, error) {
+	if username == "" {
+		return nil, errors.New("username wajib diisi")
+	}
+	registrations, err := s.repository.GetUserRegistrations(username)
+	if err != nil {
+		return nil, err
+	}
+	for i := range registrations {
+		registration := &registrations[i]
+		if registration.Status != models.EventRegistrationWaitlisted {
+			continue
+		}
+		if registration.WaitlistPosition, err = s.repository.WaitlistPosition(registration); err != nil {
+			return nil, err
+		}
+	}
+	return registrations, nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// reminderOffsets membaca EVENT_REMINDER_OFFSETS (durasi Go dipisah koma, default
+// "24h,1h"), urut dari yang terkecil. Nilai yang tidak valid diabaikan.
+func reminderOffsets() []time.Duration {
+	var offsets []time.Duration
+	for _, value := range splitList(utils.GetEnvWithDefault("EVENT_REMINDER_OFFSETS", "24h,1h")) {
+		offset, err := time.ParseDuration(value)
+		if err != nil || offset < time.Minute {
+			log.Printf("Offset pengingat event %q diabaikan", value)
+			continue
+		}
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets
+}
+
+// SendDueReminders mengirim pengingat kemunculan event yang sudah memasuki salah satu
+// offset. Tiap kemunculan hanya diingatkan dengan offset terkecil yang sudah jatuh tempo,
+// sehingga server yang sempat mati tidak mengirim pengingat "besok" dan "1 jam lagi"
+// bersamaan. Kemunculan dihitung ulang setiap putaran: event yang dihapus atau
+// kemunculan yang dibatalkan tidak lagi diingatkan, dan event yang dijadwal ulang
+// diingatkan sesuai jadwal barunya.
+func (s *CalenderService) SendDueReminders(now time.Time) (int, error) {
+	offsets := reminderOffsets()
+	if len(offsets) == 0 {
+		return 0, nil
+	}
+	horizon := now.Add(offsets[len(offsets)-1])
+	events, err := s.repository.GetEventsInRange(now, horizon)
+	if err != nil {
+		return 0, err
+	}
+	occurrences, err := s.expandEvents(events, now, horizon)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range occurrences {
+		occurrence := &occurrences[i]
+		if !occurrence.StartTime.After(now) || occurrence.StartTime.After(horizon) {
+			continue
+		}
+		for _, offset := range offsets {
+			if now.Before(occurrence.StartTime.Add(-offset)) {
+				continue
+			}
+			delivered, err := s.sendReminder(occurrence, offset, now)
+			if err != nil {
+				log.Printf("Gagal mengirim pengingat event %d: %v", occurrence.ID, err)
+			} else if delivered {
+				sent++
+			}
+			break
+		}
+	}
+	return sent, nil
+}
+
+// sendReminder mengirim satu pengingat ke peserta terdaftar dan pengurus organisasi
+// penyelenggara; false jika pengingat ini sudah pernah dikirim. Klaim pengingat dan
+// notifikasinya disimpan dalam satu transaksi, sehingga pengingat yang gagal dikirim tidak
+// tercatat terkirim dan dicoba lagi di putaran berikutnya.
+func (s *CalenderService) sendReminder(occurrence *models.Calender, offset time.Duration, now time.Time) (bool, error) {
+	seriesID := occurrence.ID
+	if occurrence.ParentID != nil {
+		seriesID = *occurrence.ParentID
+	}
+	reminder := &models.EventReminder{
+		EventID:         seriesID,
+		OccurrenceStart: occurrenceKey(occurrence),
+		StartTime:       occurrence.StartTime.UTC(),
+		OffsetMinutes:   int(offset / time.Minute),
+	}
+
+	recipients, err := s.reminderRecipients(seriesID, occurrence.OrganizationID)
+	if err != nil {
+		return false, err
+	}
+	rescheduled, err := s.repository.WasRescheduled(seriesID, reminder.OccurrenceStart, reminder.StartTime)
+	if err != nil {
+		return false, err
+	}
+
+	title := "Pengingat: " + occurrence.Title
+	message := fmt.Sprintf("%s dimulai %s lagi, %s", occurrence.Title, reminderLead(occurrence.StartTime.Sub(now)),
+		occurrence.StartTime.In(calendarLocation()).Format("02-01-2006 15:04"))
+	if rescheduled {
+		title = "Jadwal Berubah: " + occurrence.Title
+		message = fmt.Sprintf("Jadwal %s berubah. Kegiatan dimulai %s lagi, %s", occurrence.Title,
+			reminderLead(occurrence.StartTime.Sub(now)), occurrence.StartTime.In(calendarLocation()).Format("02-01-2006 15:04"))
+	}
+	if occurrence.Location != "" {
+		message += " di " + occurrence.Location
+	}
+	message += "."
+
+	target := NotificationTarget{
+		Type:       models.NotificationTypeEvent,
+		EntityType: models.NotificationTypeEvent,
+		EntityID:   seriesID,
+	}
+	delivered := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewCalenderRepository(tx)
+		// insert yang bentrok unique index menunggu transaksi lain selesai, jadi pengingat
+		// yang sedang dikirim worker lain tidak ikut dikirim ulang
+		claimed, err := repo.ClaimReminder(reminder)
+		if err != nil || !claimed {
+			return err
+		}
+		if len(recipients) == 0 || s.notificationService == nil {
+			return nil
+		}
+		count, err := s.notificationService.WithTx(tx).CreateNotificationForUsers(title, message, target, recipients)
+		if err != nil {
+			return err
+		}
+		delivered = true
+		return repo.UpdateReminderRecipients(reminder.ID, count)
+	})
+	if err != nil {
+		return false, err
+	}
+	return delivered, nil
+}
+
+// reminderRecipients menggabungkan peserta terdaftar dan pengurus inti organisasi penyelenggara
+func (s *CalenderService) reminderRecipients(seriesID uint, organizationID *int) ([]string, error) {
+	usernames, err := s.repository.GetRegisteredUsernames(seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if organizationID != nil {
+		officers, err := s.studentRepo.FindUsernamesByOrganization(*organizationID)
+		if err != nil {
+			return nil, err
+		}
+		usernames = append(usernames, officers...)
+	}
+
+	seen := map[string]bool{}
+	recipients := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		key := strings.ToLower(username)
+		if username == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		recipients = append(recipients, username)
+	}
+	return recipients, nil
+}
+
+// reminderLead menuliskan sisa waktu sampai event dimulai, dibulatkan ke satuan terdekat
+// (penjadwal berjalan per menit, jadi pengingat 24 jam tetap tertulis "1 hari")
+func reminderLead(d time.Duration) string {
+	switch {
+	case d >= 23*time.Hour+30*time.Minute:
+		return fmt.Sprintf("%d hari", int((d+12*time.Hour)/(24*time.Hour)))
+	case d >= 59*time.Minute+30*time.Second:
+		return fmt.Sprintf("%d jam", int((d+30*time.Minute)/time.Hour))
+	case d >= 30*time.Second:
+		return fmt.Sprintf("%d menit", int((d+30*time.Second)/time.Minute))
+	default:
+		return "kurang dari 1 menit"
+	}
+}
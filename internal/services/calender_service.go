@@ -2,23 +2,31 @@ package services
 
 import (
 	"errors"
+	"io"
+	"regexp"
+	"sort"
 	"time"
 	"bem_be/internal/models"
 	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
 
 	"gorm.io/gorm"
 )
 
 type CalenderService struct {
-	repository *repositories.CalenderRepository
-	db         *gorm.DB
+	repository          *repositories.CalenderRepository
+	studentRepo         *repositories.StudentRepository
+	notificationService *NotificationService
+	db                  *gorm.DB
 }
 
-func NewCalenderService(db *gorm.DB) *CalenderService {
+func NewCalenderService(db *gorm.DB, notificationService *NotificationService) *CalenderService {
 	repo := repositories.NewCalenderRepository(db)
 	return &CalenderService{
-		repository: repo,
-		db:         db,
+		repository:          repo,
+		studentRepo:         repositories.NewStudentRepository(),
+		notificationService: notificationService,
+		db:                  db,
 	}
 }
 
@@ -29,47 +37,72 @@ func (s *CalenderService) CreateEvent(event *models.Calender) error {
 	if event.EndTime.Before(event.StartTime) {
 		return errors.New("waktu selesai harus setelah waktu mulai")
 	}
-	return (*s.repository).Create(event)
-}
-
-func (s *CalenderService) UpdateEvent(event *models.Calender) error {
-	existingEvent, err := (*s.repository).GetByID(event.ID)
-	if err != nil {
+	// kemunculan pengganti hanya dibuat lewat UpdateEvent dengan scope occurrence
+	event.ParentID = nil
+	event.OriginalStart = nil
+	if err := prepareRecurrence(event); err != nil {
 		return err
 	}
-	if existingEvent == nil {
-		return errors.New("event tidak ditemukan")
+	if err := validateRegistrationSettings(event); err != nil {
+		return err
 	}
-
-	// Validasi waktu
-	if event.EndTime.Before(event.StartTime) {
-		return errors.New("waktu selesai harus setelah waktu mulai")
+	if err := validateAttendanceSettings(event); err != nil {
+		return err
 	}
 
-	return (*s.repository).Update(event)
+	var reservation *models.VenueReservation
+	var queued bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := repositories.NewCalenderRepository(tx).Create(event); err != nil {
+			return err
+		}
+		var err error
+		reservation, queued, err = bookVenue(tx, event.ID, true)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.notifyVenueApprover(reservation, queued)
+	return nil
 }
 
 func (s *CalenderService) GetEventByID(id uint) (*models.Calender, error) {
 	return (*s.repository).GetByID(id)
 }
 
-func (s *CalenderService) GetAllEventsInRange(start, end string) ([]models.Calender, error) {
-	return (*s.repository).GetEventsInRangeString(start, end)
-}
+// GetAllEventsInRange mengambil event (RFC3339) beserta kemunculan event berulang di rentang tersebut
+func (s *CalenderService) GetAllEventsInRange(startStr, endStr string) ([]models.Calender, error) {
+	var start, end time.Time
+	var err error
 
-func (s *CalenderService) GetEventsByMonthYear(month, year int) ([]models.Calender, error) {
-	return (*s.repository).GetEventsByMonthYear(month, year)
-}
+	if startStr != "" {
+		start, err = time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			return nil, errors.New("format start time tidak valid")
+		}
+	}
+	if endStr != "" {
+		end, err = time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			return nil, errors.New("format end time tidak valid")
+		}
+	}
 
-func (s *CalenderService) DeleteEvent(id uint) error {
-	event, err := (*s.repository).GetByID(id)
+	events, err := (*s.repository).GetEventsInRange(start, end)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if event == nil {
-		return errors.New("event tidak ditemukan")
+	return s.expandEvents(events, start, end)
+}
+
+func (s *CalenderService) GetEventsByMonthYear(month, year int) ([]models.Calender, error) {
+	events, err := (*s.repository).GetEventsByMonthYear(month, year)
+	if err != nil {
+		return nil, err
 	}
-	return (*s.repository).Delete(id)
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return s.expandEvents(events, start, start.AddDate(0, 1, 0).Add(-time.Nanosecond))
 }
 
 func (s *CalenderService) GetEventsCurrentMonth() ([]models.Calender, int, int, error) {
@@ -83,6 +116,9 @@ func (s *CalenderService) GetEventsCurrentMonth() ([]models.Calender, int, int,
 	if err != nil {
 		return nil, 0, 0, err
 	}
+	if events, err = s.expandEvents(events, start, end); err != nil {
+		return nil, 0, 0, err
+	}
 
 	return events, int(month), year, nil
 }
@@ -94,3 +130,184 @@ func (s *CalenderService) GetAllEvents() ([]models.Calender, error) {
 	}
 	return events, nil
 }
+
+// CalendarImportResult merangkum hasil impor file .ics
+type CalendarImportResult struct {
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Skipped int                   `json:"skipped"`
+	Errors  []CalendarImportError `json:"errors"`
+}
+
+// CalendarImportError menjelaskan event .ics yang dilewati
+type CalendarImportError struct {
+	UID     string `json:"uid"`
+	Summary string `json:"summary"`
+	Message string `json:"message"`
+}
+
+// ownEventUID mengenali UID event buatan sendiri (hasil feed .ics); event seperti ini
+// tidak diimpor agar feed sendiri tidak bisa dipakai menimpa event aslinya
+var ownEventUID = regexp.MustCompile(`^event-\d+@`)
+
+// ImportICS membuat atau memperbarui event dari file .ics berdasarkan UID. Event baru
+// dimasukkan ke organizationID (boleh nil); yang diperbarui hanya event hasil impor
+// sebelumnya di organisasi yang sama. RRULE/EXDATE ikut disimpan dan VEVENT dengan
+// RECURRENCE-ID menjadi pengganti kemunculan seri ber-UID sama. Event yang tidak valid
+// dilewati dan dilaporkan. Hak pengimpor diperiksa lewat AuthorizeImport.
+func (s *CalenderService) ImportICS(r io.Reader, organizationID *int) (*CalendarImportResult, error) {
+	loc := calendarLocation()
+	parsed, err := utils.ParseICal(r, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	// seri induk diproses dulu agar pengganti kemunculan (RECURRENCE-ID) menemukan induknya
+	sort.SliceStable(parsed, func(i, j int) bool {
+		return parsed[i].RecurrenceID.IsZero() && !parsed[j].RecurrenceID.IsZero()
+	})
+
+	result := &CalendarImportResult{Errors: []CalendarImportError{}}
+	for _, item := range parsed {
+		created, err := s.importEvent(item, organizationID, loc)
+		if err != nil {
+			result.Skipped++
+			result.Errors = append(result.Errors, CalendarImportError{UID: item.UID, Summary: item.Summary, Message: err.Error()})
+			continue
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+	return result, nil
+}
+
+func (s *CalenderService) importEvent(item utils.ICalEvent, organizationID *int, loc *time.Location) (bool, error) {
+	if item.UID == "" {
+		return false, errors.New("UID kosong")
+	}
+	if item.Summary == "" {
+		return false, errors.New("SUMMARY (judul) kosong")
+	}
+	if item.Start.IsZero() {
+		return false, errors.New("DTSTART tidak ada atau tidak valid")
+	}
+	start, end := item.Start, item.End
+	exDates, recurrenceID := item.ExDates, item.RecurrenceID
+	if item.AllDay {
+		// tanggal acara seharian dibaca sebagai tengah malam waktu kampus
+		start, end = localMidnight(start, loc), localMidnight(end, loc)
+		for i := range exDates {
+			exDates[i] = localMidnight(exDates[i], loc)
+		}
+		if !recurrenceID.IsZero() {
+			recurrenceID = localMidnight(recurrenceID, loc)
+		}
+	}
+	if end.Before(start) {
+		return false, errors.New("waktu selesai harus setelah waktu mulai")
+	}
+
+	if ownEventUID.MatchString(item.UID) {
+		return false, errors.New("event berasal dari kalender ini dan tidak bisa diimpor ulang")
+	}
+	existing, err := s.repository.GetByUID(item.UID, organizationID)
+	if err != nil {
+		return false, err
+	}
+
+	if !recurrenceID.IsZero() {
+		if existing == nil || !existing.IsRecurring() {
+			return false, errors.New("seri berulang dengan UID ini belum ada")
+		}
+		override, err := s.repository.GetOverride(existing.ID, recurrenceID)
+		if err != nil {
+			return false, err
+		}
+		_, err = s.updateOccurrence(existing, recurrenceID, EventChanges{
+			Title:       item.Summary,
+			Description: item.Description,
+			Location:    item.Location,
+			StartTime:   start,
+			EndTime:     end,
+		})
+		return override == nil, err
+	}
+
+	if existing == nil {
+		event := &models.Calender{
+			Title:          item.Summary,
+			Description:    item.Description,
+			Location:       item.Location,
+			StartTime:      start,
+			EndTime:        end,
+			OrganizationID: organizationID,
+			UID:            item.UID,
+			RRule:          item.RRule,
+			ExDates:        formatExDates(exDates),
+		}
+		if err := prepareRecurrence(event); err != nil {
+			return false, err
+		}
+		return true, s.repository.Create(event)
+	}
+
+	wasRecurring := existing.IsRecurring()
+	rescheduled := !existing.StartTime.Equal(start) || !existing.EndTime.Equal(end) || existing.RRule != item.RRule
+	existing.Title = item.Summary
+	existing.Description = item.Description
+	existing.Location = item.Location
+	existing.StartTime = start
+	existing.EndTime = end
+	existing.RRule = item.RRule
+	existing.ExDates = formatExDates(exDates)
+	if err := prepareRecurrence(existing); err != nil {
+		return false, err
+	}
+	existing.Organization = nil
+
+	var reservation *models.VenueReservation
+	var queued bool
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewCalenderRepository(tx)
+		if err := repo.Update(existing); err != nil {
+			return err
+		}
+		if wasRecurring && !existing.IsRecurring() {
+			if err := repo.DeleteOverrides(existing.ID); err != nil {
+				return err
+			}
+		}
+		var err error
+		reservation, queued, err = bookVenue(tx, existing.ID, rescheduled)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	s.notifyVenueApprover(reservation, queued)
+	return false, nil
+}
+
+func localMidnight(date time.Time, loc *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+// calendarLocation adalah zona waktu untuk waktu .ics tanpa zona (CALENDAR_TIMEZONE,
+// default Asia/Jakarta)
+func calendarLocation() *time.Location {
+	if loc, err := time.LoadLocation(utils.GetEnvWithDefault("CALENDAR_TIMEZONE", "Asia/Jakarta")); err == nil {
+		return loc
+	}
+	return time.Local
+}
+
+// eventFinished menandai event (atau seluruh seri berulangnya) yang sudah berakhir
+func eventFinished(event *models.Calender, now time.Time) bool {
+	if event.IsRecurring() {
+		return event.RecurrenceEnd != nil && event.RecurrenceEnd.Before(now)
+	}
+	return event.EndTime.Before(now)
+}
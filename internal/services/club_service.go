@@ -3,13 +3,12 @@ package services
 import (
 	"gorm.io/gorm"
 	"errors"
-	"fmt"
 	"mime/multipart"
-	"os"
-	"time"
 
 	"bem_be/internal/models"
 	"bem_be/internal/repositories"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 )
 
 // ClubService is a service for club operations
@@ -27,29 +26,31 @@ func NewClubService(db *gorm.DB) *ClubService {
 
 // CreateClub creates a new club
 func (s *ClubService) CreateClub(association *models.Organization, file *multipart.FileHeader) error {
-	// bikin folder kalau belum ada
-	if err := os.MkdirAll("uploads/clubs", os.ModePerm); err != nil {
-		return err
-	}
-
-	// nama file unik
-	filename := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-	filepath := "uploads/clubs/" + filename
-
-	// simpan file
-	if err := saveUploadedFile(file, filepath); err != nil {
+	// simpan gambar lewat pipeline (validasi isi, buang EXIF, buat varian)
+	filename, err := utils.SaveImage(file, storage.FolderClubs, utils.ImagePolicy)
+	if err != nil {
 		return err
 	}
 
 	// simpan path/filename ke struct
 	association.Image = filename
 
-	// simpan ke DB
-	return s.repository.Create(association)
+	// simpan ke DB; gambar dihapus lagi jika insert gagal
+	var files utils.FileChanges
+	files.Added(storage.FolderClubs, filename)
+	err = s.repository.Create(association)
+	files.Done(err)
+	return err
 }
 
 // UpdateClub updates an existing club
-func (s *ClubService) UpdateClub(club *models.Organization) error {
+func (s *ClubService) UpdateClub(club *models.Organization) (err error) {
+	// Gambar baru (jika ada) sudah disimpan handler: dihapus jika update gagal,
+	// sebaliknya gambar lama yang digantikan yang dihapus
+	var files utils.FileChanges
+	files.Added(storage.FolderClubs, club.Image)
+	defer func() { files.Done(err) }()
+
 	// Check if club exists
 	existingClub, err := s.repository.FindByID(club.ID)
 	if err != nil {
@@ -59,6 +60,10 @@ func (s *ClubService) UpdateClub(club *models.Organization) error {
 		return errors.New("himpunan tidak ditemukan")
 	}
 
+	if club.Image != "" {
+		files.Replaced(storage.FolderClubs, existingClub.Image)
+	}
+
 	// Update club
 	return s.repository.Update(club)
 }
@@ -3,13 +3,12 @@ package services
 import (
 	"gorm.io/gorm"
 	"errors"
-	"fmt"
 	"mime/multipart"
-	"os"
-	"time"
 
 	"bem_be/internal/models"
 	"bem_be/internal/repositories"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 )
 
 // DepartmentService is a service for department operations
@@ -27,29 +26,31 @@ func NewDepartmentService(db *gorm.DB) *DepartmentService {
 
 // CreateDepartment creates a new department
 func (s *DepartmentService) CreateDepartment(department *models.Organization, file *multipart.FileHeader) error {
-	// bikin folder kalau belum ada
-	if err := os.MkdirAll("uploads/departments", os.ModePerm); err != nil {
-		return err
-	}
-
-	// nama file unik
-	filename := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-	filepath := "uploads/departments/" + filename
-
-	// simpan file
-	if err := saveUploadedFile(file, filepath); err != nil {
+	// simpan gambar lewat pipeline (validasi isi, buang EXIF, buat varian)
+	filename, err := utils.SaveImage(file, storage.FolderDepartments, utils.ImagePolicy)
+	if err != nil {
 		return err
 	}
 
 	// simpan path/filename ke struct
 	department.Image = filename
 
-	// simpan ke DB
-	return s.repository.Create(department)
+	// simpan ke DB; gambar dihapus lagi jika insert gagal
+	var files utils.FileChanges
+	files.Added(storage.FolderDepartments, filename)
+	err = s.repository.Create(department)
+	files.Done(err)
+	return err
 }
 
 // UpdateDepartment updates an existing department
-func (s *DepartmentService) UpdateDepartment(department *models.Organization) error {
+func (s *DepartmentService) UpdateDepartment(department *models.Organization) (err error) {
+	// Gambar baru (jika ada) sudah disimpan handler: dihapus jika update gagal,
+	// sebaliknya gambar lama yang digantikan yang dihapus
+	var files utils.FileChanges
+	files.Added(storage.FolderDepartments, department.Image)
+	defer func() { files.Done(err) }()
+
 	// Check if department exists
 	existingDepartment, err := s.repository.FindByID(department.ID)
 	if err != nil {
@@ -59,6 +60,10 @@ func (s *DepartmentService) UpdateDepartment(department *models.Organization) er
 		return errors.New("himpunan tidak ditemukan")
 	}
 
+	if department.Image != "" {
+		files.Replaced(storage.FolderDepartments, existingDepartment.Image)
+	}
+
 	// Update department
 	return s.repository.Update(department)
 }
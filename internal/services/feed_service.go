@@ -0,0 +1,347 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bem_be/internal/repositories"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
+)
+
+const feedItemLimit = 50
+
+// FeedService membangun feed RSS 2.0 dan Atom untuk berita dan pengumuman
+type FeedService struct {
+	newsRepo         *repositories.NewsRepository
+	announcementRepo *repositories.AnnouncementRepository
+	baseURL          string // URL publik API, dipakai untuk link self feed
+	siteURL          string // URL frontend, dipakai untuk link artikel
+}
+
+func NewFeedService() *FeedService {
+	baseURL := utils.PublicBaseURL()
+	return &FeedService{
+		newsRepo:         repositories.NewNewsRepository(),
+		announcementRepo: repositories.NewAnnouncementRepository(),
+		baseURL:          baseURL,
+		siteURL:          strings.TrimRight(utils.GetEnvWithDefault("PUBLIC_SITE_URL", baseURL), "/"),
+	}
+}
+
+// Feed adalah hasil render feed beserta waktu perubahan terakhirnya (untuk Last-Modified).
+// LastModified nol berarti tidak diketahui; klien memakai ETag saja.
+type Feed struct {
+	Body         []byte
+	LastModified time.Time
+}
+
+// feedItem adalah bentuk netral satu entri sebelum dirender ke RSS atau Atom
+type feedItem struct {
+	ID            string
+	Title         string
+	Link          string
+	Content       string
+	Category      string
+	Published     time.Time
+	Updated       time.Time
+	Enclosure     string
+	EnclosureType string
+}
+
+type feedMeta struct {
+	Title       string
+	Link        string
+	Self        string
+	Description string
+}
+
+// Format feed yang didukung
+const (
+	FeedFormatRSS  = "rss"
+	FeedFormatAtom = "atom"
+)
+
+// NewsFeed membangun feed berita terbit, opsional per kategori
+func (s *FeedService) NewsFeed(format, category string) (*Feed, error) {
+	newsList, err := s.newsRepo.GetPublishedForFeed(category, feedItemLimit)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := s.newsRepo.LastChange()
+	if err != nil {
+		return nil, err
+	}
+
+	meta := feedMeta{
+		Title:       "Berita BEM",
+		Link:        s.siteURL + "/news",
+		Self:        s.baseURL + "/api/feeds/news/" + format,
+		Description: "Berita terbaru dari BEM",
+	}
+	if category != "" {
+		meta.Title += " - " + category
+		meta.Self += "?category=" + url.QueryEscape(category)
+	}
+
+	items := make([]feedItem, 0, len(newsList))
+	for _, news := range newsList {
+		published := news.CreatedAt
+		if news.PublishedAt != nil {
+			published = *news.PublishedAt
+		}
+		item := feedItem{
+			ID:        fmt.Sprintf("%s/news/%d", s.siteURL, news.ID),
+			Title:     news.Title,
+			Link:      s.siteURL + newsPagePath(news.Slug, news.ID),
+			Content:   news.Content,
+			Category:  news.Category,
+			Published: published,
+			Updated:   news.UpdatedAt,
+		}
+		if news.ImageURL != "" {
+			item.Enclosure = utils.FileURL(storage.FolderNews, news.ImageURL)
+			item.EnclosureType = mimeType(news.ImageURL)
+		}
+		items = append(items, item)
+	}
+
+	return renderFeed(format, meta, items, changed)
+}
+
+// AnnouncementFeed membangun feed pengumuman publik yang sedang aktif, opsional per organisasi
+func (s *FeedService) AnnouncementFeed(format, organization string) (*Feed, error) {
+	now := time.Now()
+	announcements, _, err := s.announcementRepo.GetAllAnnouncements(feedItemLimit, 0, repositories.AnnouncementFilter{
+		View:         "active",
+		Public:       true,
+		Organization: organization,
+	})
+	if err != nil {
+		return nil, err
+	}
+	changed, err := s.announcementRepo.LastChange(now)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := feedMeta{
+		Title:       "Pengumuman BEM",
+		Link:        s.siteURL + "/announcements",
+		Self:        s.baseURL + "/api/feeds/announcements/" + format,
+		Description: "Pengumuman aktif dari BEM dan organisasi mahasiswa",
+	}
+	if organization != "" {
+		meta.Title = "Pengumuman " + strings.ToUpper(organization)
+		meta.Self += "?organization=" + url.QueryEscape(organization)
+	}
+
+	items := make([]feedItem, 0, len(announcements))
+	for _, announcement := range announcements {
+		published := announcement.CreatedAt
+		if announcement.StartDate != nil {
+			published = *announcement.StartDate
+		}
+		item := feedItem{
+			ID:        fmt.Sprintf("%s/announcements/%d", s.siteURL, announcement.ID),
+			Title:     announcement.Title,
+			Link:      s.siteURL + announcementPagePath(announcement.Slug, announcement.ID),
+			Content:   announcement.Content,
+			Published: published,
+			Updated:   announcement.UpdatedAt,
+		}
+		if announcement.Organization != nil {
+			item.Category = announcement.Organization.ShortName
+		}
+		if announcement.FileURL != "" {
+			item.Enclosure = utils.FileURL(storage.FolderAnnouncements, announcement.FileURL)
+			item.EnclosureType = mimeType(announcement.FileURL)
+		}
+		items = append(items, item)
+	}
+
+	return renderFeed(format, meta, items, changed)
+}
+
+func mimeType(name string) string {
+	if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// renderFeed merender feed. changed adalah perubahan terakhir di tabel sumber (termasuk item
+// yang dihapus, ditarik, atau kedaluwarsa) sehingga Last-Modified ikut maju walau item yang
+// berubah sudah tidak ada di feed.
+func renderFeed(format string, meta feedMeta, items []feedItem, changed time.Time) (*Feed, error) {
+	lastModified := changed
+	for _, item := range items {
+		if item.Updated.After(lastModified) {
+			lastModified = item.Updated
+		}
+	}
+	lastModified = lastModified.UTC().Truncate(time.Second)
+
+	// lastBuildDate wajib diisi; sumber yang belum pernah berisi memakai epoch (tanpa header
+	// Last-Modified, lihat Feed)
+	buildDate := lastModified
+	if lastModified.IsZero() {
+		buildDate = time.Unix(0, 0).UTC()
+	}
+
+	var doc interface{}
+	switch format {
+	case FeedFormatRSS:
+		doc = buildRSS(meta, items, buildDate)
+	case FeedFormatAtom:
+		doc = buildAtom(meta, items, buildDate)
+	default:
+		return nil, fmt.Errorf("format feed %q tidak dikenal", format)
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &Feed{Body: append([]byte(xml.Header), body...), LastModified: lastModified}, nil
+}
+
+// ===== RSS 2.0 =====
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	Description string        `xml:"description"`
+	Category    string        `xml:"category,omitempty"`
+	PubDate     string        `xml:"pubDate"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+func buildRSS(meta feedMeta, items []feedItem, lastModified time.Time) rssDocument {
+	channel := rssChannel{
+		Title:         meta.Title,
+		Link:          meta.Link,
+		AtomLink:      atomLink{Href: meta.Self, Rel: "self", Type: "application/rss+xml"},
+		Description:   meta.Description,
+		Language:      "id",
+		LastBuildDate: lastModified.Format(time.RFC1123Z),
+	}
+	for _, item := range items {
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{IsPermaLink: true, Value: item.ID},
+			Description: item.Content,
+			Category:    item.Category,
+			PubDate:     item.Published.Format(time.RFC1123Z),
+		}
+		if item.Enclosure != "" {
+			// Panjang file tidak diketahui tanpa membaca storage; 0 diizinkan oleh validator umum
+			entry.Enclosure = &rssEnclosure{URL: item.Enclosure, Type: item.EnclosureType}
+		}
+		channel.Items = append(channel.Items, entry)
+	}
+	return rssDocument{Version: "2.0", AtomNS: "http://www.w3.org/2005/Atom", Channel: channel}
+}
+
+// ===== Atom =====
+
+type atomDocument struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string        `xml:"title"`
+	ID        string        `xml:"id"`
+	Links     []atomLink    `xml:"link"`
+	Published string        `xml:"published"`
+	Updated   string        `xml:"updated"`
+	Category  *atomCategory `xml:"category,omitempty"`
+	Content   atomContent   `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+func buildAtom(meta feedMeta, items []feedItem, lastModified time.Time) atomDocument {
+	doc := atomDocument{
+		Title:    meta.Title,
+		ID:       meta.Self,
+		Updated:  lastModified.Format(time.RFC3339),
+		Subtitle: meta.Description,
+		Links: []atomLink{
+			{Href: meta.Link, Rel: "alternate", Type: "text/html"},
+			{Href: meta.Self, Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+	for _, item := range items {
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        item.ID,
+			Links:     []atomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}},
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+			Content:   atomContent{Type: "html", Value: item.Content},
+		}
+		if item.Category != "" {
+			entry.Category = &atomCategory{Term: item.Category}
+		}
+		if item.Enclosure != "" {
+			entry.Links = append(entry.Links, atomLink{Href: item.Enclosure, Rel: "enclosure", Type: item.EnclosureType})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return doc
+}
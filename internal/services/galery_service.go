@@ -10,64 +10,236 @@ import (
 )
 
 type GaleryService struct {
-	repository *repositories.GaleryRepository
-	db         *gorm.DB
+	repository  *repositories.GaleryRepository
+	studentRepo *repositories.StudentRepository
+	eventRepo   *repositories.CalenderRepository
+	db          *gorm.DB
 }
 
 func NewGaleryService(db *gorm.DB) *GaleryService {
 	return &GaleryService{
-		repository: repositories.NewGaleryRepository(),
+		repository:  repositories.NewGaleryRepository(),
+		studentRepo: repositories.NewStudentRepository(),
+		eventRepo:   repositories.NewCalenderRepository(db),
+		db:          db,
 	}
 }
 
-func (s *GaleryService) CreateGalery(galery *models.Galery) error {
-	return s.repository.Create(galery)
+// UpdatePhoto menyimpan perubahan keterangan foto yang sudah dicek kepemilikannya
+func (s *GaleryService) UpdatePhoto(photo *models.Galery) error {
+	return s.repository.Update(photo)
 }
 
-func (s *GaleryService) UpdateGalery(galery *models.Galery) error {
-	existingGalery, err := s.repository.FindByID(galery.ID)
+type GaleryWithStats struct {
+	Galery    models.Galery `json:"galery"`
+	RoomCount int64         `json:"room_count"`
+}
+
+func (s *GaleryService) GetGaleryWithStats(id uint) (*GaleryWithStats, error) {
+	galery, err := s.repository.FindByID(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if existingGalery == nil {
-		return errors.New("gambar tidak ditemukan")
+	if galery == nil {
+		return nil, errors.New("gedung tidak ditemukan")
 	}
-	return s.repository.Update(galery)
+	return &GaleryWithStats{
+		Galery: *galery,
+	}, nil
 }
 
-func (s *GaleryService) GetGaleryByID(id uint) (*models.Galery, error) {
-	return s.repository.FindByID(id)
+// ===== Album =====
+
+// ErrGaleryForbidden dikembalikan jika pengurus mengelola album di luar organisasinya
+var ErrGaleryForbidden = errors.New("album berada di luar organisasi Anda")
+
+// canManage memeriksa scope pengelola; scope nil berarti admin (semua album)
+func canManage(album *models.GaleryAlbum, scope *uint) bool {
+	if scope == nil {
+		return true
+	}
+	return album.OrganizationID != nil && *album.OrganizationID == *scope
 }
 
-func (s *GaleryService) GetAllGalerys(limit, offset int) ([]models.Galery, int64, error) {
-	return s.repository.GetAllGalerys(limit, offset)
+// GetOfficerOrganization mengembalikan organisasi tempat mahasiswa menjadi pengurus inti;
+// anggota tanpa jabatan pengurus ditolak
+func (s *GaleryService) GetOfficerOrganization(username string) (*uint, error) {
+	if username == "" {
+		return nil, errors.New("username wajib diisi")
+	}
+	student, err := s.studentRepo.FindByUsername(username)
+	if err != nil || !student.IsOfficer() {
+		return nil, errors.New("hanya pengurus organisasi yang dapat mengelola galeri")
+	}
+	orgID := uint(*student.OrganizationID)
+	return &orgID, nil
 }
 
-func (s *GaleryService) DeleteGalery(id uint) error {
-	galery, err := s.repository.FindByID(id)
+func (s *GaleryService) GetAlbums(filter repositories.GaleryAlbumFilter, limit, offset int) ([]models.GaleryAlbum, int64, error) {
+	return s.repository.GetAlbums(filter, limit, offset)
+}
+
+func (s *GaleryService) GetAlbumByID(id uint) (*models.GaleryAlbum, error) {
+	album, err := s.repository.FindAlbumByID(id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("album tidak ditemukan")
+		}
+		return nil, err
+	}
+	return album, nil
+}
+
+// GetManagedAlbum mengambil album dan memastikan berada dalam scope pengelola
+func (s *GaleryService) GetManagedAlbum(id uint, scope *uint) (*models.GaleryAlbum, error) {
+	album, err := s.GetAlbumByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !canManage(album, scope) {
+		return nil, ErrGaleryForbidden
+	}
+	return album, nil
+}
+
+func (s *GaleryService) GetAlbumPhotos(albumID uint, limit, offset int) ([]models.Galery, int64, error) {
+	return s.repository.GetAlbumPhotos(albumID, limit, offset)
+}
+
+// CreateAlbum membuat album; album milik pengurus selalu terikat ke organisasinya
+func (s *GaleryService) CreateAlbum(album *models.GaleryAlbum, scope *uint) error {
+	if album.Title == "" {
+		return errors.New("judul album wajib diisi")
+	}
+	if scope != nil {
+		album.OrganizationID = scope
+	}
+	if err := s.checkAlbumEvent(album); err != nil {
 		return err
 	}
-	if galery == nil {
-		return errors.New("gambar tidak ditemukan")
+	return s.repository.CreateAlbum(album)
+}
+
+// checkAlbumEvent memastikan event yang dikaitkan ke album ada
+func (s *GaleryService) checkAlbumEvent(album *models.GaleryAlbum) error {
+	if album.EventID == nil {
+		return nil
 	}
-	return s.repository.DeleteByID(id)
+	event, err := s.eventRepo.GetByID(*album.EventID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return errors.New("event tidak ditemukan")
+	}
+	return nil
 }
 
-type GaleryWithStats struct {
-	Galery    models.Galery `json:"galery"`
-	RoomCount int64         `json:"room_count"`
+func (s *GaleryService) UpdateAlbum(album *models.GaleryAlbum, scope *uint) error {
+	if album.Title == "" {
+		return errors.New("judul album wajib diisi")
+	}
+	if !canManage(album, scope) {
+		return ErrGaleryForbidden
+	}
+	if err := s.checkAlbumEvent(album); err != nil {
+		return err
+	}
+	return s.repository.UpdateAlbum(album)
 }
 
-func (s *GaleryService) GetGaleryWithStats(id uint) (*GaleryWithStats, error) {
-	galery, err := s.repository.FindByID(id)
+// DeleteAlbum menghapus album beserta fotonya dan mengembalikan path file yang perlu dibersihkan
+func (s *GaleryService) DeleteAlbum(id uint, scope *uint) ([]string, error) {
+	if _, err := s.GetManagedAlbum(id, scope); err != nil {
+		return nil, err
+	}
+	photos, _, err := s.repository.GetAlbumPhotos(id, -1, -1)
 	if err != nil {
 		return nil, err
 	}
-	if galery == nil {
-		return nil, errors.New("gedung tidak ditemukan")
+	if err := s.repository.DeleteAlbum(id); err != nil {
+		return nil, err
 	}
-	return &GaleryWithStats{
-		Galery: *galery,
-	}, nil
+	files := make([]string, 0, len(photos))
+	for _, photo := range photos {
+		if photo.ImageURL != "" {
+			files = append(files, photo.ImageURL)
+		}
+	}
+	return files, nil
+}
+
+// AddPhotos menambahkan foto ke akhir album. Album tanpa sampul memakai foto pertama sebagai sampul.
+func (s *GaleryService) AddPhotos(album *models.GaleryAlbum, photos []models.Galery) ([]models.Galery, error) {
+	next, err := s.repository.NextSortOrder(album.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range photos {
+		photos[i].AlbumID = &album.ID
+		photos[i].SortOrder = next + i
+		if photos[i].Title == "" {
+			photos[i].Title = album.Title
+		}
+	}
+	if err := s.repository.CreatePhotos(photos); err != nil {
+		return nil, err
+	}
+	if album.CoverPhotoID == nil && len(photos) > 0 {
+		album.CoverPhotoID = &photos[0].ID
+		if err := s.repository.UpdateAlbum(album); err != nil {
+			return nil, err
+		}
+	}
+	return photos, nil
+}
+
+// GetManagedPhoto mengambil foto album dan memastikan albumnya berada dalam scope pengelola
+func (s *GaleryService) GetManagedPhoto(id uint, scope *uint) (*models.Galery, error) {
+	photo, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, errors.New("foto tidak ditemukan")
+	}
+	if photo.AlbumID == nil {
+		if scope != nil {
+			return nil, ErrGaleryForbidden
+		}
+		return photo, nil
+	}
+	if _, err := s.GetManagedAlbum(*photo.AlbumID, scope); err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+// DeletePhoto menghapus foto dan melepaskannya dari sampul album
+func (s *GaleryService) DeletePhoto(photo *models.Galery) error {
+	if err := s.repository.DeleteByID(photo.ID); err != nil {
+		return err
+	}
+	return s.repository.ClearCover(photo.ID)
+}
+
+// ReorderPhotos mengatur ulang urutan foto; photoIDs harus milik album tersebut
+func (s *GaleryService) ReorderPhotos(albumID uint, photoIDs []uint) error {
+	if len(photoIDs) == 0 {
+		return errors.New("photo_ids wajib diisi")
+	}
+	if err := s.repository.ReorderPhotos(albumID, photoIDs); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("ada foto yang bukan milik album ini")
+		}
+		return err
+	}
+	return nil
+}
+
+// SetCover menjadikan salah satu foto album sebagai sampul
+func (s *GaleryService) SetCover(album *models.GaleryAlbum, photoID uint) error {
+	photo, err := s.repository.FindByID(photoID)
+	if err != nil || photo.AlbumID == nil || *photo.AlbumID != album.ID {
+		return errors.New("foto bukan milik album ini")
+	}
+	album.CoverPhotoID = &photo.ID
+	return s.repository.UpdateAlbum(album)
 }
@@ -2,16 +2,15 @@ package services
 
 import (
 	"errors"
-	"fmt"
 	"mime/multipart"
-	"os"
-	"time"
 
 	"gorm.io/gorm"
 
 	"bem_be/internal/database"
 	"bem_be/internal/models"
 	"bem_be/internal/repositories"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 )
 
 // ItemService is a service for item operations
@@ -29,25 +28,21 @@ func NewItemService(db *gorm.DB) *ItemService {
 
 // CreateItem creates a new item
 func (s *ItemService) CreateItemSarpras(item *models.Item, file *multipart.FileHeader) error {
-	// bikin folder kalau belum ada
-	if err := os.MkdirAll("uploads/items", os.ModePerm); err != nil {
-		return err
-	}
-
-	// nama file unik
-	filename := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-	filepath := "uploads/items/" + filename
-
-	// simpan file
-	if err := saveUploadedFile(file, filepath); err != nil {
+	// simpan gambar lewat pipeline (validasi isi, buang EXIF, buat varian)
+	filename, err := utils.SaveImage(file, storage.FolderItems, utils.ImagePolicy)
+	if err != nil {
 		return err
 	}
 
 	// simpan path/filename ke struct
 	item.Image = filename
 
-	// simpan ke DB
-	return s.repository.Create(item)
+	// simpan ke DB; gambar dihapus lagi jika insert gagal
+	var files utils.FileChanges
+	files.Added(storage.FolderItems, filename)
+	err = s.repository.Create(item)
+	files.Done(err)
+	return err
 }
 
 // UpdateItem updates an existing item
@@ -137,25 +132,21 @@ func (s *ItemService) GetItemWithStatsSarpras(id uint) (*ItemSarprasWithStats, e
 
 // CreateItem creates a new item
 func (s *ItemService) CreateItemDepol(item *models.Item, file *multipart.FileHeader) error {
-	// bikin folder kalau belum ada
-	if err := os.MkdirAll("uploads/items", os.ModePerm); err != nil {
-		return err
-	}
-
-	// nama file unik
-	filename := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-	filepath := "uploads/items/" + filename
-
-	// simpan file
-	if err := saveUploadedFile(file, filepath); err != nil {
+	// simpan gambar lewat pipeline (validasi isi, buang EXIF, buat varian)
+	filename, err := utils.SaveImage(file, storage.FolderItems, utils.ImagePolicy)
+	if err != nil {
 		return err
 	}
 
 	// simpan path/filename ke struct
 	item.Image = filename
 
-	// simpan ke DB
-	return s.repository.Create(item)
+	// simpan ke DB; gambar dihapus lagi jika insert gagal
+	var files utils.FileChanges
+	files.Added(storage.FolderItems, filename)
+	err = s.repository.Create(item)
+	files.Done(err)
+	return err
 }
 
 // UpdateItem updates an existing item
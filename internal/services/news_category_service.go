@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// NewsCategoryService adalah service untuk kategori dan tag berita
+type NewsCategoryService struct {
+	repository *repositories.NewsCategoryRepository
+}
+
+// NewNewsCategoryService membuat service kategori berita baru
+func NewNewsCategoryService() *NewsCategoryService {
+	return &NewsCategoryService{
+		repository: repositories.NewNewsCategoryRepository(),
+	}
+}
+
+// GetCategories mengambil semua kategori beserta jumlah berita. publishedOnly hanya
+// menghitung berita terbit (halaman publik).
+func (s *NewsCategoryService) GetCategories(publishedOnly bool) ([]models.NewsCategory, error) {
+	return s.repository.GetCategories(countedStatus(publishedOnly))
+}
+
+// ResolveCategory mencari kategori dari input editor: ID, slug, atau nama
+func (s *NewsCategoryService) ResolveCategory(value string) (*models.NewsCategory, error) {
+	value = strings.TrimSpace(value)
+	var (
+		category *models.NewsCategory
+		err      error
+	)
+	if id, convErr := strconv.ParseUint(value, 10, 64); convErr == nil {
+		category, err = s.repository.FindCategoryByID(uint(id))
+	} else {
+		category, err = s.repository.FindCategory(utils.Slugify(value), value)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("kategori berita %q tidak ditemukan", value)
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+// CreateCategory membuat kategori baru. Slug dibuat dari nama jika tidak diisi.
+func (s *NewsCategoryService) CreateCategory(category *models.NewsCategory) error {
+	if err := s.prepareCategory(category); err != nil {
+		return err
+	}
+	return s.repository.CreateCategory(category)
+}
+
+// UpdateCategory memperbarui kategori; nama baru ikut disalin ke berita di kategori ini
+func (s *NewsCategoryService) UpdateCategory(id uint, input *models.NewsCategory) (*models.NewsCategory, error) {
+	category, err := s.findCategory(id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != "" {
+		category.Name = input.Name
+	}
+	if input.Slug != "" {
+		category.Slug = input.Slug
+	}
+	category.Description = input.Description
+	category.SortOrder = input.SortOrder
+
+	if err := s.prepareCategory(category); err != nil {
+		return nil, err
+	}
+	if err := s.repository.UpdateCategory(category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory menghapus kategori yang sudah tidak dipakai berita mana pun
+func (s *NewsCategoryService) DeleteCategory(id uint) error {
+	if _, err := s.findCategory(id); err != nil {
+		return err
+	}
+	count, err := s.repository.CountCategoryNews(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("kategori masih dipakai %d berita, pindahkan beritanya dulu", count)
+	}
+	return s.repository.DeleteCategory(id)
+}
+
+// ReorderCategories menyimpan urutan kategori sesuai urutan ID
+func (s *NewsCategoryService) ReorderCategories(ids []uint) error {
+	if err := s.repository.ReorderCategories(ids); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("kategori tidak ditemukan")
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *NewsCategoryService) findCategory(id uint) (*models.NewsCategory, error) {
+	category, err := s.repository.FindCategoryByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("kategori tidak ditemukan")
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *NewsCategoryService) prepareCategory(category *models.NewsCategory) error {
+	category.Name = strings.Join(strings.Fields(category.Name), " ")
+	if category.Name == "" {
+		return errors.New("nama kategori tidak boleh kosong")
+	}
+	if category.Slug == "" {
+		category.Slug = category.Name
+	}
+	category.Slug = utils.Slugify(category.Slug)
+	if category.Slug == "" {
+		return errors.New("slug kategori harus mengandung huruf atau angka")
+	}
+	exists, err := s.repository.CategorySlugExists(category.Slug, category.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("slug kategori %q sudah dipakai", category.Slug)
+	}
+	return nil
+}
+
+// GetTags mengambil semua tag beserta jumlah berita. publishedOnly hanya menghitung
+// berita terbit (halaman publik).
+func (s *NewsCategoryService) GetTags(publishedOnly bool) ([]models.NewsTag, error) {
+	return s.repository.GetTags(countedStatus(publishedOnly))
+}
+
+// ResolveTags mengubah daftar nama tag menjadi tag tersimpan; tag yang belum ada dibuat.
+// Nama yang menghasilkan slug sama dianggap satu tag.
+func (s *NewsCategoryService) ResolveTags(names []string) ([]models.NewsTag, error) {
+	tags := []models.NewsTag{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.Join(strings.Fields(name), " ")
+		slug := utils.Slugify(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		tag, err := s.repository.FindOrCreateTag(slug, name)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, *tag)
+	}
+	return tags, nil
+}
+
+// UpdateTag mengganti nama (dan slug) tag
+func (s *NewsCategoryService) UpdateTag(id uint, name, slug string) (*models.NewsTag, error) {
+	tag, err := s.repository.FindTagByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("tag tidak ditemukan")
+		}
+		return nil, err
+	}
+	if name = strings.Join(strings.Fields(name), " "); name != "" {
+		tag.Name = name
+	}
+	if slug == "" {
+		slug = tag.Name
+	}
+	tag.Slug = utils.Slugify(slug)
+	if tag.Slug == "" {
+		return nil, errors.New("slug tag harus mengandung huruf atau angka")
+	}
+	exists, err := s.repository.TagSlugExists(tag.Slug, tag.ID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("slug tag %q sudah dipakai", tag.Slug)
+	}
+	if err := s.repository.UpdateTag(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// DeleteTag menghapus tag dari semua berita
+func (s *NewsCategoryService) DeleteTag(id uint) error {
+	if _, err := s.repository.FindTagByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("tag tidak ditemukan")
+		}
+		return err
+	}
+	return s.repository.DeleteTag(id)
+}
+
+func countedStatus(publishedOnly bool) string {
+	if publishedOnly {
+		return models.NewsStatusPublished
+	}
+	return ""
+}
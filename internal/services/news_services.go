@@ -4,28 +4,288 @@ import (
 	"bem_be/internal/models"
 	"bem_be/internal/repositories"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// ErrNewsForbidden dikembalikan bila pengguna bukan penulis maupun penyunting berita
+var ErrNewsForbidden = errors.New("anda tidak berhak mengelola berita ini")
+
 // NewsService adalah service untuk operasi berita.
 type NewsService struct {
-	repository *repositories.NewsRepository
+	repository          *repositories.NewsRepository
+	studentRepo         *repositories.StudentRepository
+	revisions           *RevisionService
+	categories          *NewsCategoryService
+	slugs               *SlugService
+	notificationService *NotificationService
 }
 
 // NewNewsService membuat service berita baru.
-func NewNewsService(db *gorm.DB) *NewsService {
+func NewNewsService(db *gorm.DB, notificationService *NotificationService) *NewsService {
 	return &NewsService{
-		repository: repositories.NewNewsRepository(),
+		repository:          repositories.NewNewsRepository(),
+		studentRepo:         repositories.NewStudentRepository(),
+		revisions:           NewRevisionService(),
+		categories:          NewNewsCategoryService(),
+		slugs:               NewSlugService(),
+		notificationService: notificationService,
 	}
 }
 
-// CreateNews membuat berita baru.
-func (s *NewsService) CreateNews(news *models.News) error {
+// newsTransitions adalah perpindahan status berita yang diizinkan.
+var newsTransitions = map[string][]string{
+	models.NewsStatusDraft:     {models.NewsStatusInReview, models.NewsStatusArchived},
+	models.NewsStatusInReview:  {models.NewsStatusDraft, models.NewsStatusScheduled, models.NewsStatusPublished, models.NewsStatusArchived},
+	models.NewsStatusScheduled: {models.NewsStatusDraft, models.NewsStatusPublished, models.NewsStatusArchived},
+	models.NewsStatusPublished: {models.NewsStatusArchived},
+	models.NewsStatusArchived:  {models.NewsStatusDraft},
+}
+
+// authorTransitions adalah perpindahan yang boleh dilakukan penulis atas beritanya sendiri;
+// menjadwalkan, menerbitkan, dan mengarsipkan hanya oleh penyunting.
+var authorTransitions = map[string][]string{
+	models.NewsStatusDraft:    {models.NewsStatusInReview},
+	models.NewsStatusInReview: {models.NewsStatusDraft},
+}
+
+// CreateNews membuat berita baru. Berita baru selalu dimulai sebagai draft
+// atau langsung diajukan untuk review; penerbitan lewat ChangeStatus.
+// Isi awal dicatat sebagai revisi pertama atas nama editor.
+func (s *NewsService) CreateNews(news *models.News, editor string) error {
 	if news.Title == "" || news.Content == "" {
 		return errors.New("judul dan konten tidak boleh kosong")
 	}
-	return s.repository.Create(news)
+	if news.Status == "" {
+		news.Status = models.NewsStatusDraft
+	}
+	if news.Status != models.NewsStatusDraft && news.Status != models.NewsStatusInReview {
+		return errors.New("berita baru hanya boleh berstatus draft atau in_review")
+	}
+	news.CreatedBy = editor
+	if err := s.prepareSEO(news, ""); err != nil {
+		return err
+	}
+	if err := s.repository.Create(news); err != nil {
+		return err
+	}
+	if news.Tags != nil {
+		if err := s.repository.ReplaceTags(news, news.Tags); err != nil {
+			return err
+		}
+	}
+	s.revisions.RecordCreated(NewsSnapshot(news), editor)
+	return nil
+}
+
+// prepareSEO menentukan slug dan merapikan meta description sebelum berita disimpan.
+// Slug hanya berubah jika diganti editor, sehingga judul baru tidak mengubah URL;
+// slug yang dikosongkan dibuat ulang dari judul.
+func (s *NewsService) prepareSEO(news *models.News, currentSlug string) error {
+	if news.Slug == "" || news.Slug != currentSlug {
+		slug, err := s.slugs.AssignSlug(models.SlugEntityNews, news.ID, news.Title, news.Slug)
+		if err != nil {
+			return err
+		}
+		news.Slug = slug
+	}
+	meta, err := normalizeMetaDescription(news.MetaDescription)
+	if err != nil {
+		return err
+	}
+	news.MetaDescription = meta
+	return nil
+}
+
+// SetCategory memasang kategori terkelola ke berita berdasarkan ID, slug, atau nama.
+// Nilai kosong mengosongkan kategori.
+func (s *NewsService) SetCategory(news *models.News, value string) error {
+	if value == "" {
+		news.CategoryID = nil
+		news.CategoryRef = nil
+		news.Category = ""
+		return nil
+	}
+	category, err := s.categories.ResolveCategory(value)
+	if err != nil {
+		return err
+	}
+	news.CategoryID = &category.ID
+	news.CategoryRef = category
+	news.Category = category.Name
+	return nil
+}
+
+// SetTags memasang tag ke berita berdasarkan nama; tag baru dibuat otomatis.
+// Tag baru tersimpan ke berita saat CreateNews/UpdateNews.
+func (s *NewsService) SetTags(news *models.News, names []string) error {
+	tags, err := s.categories.ResolveTags(names)
+	if err != nil {
+		return err
+	}
+	news.Tags = tags
+	return nil
+}
+
+// isNewsEditor menentukan apakah pengguna penyunting berita: admin atau pengurus inti BEM/MPM
+func (s *NewsService) isNewsEditor(username string, isAdmin bool) (bool, error) {
+	if isAdmin {
+		return true, nil
+	}
+	if username == "" {
+		return false, nil
+	}
+	student, err := s.studentRepo.FindByUserID(username)
+	if err != nil {
+		return false, err
+	}
+	return student != nil && student.IsExecutive(), nil
+}
+
+// isNewsAuthor melaporkan apakah username adalah penulis berita
+func isNewsAuthor(news *models.News, username string) bool {
+	return news.CreatedBy != "" && strings.EqualFold(news.CreatedBy, username)
+}
+
+// ChangeStatus memindahkan berita ke status baru sesuai alur kerja.
+// publishAt wajib diisi (dan di masa depan) untuk status scheduled.
+// Penulis hanya boleh mengajukan atau menarik review beritanya sendiri.
+func (s *NewsService) ChangeStatus(id uint, status string, publishAt *time.Time, username string, isAdmin bool) (*models.News, error) {
+	news, err := s.GetNewsByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	current := news.Status
+	if current == "" {
+		current = models.NewsStatusPublished
+	}
+	if !newsTransitionAllowed(newsTransitions, current, status) {
+		return nil, fmt.Errorf("status berita tidak bisa diubah dari %s ke %s", current, status)
+	}
+	if !isNewsAuthor(news, username) || !newsTransitionAllowed(authorTransitions, current, status) {
+		editor, err := s.isNewsEditor(username, isAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if !editor {
+			return nil, ErrNewsForbidden
+		}
+	}
+
+	updates := map[string]interface{}{"status": status}
+	switch status {
+	case models.NewsStatusScheduled:
+		if publishAt == nil || !publishAt.After(time.Now()) {
+			return nil, errors.New("publish_at wajib diisi dengan waktu di masa depan untuk berita terjadwal")
+		}
+		updates["publish_at"] = *publishAt
+	case models.NewsStatusPublished:
+		now := time.Now()
+		updates["publish_at"] = now
+		updates["published_at"] = now
+	case models.NewsStatusDraft:
+		updates["publish_at"] = nil
+	}
+
+	ok, err := s.repository.UpdateStatusIf(id, news.Status, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("status berita sudah berubah, silakan muat ulang")
+	}
+
+	updated, err := s.GetNewsByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if status == models.NewsStatusPublished {
+		s.notifyPublished(updated)
+	}
+	return updated, nil
+}
+
+func newsTransitionAllowed(transitions map[string][]string, from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PreviewNews mengambil berita apa pun statusnya untuk pratinjau; berita yang belum terbit
+// hanya untuk penulis dan penyunting
+func (s *NewsService) PreviewNews(id uint, username string, isAdmin bool) (*models.News, error) {
+	news, err := s.GetNewsByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if news.Status == models.NewsStatusPublished || isNewsAuthor(news, username) {
+		return news, nil
+	}
+	editor, err := s.isNewsEditor(username, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if !editor {
+		return nil, ErrNewsForbidden
+	}
+	return news, nil
+}
+
+// PublishScheduledNews menerbitkan berita terjadwal yang publish_at-nya sudah lewat
+// dan mengirim notifikasinya. Dipanggil berkala oleh scheduler.
+func (s *NewsService) PublishScheduledNews() (int, error) {
+	now := time.Now()
+	dueNews, err := s.repository.FindDueScheduled(now)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, news := range dueNews {
+		ok, err := s.repository.UpdateStatusIf(news.ID, models.NewsStatusScheduled, map[string]interface{}{
+			"status":       models.NewsStatusPublished,
+			"published_at": now,
+		})
+		if err != nil {
+			log.Printf("Gagal menerbitkan berita terjadwal %d: %v", news.ID, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		news.Status = models.NewsStatusPublished
+		news.PublishedAt = &now
+		s.notifyPublished(&news)
+		published++
+	}
+	return published, nil
+}
+
+// notifyPublished membuat notifikasi (dan event webhook) untuk berita yang baru terbit.
+func (s *NewsService) notifyPublished(news *models.News) {
+	if s.notificationService == nil {
+		return
+	}
+	title := "Berita Baru: " + news.Title
+	message := fmt.Sprintf("Berita baru telah diterbitkan di kategori %s. Cek sekarang!", news.Category)
+
+	if _, err := s.notificationService.CreateNotification(title, message, NotificationTarget{
+		Type:       models.NotificationTypeNews,
+		EntityType: models.NotificationTypeNews,
+		EntityID:   news.ID,
+		Event:      models.WebhookEventNewsPublished,
+		Entity:     NewsWebhookEntity(news),
+	}); err != nil {
+		log.Printf("Gagal membuat notifikasi berita %d: %v", news.ID, err)
+	}
 }
 type NewsWithStats struct {
 	News  models.News `json:"news"`
@@ -47,9 +307,68 @@ func (s *NewsService) GetNewsWithStats(id uint) (*NewsWithStats, error) {
 	}, nil
 }
 
-// UpdateNews memperbarui berita yang ada.
-func (s *NewsService) UpdateNews(news *models.News) error {
-	return s.repository.Update(news)
+// UpdateNews memperbarui berita yang ada dan mencatat revisinya atas nama editor.
+func (s *NewsService) UpdateNews(news *models.News, editor string) error {
+	before, err := s.GetNewsByID(news.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.prepareSEO(news, before.Slug); err != nil {
+		return err
+	}
+	if err := s.repository.Update(news); err != nil {
+		return err
+	}
+	s.slugs.Renamed(models.SlugEntityNews, news.ID, before.Slug, news.Slug)
+	if news.Tags != nil {
+		if err := s.repository.ReplaceTags(news, news.Tags); err != nil {
+			return err
+		}
+	}
+	s.revisions.RecordUpdate(NewsSnapshot(before), NewsSnapshot(news), editor, nil)
+	return nil
+}
+
+// GetNewsRevisions mengambil riwayat revisi berita, terbaru dulu
+func (s *NewsService) GetNewsRevisions(id uint, page, perPage int) ([]models.ContentRevision, int64, error) {
+	if _, err := s.GetNewsByID(id); err != nil {
+		return nil, 0, err
+	}
+	return s.revisions.GetRevisions(models.RevisionEntityNews, id, page, perPage)
+}
+
+// GetNewsRevision mengambil isi berita pada revisi tertentu
+func (s *NewsService) GetNewsRevision(id uint, version int) (*models.ContentRevision, error) {
+	return s.revisions.GetRevision(models.RevisionEntityNews, id, version)
+}
+
+// RollbackNews mengembalikan judul, konten, kategori dan gambar berita ke isi revisi
+// tertentu. Rollback dicatat sebagai revisi baru sehingga riwayat tidak pernah hilang.
+// Status terbit tidak ikut berubah.
+func (s *NewsService) RollbackNews(id uint, version int, editor string) (*models.News, error) {
+	news, err := s.GetNewsByID(id)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.GetNewsRevision(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	before := NewsSnapshot(news)
+	news.Title = revision.Title
+	news.Content = revision.Content
+	news.ImageURL = revision.FileURL
+	// Kategori yang sudah dihapus tidak bisa dikembalikan; kategori saat ini dipertahankan
+	if err := s.SetCategory(news, revision.Category); err != nil {
+		log.Printf("Kategori revisi #%d berita %d tidak dipulihkan: %v", revision.Version, id, err)
+	}
+
+	if err := s.repository.Update(news); err != nil {
+		return nil, err
+	}
+	s.revisions.RecordUpdate(before, NewsSnapshot(news), editor, &revision.Version)
+	return s.GetNewsByID(id)
 }
 
 // GetNewsByID mendapatkan berita berdasarkan ID.
@@ -64,9 +383,42 @@ func (s *NewsService) GetNewsByID(id uint) (*models.News, error) {
 	return news, nil
 }
 
-// GetAllNews mendapatkan semua berita dengan pagination.
-func (s *NewsService) GetAllNews(limit, offset int) ([]models.News, int64, error) {
-	return s.repository.GetAllNews(limit, offset)
+// GetAllNews mendapatkan semua berita dengan pagination, opsional difilter status,
+// kategori dan tag.
+func (s *NewsService) GetAllNews(limit, offset int, filter repositories.NewsFilter) ([]models.News, int64, error) {
+	return s.repository.GetAllNews(limit, offset, filter)
+}
+
+// GetPublishedNews mendapatkan berita yang sudah terbit (untuk halaman publik).
+func (s *NewsService) GetPublishedNews(limit, offset int, filter repositories.NewsFilter) ([]models.News, int64, error) {
+	filter.Status = models.NewsStatusPublished
+	return s.repository.GetAllNews(limit, offset, filter)
+}
+
+// GetPublishedNewsBySlug mendapatkan berita terbit berdasarkan slug. redirected bernilai
+// true jika slug adalah slug lama; slug terbaru ada di news.Slug.
+func (s *NewsService) GetPublishedNewsBySlug(slug string) (news *models.News, redirected bool, err error) {
+	id, redirected, err := s.slugs.Resolve(models.SlugEntityNews, slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, errors.New("berita tidak ditemukan")
+		}
+		return nil, false, err
+	}
+	news, err = s.GetPublishedNewsByID(id)
+	return news, redirected, err
+}
+
+// GetPublishedNewsByID mendapatkan berita terbit berdasarkan ID.
+func (s *NewsService) GetPublishedNewsByID(id uint) (*models.News, error) {
+	news, err := s.repository.FindPublishedByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("berita tidak ditemukan")
+		}
+		return nil, err
+	}
+	return news, nil
 }
 
 // DeleteNews menghapus sebuah berita.
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+
+	"bem_be/internal/models"
+)
+
+func TestAuthorTransitions(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{models.NewsStatusDraft, models.NewsStatusInReview, true},
+		{models.NewsStatusInReview, models.NewsStatusDraft, true},
+		{models.NewsStatusInReview, models.NewsStatusPublished, false},
+		{models.NewsStatusInReview, models.NewsStatusScheduled, false},
+		{models.NewsStatusDraft, models.NewsStatusArchived, false},
+		{models.NewsStatusPublished, models.NewsStatusArchived, false},
+	}
+	for _, tc := range cases {
+		if got := newsTransitionAllowed(authorTransitions, tc.from, tc.to); got != tc.want {
+			t.Errorf("penulis %s -> %s = %v; ingin %v", tc.from, tc.to, got, tc.want)
+		}
+		// setiap perpindahan penulis juga harus sah di alur kerja umum
+		if tc.want && !newsTransitionAllowed(newsTransitions, tc.from, tc.to) {
+			t.Errorf("%s -> %s tidak ada di newsTransitions", tc.from, tc.to)
+		}
+	}
+}
+
+func TestIsNewsAuthor(t *testing.T) {
+	news := &models.News{CreatedBy: "ifs21001"}
+	if !isNewsAuthor(news, "IFS21001") {
+		t.Error("penulis dengan huruf berbeda tidak dikenali")
+	}
+	if isNewsAuthor(news, "ifs21002") {
+		t.Error("pengguna lain dianggap penulis")
+	}
+	if isNewsAuthor(&models.News{}, "") {
+		t.Error("berita tanpa penulis cocok dengan username kosong")
+	}
+}
@@ -3,15 +3,26 @@ package services
 import (
 	"bem_be/internal/models"
 	"bem_be/internal/repositories"
+	"errors"
+	"log"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type NotificationService struct {
-	repo *repositories.NotificationRepository
+	repo     *repositories.NotificationRepository
+	webhooks *WebhookService
+}
+
+func NewNotificationService(repo *repositories.NotificationRepository, webhooks *WebhookService) *NotificationService {
+	return &NotificationService{repo: repo, webhooks: webhooks}
 }
 
-func NewNotificationService(repo *repositories.NotificationRepository) *NotificationService {
-	return &NotificationService{repo: repo}
+// WithTx mengembalikan service yang menyimpan notifikasi di transaksi tx, agar notifikasi
+// ikut batal bila transaksi pemanggil gagal
+func (s *NotificationService) WithTx(tx *gorm.DB) *NotificationService {
+	return &NotificationService{repo: repositories.NewNotificationRepository(tx), webhooks: s.webhooks}
 }
 
 // Ambil semua notif (umum)
@@ -24,49 +35,181 @@ func (s *NotificationService) GetUserNotifications(username string) ([]models.Us
 	return s.repo.GetUserNotifications(username)
 }
 
-func (s *NotificationService) CreateNotification(title, message string) (*models.Notification, error) {
+// NotificationTarget menentukan jenis notifikasi, entitas yang dibuka saat
+// notifikasi diklik (deep-link), dan penerimanya. Username kosong berarti
+// notifikasi dikirim ke semua user. Jika Event diisi, event tersebut juga
+// dikirim ke webhook subscriber beserta Entity.
+type NotificationTarget struct {
+	Type       string
+	EntityType string
+	EntityID   uint
+	Username   string
+	Event      string
+	Entity     *WebhookEntity
+}
+
+// webhookPayload menyusun data event webhook. Isi pesan dan penerima tidak ikut dikirim
+// karena notifikasi personal bisa memuat data pribadi (mis. alasan penolakan peminjaman).
+func webhookPayload(title string, target NotificationTarget) map[string]interface{} {
+	return map[string]interface{}{
+		"notification": map[string]interface{}{"title": title, "type": target.Type},
+		"entity_type":  target.EntityType,
+		"entity_id":    target.EntityID,
+		"entity":       target.Entity,
+	}
+}
+
+func (s *NotificationService) CreateNotification(title, message string, target NotificationTarget) (*models.Notification, error) {
 	notification := &models.Notification{
-		Title:   title,
-		Message: message,
+		Title:      title,
+		Message:    message,
+		Type:       target.Type,
+		EntityType: target.EntityType,
+		Username:   target.Username,
+	}
+	if target.EntityID != 0 {
+		entityID := target.EntityID
+		notification.EntityID = &entityID
+	}
+	if err := s.repo.CreateNotification(notification); err != nil {
+		return notification, err
+	}
+
+	if s.webhooks != nil && target.Event != "" {
+		if err := s.webhooks.Dispatch(target.Event, webhookPayload(title, target)); err != nil {
+			log.Printf("Gagal mengirim webhook %s: %v", target.Event, err)
+		}
 	}
-	err := s.repo.CreateNotification(notification)
-	return notification, err
+	return notification, nil
 }
 
-func (s *NotificationService) MarkNotificationAsRead(username, notificationID string) error {
-    return s.repo.CreateUserNotificationIfNotExists(username, notificationID)
+// CreateNotificationForUsers membuat notifikasi personal untuk setiap username
+// (mis. pengumuman dengan target audiens). Event webhook hanya dikirim sekali.
+func (s *NotificationService) CreateNotificationForUsers(title, message string, target NotificationTarget, usernames []string) (int, error) {
+	notifications := make([]models.Notification, 0, len(usernames))
+	for _, username := range usernames {
+		notification := models.Notification{
+			Title:      title,
+			Message:    message,
+			Type:       target.Type,
+			EntityType: target.EntityType,
+			Username:   username,
+		}
+		if target.EntityID != 0 {
+			entityID := target.EntityID
+			notification.EntityID = &entityID
+		}
+		notifications = append(notifications, notification)
+	}
+	if err := s.repo.CreateNotifications(notifications); err != nil {
+		return 0, err
+	}
+
+	if s.webhooks != nil && target.Event != "" {
+		payload := webhookPayload(title, target)
+		payload["recipients"] = len(usernames)
+		if err := s.webhooks.Dispatch(target.Event, payload); err != nil {
+			log.Printf("Gagal mengirim webhook %s: %v", target.Event, err)
+		}
+	}
+	return len(notifications), nil
 }
 
-type NotificationWithRead struct {
-	ID        uint      `json:"id"`
-	Title     string    `json:"title"`
-	Message   string    `json:"message"`
-	CreatedAt time.Time `json:"created_at"`
-	IsRead    bool      `json:"is_read"`
+// MarkNotificationAsRead menandai notifikasi sebagai dibaca, hanya bila notifikasi itu terlihat oleh user
+func (s *NotificationService) MarkNotificationAsRead(username string, notificationID uint) error {
+	if _, err := s.findVisible(username, notificationID); err != nil {
+		return err
+	}
+	return s.repo.CreateUserNotificationIfNotExists(username, notificationID)
 }
 
-func (s *NotificationService) GetAllNotificationsForUser(username string) ([]NotificationWithRead, error) {
-	notifications, err := s.repo.GetAllNotifications()
+// MarkAllAsRead menandai semua notifikasi user sebagai dibaca
+func (s *NotificationService) MarkAllAsRead(username string) (int64, error) {
+	return s.repo.MarkAllAsRead(username)
+}
+
+// CountUnread menghitung notifikasi yang belum dibaca user
+func (s *NotificationService) CountUnread(username string) (int64, error) {
+	return s.repo.CountUnread(username)
+}
+
+// ArchiveNotification mengarsipkan (atau mengeluarkan dari arsip) notifikasi milik user
+func (s *NotificationService) ArchiveNotification(username string, notificationID uint, archived bool) error {
+	if _, err := s.findVisible(username, notificationID); err != nil {
+		return err
+	}
+	updates := map[string]interface{}{"is_archived": archived, "archived_at": nil}
+	if archived {
+		updates["archived_at"] = time.Now()
+	}
+	return s.repo.UpsertUserState(username, notificationID, updates)
+}
+
+// DeleteNotification menghapus notifikasi dari inbox user (tidak menghapus untuk user lain)
+func (s *NotificationService) DeleteNotification(username string, notificationID uint) error {
+	if _, err := s.findVisible(username, notificationID); err != nil {
+		return err
+	}
+	return s.repo.UpsertUserState(username, notificationID, map[string]interface{}{"is_deleted": true})
+}
+
+func (s *NotificationService) findVisible(username string, notificationID uint) (*models.Notification, error) {
+	notification, err := s.repo.FindVisibleByID(username, notificationID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("notifikasi tidak ditemukan")
+		}
 		return nil, err
 	}
+	return notification, nil
+}
+
+// PruneReadNotifications membersihkan notifikasi yang sudah dibaca atau diarsipkan lebih lama dari retention
+func (s *NotificationService) PruneReadNotifications(retention time.Duration) (int64, error) {
+	return s.repo.PruneReadNotifications(time.Now().Add(-retention))
+}
+
+type NotificationWithRead struct {
+	ID         uint       `json:"id"`
+	Title      string     `json:"title"`
+	Message    string     `json:"message"`
+	Type       string     `json:"type"`
+	EntityType string     `json:"entity_type"`
+	EntityID   *uint      `json:"entity_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	IsRead     bool       `json:"is_read"`
+	IsArchived bool       `json:"is_archived"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+}
 
-	// ambil UserNotifications untuk user
-	readMap, err := s.repo.GetUserNotificationsMap(username)
+// GetInbox mengambil notifikasi user beserta status baca, dengan filter dan pagination.
+// Mengembalikan cursor untuk halaman berikutnya (0 jika sudah habis).
+func (s *NotificationService) GetInbox(username string, filter repositories.NotificationFilter) ([]NotificationWithRead, int64, uint, error) {
+	rows, total, err := s.repo.GetInbox(username, filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, 0, err
 	}
 
-	result := make([]NotificationWithRead, len(notifications))
-	for i, n := range notifications {
+	result := make([]NotificationWithRead, len(rows))
+	for i, n := range rows {
 		result[i] = NotificationWithRead{
-			ID:        n.ID,
-			Title:     n.Title,
-			Message:   n.Message,
-			CreatedAt: n.CreatedAt,
-			IsRead:    readMap[n.ID], // true jika user sudah baca
+			ID:         n.ID,
+			Title:      n.Title,
+			Message:    n.Message,
+			Type:       n.Type,
+			EntityType: n.EntityType,
+			EntityID:   n.EntityID,
+			CreatedAt:  n.CreatedAt,
+			IsRead:     n.IsRead,
+			IsArchived: n.IsArchived,
+			ReadAt:     n.ReadAt,
 		}
 	}
 
-	return result, nil
+	var nextCursor uint
+	if filter.Limit > 0 && len(rows) == filter.Limit {
+		nextCursor = rows[len(rows)-1].ID
+	}
+
+	return result, total, nextCursor, nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"bem_be/internal/models"
 	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
 )
 
 // OrganizationService is a service for organization operations
@@ -17,7 +18,7 @@ type OrganizationService struct {
 
 func NewOrganizationService(db *gorm.DB) *OrganizationService {
     return &OrganizationService{
-        // repository: repositories.NewOrganizationRepository(),
+        repository: repositories.NewOrganizationRepository(),
     }
 }
 
@@ -46,3 +47,29 @@ func (s *OrganizationService) GetOrganizationWithStats(id uint) (*OrganizationWi
 		Organization: *organization,
 	}, nil
 }
+
+// UpdateOrganizationSEO updates the meta description and OpenGraph image of an
+// organization profile. metaDescription nil leaves it unchanged; ogImage is the new
+// image already stored in the organization's folder, removeOGImage clears it.
+func (s *OrganizationService) UpdateOrganizationSEO(organization *models.Organization, folder string, metaDescription *string, ogImage string, removeOGImage bool) (err error) {
+	var files utils.FileChanges
+	files.Added(folder, ogImage)
+	defer func() { files.Done(err) }()
+
+	updates := map[string]interface{}{}
+	if metaDescription != nil {
+		meta, err := normalizeMetaDescription(*metaDescription)
+		if err != nil {
+			return err
+		}
+		updates["meta_description"] = meta
+	}
+	if ogImage != "" || removeOGImage {
+		files.Replaced(folder, organization.OGImage)
+		updates["og_image"] = ogImage
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return s.repository.UpdateFields(organization.ID, updates)
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"bem_be/internal/models"
 	"bem_be/internal/repositories"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -168,7 +170,12 @@ func (s *RequestService) ProcessRequestStatusSarpras(requestID uint, status stri
 	return request, nil
 }
 
-func (s *RequestService) UpdateImageBarangAndStatusSarpras(id uint, filename, status string) error {
+func (s *RequestService) UpdateImageBarangAndStatusSarpras(id uint, filename, status string) (err error) {
+	// Foto baru dihapus jika update gagal, sebaliknya foto barang sebelumnya yang dihapus
+	var files utils.FileChanges
+	files.Added(storage.FolderRequestItems, filename)
+	defer func() { files.Done(err) }()
+
 	// 1. Ambil data request
 	request, err := s.repository.FindByIDSarpras(id)
 	if err != nil {
@@ -177,6 +184,9 @@ func (s *RequestService) UpdateImageBarangAndStatusSarpras(id uint, filename, st
 	if request == nil {
 		return fmt.Errorf("request not found")
 	}
+	// Foto lama bisa dari form peminjaman (folder requests) atau unggahan sebelumnya (requests/barang)
+	files.Replaced(storage.FolderRequests, request.ImageURLBRG)
+	files.Replaced(storage.FolderRequestItems, request.ImageURLBRG)
 
 	// 2. Decode item IDs dari request.Item
 	var itemIDs []uint
@@ -410,7 +420,12 @@ func (s *RequestService) ProcessRequestStatusDepol(requestID uint, status string
 	return request, nil
 }
 
-func (s *RequestService) UpdateImageBarangAndStatusDepol(id uint, filename, status string) error {
+func (s *RequestService) UpdateImageBarangAndStatusDepol(id uint, filename, status string) (err error) {
+	// Foto baru dihapus jika update gagal, sebaliknya foto barang sebelumnya yang dihapus
+	var files utils.FileChanges
+	files.Added(storage.FolderRequestItems, filename)
+	defer func() { files.Done(err) }()
+
 	// 1. Ambil data request
 	request, err := s.repository.FindByIDDepol(id)
 	if err != nil {
@@ -419,6 +434,9 @@ func (s *RequestService) UpdateImageBarangAndStatusDepol(id uint, filename, stat
 	if request == nil {
 		return fmt.Errorf("request not found")
 	}
+	// Foto lama bisa dari form peminjaman (folder requests) atau unggahan sebelumnya (requests/barang)
+	files.Replaced(storage.FolderRequests, request.ImageURLBRG)
+	files.Replaced(storage.FolderRequestItems, request.ImageURLBRG)
 
 	// 2. Decode item IDs dari request.Item
 	var itemIDs []uint
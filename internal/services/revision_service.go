@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// RevisionService mencatat dan membaca riwayat revisi berita dan pengumuman
+type RevisionService struct {
+	repository *repositories.RevisionRepository
+}
+
+// NewRevisionService membuat service revisi baru
+func NewRevisionService() *RevisionService {
+	return &RevisionService{
+		repository: repositories.NewRevisionRepository(),
+	}
+}
+
+// NewsSnapshot mengambil isi berita yang disimpan di revisi
+func NewsSnapshot(news *models.News) models.ContentRevision {
+	return models.ContentRevision{
+		EntityType: models.RevisionEntityNews,
+		EntityID:   news.ID,
+		Title:      news.Title,
+		Content:    news.Content,
+		Category:   news.Category,
+		FileURL:    news.ImageURL,
+	}
+}
+
+// AnnouncementSnapshot mengambil isi pengumuman yang disimpan di revisi
+func AnnouncementSnapshot(announcement *models.Announcement) models.ContentRevision {
+	return models.ContentRevision{
+		EntityType: models.RevisionEntityAnnouncement,
+		EntityID:   announcement.ID,
+		Title:      announcement.Title,
+		Content:    announcement.Content,
+		FileURL:    announcement.FileURL,
+		StartDate:  announcement.StartDate,
+		EndDate:    announcement.EndDate,
+	}
+}
+
+// RecordCreated mencatat revisi pertama untuk konten yang baru dibuat
+func (s *RevisionService) RecordCreated(snapshot models.ContentRevision, editor string) {
+	snapshot.Editor = editor
+	snapshot.Summary = "Dibuat"
+	if err := s.repository.Create(&snapshot); err != nil {
+		log.Printf("Gagal mencatat revisi %s %d: %v", snapshot.EntityType, snapshot.EntityID, err)
+	}
+}
+
+// RecordUpdate mencatat revisi setelah konten disunting. Konten lama yang belum punya
+// riwayat (dibuat sebelum fitur revisi) lebih dulu dicatat isi sebelumnya sebagai versi
+// awal, supaya tetap bisa di-rollback. Penyuntingan tanpa perubahan isi tidak dicatat,
+// kecuali rollback (restoredFrom diisi).
+//
+// Kegagalan hanya dicatat di log: perubahan konten sudah tersimpan dan tidak dibatalkan.
+func (s *RevisionService) RecordUpdate(before, after models.ContentRevision, editor string, restoredFrom *int) {
+	summary, fields := diffRevisions(&before, &after)
+	if len(fields) == 0 && restoredFrom == nil {
+		return
+	}
+
+	total, err := s.repository.Count(after.EntityType, after.EntityID)
+	if err != nil {
+		log.Printf("Gagal membaca revisi %s %d: %v", after.EntityType, after.EntityID, err)
+		return
+	}
+	if total == 0 {
+		before.Summary = "Versi awal (sebelum riwayat revisi dicatat)"
+		if err := s.repository.Create(&before); err != nil {
+			log.Printf("Gagal mencatat revisi %s %d: %v", before.EntityType, before.EntityID, err)
+			return
+		}
+	}
+
+	if restoredFrom != nil {
+		summary = strings.TrimSuffix(fmt.Sprintf("Rollback ke revisi #%d; %s", *restoredFrom, summary), "; ")
+	}
+	after.Editor = editor
+	after.Summary = summary
+	after.ChangedFields = strings.Join(fields, ",")
+	after.RestoredFrom = restoredFrom
+	if err := s.repository.Create(&after); err != nil {
+		log.Printf("Gagal mencatat revisi %s %d: %v", after.EntityType, after.EntityID, err)
+	}
+}
+
+// GetRevisions mengambil daftar revisi sebuah konten (tanpa isi lengkap), terbaru dulu
+func (s *RevisionService) GetRevisions(entityType string, entityID uint, page, perPage int) ([]models.ContentRevision, int64, error) {
+	return s.repository.FindByEntity(entityType, entityID, perPage, (page-1)*perPage)
+}
+
+// GetRevision mengambil satu revisi lengkap berdasarkan nomor versinya
+func (s *RevisionService) GetRevision(entityType string, entityID uint, version int) (*models.ContentRevision, error) {
+	revision, err := s.repository.FindVersion(entityType, entityID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("revisi tidak ditemukan")
+		}
+		return nil, err
+	}
+	return revision, nil
+}
+
+// diffRevisions membandingkan dua isi konten dan mengembalikan ringkasan yang bisa
+// dibaca editor beserta nama field yang berubah
+func diffRevisions(before, after *models.ContentRevision) (string, []string) {
+	var parts, fields []string
+
+	if before.Title != after.Title {
+		fields = append(fields, "title")
+		parts = append(parts, fmt.Sprintf("judul: %q → %q", before.Title, after.Title))
+	}
+	if before.Content != after.Content {
+		fields = append(fields, "content")
+		parts = append(parts, fmt.Sprintf("konten diubah (%d → %d karakter)",
+			utf8.RuneCountInString(before.Content), utf8.RuneCountInString(after.Content)))
+	}
+	if before.Category != after.Category {
+		fields = append(fields, "category")
+		parts = append(parts, fmt.Sprintf("kategori: %s → %s", orDash(before.Category), orDash(after.Category)))
+	}
+	if before.FileURL != after.FileURL {
+		fields = append(fields, "file_url")
+		label := "lampiran"
+		if after.EntityType == models.RevisionEntityNews {
+			label = "gambar"
+		}
+		switch {
+		case before.FileURL == "":
+			parts = append(parts, label+" ditambahkan")
+		case after.FileURL == "":
+			parts = append(parts, label+" dihapus")
+		default:
+			parts = append(parts, label+" diganti")
+		}
+	}
+	if !sameDate(before.StartDate, after.StartDate) {
+		fields = append(fields, "start_date")
+		parts = append(parts, fmt.Sprintf("tanggal mulai: %s → %s", formatDate(before.StartDate), formatDate(after.StartDate)))
+	}
+	if !sameDate(before.EndDate, after.EndDate) {
+		fields = append(fields, "end_date")
+		parts = append(parts, fmt.Sprintf("tanggal selesai: %s → %s", formatDate(before.EndDate), formatDate(after.EndDate)))
+	}
+
+	return strings.Join(parts, "; "), fields
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"bem_be/internal/models"
+)
+
+func TestDiffRevisions(t *testing.T) {
+	day := func(d int) *time.Time {
+		t := time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	cases := []struct {
+		name          string
+		before, after models.ContentRevision
+		summary       string
+		fields        []string
+	}{
+		{
+			name:   "tidak ada perubahan",
+			before: models.ContentRevision{Title: "A", Content: "isi", StartDate: day(1)},
+			after:  models.ContentRevision{Title: "A", Content: "isi", StartDate: day(1)},
+		},
+		{
+			name:    "judul dan konten",
+			before:  models.ContentRevision{Title: "Lama", Content: "héllo"},
+			after:   models.ContentRevision{Title: "Baru", Content: "héllo dunia"},
+			summary: `judul: "Lama" → "Baru"; konten diubah (5 → 11 karakter)`,
+			fields:  []string{"title", "content"},
+		},
+		{
+			name:    "kategori dikosongkan",
+			before:  models.ContentRevision{Category: "Akademik"},
+			after:   models.ContentRevision{},
+			summary: "kategori: Akademik → -",
+			fields:  []string{"category"},
+		},
+		{
+			name:    "gambar berita diganti",
+			before:  models.ContentRevision{EntityType: models.RevisionEntityNews, FileURL: "a.jpg"},
+			after:   models.ContentRevision{EntityType: models.RevisionEntityNews, FileURL: "b.jpg"},
+			summary: "gambar diganti",
+			fields:  []string{"file_url"},
+		},
+		{
+			name:    "lampiran pengumuman ditambahkan",
+			before:  models.ContentRevision{EntityType: models.RevisionEntityAnnouncement},
+			after:   models.ContentRevision{EntityType: models.RevisionEntityAnnouncement, FileURL: "a.pdf"},
+			summary: "lampiran ditambahkan",
+			fields:  []string{"file_url"},
+		},
+		{
+			name:    "lampiran dihapus",
+			before:  models.ContentRevision{EntityType: models.RevisionEntityAnnouncement, FileURL: "a.pdf"},
+			after:   models.ContentRevision{EntityType: models.RevisionEntityAnnouncement},
+			summary: "lampiran dihapus",
+			fields:  []string{"file_url"},
+		},
+		{
+			name:    "tanggal",
+			before:  models.ContentRevision{StartDate: day(1), EndDate: day(3)},
+			after:   models.ContentRevision{StartDate: day(2)},
+			summary: "tanggal mulai: 2024-05-01 → 2024-05-02; tanggal selesai: 2024-05-03 → -",
+			fields:  []string{"start_date", "end_date"},
+		},
+	}
+	for _, c := range cases {
+		summary, fields := diffRevisions(&c.before, &c.after)
+		if summary != c.summary {
+			t.Errorf("%s: ringkasan = %q; ingin %q", c.name, summary, c.summary)
+		}
+		if !reflect.DeepEqual(fields, c.fields) {
+			t.Errorf("%s: field = %v; ingin %v", c.name, fields, c.fields)
+		}
+	}
+}
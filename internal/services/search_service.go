@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"bem_be/internal/repositories"
+)
+
+// SearchService adalah service untuk pencarian gabungan berita, pengumuman, kegiatan, organisasi dan aspirasi
+type SearchService struct {
+	repository *repositories.SearchRepository
+}
+
+func NewSearchService() *SearchService {
+	return &SearchService{
+		repository: repositories.NewSearchRepository(),
+	}
+}
+
+// Search mencari keyword di jenis konten yang dipilih; types kosong berarti semua jenis
+func (s *SearchService) Search(keyword string, types []string, page, limit int) ([]repositories.SearchResult, int64, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, 0, errors.New("kata kunci pencarian (q) wajib diisi")
+	}
+
+	if len(types) == 0 {
+		types = repositories.SearchTypes
+	}
+	for _, t := range types {
+		if !isSearchType(t) {
+			return nil, 0, errors.New("type tidak dikenal: " + t + " (pilihan: " + strings.Join(repositories.SearchTypes, ", ") + ")")
+		}
+	}
+
+	offset := (page - 1) * limit
+	return s.repository.Search(keyword, types, limit, offset)
+}
+
+func isSearchType(t string) bool {
+	for _, known := range repositories.SearchTypes {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// slugFallbacks dipakai bila judul tidak menghasilkan slug (mis. hanya emoji)
+var slugFallbacks = map[string]string{
+	models.SlugEntityNews:         "berita",
+	models.SlugEntityAnnouncement: "pengumuman",
+}
+
+// SlugService membuat slug unik untuk konten publik dan mengelola pengalihan slug lama
+type SlugService struct {
+	repository *repositories.SlugRepository
+}
+
+// NewSlugService membuat service slug baru
+func NewSlugService() *SlugService {
+	return &SlugService{
+		repository: repositories.NewSlugRepository(),
+	}
+}
+
+// AssignSlug menentukan slug konten. Slug dari editor (requested) dinormalisasi, tidak boleh
+// hanya angka (bentrok dengan lookup ID) dan harus belum dipakai; jika kosong slug dibuat dari
+// judul dengan akhiran angka bila perlu.
+func (s *SlugService) AssignSlug(entityType string, id uint, title, requested string) (string, error) {
+	if requested != "" {
+		slug := utils.Slugify(requested)
+		if slug == "" {
+			return "", errors.New("slug harus mengandung huruf atau angka")
+		}
+		if utils.NumericSlug(slug) {
+			return "", errors.New("slug tidak boleh hanya berisi angka")
+		}
+		taken, err := s.repository.SlugTaken(entityType, slug, id)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", fmt.Errorf("slug %q sudah dipakai", slug)
+		}
+		return slug, nil
+	}
+
+	var lookupErr error
+	slug := utils.UniqueSlug(title, slugFallbacks[entityType], func(candidate string) bool {
+		taken, err := s.repository.SlugTaken(entityType, candidate, id)
+		if err != nil {
+			lookupErr = err
+			return false
+		}
+		return taken
+	})
+	return slug, lookupErr
+}
+
+// Renamed mencatat slug lama sebagai pengalihan ke konten setelah slugnya diganti
+func (s *SlugService) Renamed(entityType string, id uint, oldSlug, newSlug string) {
+	if oldSlug == "" || oldSlug == newSlug {
+		return
+	}
+	if err := s.repository.SaveRedirect(entityType, oldSlug, id); err != nil {
+		log.Printf("Gagal menyimpan pengalihan slug %s %q: %v", entityType, oldSlug, err)
+	}
+	// slug baru mungkin dulunya slug lama konten ini sendiri
+	if err := s.repository.DeleteRedirect(entityType, newSlug); err != nil {
+		log.Printf("Gagal menghapus pengalihan slug %s %q: %v", entityType, newSlug, err)
+	}
+}
+
+// Resolve mencari ID konten dari slug. redirected bernilai true jika slug adalah slug
+// lama, sehingga pemanggil sebaiknya mengarahkan ke slug terbaru.
+func (s *SlugService) Resolve(entityType, slug string) (id uint, redirected bool, err error) {
+	id, err = s.repository.FindIDBySlug(entityType, slug)
+	if err == nil {
+		return id, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, err
+	}
+
+	redirect, err := s.repository.FindRedirect(entityType, slug)
+	if err != nil {
+		return 0, false, err
+	}
+	return redirect.EntityID, true, nil
+}
+
+// maxMetaDescription adalah panjang kolom meta_description
+const maxMetaDescription = 300
+
+// normalizeMetaDescription merapikan spasi meta description dan membatasi panjangnya
+func normalizeMetaDescription(value string) (string, error) {
+	value = strings.Join(strings.Fields(value), " ")
+	if utf8.RuneCountInString(value) > maxMetaDescription {
+		return "", fmt.Errorf("meta description maksimal %d karakter", maxMetaDescription)
+	}
+	return value, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
+)
+
+// organizationPagePaths memetakan kategori organisasi ke path halaman profilnya di frontend
+var organizationPagePaths = map[int]string{
+	1: "/clubs/",
+	2: "/departments/",
+	3: "/associations/",
+}
+
+// newsPagePath adalah path halaman berita di frontend; berita tanpa slug memakai ID
+func newsPagePath(slug string, id uint) string {
+	if slug == "" {
+		return fmt.Sprintf("/news/%d", id)
+	}
+	return "/news/" + url.PathEscape(slug)
+}
+
+// announcementPagePath adalah path halaman pengumuman di frontend
+func announcementPagePath(slug string, id uint) string {
+	if slug == "" {
+		return fmt.Sprintf("/announcements/%d", id)
+	}
+	return "/announcements/" + url.PathEscape(slug)
+}
+
+// SitemapService membangun sitemap.xml berisi semua halaman publik
+type SitemapService struct {
+	repository *repositories.SitemapRepository
+	siteURL    string
+}
+
+func NewSitemapService() *SitemapService {
+	return &SitemapService{
+		repository: repositories.NewSitemapRepository(),
+		siteURL:    strings.TrimRight(utils.GetEnvWithDefault("PUBLIC_SITE_URL", utils.PublicBaseURL()), "/"),
+	}
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Sitemap membangun sitemap berita terbit, pengumuman publik, dan profil organisasi.
+// Halaman daftar (beranda, berita, pengumuman) memakai lastmod terbaru dari isinya.
+func (s *SitemapService) Sitemap() (*Feed, error) {
+	now := time.Now()
+	// diambil sebelum daftar halaman agar perubahan di antaranya tidak tertutup Last-Modified lama
+	changed, err := s.repository.LastChange(now)
+	if err != nil {
+		return nil, err
+	}
+	news, err := s.repository.PublishedNews()
+	if err != nil {
+		return nil, err
+	}
+	announcements, err := s.repository.PublicAnnouncements(now)
+	if err != nil {
+		return nil, err
+	}
+	organizations, err := s.repository.Organizations()
+	if err != nil {
+		return nil, err
+	}
+
+	// Last-Modified ikut maju saat halaman hilang dari sitemap (dihapus, ditarik, diarsipkan),
+	// bukan hanya saat halaman yang tersisa berubah; nol berarti tidak diketahui
+	var pages []sitemapURL
+	lastModified := changed
+	add := func(path string, updated time.Time) {
+		page := sitemapURL{Loc: s.siteURL + path}
+		if !updated.IsZero() {
+			page.LastMod = updated.UTC().Format(time.RFC3339)
+		}
+		pages = append(pages, page)
+		if updated.After(lastModified) {
+			lastModified = updated
+		}
+	}
+	latest := func(entries []repositories.SitemapEntry) time.Time {
+		var t time.Time
+		for _, e := range entries {
+			if e.UpdatedAt.After(t) {
+				t = e.UpdatedAt
+			}
+		}
+		return t
+	}
+
+	newsUpdated, announcementsUpdated := latest(news), latest(announcements)
+	home := newsUpdated
+	if announcementsUpdated.After(home) {
+		home = announcementsUpdated
+	}
+	add("/", home)
+	add("/news", newsUpdated)
+	add("/announcements", announcementsUpdated)
+
+	for _, e := range news {
+		add(newsPagePath(e.Slug, e.ID), e.UpdatedAt)
+	}
+	for _, e := range announcements {
+		add(announcementPagePath(e.Slug, e.ID), e.UpdatedAt)
+	}
+	for _, e := range organizations {
+		if prefix, ok := organizationPagePaths[e.CategoryID]; ok {
+			add(prefix+url.PathEscape(e.Slug), e.UpdatedAt)
+		}
+	}
+
+	body, err := xml.MarshalIndent(sitemapURLSet{
+		XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  pages,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &Feed{
+		Body:         append([]byte(xml.Header), body...),
+		LastModified: lastModified.UTC().Truncate(time.Second),
+	}, nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"bem_be/internal/repositories"
+	"bem_be/internal/storage"
+	"bem_be/internal/utils"
+)
+
+// OrphanedFile adalah file di storage yang tidak dirujuk kolom database mana pun
+type OrphanedFile struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modified_at"`
+	// InGrace berarti file masih lebih muda dari grace period sehingga tidak dihapus;
+	// bisa jadi unggahan yang record database-nya sedang dibuat
+	InGrace bool `json:"in_grace"`
+	Deleted bool `json:"deleted"`
+}
+
+// UploadGCReport adalah hasil satu kali rekonsiliasi storage dengan database
+type UploadGCReport struct {
+	StartedAt    time.Time                    `json:"started_at"`
+	DryRun       bool                         `json:"dry_run"`
+	GracePeriod  string                       `json:"grace_period"`
+	FilesScanned int                          `json:"files_scanned"`
+	References   int                          `json:"references"`
+	Orphans      []OrphanedFile               `json:"orphans"`
+	Dangling     []repositories.FileReference `json:"dangling_references"`
+	DeletedCount int                          `json:"deleted_count"`
+	DeletedBytes int64                        `json:"deleted_bytes"`
+	Errors       []string                     `json:"errors,omitempty"`
+}
+
+// UploadGCService mencari file unggahan yatim (tidak dirujuk database) dan referensi
+// database yang filenya sudah tidak ada
+type UploadGCService struct {
+	repository *repositories.UploadRepository
+	storage    storage.Storage
+}
+
+func NewUploadGCService() *UploadGCService {
+	return &UploadGCService{
+		repository: repositories.NewUploadRepository(),
+		storage:    storage.Default(),
+	}
+}
+
+// Run merekonsiliasi storage dengan database. File yatim yang lebih tua dari grace dihapus,
+// kecuali dryRun. Referensi dibaca sebelum storage didaftar, sehingga file yang diunggah di
+// tengah proses selalu lebih muda dari grace dan tidak ikut terhapus.
+func (s *UploadGCService) Run(grace time.Duration, dryRun bool) (*UploadGCReport, error) {
+	report := &UploadGCReport{
+		StartedAt:   time.Now(),
+		DryRun:      dryRun,
+		GracePeriod: grace.String(),
+		Orphans:     []OrphanedFile{},
+		Dangling:    []repositories.FileReference{},
+	}
+
+	refs, err := s.repository.FindFileReferences()
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca referensi file: %w", err)
+	}
+	report.References = len(refs)
+
+	referenced := map[string]bool{}
+	for _, ref := range refs {
+		if isExternalURL(ref.Value) {
+			continue
+		}
+		for _, folder := range ref.Folders {
+			if key := storage.Key(folder, ref.Value); key != "" {
+				for _, variant := range utils.ImageVariantKeys(key) {
+					referenced[variant] = true
+				}
+			}
+		}
+	}
+
+	objects, err := s.storage.List("")
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca isi storage: %w", err)
+	}
+
+	known := map[string]bool{}
+	for _, folder := range storage.Folders {
+		known[folder] = true
+	}
+
+	existing := map[string]bool{}
+	cutoff := report.StartedAt.Add(-grace)
+	for _, obj := range objects {
+		existing[obj.Key] = true
+		// Hanya file di folder kanonik yang dikelola; file lain (mis. sisa layout lama yang
+		// belum dimigrasi) tidak pernah disentuh
+		if !known[path.Dir(obj.Key)] {
+			continue
+		}
+		report.FilesScanned++
+		if referenced[obj.Key] {
+			continue
+		}
+
+		orphan := OrphanedFile{Key: obj.Key, Size: obj.Size, ModTime: obj.ModTime, InGrace: obj.ModTime.After(cutoff)}
+		if !orphan.InGrace && !dryRun {
+			if err := s.storage.Delete(obj.Key); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", obj.Key, err))
+			} else {
+				orphan.Deleted = true
+				report.DeletedCount++
+				report.DeletedBytes += obj.Size
+			}
+		}
+		report.Orphans = append(report.Orphans, orphan)
+	}
+
+	for _, ref := range refs {
+		if isExternalURL(ref.Value) {
+			continue
+		}
+		found := false
+		for _, folder := range ref.Folders {
+			if existing[storage.Key(folder, ref.Value)] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report.Dangling = append(report.Dangling, ref)
+		}
+	}
+
+	return report, nil
+}
+
+// RunAndLog menjalankan Run dan mencatat ringkasannya, untuk job latar belakang
+func (s *UploadGCService) RunAndLog(grace time.Duration, dryRun bool) {
+	report, err := s.Run(grace, dryRun)
+	if err != nil {
+		log.Printf("Gagal menjalankan pembersihan file unggahan: %v", err)
+		return
+	}
+	log.Printf("Pembersihan file unggahan: %d file diperiksa, %d yatim (%d dihapus, %d byte), %d referensi tanpa file, %d error",
+		report.FilesScanned, len(report.Orphans), report.DeletedCount, report.DeletedBytes, len(report.Dangling), len(report.Errors))
+	for _, ref := range report.Dangling {
+		log.Printf("Referensi tanpa file: %s.%s id=%d -> %s", ref.Table, ref.Column, ref.ID, ref.Value)
+	}
+}
+
+func isExternalURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// Error booking venue
+var (
+	ErrVenueNotFound       = errors.New("venue tidak ditemukan")
+	ErrVenueConflict       = errors.New("venue sudah dipesan pada waktu tersebut")
+	ErrReservationNotFound = errors.New("booking venue tidak ditemukan")
+)
+
+const (
+	// venueBookingHorizon membatasi pemeriksaan bentrok seri tanpa akhir (hari ke depan)
+	venueBookingHorizon = 366
+	// maxAvailabilityDays membatasi rentang tampilan ketersediaan venue
+	maxAvailabilityDays = 92
+)
+
+// VenueService mengelola venue, antrean persetujuan booking dan ketersediaannya
+type VenueService struct {
+	repository          *repositories.VenueRepository
+	events              *repositories.CalenderRepository
+	studentRepo         *repositories.StudentRepository
+	notificationService *NotificationService
+}
+
+func NewVenueService(db *gorm.DB, notificationService *NotificationService) *VenueService {
+	return &VenueService{
+		repository:          repositories.NewVenueRepository(db),
+		events:              repositories.NewCalenderRepository(db),
+		studentRepo:         repositories.NewStudentRepository(),
+		notificationService: notificationService,
+	}
+}
+
+// VenueAvailability adalah slot terpakai sebuah venue dalam satu rentang waktu
+type VenueAvailability struct {
+	Venue    *models.Venue         `json:"venue"`
+	Start    time.Time             `json:"start"`
+	End      time.Time             `json:"end"`
+	Bookings []models.VenueBooking `json:"bookings"`
+}
+
+// GetVenues mengambil daftar venue; activeOnly untuk pilihan saat membuat event
+func (s *VenueService) GetVenues(activeOnly bool) ([]models.Venue, error) {
+	return s.repository.FindAll(activeOnly)
+}
+
+// GetVenue mengambil satu venue
+func (s *VenueService) GetVenue(id uint) (*models.Venue, error) {
+	venue, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if venue == nil {
+		return nil, ErrVenueNotFound
+	}
+	return venue, nil
+}
+
+// CreateVenue menambah venue baru
+func (s *VenueService) CreateVenue(venue *models.Venue) error {
+	if err := s.validateVenue(venue); err != nil {
+		return err
+	}
+	venue.ID = 0
+	return s.repository.Create(venue)
+}
+
+// UpdateVenue mengubah venue. Booking yang sudah ada tidak diperiksa ulang terhadap
+// kapasitas atau approver baru.
+func (s *VenueService) UpdateVenue(id uint, input models.Venue) (*models.Venue, error) {
+	venue, err := s.GetVenue(id)
+	if err != nil {
+		return nil, err
+	}
+	venue.Name = input.Name
+	venue.Description = input.Description
+	venue.Capacity = input.Capacity
+	venue.ApproverUsername = input.ApproverUsername
+	venue.Active = input.Active
+	if err := s.validateVenue(venue); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Update(venue); err != nil {
+		return nil, err
+	}
+	return venue, nil
+}
+
+// DeleteVenue menghapus venue. Venue yang masih punya booking aktif ke depan tidak bisa
+// dihapus; nonaktifkan saja agar tidak bisa dipesan lagi.
+func (s *VenueService) DeleteVenue(id uint) error {
+	if _, err := s.GetVenue(id); err != nil {
+		return err
+	}
+	now := time.Now()
+	bookings, err := venueBookings(s.events, s.repository, id, now, now.AddDate(0, 0, venueBookingHorizon), 0)
+	if err != nil {
+		return err
+	}
+	if len(bookings) > 0 {
+		return fmt.Errorf("venue masih dipesan %d kegiatan mendatang; nonaktifkan venue sebagai gantinya", len(bookings))
+	}
+	return s.repository.Delete(id)
+}
+
+func (s *VenueService) validateVenue(venue *models.Venue) error {
+	venue.Name = strings.TrimSpace(venue.Name)
+	venue.ApproverUsername = strings.TrimSpace(venue.ApproverUsername)
+	if venue.Name == "" {
+		return errors.New("nama venue wajib diisi")
+	}
+	if venue.Capacity < 0 {
+		return errors.New("kapasitas venue tidak boleh negatif")
+	}
+	existing, err := s.repository.FindByName(venue.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != venue.ID {
+		return fmt.Errorf("venue %q sudah ada", venue.Name)
+	}
+	return nil
+}
+
+// GetAvailability mengambil slot terpakai venue di [start, end]; default tujuh hari ke depan
+func (s *VenueService) GetAvailability(id uint, start, end *time.Time) (*VenueAvailability, error) {
+	venue, err := s.GetVenue(id)
+	if err != nil {
+		return nil, err
+	}
+	from := time.Now()
+	if start != nil {
+		from = *start
+	}
+	to := from.AddDate(0, 0, 7)
+	if end != nil {
+		to = *end
+	}
+	if !to.After(from) {
+		return nil, errors.New("akhir rentang harus setelah awal rentang")
+	}
+	if to.Sub(from) > maxAvailabilityDays*24*time.Hour {
+		return nil, fmt.Errorf("rentang ketersediaan maksimal %d hari", maxAvailabilityDays)
+	}
+
+	bookings, err := venueBookings(s.events, s.repository, venue.ID, from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &VenueAvailability{Venue: venue, Start: from, End: to, Bookings: bookings}, nil
+}
+
+// GetReservations mengambil antrean booking. Approver kosong berarti semua venue (admin).
+func (s *VenueService) GetReservations(status, approver string) ([]models.VenueReservation, error) {
+	switch status {
+	case "", models.VenueReservationPending, models.VenueReservationApproved, models.VenueReservationRejected:
+	default:
+		return nil, errors.New("status harus pending, approved, atau rejected")
+	}
+	return s.repository.GetReservations(status, approver)
+}
+
+// DecideReservation menyetujui atau menolak booking. Selain admin, hanya approver venue
+// yang boleh memutuskan. Penolakan membebaskan slot untuk organisasi lain.
+func (s *VenueService) DecideReservation(id uint, approve bool, note, username string, isAdmin bool) (*models.VenueReservation, error) {
+	reservation, err := s.repository.FindReservationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation == nil || reservation.Event == nil || reservation.Venue == nil {
+		return nil, ErrReservationNotFound
+	}
+	if !isAdmin && (username == "" || !strings.EqualFold(reservation.Venue.ApproverUsername, username)) {
+		return nil, errors.New("hanya approver venue yang bisa memutuskan booking ini")
+	}
+	if reservation.Status != models.VenueReservationPending {
+		return nil, errors.New("booking ini sudah diputuskan")
+	}
+
+	now := time.Now()
+	reservation.Status = models.VenueReservationRejected
+	if approve {
+		reservation.Status = models.VenueReservationApproved
+	}
+	reservation.DecidedBy = username
+	reservation.DecidedAt = &now
+	reservation.Note = strings.TrimSpace(note)
+	if err := s.repository.SaveReservation(reservation); err != nil {
+		return nil, err
+	}
+	s.notifyDecision(reservation)
+	return reservation, nil
+}
+
+// notifyDecision mengabarkan keputusan booking ke pengurus organisasi penyelenggara
+func (s *VenueService) notifyDecision(reservation *models.VenueReservation) {
+	event := reservation.Event
+	if s.notificationService == nil || event.OrganizationID == nil {
+		return
+	}
+	officers, err := s.studentRepo.FindUsernamesByOrganization(*event.OrganizationID)
+	if err != nil || len(officers) == 0 {
+		return
+	}
+	title := "Booking Venue Disetujui: " + event.Title
+	message := fmt.Sprintf("Booking %s untuk %s disetujui.", reservation.Venue.Name, event.Title)
+	if reservation.Status == models.VenueReservationRejected {
+		title = "Booking Venue Ditolak: " + event.Title
+		message = fmt.Sprintf("Booking %s untuk %s ditolak.", reservation.Venue.Name, event.Title)
+		if reservation.Note != "" {
+			message += " Catatan: " + reservation.Note
+		}
+	}
+	target := NotificationTarget{
+		Type:       models.NotificationTypeEvent,
+		EntityType: models.NotificationTypeEvent,
+		EntityID:   event.ID,
+	}
+	if _, err := s.notificationService.CreateNotificationForUsers(title, message, target, officers); err != nil {
+		log.Printf("Gagal mengirim notifikasi booking venue %d: %v", reservation.ID, err)
+	}
+}
+
+// bookVenue menyimpan booking venue seri seriesID setelah event-nya ditulis di tx dan
+// menolak jika ada kemunculan yang beririsan dengan booking pending/approved lain di
+// venue yang sama. Booking baru, pindah venue, atau jadwal yang berubah (rescheduled)
+// kembali menunggu approver; queued bernilai true jika booking baru masuk antrean.
+func bookVenue(tx *gorm.DB, seriesID uint, rescheduled bool) (*models.VenueReservation, bool, error) {
+	events := repositories.NewCalenderRepository(tx)
+	venues := repositories.NewVenueRepository(tx)
+	series, err := events.GetByID(seriesID)
+	if err != nil {
+		return nil, false, err
+	}
+	if series == nil {
+		return nil, false, ErrEventNotFound
+	}
+	reservation, err := venues.FindReservation(series.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if series.VenueID == nil {
+		if reservation != nil {
+			return nil, false, venues.DeleteReservation(series.ID)
+		}
+		return nil, false, nil
+	}
+
+	venue, err := venues.Lock(*series.VenueID)
+	if err != nil {
+		return nil, false, err
+	}
+	if venue == nil {
+		return nil, false, ErrVenueNotFound
+	}
+	moved := reservation == nil || reservation.VenueID != venue.ID
+	if !moved && !rescheduled && reservation.Status == models.VenueReservationRejected {
+		// booking yang ditolak tidak menahan slot; perubahan lain tidak perlu diperiksa
+		return reservation, false, nil
+	}
+	if moved && !venue.Active {
+		return nil, false, fmt.Errorf("venue %s sedang tidak bisa dipesan", venue.Name)
+	}
+	if venue.Capacity > 0 && series.Capacity > venue.Capacity {
+		return nil, false, fmt.Errorf("kapasitas event (%d) melebihi kapasitas %s (%d)", series.Capacity, venue.Name, venue.Capacity)
+	}
+	if err := checkVenueConflict(events, venues, venue, series); err != nil {
+		return nil, false, err
+	}
+
+	if reservation == nil {
+		reservation = &models.VenueReservation{EventID: series.ID}
+	}
+	previous := reservation.Status
+	if moved || rescheduled {
+		reservation.VenueID = venue.ID
+		reservation.Status = models.VenueReservationApproved
+		if venue.ApproverUsername != "" {
+			reservation.Status = models.VenueReservationPending
+		}
+		reservation.DecidedBy = ""
+		reservation.DecidedAt = nil
+		reservation.Note = ""
+	}
+	if err := venues.SaveReservation(reservation); err != nil {
+		return nil, false, err
+	}
+	reservation.Venue = venue
+	reservation.Event = series
+	queued := reservation.Status == models.VenueReservationPending && previous != models.VenueReservationPending
+	return reservation, queued, nil
+}
+
+// inheritReservation menyalin booking seri lama ke seri hasil pemecahan agar persetujuan
+// yang sudah ada tidak hilang; bookVenue tetap memeriksa ulang jadwal seri baru
+func inheritReservation(tx *gorm.DB, fromID, toID uint) error {
+	venues := repositories.NewVenueRepository(tx)
+	reservation, err := venues.FindReservation(fromID)
+	if err != nil || reservation == nil {
+		return err
+	}
+	inherited := *reservation
+	inherited.ID = 0
+	inherited.EventID = toID
+	return venues.SaveReservation(&inherited)
+}
+
+// checkVenueConflict membandingkan kemunculan seri dengan booking lain di venue. Seri
+// tanpa akhir hanya diperiksa sampai venueBookingHorizon hari ke depan.
+func checkVenueConflict(events *repositories.CalenderRepository, venues *repositories.VenueRepository, venue *models.Venue, series *models.Calender) error {
+	from, to := series.StartTime, series.EndTime
+	if series.IsRecurring() {
+		if now := time.Now(); from.Before(now) {
+			from = now
+		}
+		to = from.AddDate(0, 0, venueBookingHorizon)
+		if series.RecurrenceEnd != nil && series.RecurrenceEnd.Before(to) {
+			to = *series.RecurrenceEnd
+		}
+		if to.Before(from) {
+			return nil
+		}
+	}
+
+	occurrences, err := seriesOccurrences(events, series, from, to)
+	if err != nil {
+		return err
+	}
+	bookings, err := venueBookings(events, venues, venue.ID, from, to, series.ID)
+	if err != nil {
+		return err
+	}
+	loc := calendarLocation()
+	for _, occurrence := range occurrences {
+		for _, booking := range bookings {
+			if occurrence.StartTime.Before(booking.End) && booking.Start.Before(occurrence.EndTime) {
+				return fmt.Errorf("%w: %s dipakai %q pada %s-%s", ErrVenueConflict, venue.Name, booking.Title,
+					booking.Start.In(loc).Format("02-01-2006 15:04"), booking.End.In(loc).Format("15:04"))
+			}
+		}
+	}
+	return nil
+}
+
+// venueBookings menjabarkan booking pending/approved di venue menjadi slot per
+// kemunculan yang beririsan dengan [from, to], kecuali milik seri excludeID
+func venueBookings(events *repositories.CalenderRepository, venues *repositories.VenueRepository, venueID uint, from, to time.Time, excludeID uint) ([]models.VenueBooking, error) {
+	reservations, err := venues.GetActiveBookings(venueID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	bookings := []models.VenueBooking{}
+	for _, reservation := range reservations {
+		if reservation.EventID == excludeID || reservation.Event == nil {
+			continue
+		}
+		occurrences, err := seriesOccurrences(events, reservation.Event, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, occurrence := range occurrences {
+			if occurrence.EndTime.Before(from) || occurrence.StartTime.After(to) {
+				continue
+			}
+			booking := models.VenueBooking{
+				ReservationID: reservation.ID,
+				EventID:       reservation.EventID,
+				Title:         occurrence.Title,
+				Start:         occurrence.StartTime,
+				End:           occurrence.EndTime,
+				Status:        reservation.Status,
+			}
+			if reservation.Event.Organization != nil {
+				booking.Organization = reservation.Event.Organization.ShortName
+			}
+			bookings = append(bookings, booking)
+		}
+	}
+	sort.SliceStable(bookings, func(i, j int) bool { return bookings[i].Start.Before(bookings[j].Start) })
+	return bookings, nil
+}
+
+// notifyVenueApprover memberi tahu approver ada booking baru di antreannya
+func (s *CalenderService) notifyVenueApprover(reservation *models.VenueReservation, queued bool) {
+	if !queued || reservation == nil || reservation.Venue == nil || s.notificationService == nil {
+		return
+	}
+	event := reservation.Event
+	title := "Booking Venue Menunggu Persetujuan: " + reservation.Venue.Name
+	message := fmt.Sprintf("%s memesan %s pada %s.", event.Title, reservation.Venue.Name,
+		event.StartTime.In(calendarLocation()).Format("02-01-2006 15:04"))
+	if event.IsRecurring() {
+		message = fmt.Sprintf("%s (berulang) memesan %s mulai %s.", event.Title, reservation.Venue.Name,
+			event.StartTime.In(calendarLocation()).Format("02-01-2006 15:04"))
+	}
+	_, err := s.notificationService.CreateNotification(title, message, NotificationTarget{
+		Type:       models.NotificationTypeEvent,
+		EntityType: models.NotificationTypeEvent,
+		EntityID:   event.ID,
+		Username:   reservation.Venue.ApproverUsername,
+	})
+	if err != nil {
+		log.Printf("Gagal mengirim notifikasi approver venue %d: %v", reservation.VenueID, err)
+	}
+}
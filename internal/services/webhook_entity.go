@@ -0,0 +1,74 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"bem_be/internal/models"
+	"bem_be/internal/utils"
+)
+
+// WebhookEntity adalah ringkasan entitas yang dikirim ke webhook. Payload webhook keluar ke
+// layanan pihak ketiga (bot Discord/Telegram), jadi hanya memuat data yang memang publik:
+// jangan pernah mengirim struct model karena ikut membawa username, foto KTM, alasan
+// penolakan, dan sebagainya.
+type WebhookEntity struct {
+	ID     uint   `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status,omitempty"`
+	URL    string `json:"url,omitempty"` // halaman publik di frontend; kosong jika tidak publik
+}
+
+// publicPageURL menggabungkan PUBLIC_SITE_URL dengan path halaman frontend
+func publicPageURL(path string) string {
+	return strings.TrimRight(utils.GetEnvWithDefault("PUBLIC_SITE_URL", utils.PublicBaseURL()), "/") + path
+}
+
+func NewsWebhookEntity(news *models.News) *WebhookEntity {
+	return &WebhookEntity{
+		ID:     news.ID,
+		Title:  news.Title,
+		Status: news.Status,
+		URL:    publicPageURL(newsPagePath(news.Slug, news.ID)),
+	}
+}
+
+// AnnouncementWebhookEntity tidak menyertakan URL untuk pengumuman dengan target audiens
+func AnnouncementWebhookEntity(announcement *models.Announcement) *WebhookEntity {
+	entity := &WebhookEntity{ID: announcement.ID, Title: announcement.Title}
+	if !announcement.HasAudience() {
+		entity.URL = publicPageURL(announcementPagePath(announcement.Slug, announcement.ID))
+	}
+	return entity
+}
+
+func EventWebhookEntity(event *models.Calender) *WebhookEntity {
+	return &WebhookEntity{
+		ID:    event.ID,
+		Title: event.Title,
+		URL:   publicPageURL(fmt.Sprintf("/events/%d", event.ID)),
+	}
+}
+
+func OrganizationWebhookEntity(organization *models.Organization) *WebhookEntity {
+	entity := &WebhookEntity{ID: organization.ID, Title: organization.Name}
+	if prefix, ok := organizationPagePaths[organization.CategoryID]; ok && organization.ShortName != "" {
+		entity.URL = publicPageURL(prefix + url.PathEscape(organization.ShortName))
+	}
+	return entity
+}
+
+// RequestWebhookEntity hanya memuat kegiatan dan status; peminjam dan KTM-nya tidak dikirim
+func RequestWebhookEntity(request *models.Request) *WebhookEntity {
+	return &WebhookEntity{ID: request.ID, Title: request.Activity, Status: request.Status}
+}
+
+// AspirationWebhookEntity menyertakan URL hanya untuk aspirasi yang tampil di papan publik
+func AspirationWebhookEntity(aspiration *models.Aspiration) *WebhookEntity {
+	entity := &WebhookEntity{ID: aspiration.ID, Title: aspiration.Title, Status: aspiration.Status}
+	if aspiration.Public {
+		entity.URL = publicPageURL(fmt.Sprintf("/aspirations/%d", aspiration.ID))
+	}
+	return entity
+}
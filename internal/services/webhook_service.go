@@ -0,0 +1,347 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bem_be/internal/models"
+	"bem_be/internal/repositories"
+	"bem_be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	webhookLease           = 2 * time.Minute
+	webhookResponseMaxSize = 2048
+)
+
+// WebhookService mengelola subscription webhook dan pengiriman event bertanda tangan
+type WebhookService struct {
+	repo        *repositories.WebhookRepository
+	client      *http.Client
+	maxAttempts int
+	baseBackoff time.Duration
+}
+
+func NewWebhookService(repo *repositories.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		repo:        repo,
+		client:      newWebhookClient(10 * time.Second),
+		maxAttempts: utils.GetEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 6),
+		baseBackoff: time.Duration(utils.GetEnvAsInt("WEBHOOK_BACKOFF_SECONDS", 30)) * time.Second,
+	}
+}
+
+// WebhookEvent adalah body JSON yang dikirim ke endpoint subscriber
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// ===== Subscription =====
+
+func (s *WebhookService) GetAllSubscriptions() ([]models.WebhookSubscription, error) {
+	return s.repo.GetAllSubscriptions()
+}
+
+func (s *WebhookService) GetSubscriptionByID(id uint) (*models.WebhookSubscription, error) {
+	subscription, err := s.repo.FindSubscriptionByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("webhook tidak ditemukan")
+		}
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// CreateSubscription menyimpan subscription baru; secret dibuat otomatis bila kosong
+func (s *WebhookService) CreateSubscription(subscription *models.WebhookSubscription) error {
+	if err := validateSubscription(subscription); err != nil {
+		return err
+	}
+	if subscription.Secret == "" {
+		secret, err := randomHex(32)
+		if err != nil {
+			return err
+		}
+		subscription.Secret = secret
+	}
+	return s.repo.CreateSubscription(subscription)
+}
+
+func (s *WebhookService) UpdateSubscription(subscription *models.WebhookSubscription) error {
+	if err := validateSubscription(subscription); err != nil {
+		return err
+	}
+	return s.repo.UpdateSubscription(subscription)
+}
+
+// RotateSecret mengganti secret subscription dan mengembalikan secret baru
+func (s *WebhookService) RotateSecret(id uint) (*models.WebhookSubscription, error) {
+	subscription, err := s.GetSubscriptionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = secret
+	if err := s.repo.UpdateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *WebhookService) DeleteSubscription(id uint) error {
+	if _, err := s.GetSubscriptionByID(id); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(id)
+}
+
+func validateSubscription(subscription *models.WebhookSubscription) error {
+	if strings.TrimSpace(subscription.Name) == "" {
+		return errors.New("nama webhook wajib diisi")
+	}
+	u, err := url.Parse(subscription.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("URL webhook harus berupa URL http/https yang valid")
+	}
+	if err := checkWebhookHost(u.Hostname()); err != nil {
+		return err
+	}
+
+	var events []string
+	for _, event := range strings.Split(subscription.EventTypes, ",") {
+		event = strings.TrimSpace(event)
+		if event == "" {
+			continue
+		}
+		if event != "*" && !isKnownWebhookEvent(event) {
+			return fmt.Errorf("event %q tidak dikenal", event)
+		}
+		events = append(events, event)
+	}
+	subscription.EventTypes = strings.Join(events, ",")
+	return nil
+}
+
+func isKnownWebhookEvent(event string) bool {
+	for _, e := range models.WebhookEventTypes {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func subscribedTo(subscription models.WebhookSubscription, event string) bool {
+	if subscription.EventTypes == "" {
+		return true
+	}
+	for _, e := range strings.Split(subscription.EventTypes, ",") {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ===== Delivery =====
+
+// Dispatch mencatat pengiriman event ke setiap subscription aktif yang berlangganan
+// lalu langsung mencoba mengirimkannya di background
+func (s *WebhookService) Dispatch(event string, data interface{}) error {
+	subscriptions, err := s.repo.GetActiveSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	eventID, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(WebhookEvent{
+		ID:        eventID,
+		Event:     event,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		if !subscribedTo(subscription, event) {
+			continue
+		}
+		delivery := &models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      event,
+			Payload:        string(body),
+			Status:         models.WebhookStatusPending,
+			NextAttemptAt:  &now,
+		}
+		if err := s.repo.CreateDelivery(delivery); err != nil {
+			log.Printf("Gagal mencatat pengiriman webhook %s ke subscription %d: %v", event, subscription.ID, err)
+			continue
+		}
+		go s.attempt(delivery.ID)
+	}
+	return nil
+}
+
+// ProcessDueDeliveries mengirim ulang pengiriman pending yang sudah jatuh tempo (dipanggil worker)
+func (s *WebhookService) ProcessDueDeliveries() {
+	ids, err := s.repo.GetDueDeliveryIDs(time.Now(), 50)
+	if err != nil {
+		log.Printf("Gagal mengambil antrean webhook: %v", err)
+		return
+	}
+	for _, id := range ids {
+		s.attempt(id)
+	}
+}
+
+// Redeliver mengantrekan ulang sebuah pengiriman (berhasil maupun gagal) dan langsung mengirimkannya
+func (s *WebhookService) Redeliver(id uint) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.FindDeliveryByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("pengiriman webhook tidak ditemukan")
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery.Status = models.WebhookStatusPending
+	delivery.Attempts = 0
+	delivery.LastError = ""
+	delivery.NextAttemptAt = &now
+	delivery.Subscription = nil
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	s.attempt(delivery.ID)
+	return s.repo.FindDeliveryByID(delivery.ID)
+}
+
+func (s *WebhookService) GetDeliveries(subscriptionID uint, status string, limit, offset int) ([]models.WebhookDelivery, int64, error) {
+	return s.repo.GetDeliveries(subscriptionID, status, limit, offset)
+}
+
+// attempt mengirim satu pengiriman dan menjadwalkan retry dengan exponential backoff bila gagal
+func (s *WebhookService) attempt(id uint) {
+	claimed, err := s.repo.ClaimDelivery(id, time.Now(), webhookLease)
+	if err != nil || !claimed {
+		return
+	}
+
+	delivery, err := s.repo.FindDeliveryByID(id)
+	if err != nil {
+		log.Printf("Gagal memuat pengiriman webhook %d: %v", id, err)
+		return
+	}
+	subscription := delivery.Subscription
+	delivery.Subscription = nil
+	delivery.Attempts++
+
+	statusCode, respBody, sendErr := s.send(subscription, delivery)
+	delivery.ResponseStatus = statusCode
+	delivery.ResponseBody = respBody
+
+	now := time.Now()
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookStatusSuccess
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.Status = models.WebhookStatusFailed
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = nil
+	default:
+		// 30s, 1m, 2m, 4m, ...
+		next := now.Add(s.baseBackoff * time.Duration(1<<uint(delivery.Attempts-1)))
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		log.Printf("Gagal menyimpan hasil pengiriman webhook %d: %v", id, err)
+	}
+}
+
+func (s *WebhookService) send(subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	if subscription == nil || subscription.DeletedAt.Valid {
+		return 0, "", errors.New("subscription sudah dihapus")
+	}
+	if !subscription.IsActive {
+		return 0, "", errors.New("subscription tidak aktif")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "BEM-Webhook/1.0")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhookPayload(subscription.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseMaxSize))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(body), fmt.Errorf("endpoint membalas status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// SignWebhookPayload menghasilkan HMAC-SHA256 (hex) dari "<timestamp>.<body>".
+// Subscriber memverifikasi dengan menghitung ulang nilai ini memakai secret yang sama
+// dan menolak timestamp yang terlalu lama untuk mencegah replay.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"bem_be/internal/utils"
+)
+
+var errWebhookTargetPrivate = errors.New("URL webhook tidak boleh mengarah ke alamat lokal atau jaringan internal")
+
+// carrierGradeNAT (100.64.0.0/10) tidak termasuk net.IP.IsPrivate tetapi tetap jaringan internal
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// webhookAllowPrivate membaca WEBHOOK_ALLOW_PRIVATE, hanya untuk pengembangan lokal
+// (misalnya penerima webhook di jaringan docker yang sama)
+func webhookAllowPrivate() bool {
+	return utils.GetEnvWithDefault("WEBHOOK_ALLOW_PRIVATE", "false") == "true"
+}
+
+// blockedWebhookIP menandai alamat loopback, privat, link-local (termasuk metadata cloud
+// 169.254.169.254), multicast, dan tak-spesifik yang tidak boleh dihubungi webhook
+func blockedWebhookIP(ip net.IP) bool {
+	return ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || carrierGradeNAT.Contains(ip)
+}
+
+// checkWebhookHost menolak host yang (saat didaftarkan) mengarah ke alamat terlarang.
+// Pemeriksaan ini untuk pesan galat yang jelas; perlindungan sebenarnya ada di
+// webhookDialControl karena DNS bisa berubah setelah subscription disimpan.
+func checkWebhookHost(host string) error {
+	if webhookAllowPrivate() {
+		return nil
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return errWebhookTargetPrivate
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if blockedWebhookIP(ip) {
+			return errWebhookTargetPrivate
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("host webhook %q tidak bisa di-resolve", host)
+	}
+	for _, addr := range addrs {
+		if blockedWebhookIP(addr.IP) {
+			return errWebhookTargetPrivate
+		}
+	}
+	return nil
+}
+
+// webhookDialControl memeriksa alamat yang benar-benar dihubungi (setelah DNS dan redirect)
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	if webhookAllowPrivate() {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if blockedWebhookIP(net.ParseIP(host)) {
+		return errWebhookTargetPrivate
+	}
+	return nil
+}
+
+// newWebhookClient membuat HTTP client pengiriman webhook yang tidak bisa menjangkau
+// jaringan internal
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: webhookDialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
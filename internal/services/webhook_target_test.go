@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bem_be/internal/models"
+)
+
+func TestBlockedWebhookIP(t *testing.T) {
+	blocked := []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.10", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::1", "fd00::1", "fe80::1", "224.0.0.1"}
+	for _, addr := range blocked {
+		if !blockedWebhookIP(net.ParseIP(addr)) {
+			t.Errorf("%s seharusnya diblokir", addr)
+		}
+	}
+	for _, addr := range []string{"93.184.216.34", "1.1.1.1", "2606:4700:4700::1111"} {
+		if blockedWebhookIP(net.ParseIP(addr)) {
+			t.Errorf("%s seharusnya diizinkan", addr)
+		}
+	}
+}
+
+func TestValidateSubscriptionRejectsPrivateTargets(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "false")
+	for _, target := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook",
+		"http://[::1]/hook", "http://169.254.169.254/latest/meta-data", "https://10.0.0.5/hook"} {
+		err := validateSubscription(&models.WebhookSubscription{Name: "bot", URL: target})
+		if !errors.Is(err, errWebhookTargetPrivate) {
+			t.Errorf("%s: err = %v; ingin errWebhookTargetPrivate", target, err)
+		}
+	}
+	if err := validateSubscription(&models.WebhookSubscription{Name: "bot", URL: "https://93.184.216.34/hook"}); err != nil {
+		t.Errorf("alamat publik ditolak: %v", err)
+	}
+}
+
+func TestWebhookClientRefusesLoopback(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "false")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("rahasia internal"))
+	}))
+	defer server.Close()
+
+	_, err := newWebhookClient(time.Second).Post(server.URL, "application/json", nil)
+	if !errors.Is(err, errWebhookTargetPrivate) {
+		t.Fatalf("err = %v; ingin errWebhookTargetPrivate", err)
+	}
+
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "true")
+	resp, err := newWebhookClient(time.Second).Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("WEBHOOK_ALLOW_PRIVATE=true: %v", err)
+	}
+	resp.Body.Close()
+}
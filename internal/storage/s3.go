@@ -0,0 +1,366 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3Config adalah koneksi ke bucket S3 atau layanan kompatibel (MinIO, R2, dll)
+type S3Config struct {
+	Endpoint  string // mis. http://localhost:9000 untuk MinIO lokal
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// Cache hasil HEAD untuk Exists (dipakai migrate-uploads untuk melewati file yang sudah
+// tersalin). Entri kedaluwarsa setelah existsCacheTTL dan dibuang saat cache penuh,
+// sehingga ukurannya tidak pernah melebihi existsCacheMax.
+const (
+	existsCacheTTL = 10 * time.Minute
+	existsCacheMax = 10000
+)
+
+type existsEntry struct {
+	exists  bool
+	expires time.Time
+}
+
+// S3Storage menyimpan file di bucket S3 memakai path-style request yang ditandatangani
+// dengan AWS Signature V4. File dibaca publik lewat publicURL, sehingga bucket (atau CDN
+// di depannya) perlu mengizinkan akses baca anonim.
+type S3Storage struct {
+	cfg         S3Config
+	endpoint    *url.URL
+	publicURL   string
+	client      *http.Client
+	cacheMu     sync.Mutex
+	existsCache map[string]existsEntry
+}
+
+func NewS3Storage(cfg S3Config, publicURL string) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY dan S3_SECRET_KEY wajib diisi")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("S3_ENDPOINT tidak valid: %q", cfg.Endpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return &S3Storage{
+		cfg:         cfg,
+		endpoint:    endpoint,
+		publicURL:   publicURL,
+		client:      &http.Client{Timeout: 60 * time.Second},
+		existsCache: make(map[string]existsEntry),
+	}, nil
+}
+
+func (s *S3Storage) Put(key string, r io.Reader, contentType string) error {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	resp, err := s.do(http.MethodPut, cleaned, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	s.cacheExists(cleaned, true)
+	return nil
+}
+
+func (s *S3Storage) Get(key string) (io.ReadCloser, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(http.MethodGet, cleaned, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, s3Error(resp)
+	}
+}
+
+// Delete menghapus object; S3 mengembalikan 204 juga untuk key yang tidak ada
+func (s *S3Storage) Delete(key string) error {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(http.MethodDelete, cleaned, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error(resp)
+	}
+	s.cacheMu.Lock()
+	delete(s.existsCache, cleaned)
+	s.cacheMu.Unlock()
+	return nil
+}
+
+func (s *S3Storage) Exists(key string) bool {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return false
+	}
+	if exists, ok := s.cachedExists(cleaned); ok {
+		return exists
+	}
+
+	resp, err := s.do(http.MethodHead, cleaned, nil, "")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	exists := resp.StatusCode == http.StatusOK
+	s.cacheExists(cleaned, exists)
+	return exists
+}
+
+func (s *S3Storage) cachedExists(key string) (exists, ok bool) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	entry, ok := s.existsCache[key]
+	if !ok || !time.Now().Before(entry.expires) {
+		return false, false
+	}
+	return entry.exists, true
+}
+
+// cacheExists menyimpan hasil Exists. Jika cache penuh, entri kedaluwarsa dibuang lebih dulu;
+// bila masih penuh, cache dikosongkan.
+func (s *S3Storage) cacheExists(key string, exists bool) {
+	now := time.Now()
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	if _, ok := s.existsCache[key]; !ok && len(s.existsCache) >= existsCacheMax {
+		for k, entry := range s.existsCache {
+			if !now.Before(entry.expires) {
+				delete(s.existsCache, k)
+			}
+		}
+		if len(s.existsCache) >= existsCacheMax {
+			s.existsCache = make(map[string]existsEntry)
+		}
+	}
+	s.existsCache[key] = existsEntry{exists: exists, expires: now.Add(existsCacheTTL)}
+}
+
+func (s *S3Storage) URL(key string) string {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return ""
+	}
+	return s.publicURL + "/" + escapeKey(cleaned)
+}
+
+// do mengirim request path-style (endpoint/bucket/key) yang sudah ditandatangani
+func (s *S3Storage) do(method, key string, body []byte, contentType string) (*http.Response, error) {
+	return s.doQuery(method, key, nil, body, contentType)
+}
+
+// doQuery seperti do, dengan query string. Query disusun langsung dalam bentuk kanonik SigV4
+// (key terurut, encoding RFC 3986) sehingga yang dikirim sama dengan yang ditandatangani.
+func (s *S3Storage) doQuery(method, key string, query map[string]string, body []byte, contentType string) (*http.Response, error) {
+	u := *s.endpoint
+	basePath := strings.TrimRight(u.Path, "/")
+	u.Path = basePath + "/" + s.cfg.Bucket
+	u.RawPath = basePath + "/" + s3Escape(s.cfg.Bucket)
+	if key != "" {
+		u.Path += "/" + key
+		u.RawPath += "/" + escapeS3Key(key)
+	}
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	params := make([]string, 0, len(names))
+	for _, name := range names {
+		params = append(params, s3Escape(name)+"="+s3Escape(query[name]))
+	}
+	u.RawQuery = strings.Join(params, "&")
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, body, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List memakai ListObjectsV2 dan mengikuti continuation token sampai habis
+func (s *S3Storage) List(prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		query := map[string]string{"list-type": "2", "prefix": prefix}
+		if token != "" {
+			query["continuation-token"] = token
+		}
+		resp, err := s.doQuery(http.MethodGet, "", query, nil, "")
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := s3Error(resp)
+			resp.Body.Close()
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range result.Contents {
+			objects = append(objects, Object{Key: c.Key, Size: c.Size, ModTime: c.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// sign menambahkan header Authorization AWS Signature V4
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	headerValues := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		signedHeaders = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+		headerValues["content-type"] = ct
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, h := range signedHeaders {
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(headerValues[h]) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, s.cfg.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, strings.Join(signedHeaders, ";"), signature,
+	))
+}
+
+func s3Error(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("storage s3: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// escapeS3Key meng-encode key sesuai aturan URI encoding SigV4 (RFC 3986, "/" tidak di-encode)
+func escapeS3Key(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
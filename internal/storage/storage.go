@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Folder kanonik file unggahan. Setiap file disimpan dengan key "<folder>/<nama file>",
+// sedangkan database hanya menyimpan nama file-nya.
+const (
+	FolderAssociations  = "associations"
+	FolderClubs         = "clubs"
+	FolderDepartments   = "departments"
+	FolderBems          = "bems" // file lama tanpa kolom database; tidak termasuk Folders
+	FolderUsers         = "users"
+	FolderRequests      = "requests"
+	FolderRequestItems  = "requests/barang"
+	FolderNews          = "news"
+	FolderAnnouncements = "announcements"
+	FolderGalery        = "galery"
+	FolderItems         = "items"
+)
+
+var (
+	// ErrNotFound dikembalikan Get jika key tidak ada di storage
+	ErrNotFound = errors.New("file tidak ditemukan")
+	// ErrInvalidKey dikembalikan jika key kosong, absolut atau keluar dari root storage
+	ErrInvalidKey = errors.New("key file tidak valid")
+)
+
+// Folders adalah folder kanonik yang isinya dirujuk kolom database, dipakai untuk rekonsiliasi
+// storage dengan database. File di luar folder ini tidak pernah dihapus upload GC.
+var Folders = []string{
+	FolderAssociations, FolderClubs, FolderDepartments, FolderUsers, FolderRequests,
+	FolderRequestItems, FolderNews, FolderAnnouncements, FolderGalery, FolderItems,
+}
+
+// Object adalah satu file di storage beserta waktu terakhir ditulis
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage adalah tempat penyimpanan file unggahan. Key selalu berupa path relatif
+// dengan pemisah "/", mis. "news/1700000000_banner.jpg".
+type Storage interface {
+	Put(key string, r io.Reader, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	Exists(key string) bool
+	URL(key string) string
+	// List mengembalikan semua file dengan awalan key prefix ("" untuk seluruh storage)
+	List(prefix string) ([]Object, error)
+}
+
+// Config adalah konfigurasi backend storage, dibaca dari environment oleh ConfigFromEnv
+type Config struct {
+	Driver    string // "local" (default) atau "s3"
+	LocalRoot string // root folder backend local
+	PublicURL string // URL publik tempat file disajikan; satu-satunya sumber URL file
+	S3        S3Config
+}
+
+// ConfigFromEnv membaca konfigurasi storage:
+//
+//	STORAGE_DRIVER      local | s3 (default local)
+//	STORAGE_LOCAL_ROOT  folder root backend local (default ./uploads)
+//	STORAGE_PUBLIC_URL  URL publik file; default PUBLIC_BASE_URL + "/uploads" untuk local,
+//	                    atau S3_ENDPOINT/S3_BUCKET untuk s3
+//	S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Driver:    strings.ToLower(getEnv("STORAGE_DRIVER", "local")),
+		LocalRoot: getEnv("STORAGE_LOCAL_ROOT", "./uploads"),
+		PublicURL: strings.TrimRight(os.Getenv("STORAGE_PUBLIC_URL"), "/"),
+		S3: S3Config{
+			Endpoint:  strings.TrimRight(os.Getenv("S3_ENDPOINT"), "/"),
+			Region:    getEnv("S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		},
+	}
+	if cfg.PublicURL == "" {
+		if cfg.Driver == "s3" {
+			cfg.PublicURL = cfg.S3.Endpoint + "/" + cfg.S3.Bucket
+		} else {
+			cfg.PublicURL = strings.TrimRight(getEnv("PUBLIC_BASE_URL", "http://localhost:9090"), "/") + "/uploads"
+		}
+	}
+	return cfg
+}
+
+// New membuat backend storage sesuai cfg.Driver
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalRoot, cfg.PublicURL), nil
+	case "s3":
+		return NewS3Storage(cfg.S3, cfg.PublicURL)
+	default:
+		return nil, fmt.Errorf("driver storage %q tidak dikenal", cfg.Driver)
+	}
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultStorage Storage
+)
+
+// Default mengembalikan storage global yang dibangun dari environment saat pertama dipakai
+func Default() Storage {
+	defaultOnce.Do(func() {
+		s, err := New(ConfigFromEnv())
+		if err != nil {
+			log.Fatalf("Gagal menginisialisasi storage: %v", err)
+		}
+		defaultStorage = s
+	})
+	return defaultStorage
+}
+
+// Key menggabungkan folder dan nama file yang tersimpan di database menjadi key storage.
+// Nilai lama yang masih berisi path (mis. "uploads/announcements/x.pdf") hanya diambil nama filenya,
+// sehingga isi kolom database tidak pernah bisa menunjuk ke luar folder-nya. Nama kosong, "." atau
+// ".." menghasilkan key kosong yang ditolak oleh setiap operasi storage.
+func Key(folder, stored string) string {
+	name := path.Base(strings.ReplaceAll(stored, "\\", "/"))
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return folder + "/" + name
+}
+
+// cleanKey memvalidasi key agar tidak bisa keluar dari root storage
+func cleanKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, "\\", "/")
+	if key == "" || strings.HasPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// escapeKey meng-encode setiap segmen key untuk dipakai di URL
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
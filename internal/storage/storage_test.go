@@ -0,0 +1,57 @@
+package storage
+
+import "testing"
+
+func TestCleanKey(t *testing.T) {
+	valid := map[string]string{
+		"news/a.jpg":            "news/a.jpg",
+		"news//a.jpg":           "news/a.jpg",
+		"news/./a.jpg":          "news/a.jpg",
+		"news/x/../a.jpg":       "news/a.jpg",
+		`requests\barang\b.png`: "requests/barang/b.png",
+	}
+	for key, want := range valid {
+		got, err := cleanKey(key)
+		if err != nil || got != want {
+			t.Errorf("cleanKey(%q) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+
+	invalid := []string{"", ".", "..", "/etc/passwd", "../secret", "news/../../secret", `..\secret`, `\etc\passwd`}
+	for _, key := range invalid {
+		if got, err := cleanKey(key); err != ErrInvalidKey {
+			t.Errorf("cleanKey(%q) = %q, %v; want ErrInvalidKey", key, got, err)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	cases := []struct{ stored, want string }{
+		{"a.jpg", "news/a.jpg"},
+		{"uploads/news/a.jpg", "news/a.jpg"},
+		{"../../etc/passwd", "news/passwd"},
+		{`..\..\secret.txt`, "news/secret.txt"},
+		{"", ""},
+		{".", ""},
+		{"..", ""},
+		{"/", ""},
+		{"news/..", ""},
+	}
+	for _, c := range cases {
+		if got := Key(FolderNews, c.stored); got != c.want {
+			t.Errorf("Key(news, %q) = %q; want %q", c.stored, got, c.want)
+		}
+	}
+}
+
+func TestLocalStorageRejectsTraversal(t *testing.T) {
+	store := NewLocalStorage(t.TempDir(), "http://localhost/uploads")
+	for _, key := range []string{"../outside.txt", "/abs.txt", "news/../../outside.txt"} {
+		if err := store.Put(key, nil, ""); err != ErrInvalidKey {
+			t.Errorf("Put(%q) = %v; want ErrInvalidKey", key, err)
+		}
+		if store.Exists(key) {
+			t.Errorf("Exists(%q) = true", key)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package utils
+
+import "math"
+
+// earthRadiusMeters adalah jari-jari rata-rata bumi untuk rumus haversine
+const earthRadiusMeters = 6371000
+
+// DistanceMeters menghitung jarak lingkaran besar (haversine) antara dua koordinat
+// dalam derajat
+func DistanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
+
+// ValidCoordinate memeriksa rentang lintang dan bujur
+func ValidCoordinate(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
@@ -0,0 +1,331 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ICalEvent adalah satu VEVENT dalam dokumen iCalendar (RFC 5545)
+type ICalEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Categories  string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool      // DTSTART/DTEND berupa tanggal; End eksklusif
+	Updated     time.Time // dipakai untuk DTSTAMP dan LAST-MODIFIED
+
+	RRule        string      // aturan pengulangan tanpa awalan "RRULE:"
+	ExDates      []time.Time // kemunculan yang dikecualikan
+	RecurrenceID time.Time   // diisi jika VEVENT mengganti satu kemunculan seri UID yang sama
+}
+
+const (
+	icalDateTimeUTC = "20060102T150405Z"
+	icalDateTime    = "20060102T150405"
+	icalDate        = "20060102"
+	icalLineLimit   = 75 // oktet per baris sebelum dilipat
+)
+
+// WriteICal merender daftar event menjadi dokumen VCALENDAR. Waktu ditulis dalam UTC
+// sehingga tidak perlu komponen VTIMEZONE.
+func WriteICal(calendarName string, events []ICalEvent) []byte {
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeICalLine(&buf, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//BEM//Kalender Kegiatan//ID")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeICalText(calendarName))
+
+	for _, event := range events {
+		line("BEGIN", "VEVENT")
+		line("UID", escapeICalText(event.UID))
+		line("DTSTAMP", event.Updated.UTC().Format(icalDateTimeUTC))
+		if event.AllDay {
+			writeICalLine(&buf, "DTSTART;VALUE=DATE:"+event.Start.Format(icalDate))
+			writeICalLine(&buf, "DTEND;VALUE=DATE:"+event.End.Format(icalDate))
+		} else {
+			line("DTSTART", event.Start.UTC().Format(icalDateTimeUTC))
+			line("DTEND", event.End.UTC().Format(icalDateTimeUTC))
+		}
+		if !event.RecurrenceID.IsZero() {
+			line("RECURRENCE-ID", event.RecurrenceID.UTC().Format(icalDateTimeUTC))
+		}
+		if event.RRule != "" {
+			line("RRULE", event.RRule)
+		}
+		if len(event.ExDates) > 0 {
+			dates := make([]string, len(event.ExDates))
+			for i, date := range event.ExDates {
+				dates[i] = date.UTC().Format(icalDateTimeUTC)
+			}
+			line("EXDATE", strings.Join(dates, ","))
+		}
+		line("SUMMARY", escapeICalText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeICalText(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", escapeICalText(event.Location))
+		}
+		if event.Categories != "" {
+			line("CATEGORIES", escapeICalText(event.Categories))
+		}
+		if event.URL != "" {
+			line("URL", event.URL)
+		}
+		line("LAST-MODIFIED", event.Updated.UTC().Format(icalDateTimeUTC))
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+// writeICalLine menulis satu content line dengan CRLF dan melipatnya setiap 75 oktet
+// tanpa memotong karakter UTF-8
+func writeICalLine(buf *bytes.Buffer, content string) {
+	limit := icalLineLimit
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		buf.WriteString(content[:cut])
+		buf.WriteString("\r\n ")
+		content = content[cut:]
+		limit = icalLineLimit - 1 // spasi awal baris lipatan ikut dihitung
+	}
+	buf.WriteString(content)
+	buf.WriteString("\r\n")
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeICalText(value string) string {
+	return icalEscaper.Replace(value)
+}
+
+func unescapeICalText(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+			switch value[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(value[i])
+			}
+			continue
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
+// ParseICal membaca semua VEVENT dari dokumen iCalendar. Waktu tanpa zona (floating)
+// dan TZID yang tidak dikenal dibaca dalam loc. DTSTART yang tidak bisa dibaca
+// dibiarkan kosong (zero) supaya pemanggil bisa melaporkannya per event.
+func ParseICal(r io.Reader, loc *time.Location) ([]ICalEvent, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events    []ICalEvent
+		current   *ICalEvent
+		duration  time.Duration
+		hasEnd    bool
+		depth     int // kedalaman komponen di dalam VEVENT (mis. VALARM)
+		calendars int
+	)
+	for _, raw := range lines {
+		name, params, value := parseICalLine(raw)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCALENDAR"):
+			calendars++
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT") && current == nil:
+			current = &ICalEvent{}
+			duration, hasEnd, depth = 0, false, 0
+		case current == nil:
+			// properti kalender atau komponen lain (VTIMEZONE, VTODO) diabaikan
+		case name == "BEGIN":
+			depth++
+		case name == "END" && depth > 0:
+			depth--
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if !hasEnd && !current.Start.IsZero() {
+				switch {
+				case duration > 0:
+					current.End = current.Start.Add(duration)
+				case current.AllDay:
+					current.End = current.Start.AddDate(0, 0, 1)
+				default:
+					current.End = current.Start
+				}
+			}
+			events = append(events, *current)
+			current = nil
+		case depth > 0:
+			// properti milik sub-komponen
+		case name == "UID":
+			current.UID = strings.TrimSpace(value)
+		case name == "SUMMARY":
+			current.Summary = unescapeICalText(value)
+		case name == "DESCRIPTION":
+			current.Description = unescapeICalText(value)
+		case name == "LOCATION":
+			current.Location = unescapeICalText(value)
+		case name == "CATEGORIES":
+			current.Categories = unescapeICalText(value)
+		case name == "URL":
+			current.URL = value
+		case name == "DTSTART":
+			current.Start, current.AllDay, _ = parseICalTime(value, params, loc)
+		case name == "DTEND":
+			if end, _, err := parseICalTime(value, params, loc); err == nil {
+				current.End, hasEnd = end, true
+			}
+		case name == "RRULE":
+			current.RRule = strings.TrimSpace(value)
+		case name == "EXDATE":
+			for _, date := range strings.Split(value, ",") {
+				if t, _, err := parseICalTime(date, params, loc); err == nil {
+					current.ExDates = append(current.ExDates, t)
+				}
+			}
+		case name == "RECURRENCE-ID":
+			current.RecurrenceID, _, _ = parseICalTime(value, params, loc)
+		case name == "DURATION":
+			duration, _ = parseICalDuration(value)
+		case name == "LAST-MODIFIED" || (name == "DTSTAMP" && current.Updated.IsZero()):
+			current.Updated, _, _ = parseICalTime(value, params, loc)
+		}
+	}
+
+	if calendars == 0 {
+		return nil, errors.New("file bukan dokumen iCalendar (BEGIN:VCALENDAR tidak ditemukan)")
+	}
+	return events, nil
+}
+
+func unfoldICalLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += text[1:]
+			continue
+		}
+		if text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseICalLine memecah "NAME;PARAM=VALUE:isi" menjadi nama (huruf besar), parameter
+// dan isinya. Titik dua di dalam parameter bertanda kutip tidak dianggap pemisah.
+func parseICalLine(line string) (string, map[string]string, string) {
+	inQuote := false
+	split := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuote = !inQuote
+		} else if r == ':' && !inQuote {
+			split = i
+			break
+		}
+	}
+	if split < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+
+	parts := strings.Split(line[:split], ";")
+	params := map[string]string{}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[split+1:]
+}
+
+func parseICalTime(value string, params map[string]string, loc *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len(icalDate) {
+		t, err := time.ParseInLocation(icalDate, value, time.UTC)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icalDateTimeUTC, value)
+		return t, false, err
+	}
+	if tzid := params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+	t, err := time.ParseInLocation(icalDateTime, value, loc)
+	return t, false, err
+}
+
+// parseICalDuration membaca DURATION seperti PT1H30M, P1D atau P2W
+func parseICalDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "+")
+	if !strings.HasPrefix(value, "P") {
+		return 0, fmt.Errorf("durasi tidak valid: %q", value)
+	}
+
+	var total time.Duration
+	number := ""
+	for _, r := range value[1:] {
+		if r >= '0' && r <= '9' {
+			number += string(r)
+			continue
+		}
+		if r == 'T' {
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("durasi tidak valid: %q", value)
+		}
+		number = ""
+		switch r {
+		case 'W':
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case 'D':
+			total += time.Duration(n) * 24 * time.Hour
+		case 'H':
+			total += time.Duration(n) * time.Hour
+		case 'M':
+			total += time.Duration(n) * time.Minute
+		case 'S':
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("durasi tidak valid: %q", value)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestWriteICalLineFoldsAt75Octets(t *testing.T) {
+	// campuran ASCII, huruf 2 byte, aksara 3 byte dan emoji 4 byte agar batas 75 oktet
+	// jatuh di tengah karakter
+	content := "DESCRIPTION:" + strings.Repeat("Rapat é 会議 🎉 ", 20)
+
+	var buf bytes.Buffer
+	writeICalLine(&buf, content)
+	out := buf.String()
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Fatal("baris tidak diakhiri CRLF")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("baris panjang tidak dilipat: %q", out)
+	}
+	var unfolded strings.Builder
+	for i, line := range lines {
+		if len(line) > icalLineLimit {
+			t.Errorf("baris %d panjangnya %d oktet", i, len(line))
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("baris %d memotong karakter UTF-8: %q", i, line)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(line, " ") {
+				t.Errorf("baris lipatan %d tidak diawali spasi", i)
+			}
+			line = line[1:]
+		}
+		unfolded.WriteString(line)
+	}
+	if unfolded.String() != content {
+		t.Error("isi berubah setelah dilipat dan dibuka kembali")
+	}
+}
+
+func TestWriteICalLineShort(t *testing.T) {
+	var buf bytes.Buffer
+	content := "SUMMARY:" + strings.Repeat("a", icalLineLimit-len("SUMMARY:"))
+	writeICalLine(&buf, content)
+	if buf.String() != content+"\r\n" {
+		t.Errorf("baris tepat 75 oktet tidak boleh dilipat: %q", buf.String())
+	}
+}
+
+func TestICalRoundTrip(t *testing.T) {
+	start := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	event := ICalEvent{
+		UID:         "event-1@bem",
+		Summary:     "Rapat; koordinasi, 🎉 " + strings.Repeat("panjang ", 15),
+		Description: "Baris satu\nBaris dua \\ selesai",
+		Location:    "Aula, Gedung 9",
+		Start:       start,
+		End:         start.Add(2 * time.Hour),
+		Updated:     start,
+		RRule:       "FREQ=WEEKLY;BYDAY=FR;COUNT=3",
+		ExDates:     []time.Time{start.AddDate(0, 0, 7)},
+	}
+
+	events, err := ParseICal(bytes.NewReader(WriteICal("Kalender", []ICalEvent{event})), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("dapat %d event; ingin 1", len(events))
+	}
+	got := events[0]
+	if got.Summary != event.Summary || got.Description != event.Description || got.Location != event.Location {
+		t.Errorf("teks berubah:\n got %+v\nwant %+v", got, event)
+	}
+	if !got.Start.Equal(event.Start) || !got.End.Equal(event.End) || got.RRule != event.RRule {
+		t.Errorf("waktu/aturan berubah: %+v", got)
+	}
+	if len(got.ExDates) != 1 || !got.ExDates[0].Equal(event.ExDates[0]) {
+		t.Errorf("EXDATE = %v", got.ExDates)
+	}
+}
+
+func TestParseICalTZID(t *testing.T) {
+	floating := time.FixedZone("WIB", 7*3600)
+	doc := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:tz",
+		"DTSTART;TZID=America/New_York:20240115T090000",
+		"DTEND;TZID=\"America/New_York\":20240115T100000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:unknown",
+		"DTSTART;TZID=Mars/Olympus:20240115T090000",
+		"DURATION:PT1H30M",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:utc",
+		"DTSTART:20240115T090000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:allday",
+		"DTSTART;VALUE=DATE:20240115",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := ParseICal(strings.NewReader(doc), floating)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("dapat %d event; ingin 4", len(events))
+	}
+
+	// New York UTC-5 pada bulan Januari
+	if want := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC); !events[0].Start.Equal(want) {
+		t.Errorf("TZID: start = %v; ingin %v", events[0].Start, want)
+	}
+	if want := time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC); !events[0].End.Equal(want) {
+		t.Errorf("TZID bertanda kutip: end = %v; ingin %v", events[0].End, want)
+	}
+	// TZID tidak dikenal dibaca dalam zona bawaan
+	if want := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC); !events[1].Start.Equal(want) {
+		t.Errorf("TZID tidak dikenal: start = %v; ingin %v", events[1].Start, want)
+	}
+	if d := events[1].End.Sub(events[1].Start); d != 90*time.Minute {
+		t.Errorf("DURATION = %v; ingin 1h30m", d)
+	}
+	if want := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC); !events[2].Start.Equal(want) {
+		t.Errorf("UTC: start = %v; ingin %v", events[2].Start, want)
+	}
+	if !events[3].AllDay || events[3].End.Sub(events[3].Start) != 24*time.Hour {
+		t.Errorf("event sehari penuh: %+v", events[3])
+	}
+}
+
+func TestParseICalRejectsNonCalendar(t *testing.T) {
+	if _, err := ParseICal(strings.NewReader("hello"), time.UTC); err == nil {
+		t.Error("dokumen tanpa VCALENDAR seharusnya ditolak")
+	}
+}
@@ -0,0 +1,461 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decoder GIF untuk image.Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"bem_be/internal/storage"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decoder WebP untuk image.Decode
+)
+
+// Varian gambar yang dihasilkan pipeline upload. Ukuran adalah sisi terpanjang dalam piksel;
+// gambar yang lebih kecil tidak diperbesar.
+var imageVariants = []struct {
+	Name    string
+	Suffix  string
+	MaxSize int
+}{
+	{"thumbnail", "_thumb", 320},
+	{"medium", "_medium", 1024},
+	{"original", "", 2048},
+}
+
+const (
+	maxImagePixels = 50_000_000 // tolak gambar > 50MP (mencegah decompression bomb)
+	jpegQuality    = 85
+	webpQuality    = "80"
+
+	// webpMarker ditambahkan ke nama file jika varian WebP ikut disimpan
+	webpMarker = "-w"
+)
+
+var (
+	// ErrUnsupportedImage dikembalikan jika isi file tidak bisa dibaca sebagai gambar
+	ErrUnsupportedImage error = &UploadError{msg: "file bukan gambar yang valid (hanya jpg/png/gif/webp)"}
+
+	cwebpOnce sync.Once
+	cwebpPath string
+
+	// pipelineImageName mencocokkan nama file yang dibuat SaveImage; grup 1 berisi webpMarker
+	pipelineImageName = regexp.MustCompile(`^[0-9a-f]{32}(` + webpMarker + `)?\.(jpg|png)$`)
+)
+
+// SaveImage menjalankan pipeline upload gambar: validasi ukuran dan tipe sesuai policy (tipe
+// dideteksi dari isi file), membuang metadata EXIF/GPS dengan encode ulang, lalu menyimpan varian
+// thumbnail, medium dan original (dibatasi 2048px) ke folder storage dengan nama acak. Jika cwebp
+// tersedia, setiap varian juga disimpan sebagai WebP dan nama file diberi webpMarker. Mengembalikan nama file varian original
+// yang disimpan ke database.
+func SaveImage(file *multipart.FileHeader, folder string, policy UploadPolicy) (string, error) {
+	if _, err := policy.Check(file); err != nil {
+		return "", err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("gagal membuka file")
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return "", fmt.Errorf("gagal membaca file")
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", ErrUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return "", uploadErrorf("resolusi gambar terlalu besar (%dx%d)", cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", ErrUnsupportedImage
+	}
+	img = applyOrientation(img, jpegOrientation(data))
+
+	// PNG dipertahankan untuk gambar transparan (logo), selain itu JPEG
+	ext, contentType := ".jpg", "image/jpeg"
+	if hasAlpha(img) {
+		ext, contentType = ".png", "image/png"
+	}
+	// Seluruh varian di-encode dulu agar nama file bisa mencatat apakah varian WebP tersedia;
+	// ImageVariantURLs menurunkan URL varian dari nama itu tanpa mengecek storage.
+	type encodedVariant struct {
+		suffix, ext, contentType string
+		data                     []byte
+	}
+	var variants, webps []encodedVariant
+	withWebP := webpEncoder() != ""
+	for _, v := range imageVariants {
+		var buf bytes.Buffer
+		if err := encodeImage(&buf, resizeToFit(img, v.MaxSize), ext); err != nil {
+			return "", fmt.Errorf("gagal menyimpan gambar")
+		}
+		variants = append(variants, encodedVariant{v.Suffix, ext, contentType, buf.Bytes()})
+		if withWebP {
+			webp, ok := encodeWebP(buf.Bytes(), ext)
+			withWebP = ok
+			webps = append(webps, encodedVariant{v.Suffix, ".webp", "image/webp", webp})
+		}
+	}
+	base := randomFileName()
+	if withWebP {
+		base += webpMarker
+		variants = append(variants, webps...)
+	}
+
+	store := storage.Default()
+	var written []string
+	for _, v := range variants {
+		key := folder + "/" + base + v.suffix + v.ext
+		if err := store.Put(key, bytes.NewReader(v.data), v.contentType); err != nil {
+			log.Printf("Gagal menyimpan %s: %v", key, err)
+			removeKeys(written)
+			return "", fmt.Errorf("gagal menyimpan gambar")
+		}
+		written = append(written, key)
+	}
+
+	return base + ext, nil
+}
+
+// ImageVariantURLs mengembalikan URL setiap varian gambar yang tersimpan di folder storage,
+// untuk dipakai frontend sebagai srcset. Varian diturunkan dari nama file tanpa mengakses
+// storage: nama hasil pipeline selalu punya thumbnail dan medium, dan penanda webpMarker berarti
+// varian WebP juga disimpan. File lama yang diunggah sebelum pipeline ini hanya memiliki original.
+func ImageVariantURLs(folder, stored string) map[string]string {
+	if stored == "" {
+		return nil
+	}
+	if strings.HasPrefix(stored, "http://") || strings.HasPrefix(stored, "https://") {
+		return map[string]string{"original": stored}
+	}
+
+	key := storage.Key(folder, stored)
+	if key == "" {
+		return nil
+	}
+	store := storage.Default()
+	urls := map[string]string{"original": store.URL(key)}
+
+	match := pipelineImageName.FindStringSubmatch(path.Base(key))
+	if match == nil {
+		return urls
+	}
+	ext := path.Ext(key)
+	base := strings.TrimSuffix(key, ext)
+	for _, v := range imageVariants {
+		if v.Suffix != "" {
+			urls[v.Name] = store.URL(base + v.Suffix + ext)
+		}
+		if match[1] != "" {
+			urls[v.Name+"_webp"] = store.URL(base + v.Suffix + ".webp")
+		}
+	}
+	return urls
+}
+
+// RemoveImage menghapus file gambar beserta seluruh variannya dari storage
+func RemoveImage(folder, stored string) {
+	if stored == "" {
+		return
+	}
+	key := storage.Key(folder, stored)
+	if key == "" {
+		return
+	}
+	removeKeys(ImageVariantKeys(key))
+}
+
+// ImageVariantKeys mengembalikan key file beserta key seluruh varian yang mungkin dibuat
+// pipeline gambar untuknya (thumbnail, medium, WebP)
+func ImageVariantKeys(key string) []string {
+	ext := path.Ext(key)
+	base := strings.TrimSuffix(key, ext)
+
+	keys := []string{key}
+	for _, v := range imageVariants {
+		if v.Suffix != "" {
+			keys = append(keys, base+v.Suffix+ext)
+		}
+		keys = append(keys, base+v.Suffix+".webp")
+	}
+	return keys
+}
+
+// SaveFile menyimpan file unggahan apa adanya (mis. lampiran PDF) ke folder storage setelah
+// divalidasi dengan policy, dengan nama acak dan ekstensi sesuai tipe isinya. Mengembalikan
+// nama file yang disimpan ke database.
+func SaveFile(file *multipart.FileHeader, folder string, policy UploadPolicy) (string, error) {
+	contentType, err := policy.Check(file)
+	if err != nil {
+		return "", err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("gagal membuka file")
+	}
+	defer src.Close()
+
+	name := randomFileName() + extensionByType[contentType]
+	if err := storage.Default().Put(folder+"/"+name, src, contentType); err != nil {
+		log.Printf("Gagal menyimpan %s/%s: %v", folder, name, err)
+		return "", fmt.Errorf("gagal menyimpan file")
+	}
+	return name, nil
+}
+
+// RemoveFile menghapus file yang disimpan dengan SaveFile
+func RemoveFile(folder, stored string) {
+	if stored == "" {
+		return
+	}
+	removeKeys([]string{storage.Key(folder, stored)})
+}
+
+// FileURL mengembalikan URL publik file yang tersimpan di folder storage
+func FileURL(folder, stored string) string {
+	if stored == "" {
+		return ""
+	}
+	if strings.HasPrefix(stored, "http://") || strings.HasPrefix(stored, "https://") {
+		return stored
+	}
+	return storage.Default().URL(storage.Key(folder, stored))
+}
+
+// PublicBaseURL adalah URL publik server API (PUBLIC_BASE_URL). URL file unggahan
+// dibangun oleh storage, lihat FileURL.
+func PublicBaseURL() string {
+	return strings.TrimRight(GetEnvWithDefault("PUBLIC_BASE_URL", "http://localhost:9090"), "/")
+}
+
+func webpEncoder() string {
+	cwebpOnce.Do(func() {
+		if path, err := exec.LookPath("cwebp"); err == nil {
+			cwebpPath = path
+		} else {
+			log.Println("cwebp tidak ditemukan, varian WebP tidak dibuat")
+		}
+	})
+	return cwebpPath
+}
+
+func resizeToFit(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+	if w >= h {
+		h = h * maxSize / w
+		w = maxSize
+	} else {
+		w = w * maxSize / h
+		h = maxSize
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func encodeImage(w io.Writer, img image.Image, ext string) error {
+	if ext == ".png" {
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		return encoder.Encode(w, img)
+	}
+	return jpeg.Encode(w, flattenOnWhite(img), &jpeg.Options{Quality: jpegQuality})
+}
+
+// encodeWebP mengonversi hasil encode JPEG/PNG ke WebP lewat cwebp. cwebp hanya bekerja
+// dengan file, jadi konversi dilakukan di folder sementara.
+func encodeWebP(encoded []byte, ext string) ([]byte, bool) {
+	webp := webpEncoder()
+	if webp == "" {
+		return nil, false
+	}
+	dir, err := os.MkdirTemp("", "webp-*")
+	if err != nil {
+		return nil, false
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in"+ext)
+	out := filepath.Join(dir, "out.webp")
+	if err := os.WriteFile(in, encoded, 0o600); err != nil {
+		return nil, false
+	}
+	cmd := exec.Command(webp, "-quiet", "-q", webpQuality, "-metadata", "none", in, "-o", out)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		log.Printf("Gagal membuat varian WebP: %v %s", err, output)
+		return nil, false
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// hasAlpha mengecek apakah gambar memiliki piksel transparan
+func hasAlpha(img image.Image) bool {
+	switch img.(type) {
+	case *image.YCbCr, *image.Gray, *image.CMYK:
+		return false
+	}
+	if p, ok := img.(*image.Paletted); ok {
+		for _, c := range p.Palette {
+			if _, _, _, a := c.RGBA(); a != 0xffff {
+				return true
+			}
+		}
+		return false
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func flattenOnWhite(img image.Image) image.Image {
+	if _, ok := img.(*image.YCbCr); ok {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+// jpegOrientation membaca tag Orientation (0x0112) dari segmen EXIF JPEG. Mengembalikan 1 jika tidak ada.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		size := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if marker == 0xDA || size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(segment) > 14 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	for n := 0; n < count; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation memutar/membalik gambar sesuai orientasi EXIF, karena metadata-nya
+// dibuang saat encode ulang
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if orientation >= 5 {
+		w, h = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = b.Dx()-1-x, y
+			case 3:
+				dx, dy = b.Dx()-1-x, b.Dy()-1-y
+			case 4:
+				dx, dy = x, b.Dy()-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = b.Dy()-1-y, x
+			case 7:
+				dx, dy = b.Dy()-1-y, b.Dx()-1-x
+			case 8:
+				dx, dy = y, b.Dx()-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+func removeKeys(keys []string) {
+	store := storage.Default()
+	for _, key := range keys {
+		if err := store.Delete(key); err != nil {
+			log.Printf("Gagal menghapus %s: %v", key, err)
+		}
+	}
+}
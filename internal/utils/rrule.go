@@ -0,0 +1,375 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frekuensi RRULE yang didukung
+const (
+	RRuleDaily   = "DAILY"
+	RRuleWeekly  = "WEEKLY"
+	RRuleMonthly = "MONTHLY"
+)
+
+// maxRRulePeriods membatasi jumlah periode (hari/minggu/bulan) yang ditelusuri saat
+// ekspansi agar aturan tanpa batas tidak membuat loop panjang
+const maxRRulePeriods = 20000
+
+// RRule adalah subset aturan pengulangan RFC 5545: FREQ DAILY/WEEKLY/MONTHLY dengan
+// INTERVAL, COUNT atau UNTIL, BYDAY dan BYMONTHDAY
+type RRule struct {
+	Freq       string
+	Interval   int
+	Count      int       // 0 = tidak dibatasi jumlah
+	Until      time.Time // zero = tidak dibatasi tanggal; inklusif
+	ByDay      []RRuleWeekday
+	ByMonthDay []int
+}
+
+// RRuleWeekday adalah satu nilai BYDAY, mis. MO, 1MO (Senin pertama) atau -1FR
+// (Jumat terakhir). N hanya dipakai untuk FREQ=MONTHLY.
+type RRuleWeekday struct {
+	N   int
+	Day time.Weekday
+}
+
+var rruleDayCodes = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var rruleDayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// ParseRRule membaca aturan seperti "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10". UNTIL tanpa
+// zona dibaca dalam loc; UNTIL berupa tanggal berarti sampai akhir hari tersebut.
+func ParseRRule(value string, loc *time.Location) (*RRule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, errors.New("aturan pengulangan kosong")
+	}
+
+	rule := &RRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("bagian RRULE %q tidak valid", part)
+		}
+		key, val = strings.ToUpper(strings.TrimSpace(key)), strings.ToUpper(strings.TrimSpace(val))
+		switch key {
+		case "FREQ":
+			if val != RRuleDaily && val != RRuleWeekly && val != RRuleMonthly {
+				return nil, fmt.Errorf("FREQ=%s tidak didukung (hanya DAILY, WEEKLY, MONTHLY)", val)
+			}
+			rule.Freq = val
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL=%s tidak valid", val)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT=%s tidak valid", val)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseRRuleUntil(val, loc)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				day, err := parseRRuleWeekday(code)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, code := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(code)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("BYMONTHDAY=%s tidak valid", code)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "WKST":
+			if val != "MO" {
+				return nil, errors.New("hanya WKST=MO yang didukung")
+			}
+		default:
+			return nil, fmt.Errorf("bagian RRULE %s tidak didukung", key)
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *RRule) validate() error {
+	if r.Freq == "" {
+		return errors.New("RRULE wajib memiliki FREQ")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return errors.New("RRULE tidak boleh memakai COUNT dan UNTIL sekaligus")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != RRuleMonthly {
+		return errors.New("BYMONTHDAY hanya untuk FREQ=MONTHLY")
+	}
+	if len(r.ByMonthDay) > 0 && len(r.ByDay) > 0 {
+		return errors.New("BYDAY dan BYMONTHDAY tidak bisa dipakai bersamaan")
+	}
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != RRuleMonthly {
+			return errors.New("BYDAY dengan urutan (mis. 1MO) hanya untuk FREQ=MONTHLY")
+		}
+		if day.N < -5 || day.N > 5 {
+			return fmt.Errorf("urutan BYDAY %d tidak valid", day.N)
+		}
+	}
+	return nil
+}
+
+func parseRRuleWeekday(code string) (RRuleWeekday, error) {
+	code = strings.TrimSpace(code)
+	if len(code) < 2 {
+		return RRuleWeekday{}, fmt.Errorf("BYDAY=%s tidak valid", code)
+	}
+	day, ok := rruleDayCodes[code[len(code)-2:]]
+	if !ok {
+		return RRuleWeekday{}, fmt.Errorf("BYDAY=%s tidak valid", code)
+	}
+	n := 0
+	if prefix := code[:len(code)-2]; prefix != "" {
+		var err error
+		if n, err = strconv.Atoi(prefix); err != nil || n == 0 {
+			return RRuleWeekday{}, fmt.Errorf("BYDAY=%s tidak valid", code)
+		}
+	}
+	return RRuleWeekday{N: n, Day: day}, nil
+}
+
+func parseRRuleUntil(value string, loc *time.Location) (time.Time, error) {
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		if t, err := time.Parse(icalDateTimeUTC, value); err == nil {
+			return t, nil
+		}
+	case len(value) == len(icalDate):
+		if t, err := time.ParseInLocation(icalDate, value, loc); err == nil {
+			return t.AddDate(0, 0, 1).Add(-time.Second), nil
+		}
+	default:
+		if t, err := time.ParseInLocation(icalDateTime, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("UNTIL=%s tidak valid", value)
+}
+
+// String menulis aturan dalam bentuk kanonik; UNTIL selalu dalam UTC
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = rruleDayNames[day.Day]
+			if day.N != 0 {
+				codes[i] = strconv.Itoa(day.N) + codes[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		codes := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			codes[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(codes, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(icalDateTimeUTC))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between mengembalikan waktu mulai kemunculan dari dtstart yang berada di [from, to].
+// DTSTART selalu dihitung sebagai kemunculan pertama (RFC 5545). Hari dan jam dihitung
+// dalam zona waktu dtstart.
+func (r *RRule) Between(dtstart, from, to time.Time) []time.Time {
+	var result []time.Time
+	r.each(dtstart, func(t time.Time) bool {
+		if t.After(to) {
+			return false
+		}
+		if !t.Before(from) {
+			result = append(result, t)
+		}
+		return true
+	})
+	return result
+}
+
+// CountBefore menghitung kemunculan yang dimulai sebelum t
+func (r *RRule) CountBefore(dtstart, t time.Time) int {
+	n := 0
+	r.each(dtstart, func(occurrence time.Time) bool {
+		if !occurrence.Before(t) {
+			return false
+		}
+		n++
+		return true
+	})
+	return n
+}
+
+// Last mengembalikan kemunculan terakhir; false jika aturan tidak berujung
+func (r *RRule) Last(dtstart time.Time) (time.Time, bool) {
+	if r.Count == 0 && r.Until.IsZero() {
+		return time.Time{}, false
+	}
+	last := dtstart
+	r.each(dtstart, func(t time.Time) bool {
+		last = t
+		return true
+	})
+	return last, true
+}
+
+// each memanggil fn untuk setiap kemunculan secara berurutan sampai fn mengembalikan
+// false, COUNT/UNTIL tercapai, atau batas periode terlampaui
+func (r *RRule) each(dtstart time.Time, fn func(time.Time) bool) {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if r.Count > 0 && emitted >= r.Count {
+			return false
+		}
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		emitted++
+		return fn(t)
+	}
+
+	if !emit(dtstart) {
+		return
+	}
+	for period := 0; period < maxRRulePeriods; period++ {
+		for _, t := range r.candidates(dtstart, period*interval) {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// candidates menghasilkan kandidat kemunculan (terurut) pada periode ke-offset
+func (r *RRule) candidates(dtstart time.Time, offset int) []time.Time {
+	loc := dtstart.Location()
+	hour, minute, second := dtstart.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, dtstart.Nanosecond(), loc)
+	}
+
+	switch r.Freq {
+	case RRuleDaily:
+		day := at(dtstart.Year(), dtstart.Month(), dtstart.Day()+offset)
+		if len(r.ByDay) > 0 && !r.hasWeekday(day.Weekday()) {
+			return nil
+		}
+		return []time.Time{day}
+
+	case RRuleWeekly:
+		monday := dtstart.Day() - (int(dtstart.Weekday())+6)%7
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []RRuleWeekday{{Day: dtstart.Weekday()}}
+		}
+		var result []time.Time
+		for _, day := range days {
+			result = append(result, at(dtstart.Year(), dtstart.Month(), monday+7*offset+(int(day.Day)+6)%7))
+		}
+		return sortUniqueTimes(result)
+
+	case RRuleMonthly:
+		first := at(dtstart.Year(), dtstart.Month()+time.Month(offset), 1)
+		year, month := first.Year(), first.Month()
+		daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+
+		var result []time.Time
+		monthDays := r.ByMonthDay
+		if len(monthDays) == 0 && len(r.ByDay) == 0 {
+			monthDays = []int{dtstart.Day()}
+		}
+		for _, day := range monthDays {
+			if day < 0 {
+				day = daysInMonth + 1 + day
+			}
+			if day >= 1 && day <= daysInMonth {
+				result = append(result, at(year, month, day))
+			}
+		}
+		for _, weekday := range r.ByDay {
+			var matches []int
+			for day := 1; day <= daysInMonth; day++ {
+				if time.Date(year, month, day, 0, 0, 0, 0, loc).Weekday() == weekday.Day {
+					matches = append(matches, day)
+				}
+			}
+			switch {
+			case weekday.N == 0:
+				for _, day := range matches {
+					result = append(result, at(year, month, day))
+				}
+			case weekday.N > 0 && weekday.N <= len(matches):
+				result = append(result, at(year, month, matches[weekday.N-1]))
+			case weekday.N < 0 && -weekday.N <= len(matches):
+				result = append(result, at(year, month, matches[len(matches)+weekday.N]))
+			}
+		}
+		return sortUniqueTimes(result)
+	}
+	return nil
+}
+
+func (r *RRule) hasWeekday(day time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d.Day == day {
+			return true
+		}
+	}
+	return false
+}
+
+func sortUniqueTimes(times []time.Time) []time.Time {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	result := times[:0]
+	for _, t := range times {
+		if len(result) == 0 || !t.Equal(result[len(result)-1]) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParseRRule(t *testing.T, value string) *RRule {
+	t.Helper()
+	rule, err := ParseRRule(value, time.UTC)
+	if err != nil {
+		t.Fatalf("ParseRRule(%q): %v", value, err)
+	}
+	return rule
+}
+
+func assertDates(t *testing.T, got []time.Time, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("dapat %d kemunculan %v; ingin %v", len(got), got, want)
+	}
+	for i := range want {
+		if d := got[i].Format("2006-01-02"); d != want[i] {
+			t.Errorf("kemunculan %d = %s; ingin %s", i, d, want[i])
+		}
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+var farFuture = date(2030, 1, 1)
+
+func TestRRuleByDayOrdinal(t *testing.T) {
+	// Selasa kedua setiap bulan
+	rule := mustParseRRule(t, "FREQ=MONTHLY;BYDAY=2TU;COUNT=4")
+	assertDates(t, rule.Between(date(2024, 1, 9), date(2024, 1, 1), farFuture),
+		"2024-01-09", "2024-02-13", "2024-03-12", "2024-04-09")
+
+	// Jumat terakhir setiap bulan, termasuk Februari kabisat
+	rule = mustParseRRule(t, "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3")
+	assertDates(t, rule.Between(date(2024, 1, 26), date(2024, 1, 1), farFuture),
+		"2024-01-26", "2024-02-23", "2024-03-29")
+
+	// Senin kelima hanya ada di sebagian bulan
+	rule = mustParseRRule(t, "FREQ=MONTHLY;BYDAY=5MO;COUNT=3")
+	assertDates(t, rule.Between(date(2024, 1, 29), date(2024, 1, 1), farFuture),
+		"2024-01-29", "2024-04-29", "2024-07-29")
+}
+
+func TestRRuleNegativeByMonthDay(t *testing.T) {
+	rule := mustParseRRule(t, "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=4")
+	assertDates(t, rule.Between(date(2024, 1, 31), date(2024, 1, 1), farFuture),
+		"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30")
+
+	rule = mustParseRRule(t, "FREQ=MONTHLY;BYMONTHDAY=-2;COUNT=2")
+	assertDates(t, rule.Between(date(2023, 1, 30), date(2023, 1, 1), farFuture),
+		"2023-01-30", "2023-02-27")
+
+	// tanggal 31 dilewati pada bulan yang lebih pendek
+	rule = mustParseRRule(t, "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=3")
+	assertDates(t, rule.Between(date(2024, 1, 31), date(2024, 1, 1), farFuture),
+		"2024-01-31", "2024-03-31", "2024-05-31")
+}
+
+func TestRRuleWeeklyUntilIsInclusive(t *testing.T) {
+	rule := mustParseRRule(t, "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20240110")
+	assertDates(t, rule.Between(date(2024, 1, 1), date(2024, 1, 1), farFuture),
+		"2024-01-01", "2024-01-03", "2024-01-08", "2024-01-10")
+
+	last, ok := rule.Last(date(2024, 1, 1))
+	if !ok || last.Format("2006-01-02") != "2024-01-10" {
+		t.Errorf("Last = %v, %v; ingin 2024-01-10", last, ok)
+	}
+	if _, ok := mustParseRRule(t, "FREQ=DAILY").Last(date(2024, 1, 1)); ok {
+		t.Error("Last aturan tanpa batas seharusnya false")
+	}
+}
+
+func TestRRuleCountBeforeSplit(t *testing.T) {
+	// memecah seri "kemunculan ini dan berikutnya": COUNT seri lama menjadi jumlah
+	// kemunculan sebelum titik pecah, sisanya untuk seri baru
+	rule := mustParseRRule(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;COUNT=6")
+	start := date(2024, 1, 2)
+	all := rule.Between(start, start, farFuture)
+	assertDates(t, all, "2024-01-02", "2024-01-16", "2024-01-30", "2024-02-13", "2024-02-27", "2024-03-12")
+
+	at := all[2]
+	before := rule.CountBefore(start, at)
+	if before != 2 {
+		t.Fatalf("CountBefore = %d; ingin 2", before)
+	}
+	if n := rule.CountBefore(start, farFuture); n != 6 {
+		t.Errorf("CountBefore setelah seri selesai = %d; ingin 6", n)
+	}
+}
+
+func TestParseRRuleErrors(t *testing.T) {
+	invalid := []string{
+		"",
+		"BYDAY=MO",
+		"FREQ=YEARLY",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=-32",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYDAY=MO;BYMONTHDAY=1",
+		"FREQ=DAILY;COUNT=3;UNTIL=20240101",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;WKST=SU",
+	}
+	for _, value := range invalid {
+		if _, err := ParseRRule(value, time.UTC); err == nil {
+			t.Errorf("ParseRRule(%q) seharusnya gagal", value)
+		}
+	}
+}
+
+func TestRRuleString(t *testing.T) {
+	cases := map[string]string{
+		"RRULE:freq=monthly;byday=-1fr,2tu;count=5":    "FREQ=MONTHLY;BYDAY=-1FR,2TU;COUNT=5",
+		"FREQ=WEEKLY;INTERVAL=1;BYDAY=MO;WKST=MO":      "FREQ=WEEKLY;BYDAY=MO",
+		"FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20240131":    "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20240131T235959Z",
+		"FREQ=DAILY;INTERVAL=3;UNTIL=20240105T100000Z": "FREQ=DAILY;INTERVAL=3;UNTIL=20240105T100000Z",
+	}
+	for in, want := range cases {
+		if got := mustParseRRule(t, in).String(); got != want {
+			t.Errorf("String(%q) = %q; ingin %q", in, got, want)
+		}
+	}
+}
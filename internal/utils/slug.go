@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Slugify mengubah teks bebas menjadi slug huruf kecil yang aman untuk URL,
+// mis. "Kegiatan  Kampus & UKM!" menjadi "kegiatan-kampus-ukm".
+// Huruf Latin beraksen diganti huruf dasarnya; karakter lain selain huruf/angka ASCII
+// menjadi pemisah.
+func Slugify(value string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(value)) {
+		if base, ok := slugFold[r]; ok {
+			r = base
+		}
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// NumericSlug melaporkan apakah slug hanya berisi angka. Slug seperti ini tidak boleh dipakai
+// konten karena route publik lebih dulu membaca angka sebagai ID.
+func NumericSlug(slug string) bool {
+	if slug == "" {
+		return false
+	}
+	for _, r := range slug {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// maxSlugLength menyisakan ruang untuk akhiran angka di kolom varchar(255)
+const maxSlugLength = 200
+
+// UniqueSlug membuat slug dari judul yang belum dipakai menurut taken, dengan menambah
+// akhiran -2, -3, ... bila perlu. Judul yang hanya berisi angka diberi awalan fallback.
+func UniqueSlug(title, fallback string, taken func(string) bool) string {
+	base := Slugify(title)
+	if len(base) > maxSlugLength {
+		base = base[:maxSlugLength]
+		for len(base) > 0 && base[len(base)-1] == '-' {
+			base = base[:len(base)-1]
+		}
+	}
+	if base == "" {
+		base = fallback
+	} else if NumericSlug(base) {
+		// slug angka saja akan terbaca sebagai ID oleh route /:slug
+		base = fallback + "-" + base
+	}
+
+	slug := base
+	for i := 2; taken(slug); i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	return slug
+}
+
+// slugFold memetakan huruf Latin beraksen yang umum ke huruf ASCII
+var slugFold = func() map[rune]rune {
+	fold := map[rune]rune{}
+	for base, accented := range map[rune]string{
+		'a': "àáâãäåā", 'c': "çć", 'e': "èéêëē", 'i': "ìíîïī", 'n': "ñń",
+		'o': "òóôõöøō", 'u': "ùúûüū", 'y': "ýÿ", 's': "šś", 'z': "žźż",
+	} {
+		for _, r := range accented {
+			fold[r] = base
+		}
+	}
+	return fold
+}()
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Kegiatan  Kampus & UKM!":   "kegiatan-kampus-ukm",
+		"  Café Déjà Vu  ":          "cafe-deja-vu",
+		"Rapat #2 -- 2024":          "rapat-2-2024",
+		"---":                       "",
+		"🎉🎉":                        "",
+		"Pengumuman_Penting":        "pengumuman-penting",
+		"Ñandú über straße":         "nandu-uber-stra-e",
+		"MAHASISWA baru 2024/2025.": "mahasiswa-baru-2024-2025",
+	}
+	for in, want := range cases {
+		if got := Slugify(in); got != want {
+			t.Errorf("Slugify(%q) = %q; ingin %q", in, got, want)
+		}
+	}
+}
+
+func TestNumericSlug(t *testing.T) {
+	for slug, want := range map[string]bool{"123": true, "0": true, "": false, "12a": false, "2024-1": false} {
+		if got := NumericSlug(slug); got != want {
+			t.Errorf("NumericSlug(%q) = %v; ingin %v", slug, got, want)
+		}
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	taken := map[string]bool{"rapat": true, "rapat-2": true, "berita": true, "berita-2024": true}
+	isTaken := func(s string) bool { return taken[s] }
+
+	cases := []struct{ title, want string }{
+		{"Rapat", "rapat-3"},
+		{"Rapat Baru", "rapat-baru"},
+		{"🎉", "berita-2"},
+		// judul angka saja akan dibaca sebagai ID, jadi diberi awalan fallback
+		{"2024", "berita-2024-2"},
+		{"42", "berita-42"},
+	}
+	for _, c := range cases {
+		if got := UniqueSlug(c.title, "berita", isTaken); got != c.want {
+			t.Errorf("UniqueSlug(%q) = %q; ingin %q", c.title, got, c.want)
+		}
+	}
+
+	long := UniqueSlug(strings.Repeat("a", maxSlugLength-1)+" "+strings.Repeat("b", 50), "berita", isTaken)
+	if len(long) > maxSlugLength || strings.HasSuffix(long, "-") {
+		t.Errorf("slug panjang tidak dipotong dengan benar: %q (%d)", long, len(long))
+	}
+}
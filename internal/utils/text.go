@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+var plainTextPolicy = bluemonday.StrictPolicy()
+
+// Excerpt mengubah konten HTML menjadi teks polos dan memotongnya maksimal max karakter
+// di batas kata, untuk meta description dan pratinjau tautan.
+func Excerpt(content string, max int) string {
+	text := html.UnescapeString(plainTextPolicy.Sanitize(content))
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:max-1])
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:-") + "…"
+}
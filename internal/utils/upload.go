@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"bem_be/internal/storage"
+)
+
+// UploadPolicy adalah aturan untuk satu field unggahan: ukuran maksimum per file, tipe MIME
+// yang diizinkan (dideteksi dari isi file, bukan ekstensi atau header dari klien) dan jumlah file.
+type UploadPolicy struct {
+	Name         string
+	MaxSize      int64
+	AllowedTypes []string
+	MaxCount     int
+}
+
+var imageTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// Kebijakan unggahan per field
+var (
+	ImagePolicy       = UploadPolicy{Name: "gambar", MaxSize: 5 << 20, AllowedTypes: imageTypes, MaxCount: 1}
+	KTMPolicy         = UploadPolicy{Name: "foto KTM", MaxSize: 5 << 20, AllowedTypes: []string{"image/jpeg", "image/png", "image/webp"}, MaxCount: 1}
+	RequestItemPolicy = UploadPolicy{Name: "foto barang", MaxSize: 5 << 20, AllowedTypes: []string{"image/jpeg", "image/png", "image/webp"}, MaxCount: 1}
+	AttachmentPolicy  = UploadPolicy{Name: "lampiran", MaxSize: 10 << 20, AllowedTypes: []string{"application/pdf", "image/jpeg", "image/png", "image/webp"}, MaxCount: 1}
+	GaleryPhotoPolicy = UploadPolicy{Name: "foto galeri", MaxSize: 5 << 20, AllowedTypes: imageTypes, MaxCount: 20}
+)
+
+// extensionByType menentukan ekstensi file tersimpan dari tipe hasil deteksi, sehingga nama
+// file dari klien tidak pernah dipakai
+var extensionByType = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// UploadError adalah kesalahan dari isi unggahan (bukan dari server), dijawab dengan 400
+type UploadError struct {
+	msg string
+}
+
+func (e *UploadError) Error() string {
+	return e.msg
+}
+
+func uploadErrorf(format string, args ...interface{}) error {
+	return &UploadError{msg: fmt.Sprintf(format, args...)}
+}
+
+// IsUploadError mengembalikan true jika err berasal dari validasi unggahan
+func IsUploadError(err error) bool {
+	var uploadErr *UploadError
+	return errors.As(err, &uploadErr)
+}
+
+// MaxRequestSize adalah batas body request untuk policy ini, dipakai dengan http.MaxBytesReader
+func (p UploadPolicy) MaxRequestSize() int64 {
+	count := p.MaxCount
+	if count < 1 {
+		count = 1
+	}
+	// Cadangan 1MB untuk field teks dan boundary multipart
+	return p.MaxSize*int64(count) + 1<<20
+}
+
+// CheckCount memvalidasi jumlah file yang diunggah sekaligus
+func (p UploadPolicy) CheckCount(n int) error {
+	if p.MaxCount > 0 && n > p.MaxCount {
+		return uploadErrorf("maksimal %d file untuk %s", p.MaxCount, p.Name)
+	}
+	return nil
+}
+
+// Check memvalidasi ukuran dan tipe file, lalu mengembalikan tipe MIME hasil deteksi isinya
+func (p UploadPolicy) Check(file *multipart.FileHeader) (string, error) {
+	if file.Size > p.MaxSize {
+		return "", uploadErrorf("ukuran %s maksimal %s", p.Name, formatSize(p.MaxSize))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("gagal membuka file")
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("gagal membaca file")
+	}
+	contentType := http.DetectContentType(head[:n])
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+
+	for _, allowed := range p.AllowedTypes {
+		if contentType == allowed {
+			return contentType, nil
+		}
+	}
+	return "", uploadErrorf("tipe file %s tidak diizinkan untuk %s", contentType, p.Name)
+}
+
+// randomFileName membuat nama objek acak di server; nama file dari klien tidak dipakai
+// sama sekali agar tidak bisa dipakai untuk path traversal atau menebak file lain
+func randomFileName() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand tidak pernah gagal di platform yang didukung
+	}
+	return hex.EncodeToString(b)
+}
+
+func formatSize(size int64) string {
+	if size >= 1<<20 && size%(1<<20) == 0 {
+		return fmt.Sprintf("%dMB", size>>20)
+	}
+	if size >= 1<<10 {
+		return fmt.Sprintf("%dKB", size>>10)
+	}
+	return fmt.Sprintf("%d byte", size)
+}
+
+type storedFile struct {
+	folder string
+	name   string
+}
+
+// FileChanges mencatat file yang baru diunggah dan file lama yang digantikan dalam satu
+// operasi, agar storage mengikuti hasil transaksi database: jika gagal file baru dihapus,
+// jika berhasil file lama yang dihapus.
+type FileChanges struct {
+	added    []storedFile
+	replaced []storedFile
+}
+
+// Added mencatat file yang baru disimpan untuk operasi ini
+func (f *FileChanges) Added(folder, name string) {
+	if name != "" {
+		f.added = append(f.added, storedFile{folder, name})
+	}
+}
+
+// Replaced mencatat file lama yang tidak lagi dipakai jika operasi berhasil
+func (f *FileChanges) Replaced(folder, name string) {
+	if name != "" {
+		f.replaced = append(f.replaced, storedFile{folder, name})
+	}
+}
+
+// Done menghapus file baru jika err != nil, atau file lama yang digantikan jika err == nil.
+// File yang tercatat di kedua sisi (nama tidak berubah) tidak pernah dihapus.
+func (f *FileChanges) Done(err error) {
+	remove, keep := f.replaced, f.added
+	if err != nil {
+		remove, keep = f.added, f.replaced
+	}
+	for _, file := range remove {
+		if containsFile(keep, file) {
+			continue
+		}
+		RemoveImage(file.folder, file.name)
+	}
+	f.added, f.replaced = nil, nil
+}
+
+func containsFile(files []storedFile, file storedFile) bool {
+	for _, f := range files {
+		if storage.Key(f.folder, f.name) == storage.Key(file.folder, file.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadErrorStatus mengembalikan 400 untuk unggahan yang ditolak policy dan 500 untuk
+// kegagalan server saat menyimpan
+func UploadErrorStatus(err error) int {
+	if IsUploadError(err) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}